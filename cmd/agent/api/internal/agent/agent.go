@@ -73,7 +73,12 @@ func SetupHandlers(r *mux.Router, flare flare.Component, server dogstatsdServer.
 
 	// Some agent subcommands do not provide these dependencies (such as JMX)
 	if server != nil && serverDebug != nil {
-		r.HandleFunc("/dogstatsd-stats", func(w http.ResponseWriter, r *http.Request) { getDogstatsdStats(w, r, server, serverDebug) }).Methods("GET")
+		r.HandleFunc("/dogstatsd-stats", func(w http.ResponseWriter, r *http.Request) {
+			getDogstatsdStats(w, r, server, serverDebug.GetJSONDebugStats)
+		}).Methods("GET")
+		r.HandleFunc("/dogstatsd-rule-stats", func(w http.ResponseWriter, r *http.Request) {
+			getDogstatsdStats(w, r, server, serverDebug.GetJSONRuleStats)
+		}).Methods("GET")
 	}
 
 	return r
@@ -271,7 +276,8 @@ func streamLogs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func getDogstatsdStats(w http.ResponseWriter, r *http.Request, dogstatsdServer dogstatsdServer.Component, serverDebug dogstatsdDebug.Component) {
+// getDogstatsdStats writes the DogStatsD debug stats returned by getStats.
+func getDogstatsdStats(w http.ResponseWriter, r *http.Request, dogstatsdServer dogstatsdServer.Component, getStats func() ([]byte, error)) {
 	log.Info("Got a request for the Dogstatsd stats.")
 
 	if !config.Datadog.GetBool("use_dogstatsd") {
@@ -305,7 +311,7 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request, dogstatsdServer d
 		return
 	}
 
-	jsonStats, err := serverDebug.GetJSONDebugStats()
+	jsonStats, err := getStats()
 	if err != nil {
 		setJSONError(w, log.Errorf("Error getting marshalled Dogstatsd stats: %s", err), 500)
 		return
@@ -11,6 +11,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"go.uber.org/fx"
@@ -19,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core"
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/replay"
 	"github.com/DataDog/datadog-agent/pkg/api/security"
 	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo"
@@ -43,6 +45,8 @@ type cliParams struct {
 	dsdCaptureDuration   time.Duration
 	dsdCaptureFilePath   string
 	dsdCaptureCompressed bool
+	dsdExportFilePath    string
+	dsdExportFormat      string
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -53,8 +57,11 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 
 	dogstatsdCaptureCmd := &cobra.Command{
 		Use:   "dogstatsd-capture",
-		Short: "Start a dogstatsd UDS traffic capture",
-		Long:  ``,
+		Short: "Start a dogstatsd traffic capture, or export an existing one",
+		Long: `Start a capture of the traffic received by the dogstatsd UDS, UDP and TCP listeners.
+
+With --export, the capture file is instead listed in a human-readable format, along with
+its tagger state, and no capture is started.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return fxutil.OneShot(dogstatsdCapture,
 				fx.Supply(cliParams),
@@ -69,6 +76,8 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	dogstatsdCaptureCmd.Flags().DurationVarP(&cliParams.dsdCaptureDuration, "duration", "d", defaultCaptureDuration, "Duration traffic capture should span.")
 	dogstatsdCaptureCmd.Flags().StringVarP(&cliParams.dsdCaptureFilePath, "path", "p", "", "Directory path to write the capture to.")
 	dogstatsdCaptureCmd.Flags().BoolVarP(&cliParams.dsdCaptureCompressed, "compressed", "z", true, "Should capture be zstd compressed.")
+	dogstatsdCaptureCmd.Flags().StringVarP(&cliParams.dsdExportFilePath, "export", "e", "", "Capture file to export to the standard output instead of starting a capture.")
+	dogstatsdCaptureCmd.Flags().StringVar(&cliParams.dsdExportFormat, "format", replay.ExportText, fmt.Sprintf("Export format, %q or %q.", replay.ExportText, replay.ExportJSON))
 
 	// shut up grpc client!
 	grpclog.SetLoggerV2(grpclog.NewLoggerV2(io.Discard, io.Discard, io.Discard))
@@ -77,6 +86,10 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 }
 
 func dogstatsdCapture(log log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.dsdExportFilePath != "" {
+		return dogstatsdExport(cliParams)
+	}
+
 	fmt.Printf("Starting a dogstatsd traffic capture session...\n\n")
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -125,3 +138,14 @@ func dogstatsdCapture(log log.Component, config config.Component, cliParams *cli
 
 	return nil
 }
+
+// dogstatsdExport lists the contents of an existing capture file on the standard output.
+func dogstatsdExport(cliParams *cliParams) error {
+	reader, err := replay.NewTrafficCaptureReader(cliParams.dsdExportFilePath, 1, false)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", cliParams.dsdExportFilePath, err)
+	}
+	defer reader.Close()
+
+	return reader.Export(os.Stdout, cliParams.dsdExportFormat)
+}
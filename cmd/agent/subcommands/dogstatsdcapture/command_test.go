@@ -27,3 +27,14 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestCommandExport(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"dogstatsd-capture", "--export", "datadog-capture.dog", "--format", "json"},
+		dogstatsdCapture,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.Equal(t, "datadog-capture.dog", cliParams.dsdExportFilePath)
+			require.Equal(t, "json", cliParams.dsdExportFormat)
+		})
+}
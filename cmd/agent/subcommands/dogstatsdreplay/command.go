@@ -35,6 +35,7 @@ import (
 
 const (
 	defaultIterations = 1
+	defaultSpeed      = 1
 )
 
 // cliParams are the command-line arguments for this subcommand
@@ -47,6 +48,9 @@ type cliParams struct {
 	dsdVerboseReplay    bool
 	dsdMmapReplay       bool
 	dsdReplayIterations int
+	dsdReplayName       string
+	dsdReplayPid        int32
+	dsdReplaySpeed      float64
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -72,12 +76,23 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	dogstatsdReplayCmd.Flags().StringVarP(&cliParams.dsdReplayFilePath, "file", "f", "", "Input file with traffic captured with dogstatsd-capture.")
 	dogstatsdReplayCmd.Flags().BoolVarP(&cliParams.dsdVerboseReplay, "verbose", "v", false, "Verbose replay.")
 	dogstatsdReplayCmd.Flags().BoolVarP(&cliParams.dsdMmapReplay, "mmap", "m", true, "Mmap file for replay. Set to false to load the entire file into memory instead")
-	dogstatsdReplayCmd.Flags().IntVarP(&cliParams.dsdReplayIterations, "loops", "l", defaultIterations, "Number of iterations to replay.")
+	dogstatsdReplayCmd.Flags().IntVarP(&cliParams.dsdReplayIterations, "loops", "l", defaultIterations, "Number of iterations to replay. Set to 0 to loop until interrupted.")
+	dogstatsdReplayCmd.Flags().StringVar(&cliParams.dsdReplayName, "name", "", "Only replay the metrics whose name matches this glob pattern, e.g. 'app.requests.*'.")
+	dogstatsdReplayCmd.Flags().Int32Var(&cliParams.dsdReplayPid, "pid", 0, "Only replay the traffic sent by the process with this pid.")
+	dogstatsdReplayCmd.Flags().Float64VarP(&cliParams.dsdReplaySpeed, "speed", "s", defaultSpeed, "Replay speed relative to the capture, e.g. 2 replays twice as fast. Set to 0 to replay as fast as possible.")
 
 	return []*cobra.Command{dogstatsdReplayCmd}
 }
 
 func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.dsdReplaySpeed < 0 {
+		return fmt.Errorf("invalid replay speed %v: it must be positive, or 0 to replay as fast as possible", cliParams.dsdReplaySpeed)
+	}
+	filter, err := replay.NewFilter(cliParams.dsdReplayName, cliParams.dsdReplayPid)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -133,6 +148,8 @@ func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliP
 		fmt.Printf("could not open: %s\n", cliParams.dsdReplayFilePath)
 		return err
 	}
+	reader.Speed = cliParams.dsdReplaySpeed
+	reader.Filter = filter
 
 	s := pkgconfig.Datadog.GetString("dogstatsd_socket")
 	if s == "" {
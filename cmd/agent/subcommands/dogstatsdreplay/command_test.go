@@ -25,3 +25,16 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestCommandFilters(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"dogstatsd-replay", "--name", "app.*", "--pid", "42", "--speed", "0", "--loops", "0"},
+		dogstatsdReplay,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.Equal(t, "app.*", cliParams.dsdReplayName)
+			require.Equal(t, int32(42), cliParams.dsdReplayPid)
+			require.Equal(t, float64(0), cliParams.dsdReplaySpeed)
+			require.Equal(t, 0, cliParams.dsdReplayIterations)
+		})
+}
@@ -115,6 +115,14 @@ func requestDogstatsdStats(log log.Component, config config.Component, cliParams
 			fmt.Printf("Could not format the statistics, the data must be inconsistent. You may want to try the JSON output. Contact the support if you continue having issues.\n")
 			return nil
 		}
+
+		// the rules stats are only available when the agent knows them
+		ruleStatsURL := fmt.Sprintf("https://%v:%v/agent/dogstatsd-rule-stats", ipcAddress, pkgconfig.Datadog.GetInt("cmd_port"))
+		if rules, err := util.DoGet(c, ruleStatsURL, util.LeaveConnectionOpen); err == nil {
+			if formatted, err := serverDebug.FormatRuleStats(rules); err == nil {
+				s += "\n\n" + formatted
+			}
+		}
 	}
 
 	if cliParams.dsdStatsFilePath == "" {
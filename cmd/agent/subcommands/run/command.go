@@ -49,6 +49,8 @@ import (
 	remoteconfig "github.com/DataDog/datadog-agent/pkg/config/remote/service"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/schedulers/channel"
 	"github.com/DataDog/datadog-agent/pkg/metadata"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
 	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
@@ -427,12 +429,35 @@ func startAgent(
 		telemetry.RegisterStatsSender(sender)
 	}
 
+	// start logs-agent.  This must happen after AutoConfig is set up (via common.LoadComponents),
+	// and before the OTLP intake which forwards its logs to the logs-agent
+	var logsAgent *logs.Agent
+	if pkgconfig.Datadog.GetBool("logs_enabled") || pkgconfig.Datadog.GetBool("log_enabled") {
+		if pkgconfig.Datadog.GetBool("log_enabled") {
+			pkglog.Warn(`"log_enabled" is deprecated, use "logs_enabled" instead`)
+		}
+		logsAgent, err = logs.Start(common.AC)
+		if err != nil {
+			pkglog.Error("Could not start logs-agent: ", err)
+		}
+	} else {
+		pkglog.Info("logs-agent disabled")
+	}
+
 	// Start OTLP intake
 	otlpEnabled := otlp.IsEnabled(pkgconfig.Datadog)
 	inventories.SetAgentMetadata(inventories.AgentOTLPEnabled, otlpEnabled)
+	// the OTLP logs are forwarded to the logs-agent through this channel, only when it is
+	// running as nothing else would consume them
+	var otlpLogsChannel chan *logsconfig.ChannelMessage
+	if otlpEnabled && logsAgent != nil && pkgconfig.Datadog.GetBool(pkgconfig.OTLPLogsEnabled) {
+		otlpLogsChannel = make(chan *logsconfig.ChannelMessage, logsconfig.ChanSize)
+		// no source is set so that the source of each OTLP log record is used
+		logsAgent.AddScheduler(channel.NewScheduler("OTLP log ingestion", "", otlpLogsChannel))
+	}
 	if otlpEnabled {
 		var err error
-		common.OTLP, err = otlp.BuildAndStart(common.MainCtx, pkgconfig.Datadog, demux.Serializer())
+		common.OTLP, err = otlp.BuildAndStart(common.MainCtx, pkgconfig.Datadog, demux.Serializer(), otlpLogsChannel)
 		if err != nil {
 			pkglog.Errorf("Could not start OTLP: %s", err)
 		} else {
@@ -471,18 +496,6 @@ func startAgent(
 		}
 	}
 
-	// start logs-agent.  This must happen after AutoConfig is set up (via common.LoadComponents)
-	if pkgconfig.Datadog.GetBool("logs_enabled") || pkgconfig.Datadog.GetBool("log_enabled") {
-		if pkgconfig.Datadog.GetBool("log_enabled") {
-			pkglog.Warn(`"log_enabled" is deprecated, use "logs_enabled" instead`)
-		}
-		if _, err := logs.Start(common.AC); err != nil {
-			pkglog.Error("Could not start logs-agent: ", err)
-		}
-	} else {
-		pkglog.Info("logs-agent disabled")
-	}
-
 	// Start NetFlow server
 	// This must happen after LoadComponents is set up (via common.LoadComponents).
 	// netflow.StartServer uses AgentDemultiplexer, that uses ContextResolver, that uses the tagger (initialized by LoadComponents)
@@ -218,6 +218,51 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 		c.RareSamplerCardinality = coreconfig.Datadog.GetInt("apm_config.rare_sampler.cardinality")
 	}
 
+	if coreconfig.Datadog.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = coreconfig.Datadog.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if coreconfig.Datadog.IsSet("apm_config.tail_sampling.decision_wait") {
+		c.TailSampling.DecisionWait = coreconfig.Datadog.GetDuration("apm_config.tail_sampling.decision_wait")
+	}
+	if coreconfig.Datadog.IsSet("apm_config.tail_sampling.max_buffer_bytes") {
+		c.TailSampling.MaxBufferBytes = coreconfig.Datadog.GetInt64("apm_config.tail_sampling.max_buffer_bytes")
+	}
+	if k := "apm_config.tail_sampling.policies"; coreconfig.Datadog.IsSet(k) {
+		policies := make([]*config.TailSamplingPolicy, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &policies); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"policy_name\",\"type\":\"latency\",\"threshold_ms\":500}]', error: %v", k, err)
+		} else {
+			if err := compileTailSamplingPolicies(policies); err != nil {
+				osutil.Exitf("tail_sampling.policies: %s", err)
+			}
+			c.TailSampling.Policies = policies
+		}
+	}
+
+	if k := "apm_config.sampling_rules"; coreconfig.Datadog.IsSet(k) {
+		rules := make([]*config.SamplingRule, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"rule_name\",\"match\":{\"service\":\"web-*\"},\"sample_rate\":0.1}]', error: %v", k, err)
+		} else {
+			for _, r := range rules {
+				if err := r.Validate(); err != nil {
+					osutil.Exitf("sampling_rules: %s", err)
+				}
+			}
+			c.SamplingRules = rules
+		}
+	}
+
+	if coreconfig.Datadog.IsSet("apm_config.stats_additional_tags") {
+		c.StatsAdditionalTags = coreconfig.Datadog.GetStringSlice("apm_config.stats_additional_tags")
+	}
+	if coreconfig.Datadog.IsSet("apm_config.stats_additional_tags_max_cardinality") {
+		c.StatsAdditionalTagsMaxCardinality = coreconfig.Datadog.GetInt("apm_config.stats_additional_tags_max_cardinality")
+	}
+	if coreconfig.Datadog.IsSet("apm_config.compute_stats_by_span_kind") {
+		c.ComputeStatsBySpanKind = coreconfig.Datadog.GetBool("apm_config.compute_stats_by_span_kind")
+	}
+
 	if coreconfig.Datadog.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = coreconfig.Datadog.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
@@ -250,6 +295,17 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 			c.ReplaceTags = rt
 		}
 	}
+	if k := "apm_config.span_rules"; coreconfig.Datadog.IsSet(k) {
+		rules := make([]*config.SpanRule, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"rule_name\",\"action\":\"delete\",\"key\":\"usr.*\"}]', error: %v", k, err)
+		} else {
+			if err := compileSpanRules(rules); err != nil {
+				osutil.Exitf("span_rules: %s", err)
+			}
+			c.SpanRules = rules
+		}
+	}
 
 	if coreconfig.Datadog.IsSet("bind_host") || coreconfig.Datadog.IsSet("apm_config.apm_non_local_traffic") {
 		if coreconfig.Datadog.IsSet("bind_host") {
@@ -341,6 +397,19 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 			log.Errorf("Error reading writer config %q: %v", key, err)
 		}
 	}
+	if k := "apm_config.outputs"; coreconfig.Datadog.IsSet(k) {
+		outputs := make([]*config.WriterOutput, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &outputs); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"type\": \"file\",\"path\":\"/tmp/traces\"}]', error: %v", k, err)
+		} else {
+			for _, o := range outputs {
+				if err := o.Validate(); err != nil {
+					osutil.Exitf("outputs: %s", err)
+				}
+			}
+			c.WriterOutputs = outputs
+		}
+	}
 	if coreconfig.Datadog.IsSet("apm_config.connection_reset_interval") {
 		c.ConnectionResetInterval = getDuration(coreconfig.Datadog.GetInt("apm_config.connection_reset_interval"))
 	}
@@ -436,6 +505,9 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 		c.EVPProxy.MaxPayloadSize = coreconfig.Datadog.GetInt64(k)
 	}
 	c.DebugServerPort = coreconfig.Datadog.GetInt("apm_config.debug.port")
+	if k := "apm_config.debug.inspect_buffer_size"; coreconfig.Datadog.IsSet(k) {
+		c.InspectBufferSize = coreconfig.Datadog.GetInt(k)
+	}
 	return nil
 }
 
@@ -501,6 +573,124 @@ func compileReplaceRules(rules []*config.ReplaceRule) error {
 	return nil
 }
 
+// compileSpanRules validates the span rules and compiles the glob and regular expressions
+// they use. If it fails it returns the first error.
+func compileSpanRules(rules []*config.SpanRule) error {
+	for _, r := range rules {
+		if r.Name == "" {
+			return errors.New(`all rules must have a "name" property`)
+		}
+		switch r.Action {
+		case config.SpanRuleDropSpan:
+		case config.SpanRuleDelete, config.SpanRuleHash, config.SpanRuleMoveToMetrics, config.SpanRuleMoveToMeta, config.SpanRuleReplace:
+			if r.Key == "" {
+				return fmt.Errorf("rule %q: %q rules must have a %q", r.Name, r.Action, "key")
+			}
+			r.KeyRe = compileGlob(r.Key)
+			if r.Action != config.SpanRuleReplace {
+				break
+			}
+			if r.Pattern == "" {
+				return fmt.Errorf("rule %q: %q rules must have a %q", r.Name, r.Action, "pattern")
+			}
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return fmt.Errorf("rule %q: %s", r.Name, err)
+			}
+			r.Re = re
+		default:
+			return fmt.Errorf("rule %q: unknown action %q", r.Name, r.Action)
+		}
+
+		m := &r.Match
+		for _, cond := range []struct {
+			pattern string
+			re      **regexp.Regexp
+		}{
+			{m.Service, &m.ServiceRe},
+			{m.Name, &m.NameRe},
+			{m.Resource, &m.ResourceRe},
+		} {
+			if cond.pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(cond.pattern)
+			if err != nil {
+				return fmt.Errorf("rule %q: %s", r.Name, err)
+			}
+			*cond.re = re
+		}
+		for _, tag := range m.Tags {
+			if tag.Key == "" {
+				return fmt.Errorf("rule %q: all tag conditions must have a %q", r.Name, "key")
+			}
+			if tag.Pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(tag.Pattern)
+			if err != nil {
+				return fmt.Errorf("rule %q: tag %q: %s", r.Name, tag.Key, err)
+			}
+			tag.Re = re
+		}
+	}
+	return nil
+}
+
+// compileGlob returns a regular expression matching the strings matched by the glob
+// pattern, where "*" matches any sequence of characters and "?" any single character.
+func compileGlob(glob string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// compileTailSamplingPolicies validates the tail sampling policies and compiles the regular
+// expressions they use. If it fails it returns the first error.
+func compileTailSamplingPolicies(policies []*config.TailSamplingPolicy) error {
+	for _, p := range policies {
+		if p.Name == "" {
+			return errors.New(`all policies must have a "name" property`)
+		}
+		switch p.Type {
+		case config.TailSamplingLatencyPolicy:
+			if p.ThresholdMs <= 0 {
+				return fmt.Errorf("policy %q: %q must be positive", p.Name, "threshold_ms")
+			}
+		case config.TailSamplingErrorPolicy:
+		case config.TailSamplingAttributePolicy:
+			if p.Key == "" {
+				return fmt.Errorf("policy %q: attribute policies must have a %q", p.Name, "key")
+			}
+			if p.Pattern != "" {
+				re, err := regexp.Compile(p.Pattern)
+				if err != nil {
+					return fmt.Errorf("policy %q: %s", p.Name, err)
+				}
+				p.Re = re
+			}
+		case config.TailSamplingRatePolicy:
+			if p.Rate < 0 || p.Rate > 1 {
+				return fmt.Errorf("policy %q: %q must be between 0 and 1", p.Name, "rate")
+			}
+		default:
+			return fmt.Errorf("policy %q: unknown type %q", p.Name, p.Type)
+		}
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
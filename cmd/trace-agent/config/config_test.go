@@ -55,6 +55,37 @@ func TestParseRepaceRules(t *testing.T) {
 	}
 }
 
+func TestCompileSpanRules(t *testing.T) {
+	for _, tt := range []struct {
+		rule *config.SpanRule
+		err  string
+	}{
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleDropSpan}},
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleDelete, Key: "usr.*"}},
+		{rule: &config.SpanRule{Action: config.SpanRuleDelete, Key: "usr.*"}, err: `all rules must have a "name" property`},
+		{rule: &config.SpanRule{Name: "a", Action: "encrypt", Key: "usr.*"}, err: `rule "a": unknown action "encrypt"`},
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleHash}, err: `rule "a": "hash" rules must have a "key"`},
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleReplace, Key: "http.url"}, err: `rule "a": "replace" rules must have a "pattern"`},
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleDropSpan, Match: config.SpanRuleMatch{Service: "("}}, err: "rule \"a\": error parsing regexp: missing closing ): `(`"},
+		{rule: &config.SpanRule{Name: "a", Action: config.SpanRuleDropSpan, Match: config.SpanRuleMatch{Tags: []*config.SpanRuleTag{{Pattern: "x"}}}}, err: `rule "a": all tag conditions must have a "key"`},
+	} {
+		err := compileSpanRules([]*config.SpanRule{tt.rule})
+		if tt.err == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestCompileGlob(t *testing.T) {
+	re := compileGlob("usr.?d*")
+	assert.True(t, re.MatchString("usr.id"))
+	assert.True(t, re.MatchString("usr.id.hash"))
+	assert.False(t, re.MatchString("usr_id"))
+	assert.False(t, re.MatchString("meta.usr.id"))
+}
+
 func TestSplitTag(t *testing.T) {
 	for _, tt := range []struct {
 		tag string
@@ -478,6 +509,59 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
 
+	if assert.Len(c.SpanRules, 3) {
+		assert.Equal(config.SpanRuleReplace, c.SpanRules[0].Action)
+		assert.Equal("/users", c.SpanRules[0].Re.ReplaceAllString("/users?id=1", c.SpanRules[0].Repl))
+		assert.True(c.SpanRules[0].KeyRe.MatchString("http.url"))
+		assert.False(c.SpanRules[0].KeyRe.MatchString("http_url"))
+		assert.True(c.SpanRules[1].KeyRe.MatchString("usr.id"))
+		assert.False(c.SpanRules[1].KeyRe.MatchString("user.id"))
+		m := c.SpanRules[2].Match
+		assert.True(m.ServiceRe.MatchString("redis"))
+		assert.Nil(m.NameRe)
+		if assert.Len(m.Tags, 1) {
+			assert.Equal("cache.hit", m.Tags[0].Key)
+			assert.True(m.Tags[0].Re.MatchString("true"))
+		}
+	}
+
+	ts := c.TailSampling
+	assert.True(ts.Enabled)
+	assert.Equal(30*time.Second, ts.DecisionWait)
+	assert.Equal(int64(1000000), ts.MaxBufferBytes)
+	if assert.Len(ts.Policies, 4) {
+		assert.Equal(&config.TailSamplingPolicy{Name: "slow-checkout", Type: config.TailSamplingLatencyPolicy, Service: "checkout", ThresholdMs: 2000}, ts.Policies[0])
+		assert.Equal(config.TailSamplingErrorPolicy, ts.Policies[1].Type)
+		assert.Equal("customer.tier", ts.Policies[2].Key)
+		assert.True(ts.Policies[2].Re.MatchString("vip-gold"))
+		assert.Equal(0.1, ts.Policies[3].Rate)
+	}
+
+	assert.Equal([]*config.SamplingRule{
+		{
+			Name: "health-checks",
+			Match: config.SamplingRuleMatch{
+				Service:  "web-*",
+				Resource: "GET /health*",
+				Tags:     []*config.SamplingRuleTag{{Key: "http.status_code", Value: "2??"}},
+			},
+			SampleRate:   0.01,
+			MaxPerSecond: 5,
+		},
+		{Name: "checkout", Match: config.SamplingRuleMatch{Service: "checkout"}, SampleRate: 1},
+	}, c.SamplingRules)
+
+	assert.Equal([]*config.WriterOutput{
+		{Type: "datadog"},
+		{Type: "file", Path: "/var/log/datadog/apm-payloads", MaxSizeMB: 10},
+		{Type: "otlp", Endpoint: "localhost:4317", Insecure: true},
+	}, c.WriterOutputs)
+
+	assert.Equal([]string{"peer.service", "db.instance"}, c.StatsAdditionalTags)
+	assert.Equal(50, c.StatsAdditionalTagsMaxCardinality)
+	assert.True(c.ComputeStatsBySpanKind)
+	assert.Equal(200, c.InspectBufferSize)
+
 	o := c.Obfuscation
 	assert.NotNil(o)
 	assert.True(o.ES.Enabled)
@@ -920,6 +1004,56 @@ func TestLoadEnv(t *testing.T) {
 		})
 	}
 
+	env = "DD_APM_STATS_ADDITIONAL_TAGS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, "peer.service http.method")
+		t.Setenv("DD_APM_STATS_ADDITIONAL_TAGS_MAX_CARDINALITY", "20")
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]string{"peer.service", "http.method"}, cfg.StatsAdditionalTags)
+		assert.Equal(20, cfg.StatsAdditionalTagsMaxCardinality)
+	})
+
+	env = "DD_APM_SAMPLING_RULES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, `[{"name":"noisy","match":{"name":"redis.*"},"sample_rate":0.5,"max_per_second":100}]`)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.SamplingRule{{
+			Name:         "noisy",
+			Match:        config.SamplingRuleMatch{Name: "redis.*"},
+			SampleRate:   0.5,
+			MaxPerSecond: 100,
+		}}, cfg.SamplingRules)
+	})
+
+	env = "DD_APM_OUTPUTS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, `[{"type":"datadog"},{"type":"file","path":"/tmp/apm","max_backups":2}]`)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.WriterOutput{
+			{Type: "datadog"},
+			{Type: "file", Path: "/tmp/apm", MaxBackups: 2},
+		}, cfg.WriterOutputs)
+	})
+
+	env = "DD_APM_DEBUG_INSPECT_BUFFER_SIZE"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		t.Setenv(env, "50")
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal(50, cfg.InspectBufferSize)
+	})
+
 	env = "DD_APM_MAX_REMOTE_TPS"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
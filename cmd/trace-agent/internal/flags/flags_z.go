@@ -20,6 +20,13 @@ var (
 	// Info will display information about a running agent.
 	Info bool
 
+	// StreamTraces will print the trace chunks processed by a running agent.
+	StreamTraces bool
+
+	// StreamTracesService and StreamTracesEnv filter the trace chunks printed by StreamTraces.
+	StreamTracesService string
+	StreamTracesEnv     string
+
 	// CPUProfile specifies the path to output CPU profiling information to.
 	// When empty, CPU profiling is disabled.
 	CPUProfile string
@@ -41,6 +48,9 @@ func init() {
 	flag.StringVar(&PIDFilePath, "pid", "", "Path to set pidfile for process")
 	flag.BoolVar(&Version, "version", false, "Show version information and exit")
 	flag.BoolVar(&Info, "info", false, "Show info about running trace agent process and exit")
+	flag.BoolVar(&StreamTraces, "stream-traces", false, "Stream the traces processed by the running trace agent along with their sampling decision")
+	flag.StringVar(&StreamTracesService, "service", "", "Only stream the traces holding spans of this service (used with -stream-traces)")
+	flag.StringVar(&StreamTracesEnv, "env", "", "Only stream the traces of this env (used with -stream-traces)")
 
 	// profiling
 	flag.StringVar(&CPUProfile, "cpuprofile", "", "Write cpu profile to file")
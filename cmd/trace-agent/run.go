@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/api"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
 	tracelog "github.com/DataDog/datadog-agent/pkg/trace/log"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
@@ -85,6 +86,15 @@ func Run(ctx context.Context) {
 		return
 	}
 
+	if flags.StreamTraces {
+		addr := fmt.Sprintf("http://127.0.0.1:%d", cfg.DebugServerPort)
+		filter := inspect.Filter{Service: flags.StreamTracesService, Env: flags.StreamTracesEnv}
+		if err := inspect.Stream(ctx, os.Stdout, addr, filter); err != nil {
+			osutil.Exitf("Failed to stream traces: %s", err)
+		}
+		return
+	}
+
 	telemetryCollector := telemetry.NewCollector(cfg)
 
 	if err := coreconfig.SetupLogger(
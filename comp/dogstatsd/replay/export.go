@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ExportText is the plain-text export format.
+	ExportText = "text"
+	// ExportJSON is the JSON export format.
+	ExportJSON = "json"
+)
+
+type exportedEntity struct {
+	ID                          string   `json:"id"`
+	LowCardinalityTags          []string `json:"low_cardinality_tags,omitempty"`
+	OrchestratorCardinalityTags []string `json:"orchestrator_cardinality_tags,omitempty"`
+	HighCardinalityTags         []string `json:"high_cardinality_tags,omitempty"`
+	StandardTags                []string `json:"standard_tags,omitempty"`
+}
+
+type exportedPacket struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Pid         int32     `json:"pid,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+	Messages    []string  `json:"messages"`
+}
+
+type exportedCapture struct {
+	Version  int              `json:"version"`
+	PidMap   map[int32]string `json:"pid_map,omitempty"`
+	Entities []exportedEntity `json:"entities,omitempty"`
+	Packets  []exportedPacket `json:"packets"`
+}
+
+// Export writes a human-readable listing of the packets of the capture selected
+// by the Filter of the reader, with their timestamp and origin, along with the
+// tagger state of the capture. format is either ExportText or ExportJSON.
+//
+// Export reads the capture from the start and must not be called while a Read is ongoing.
+func (tc *TrafficCaptureReader) Export(w io.Writer, format string) error {
+	if format != ExportText && format != ExportJSON {
+		return fmt.Errorf("unknown export format %q, expected %q or %q", format, ExportText, ExportJSON)
+	}
+
+	capture := exportedCapture{Version: tc.Version}
+
+	// older captures have no state, the packets are still worth exporting
+	pidMap, state, err := tc.ReadState()
+	if err == nil {
+		capture.PidMap = pidMap
+		for id, entity := range state {
+			capture.Entities = append(capture.Entities, exportedEntity{
+				ID:                          id,
+				LowCardinalityTags:          entity.LowCardinalityTags,
+				OrchestratorCardinalityTags: entity.OrchestratorCardinalityTags,
+				HighCardinalityTags:         entity.HighCardinalityTags,
+				StandardTags:                entity.StandardTags,
+			})
+		}
+		sort.Slice(capture.Entities, func(i, j int) bool {
+			return capture.Entities[i].ID < capture.Entities[j].ID
+		})
+	}
+
+	tsResolution := tc.timestampResolution()
+	tc.Seek(0)
+	for {
+		msg, err := tc.ReadNext()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if !tc.Filter.Apply(msg) {
+			continue
+		}
+		capture.Packets = append(capture.Packets, exportedPacket{
+			Timestamp:   time.Unix(0, msg.Timestamp*int64(tsResolution)).UTC(),
+			Pid:         msg.Pid,
+			ContainerID: pidMap[msg.Pid],
+			Messages:    strings.Split(string(bytes.TrimRight(msg.Payload[:msg.PayloadSize], "\n")), "\n"),
+		})
+	}
+
+	if format == ExportJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(capture)
+	}
+	return exportText(w, &capture)
+}
+
+func exportText(w io.Writer, capture *exportedCapture) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "Capture file version: %d\n\n", capture.Version)
+
+	fmt.Fprintf(b, "Tagger state:\n")
+	if len(capture.Entities) == 0 {
+		fmt.Fprintf(b, "  (none)\n")
+	}
+	for _, entity := range capture.Entities {
+		fmt.Fprintf(b, "  %s\n", entity.ID)
+		writeTags(b, "low", entity.LowCardinalityTags)
+		writeTags(b, "orchestrator", entity.OrchestratorCardinalityTags)
+		writeTags(b, "high", entity.HighCardinalityTags)
+		writeTags(b, "standard", entity.StandardTags)
+	}
+
+	pids := make([]int32, 0, len(capture.PidMap))
+	for pid := range capture.PidMap {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, j int) bool { return pids[i] < pids[j] })
+	fmt.Fprintf(b, "\nPID map:\n")
+	if len(pids) == 0 {
+		fmt.Fprintf(b, "  (none)\n")
+	}
+	for _, pid := range pids {
+		fmt.Fprintf(b, "  %d: %s\n", pid, capture.PidMap[pid])
+	}
+
+	fmt.Fprintf(b, "\nPackets (%d):\n", len(capture.Packets))
+	for _, packet := range capture.Packets {
+		fmt.Fprintf(b, "%s", packet.Timestamp.Format(time.RFC3339Nano))
+		if packet.Pid != 0 {
+			fmt.Fprintf(b, " pid:%d", packet.Pid)
+		}
+		if packet.ContainerID != "" {
+			fmt.Fprintf(b, " %s", packet.ContainerID)
+		}
+		fmt.Fprintf(b, "\n")
+		for _, message := range packet.Messages {
+			fmt.Fprintf(b, "    %s\n", message)
+		}
+	}
+
+	return b.Flush()
+}
+
+func writeTags(b io.Writer, cardinality string, tags []string) {
+	if len(tags) > 0 {
+		fmt.Fprintf(b, "    %s: %s\n", cardinality, strings.Join(tags, ", "))
+	}
+}
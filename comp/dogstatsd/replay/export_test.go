@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContainerID = "container_id://c1371eaf97a11f43ac700fd8524b4ea316d83a7259282a9e9eeac8d071406b22"
+
+func TestExportJSON(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog.zstd", 1, false)
+	require.NoError(t, err)
+	defer tc.Close()
+	tc.Filter, err = NewFilter("", 2815)
+	require.NoError(t, err)
+
+	var b bytes.Buffer
+	require.NoError(t, tc.Export(&b, ExportJSON))
+
+	var capture exportedCapture
+	require.NoError(t, json.Unmarshal(b.Bytes(), &capture))
+	assert.Equal(t, 2, capture.Version)
+	assert.Len(t, capture.PidMap, 7)
+	require.Len(t, capture.Entities, 1)
+	assert.Equal(t, testContainerID, capture.Entities[0].ID)
+	assert.Contains(t, capture.Entities[0].LowCardinalityTags, "image_name:ubuntu")
+
+	require.Len(t, capture.Packets, 1)
+	assert.Equal(t, int32(2815), capture.Packets[0].Pid)
+	assert.Equal(t, testContainerID, capture.Packets[0].ContainerID)
+	assert.Equal(t, []string{"jaime.uds.test:8|g|#shell:test"}, capture.Packets[0].Messages)
+	assert.Equal(t, int64(1621285675), capture.Packets[0].Timestamp.Unix())
+}
+
+func TestExportText(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+	defer tc.Close()
+
+	var b bytes.Buffer
+	require.NoError(t, tc.Export(&b, ExportText))
+
+	out := b.String()
+	assert.Contains(t, out, "Capture file version: 2\n")
+	assert.Contains(t, out, "    low: docker_image:ubuntu, image_name:ubuntu, short_image:ubuntu\n")
+	assert.Contains(t, out, "  2815: "+testContainerID+"\n")
+	assert.Contains(t, out, "Packets (21):\n")
+	assert.Contains(t, out, "2021-05-17T21:07:55Z pid:2815 "+testContainerID+"\n    jaime.uds.test:8|g|#shell:test\n")
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+	defer tc.Close()
+
+	assert.Error(t, tc.Export(&bytes.Buffer{}, "yaml"))
+}
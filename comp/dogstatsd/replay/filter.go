@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gobwas/glob"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo"
+)
+
+var (
+	eventPrefix        = []byte("_e{")
+	serviceCheckPrefix = []byte("_sc|")
+)
+
+// Filter selects the captured packets to replay or export.
+type Filter struct {
+	name glob.Glob
+	pid  int32
+}
+
+// NewFilter returns a Filter keeping the metrics whose name matches the nameGlob
+// pattern, sent by the process with the given pid. An empty pattern keeps all the
+// messages, including the events and service checks, and a pid of 0 keeps the
+// packets of all the processes.
+func NewFilter(nameGlob string, pid int32) (*Filter, error) {
+	f := &Filter{pid: pid}
+	if nameGlob != "" {
+		g, err := glob.Compile(nameGlob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric name pattern %q: %v", nameGlob, err)
+		}
+		f.name = g
+	}
+	return f, nil
+}
+
+// Apply returns false if none of the messages of the packet is selected by the
+// filter. Otherwise, the payload of msg is rewritten to only contain the selected
+// messages.
+func (f *Filter) Apply(msg *pb.UnixDogstatsdMsg) bool {
+	if f == nil {
+		return true
+	}
+	if f.pid != 0 && msg.Pid != f.pid {
+		return false
+	}
+	if f.name == nil {
+		return true
+	}
+
+	payload := msg.Payload[:msg.PayloadSize]
+	filtered := make([]byte, 0, len(payload))
+	for len(payload) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(payload, '\n'); i >= 0 {
+			line, payload = payload[:i], payload[i+1:]
+		} else {
+			line, payload = payload, nil
+		}
+		if !f.matchMetric(line) {
+			continue
+		}
+		if len(filtered) > 0 {
+			filtered = append(filtered, '\n')
+		}
+		filtered = append(filtered, line...)
+	}
+	if len(filtered) == 0 {
+		return false
+	}
+
+	msg.Payload = filtered
+	msg.PayloadSize = int32(len(filtered))
+	return true
+}
+
+// matchMetric returns whether the message is a metric whose name matches the filter.
+func (f *Filter) matchMetric(message []byte) bool {
+	if bytes.HasPrefix(message, eventPrefix) || bytes.HasPrefix(message, serviceCheckPrefix) {
+		return false
+	}
+	i := bytes.IndexByte(message, ':')
+	if i <= 0 {
+		return false
+	}
+	return f.name.Match(string(message[:i]))
+}
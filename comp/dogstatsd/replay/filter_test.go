@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo"
+)
+
+func newTestMsg(pid int32, payload string) *pb.UnixDogstatsdMsg {
+	return &pb.UnixDogstatsdMsg{
+		Pid:         pid,
+		Payload:     []byte(payload),
+		PayloadSize: int32(len(payload)),
+	}
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	_, err := NewFilter("app.[", 0)
+	assert.Error(t, err)
+}
+
+func TestFilterNil(t *testing.T) {
+	var f *Filter
+	assert.True(t, f.Apply(newTestMsg(1, "a:1|c")))
+}
+
+func TestFilterPid(t *testing.T) {
+	f, err := NewFilter("", 42)
+	require.NoError(t, err)
+
+	assert.False(t, f.Apply(newTestMsg(1, "a:1|c")))
+
+	msg := newTestMsg(42, "a:1|c\n_e{1,1}:a|b")
+	assert.True(t, f.Apply(msg))
+	assert.Equal(t, "a:1|c\n_e{1,1}:a|b", string(msg.Payload[:msg.PayloadSize]))
+}
+
+func TestFilterName(t *testing.T) {
+	f, err := NewFilter("app.*", 0)
+	require.NoError(t, err)
+
+	assert.False(t, f.Apply(newTestMsg(1, "other:1|c\n_e{3,1}:app|b\n_sc|app.check|0")))
+
+	msg := newTestMsg(1, "app.requests:1|c|#env:prod\nother:1|c\napp.latency:10|ms\n")
+	assert.True(t, f.Apply(msg))
+	assert.Equal(t, "app.requests:1|c|#env:prod\napp.latency:10|ms", string(msg.Payload[:msg.PayloadSize]))
+	assert.Equal(t, int32(len(msg.Payload)), msg.PayloadSize)
+}
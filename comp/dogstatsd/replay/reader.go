@@ -33,6 +33,12 @@ type TrafficCaptureReader struct {
 	offset      uint32
 	mmap        bool
 
+	// Speed scales the delays between the packets sent to the Traffic channel:
+	// 2 reads the capture twice as fast as it was recorded, and 0 as fast as possible.
+	Speed float64
+	// Filter, when set, selects the packets sent to the Traffic channel.
+	Filter *Filter
+
 	sync.Mutex
 }
 
@@ -77,6 +83,7 @@ func NewTrafficCaptureReader(path string, depth int, mmap bool) (*TrafficCapture
 		Version:     ver,
 		Traffic:     make(chan *pb.UnixDogstatsdMsg, depth),
 		mmap:        mmap,
+		Speed:       1,
 	}, nil
 }
 
@@ -92,12 +99,8 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	// skip header
 	tc.offset = uint32(len(datadogHeader))
 
-	var tsResolution time.Duration
-	if tc.Version < minNanoVersion {
-		tsResolution = time.Second
-	} else {
-		tsResolution = time.Nanosecond
-	}
+	tsResolution := tc.timestampResolution()
+	speed := tc.Speed
 	tc.Unlock()
 
 	last := int64(0)
@@ -118,9 +121,13 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 			break
 		}
 
-		if last != 0 {
+		if !tc.Filter.Apply(msg) {
+			continue
+		}
+
+		if last != 0 && speed > 0 {
 			if msg.Timestamp > last {
-				util.Wait(tsResolution * time.Duration(msg.Timestamp-last))
+				util.Wait(time.Duration(float64(tsResolution*time.Duration(msg.Timestamp-last)) / speed))
 			}
 		}
 
@@ -136,6 +143,14 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	}
 }
 
+// timestampResolution returns the unit of the timestamps of the captured packets.
+func (tc *TrafficCaptureReader) timestampResolution() time.Duration {
+	if tc.Version < minNanoVersion {
+		return time.Second
+	}
+	return time.Nanosecond
+}
+
 // Close cleans up any resources used by the TrafficCaptureReader, should not normally
 // be called directly.
 func (tc *TrafficCaptureReader) Close() error {
@@ -8,6 +8,7 @@ package replay
 import (
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -91,3 +92,36 @@ func TestSeek(t *testing.T) {
 	assert.Equal(t, cnt*i, total)
 
 }
+
+func TestReadFilterAsFastAsPossible(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog.zstd", 1, false)
+	assert.Nil(t, err)
+	defer tc.Close()
+
+	// the capture spans 13 seconds, it must be read without waiting
+	tc.Speed = 0
+	tc.Filter, err = NewFilter("jaime.*", 2815)
+	assert.Nil(t, err)
+
+	ready := make(chan struct{})
+	go tc.Read(ready)
+	<-ready
+
+	var pids []int32
+	for done := false; !done; {
+		select {
+		case msg := <-tc.Traffic:
+			pids = append(pids, msg.Pid)
+		case <-tc.Done:
+			// the last packets may still be buffered
+			for len(tc.Traffic) > 0 {
+				msg := <-tc.Traffic
+				pids = append(pids, msg.Pid)
+			}
+			done = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("the capture was not read as fast as possible")
+		}
+	}
+	assert.Equal(t, []int32{2815}, pids)
+}
@@ -62,6 +62,29 @@ var CapPool = sync.Pool{
 	},
 }
 
+// EnqueueCopy enqueues a copy of the payload to the ongoing capture, if any. It is
+// meant for the listeners without origin detection, which reuse their read buffers
+// instead of pooled packets. The payload is recorded without origin.
+func EnqueueCopy(capture Component, payload []byte) {
+	if capture == nil || !capture.IsOngoing() {
+		return
+	}
+
+	capBuff := CapPool.Get().(*CaptureBuffer)
+	capBuff.Pb.Timestamp = time.Now().UnixNano()
+	capBuff.Pb.Pid = 0
+	capBuff.Pb.AncillarySize = 0
+	capBuff.Pb.Ancillary = nil
+	capBuff.Pb.PayloadSize = int32(len(payload))
+	capBuff.Pb.Payload = append([]byte(nil), payload...)
+	capBuff.Pid = 0
+	capBuff.ContainerID = ""
+	capBuff.Oob = nil
+	capBuff.Buff = nil
+
+	capture.Enqueue(capBuff)
+}
+
 // TrafficCaptureWriter allows writing dogstatsd traffic to a file.
 type TrafficCaptureWriter struct {
 	zWriter   *zstd.Writer
@@ -101,11 +124,13 @@ func (tc *TrafficCaptureWriter) processMessage(msg *CaptureBuffer) error {
 		tc.taggerState[msg.Pid] = msg.ContainerID
 	}
 
-	if tc.sharedPacketPoolManager != nil {
+	// the packets captured by the listeners without origin detection are copied
+	// and hold no pooled buffer
+	if tc.sharedPacketPoolManager != nil && msg.Buff != nil {
 		tc.sharedPacketPoolManager.Put(msg.Buff)
 	}
 
-	if tc.oobPacketPoolManager != nil {
+	if tc.oobPacketPoolManager != nil && msg.Oob != nil {
 		tc.oobPacketPoolManager.Put(msg.Oob)
 	}
 
@@ -175,3 +175,35 @@ func TestValidateLocation(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, locationGood, l)
 }
+
+type recordingTrafficCapture struct {
+	mockTrafficCapture
+	enqueued []*CaptureBuffer
+}
+
+func (tc *recordingTrafficCapture) Enqueue(msg *CaptureBuffer) bool {
+	tc.enqueued = append(tc.enqueued, msg)
+	return true
+}
+
+func TestEnqueueCopy(t *testing.T) {
+	capture := &recordingTrafficCapture{}
+	payload := []byte("foo.bar:5|c|#some:tag")
+
+	// no ongoing capture
+	EnqueueCopy(capture, payload)
+	EnqueueCopy(nil, payload)
+	assert.Empty(t, capture.enqueued)
+
+	capture.Start("", time.Minute, false)
+	EnqueueCopy(capture, payload)
+	require.Len(t, capture.enqueued, 1)
+	copy(payload, "bar")
+
+	msg := capture.enqueued[0]
+	assert.Equal(t, "foo.bar:5|c|#some:tag", string(msg.Pb.Payload))
+	assert.Equal(t, int32(len(payload)), msg.Pb.PayloadSize)
+	assert.Equal(t, int32(0), msg.Pb.Pid)
+	assert.Nil(t, msg.Buff)
+	assert.Nil(t, msg.Oob)
+}
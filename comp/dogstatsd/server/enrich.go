@@ -32,6 +32,7 @@ type enrichConfig struct {
 	entityIDPrecedenceEnabled bool
 	serverlessMode            bool
 	originOptOutEnabled       bool
+	rules                     *ruleSet
 }
 
 // extractTagsMetadata returns tags (client tags + host tag) and information needed to query tagger (origins, cardinality).
@@ -173,6 +174,13 @@ func enrichMetricSample(dest []metrics.MetricSample, ddSample dogstatsdMetricSam
 		return []metrics.MetricSample{}
 	}
 
+	if conf.rules != nil {
+		var keep bool
+		if tags, keep = conf.rules.apply(metricName, tags); !keep {
+			return dest
+		}
+	}
+
 	if conf.serverlessMode { // we don't want to set the host while running in serverless mode
 		hostnameFromTags = ""
 	}
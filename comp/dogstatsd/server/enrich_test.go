@@ -8,7 +8,9 @@ package server
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 
@@ -995,6 +997,32 @@ func TestMetricBlocklistShouldNotBlock(t *testing.T) {
 	assert.Equal(t, 1, len(samples))
 }
 
+func TestMetricRules(t *testing.T) {
+	rules, err := newRuleSet([]config.DogstatsdRule{
+		{Name: "drop-b", Match: "foo.custom.metric.b", Action: ruleActionDrop},
+		{Name: "strip-request-id", Action: ruleActionDropTags, Tags: []string{"request_id"}},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	conf := enrichConfig{
+		metricPrefix:    "foo.",
+		defaultHostname: "default",
+		rules:           rules,
+	}
+
+	parsed, err := parseAndEnrichMultipleMetricMessage([]byte("custom.metric.a:21:22|ms|#request_id:42,env:prod,host:my-host"), conf)
+	assert.NoError(t, err)
+	require.Len(t, parsed, 2)
+	for _, sample := range parsed {
+		assert.Equal(t, "foo.custom.metric.a", sample.Name)
+		assert.Equal(t, []string{"env:prod"}, sample.Tags)
+		assert.Equal(t, "my-host", sample.Host)
+	}
+
+	parsed, err = parseAndEnrichMultipleMetricMessage([]byte("custom.metric.b:21|ms"), conf)
+	assert.NoError(t, err)
+	assert.Len(t, parsed, 0)
+}
+
 func TestConvertEntityOriginDetectionNoTags(t *testing.T) {
 	conf := enrichConfig{
 		defaultHostname: "default-hostname",
@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gobwas/glob"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	ruleActionDrop           = "drop"
+	ruleActionDropTags       = "drop_tags"
+	ruleActionAllowTags      = "allow_tags"
+	ruleActionRenameTags     = "rename_tags"
+	ruleActionLimitTagValues = "limit_tag_values"
+
+	// otherTagValue replaces the values of a tag over the limit of a limit_tag_values rule.
+	otherTagValue = "other"
+
+	// maxLimitedContexts bounds the number of metric name and tag key pairs whose values
+	// a limit_tag_values rule tracks. Over it, the values of the new pairs are replaced
+	// with otherTagValue until some pairs expire.
+	maxLimitedContexts = 10000
+)
+
+// rule is a compiled dogstatsd_rules entry.
+type rule struct {
+	name   string
+	action string
+	// match is nil when the rule applies to every metric
+	match glob.Glob
+	// tagKeys are the patterns of the drop_tags and allow_tags actions
+	tagKeys []glob.Glob
+	// rename maps old tag keys to new ones for the rename_tags action
+	rename map[string]string
+	// limitKeys and maxValues configure the limit_tag_values action
+	limitKeys map[string]struct{}
+	maxValues int
+
+	mu sync.Mutex // guards values and lastExpired
+	// values holds the distinct values seen, by metric name and tag key
+	values map[string]*limitedValues
+	// lastExpired is the last time the values of all the contexts were expired
+	lastExpired time.Time
+}
+
+// limitedValues holds the distinct values of a tag seen for a metric, with the last
+// time each of them was seen.
+type limitedValues struct {
+	lastSeen    map[string]time.Time
+	lastExpired time.Time
+}
+
+// expire forgets the values not seen for the expiry.
+func (v *limitedValues) expire(now time.Time, expiry time.Duration) {
+	v.lastExpired = now
+	for value, lastSeen := range v.lastSeen {
+		if now.Sub(lastSeen) >= expiry {
+			delete(v.lastSeen, value)
+		}
+	}
+}
+
+// ruleSet applies an ordered list of rules to the metric samples.
+type ruleSet struct {
+	rules []*rule
+	// onHit is called with the name of a rule every time it drops or modifies a sample
+	onHit func(rule string)
+	// expiry is the time after which the values a limit_tag_values rule has not seen
+	// anymore are forgotten, making room for new ones
+	expiry time.Duration
+	clock  clock.Clock
+}
+
+// newRuleSet compiles the given rules. onHit may be nil.
+func newRuleSet(rules []config.DogstatsdRule, expiry time.Duration, onHit func(rule string)) (*ruleSet, error) {
+	rs := &ruleSet{onHit: onHit, expiry: expiry, clock: clock.New()}
+	for _, cfg := range rules {
+		r, err := newRule(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rs.rules = append(rs.rules, r)
+	}
+	return rs, nil
+}
+
+func newRule(cfg config.DogstatsdRule) (*rule, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("rule without name")
+	}
+	r := &rule{name: cfg.Name, action: cfg.Action}
+	if cfg.Match != "" {
+		g, err := glob.Compile(cfg.Match)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: invalid match pattern %q: %v", cfg.Name, cfg.Match, err)
+		}
+		r.match = g
+	}
+
+	switch cfg.Action {
+	case ruleActionDrop:
+	case ruleActionDropTags, ruleActionAllowTags:
+		if len(cfg.Tags) == 0 {
+			return nil, fmt.Errorf("rule %q: action %q requires tags", cfg.Name, cfg.Action)
+		}
+		for _, pattern := range cfg.Tags {
+			g, err := glob.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid tag pattern %q: %v", cfg.Name, pattern, err)
+			}
+			r.tagKeys = append(r.tagKeys, g)
+		}
+	case ruleActionRenameTags:
+		if len(cfg.Rename) == 0 {
+			return nil, fmt.Errorf("rule %q: action %q requires rename", cfg.Name, cfg.Action)
+		}
+		r.rename = cfg.Rename
+	case ruleActionLimitTagValues:
+		if len(cfg.Tags) == 0 || cfg.MaxValues <= 0 {
+			return nil, fmt.Errorf("rule %q: action %q requires tags and a positive max_values", cfg.Name, cfg.Action)
+		}
+		r.limitKeys = make(map[string]struct{}, len(cfg.Tags))
+		for _, key := range cfg.Tags {
+			r.limitKeys[key] = struct{}{}
+		}
+		r.maxValues = cfg.MaxValues
+		r.values = make(map[string]*limitedValues)
+	default:
+		return nil, fmt.Errorf("rule %q: unknown action %q", cfg.Name, cfg.Action)
+	}
+	return r, nil
+}
+
+// apply applies the rules to a metric sample, modifying its tags in place. It returns
+// the new tags, and false if the sample must be dropped.
+func (rs *ruleSet) apply(name string, tags []string) ([]string, bool) {
+	for _, r := range rs.rules {
+		if r.match != nil && !r.match.Match(name) {
+			continue
+		}
+		hit := false
+		switch r.action {
+		case ruleActionDrop:
+			rs.hit(r)
+			return tags, false
+		case ruleActionDropTags:
+			tags, hit = r.filterTags(tags, false)
+		case ruleActionAllowTags:
+			tags, hit = r.filterTags(tags, true)
+		case ruleActionRenameTags:
+			hit = r.renameTags(tags)
+		case ruleActionLimitTagValues:
+			hit = r.limitTagValues(name, tags, rs.clock.Now(), rs.expiry)
+		}
+		if hit {
+			rs.hit(r)
+		}
+	}
+	return tags, true
+}
+
+func (rs *ruleSet) hit(r *rule) {
+	if rs.onHit != nil {
+		rs.onHit(r.name)
+	}
+}
+
+// splitTag returns the key and value of a tag. Tags without value are their own key.
+func splitTag(tag string) (string, string) {
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// filterTags keeps the tags whose key matches one of the patterns of the rule when
+// allow is true, and the ones which don't otherwise.
+func (r *rule) filterTags(tags []string, allow bool) ([]string, bool) {
+	n := 0
+	for _, tag := range tags {
+		key, _ := splitTag(tag)
+		if r.matchesTagKey(key) == allow {
+			tags[n] = tag
+			n++
+		}
+	}
+	return tags[:n], n != len(tags)
+}
+
+func (r *rule) matchesTagKey(key string) bool {
+	for _, g := range r.tagKeys {
+		if g.Match(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) renameTags(tags []string) bool {
+	hit := false
+	for i, tag := range tags {
+		key, _ := splitTag(tag)
+		if newKey, ok := r.rename[key]; ok {
+			tags[i] = newKey + tag[len(key):]
+			hit = true
+		}
+	}
+	return hit
+}
+
+// limitTagValues replaces with otherTagValue the values of the limited tags once
+// maxValues distinct values have been seen for the metric. The values not seen for
+// the expiry are forgotten.
+func (r *rule) limitTagValues(name string, tags []string, now time.Time, expiry time.Duration) bool {
+	hit := false
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, tag := range tags {
+		key, value := splitTag(tag)
+		if _, ok := r.limitKeys[key]; !ok || value == otherTagValue {
+			continue
+		}
+		context := name + "|" + key
+		values, ok := r.values[context]
+		if !ok {
+			if len(r.values) >= maxLimitedContexts {
+				r.expireContexts(now, expiry)
+			}
+			if len(r.values) < maxLimitedContexts {
+				values = &limitedValues{lastSeen: make(map[string]time.Time, r.maxValues), lastExpired: now}
+				r.values[context] = values
+			}
+		}
+		if values != nil {
+			if _, seen := values.lastSeen[value]; seen {
+				values.lastSeen[value] = now
+				continue
+			}
+			// the values are only expired once per expiry, so that the samples
+			// over the limit do not scan them each time
+			if len(values.lastSeen) >= r.maxValues && now.Sub(values.lastExpired) >= expiry {
+				values.expire(now, expiry)
+			}
+			if len(values.lastSeen) < r.maxValues {
+				values.lastSeen[value] = now
+				continue
+			}
+		}
+		tags[i] = key + ":" + otherTagValue
+		hit = true
+	}
+	return hit
+}
+
+// expireContexts forgets the values not seen for the expiry, and the contexts left
+// without value. Like the values of a context, they are only expired once per expiry.
+func (r *rule) expireContexts(now time.Time, expiry time.Duration) {
+	if now.Sub(r.lastExpired) < expiry {
+		return
+	}
+	r.lastExpired = now
+	for context, values := range r.values {
+		values.expire(now, expiry)
+		if len(values.lastSeen) == 0 {
+			delete(r.values, context)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestNewRuleSetErrors(t *testing.T) {
+	for name, rule := range map[string]config.DogstatsdRule{
+		"no name":           {Action: ruleActionDrop},
+		"unknown action":    {Name: "r", Action: "explode"},
+		"invalid match":     {Name: "r", Match: "a.[b", Action: ruleActionDrop},
+		"drop without tags": {Name: "r", Action: ruleActionDropTags},
+		"invalid tag":       {Name: "r", Action: ruleActionAllowTags, Tags: []string{"[a"}},
+		"rename without map": {
+			Name: "r", Action: ruleActionRenameTags,
+		},
+		"limit without max": {Name: "r", Action: ruleActionLimitTagValues, Tags: []string{"user"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rs, err := newRuleSet([]config.DogstatsdRule{rule}, time.Minute, nil)
+			assert.Error(t, err)
+			assert.Nil(t, rs)
+		})
+	}
+}
+
+func TestRuleSetApply(t *testing.T) {
+	hits := make(map[string]int)
+	rs, err := newRuleSet([]config.DogstatsdRule{
+		{Name: "drop-debug", Match: "debug.*", Action: ruleActionDrop},
+		{Name: "strip-ids", Action: ruleActionDropTags, Tags: []string{"request_id", "*_uuid"}},
+		{Name: "rename-env", Action: ruleActionRenameTags, Rename: map[string]string{"environment": "env"}},
+		{Name: "allow-app", Match: "app.*", Action: ruleActionAllowTags, Tags: []string{"env", "user"}},
+		{Name: "limit-users", Match: "app.*", Action: ruleActionLimitTagValues, Tags: []string{"user"}, MaxValues: 2},
+	}, time.Minute, func(rule string) { hits[rule]++ })
+	require.NoError(t, err)
+
+	tags, keep := rs.apply("debug.metric", []string{"a:b"})
+	assert.False(t, keep)
+
+	tags, keep = rs.apply("other.metric", []string{"request_id:42", "session_uuid:1", "environment:prod", "a:b", "flag"})
+	assert.True(t, keep)
+	assert.Equal(t, []string{"env:prod", "a:b", "flag"}, tags)
+
+	for _, user := range []string{"alice", "bob", "alice", "carol", "other"} {
+		tags, keep = rs.apply("app.requests", []string{"user:" + user, "a:b"})
+		assert.True(t, keep)
+		expected := user
+		if user == "carol" {
+			expected = otherTagValue
+		}
+		assert.Equal(t, []string{"user:" + expected}, tags)
+	}
+	// the limit applies per metric
+	tags, _ = rs.apply("app.errors", []string{"user:carol"})
+	assert.Equal(t, []string{"user:carol"}, tags)
+
+	assert.Equal(t, map[string]int{
+		"drop-debug":  1,
+		"strip-ids":   1,
+		"rename-env":  1,
+		"allow-app":   5,
+		"limit-users": 1,
+	}, hits)
+}
+
+func TestLimitTagValuesExpiry(t *testing.T) {
+	rs, err := newRuleSet([]config.DogstatsdRule{
+		{Name: "limit-pods", Action: ruleActionLimitTagValues, Tags: []string{"pod"}, MaxValues: 2},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	clk := clock.NewMock()
+	rs.clock = clk
+
+	apply := func(pod string) string {
+		tags, _ := rs.apply("app.requests", []string{"pod:" + pod})
+		return tags[0]
+	}
+	assert.Equal(t, "pod:a", apply("a"))
+	assert.Equal(t, "pod:b", apply("b"))
+	assert.Equal(t, "pod:other", apply("c"))
+
+	// the values still seen are kept, the others expire and make room for new ones
+	clk.Add(30 * time.Second)
+	assert.Equal(t, "pod:b", apply("b"))
+	clk.Add(30 * time.Second)
+	assert.Equal(t, "pod:c", apply("c"))
+	assert.Equal(t, "pod:other", apply("d"))
+	assert.Equal(t, "pod:b", apply("b"))
+}
+
+func TestLimitTagValuesMaxContexts(t *testing.T) {
+	rs, err := newRuleSet([]config.DogstatsdRule{
+		{Name: "limit-pods", Action: ruleActionLimitTagValues, Tags: []string{"pod"}, MaxValues: 2},
+	}, time.Minute, nil)
+	require.NoError(t, err)
+	clk := clock.NewMock()
+	rs.clock = clk
+
+	for i := 0; i < maxLimitedContexts; i++ {
+		rs.apply(fmt.Sprintf("metric.%d", i), []string{"pod:a"})
+	}
+	tags, _ := rs.apply("metric.new", []string{"pod:a"})
+	assert.Equal(t, []string{"pod:other"}, tags)
+	assert.Len(t, rs.rules[0].values, maxLimitedContexts)
+
+	// the expired contexts are removed to make room for the new ones
+	clk.Add(time.Minute)
+	tags, _ = rs.apply("metric.new", []string{"pod:a"})
+	assert.Equal(t, []string{"pod:a"}, tags)
+	assert.Len(t, rs.rules[0].values, 1)
+}
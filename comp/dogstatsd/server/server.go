@@ -331,6 +331,23 @@ func (s *server) Start(demultiplexer aggregator.Demultiplexer) error {
 		}
 	}
 
+	if s.config.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, s.tCapture)
+		if err != nil {
+			s.log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+	if s.config.GetInt("dogstatsd_http_port") > 0 {
+		httpListener, err := listeners.NewHTTPListener(packetsChannel, sharedPacketPoolManager, s.tCapture)
+		if err != nil {
+			s.log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, httpListener)
+		}
+	}
+
 	pipeName := s.config.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPoolManager, s.tCapture)
@@ -368,6 +385,23 @@ func (s *server) Start(demultiplexer aggregator.Demultiplexer) error {
 		}
 	}
 
+	// rules dropping metrics or rewriting their tags
+	// ----------------------
+
+	rules, err := config.GetDogstatsdRules()
+	if err != nil {
+		s.log.Warnf("Could not parse DogStatsD rules: %v", err)
+	} else if len(rules) != 0 {
+		// the values seen by the limit_tag_values rules expire like the contexts of the aggregator
+		expiry := time.Duration(s.config.GetFloat64("dogstatsd_context_expiry_seconds") * float64(time.Second))
+		ruleSet, err := newRuleSet(rules, expiry, s.Debug.StoreRuleHit)
+		if err != nil {
+			s.log.Warnf("Could not create DogStatsD rules: %v", err)
+		} else {
+			s.enrichConfig.rules = ruleSet
+		}
+	}
+
 	// start the workers processing the packets read on the socket
 	// ----------------------
 
@@ -20,6 +20,9 @@ type Component interface {
 	// StoreMetricStats stores stats on the given metric sample.
 	StoreMetricStats(sample metrics.MetricSample)
 
+	// StoreRuleHit stores a hit of the given DogStatsD rule.
+	StoreRuleHit(rule string)
+
 	// IsDebugEnabled gets the DsdServerDebug instance which provides metric stats
 	IsDebugEnabled() bool
 	// SetMetricStatsEnabled enables or disables metric stats tracking
@@ -27,6 +30,9 @@ type Component interface {
 
 	// GetJSONDebugStats returns a json representation of debug stats
 	GetJSONDebugStats() ([]byte, error)
+
+	// GetJSONRuleStats returns a json representation of the DogStatsD rules stats
+	GetJSONRuleStats() ([]byte, error)
 }
 
 // Mock implements mock-specific methods.
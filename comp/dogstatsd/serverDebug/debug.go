@@ -38,11 +38,19 @@ type metricStat struct {
 	Tags     string    `json:"tags"`
 }
 
+// ruleStat holds how many times a DogStatsD rule has dropped
+// or modified a metric sample and when was the last time.
+type ruleStat struct {
+	Count    uint64    `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
 type serverDebug struct {
 	sync.Mutex
-	log     logComponent.Component
-	enabled *atomic.Bool
-	Stats   map[ckey.ContextKey]metricStat `json:"stats"`
+	log       logComponent.Component
+	enabled   *atomic.Bool
+	Stats     map[ckey.ContextKey]metricStat `json:"stats"`
+	RuleStats map[string]ruleStat            `json:"rule_stats"`
 	// counting number of metrics processed last X seconds
 	metricsCounts metricsCountBuckets
 	// keyGen is used to generate hashes of the metrics received by dogstatsd
@@ -67,9 +75,10 @@ func newServerDebug(deps dependencies) Component {
 func newServerDebugCompat(log logComponent.Component) Component {
 
 	return &serverDebug{
-		log:     log,
-		enabled: atomic.NewBool(false),
-		Stats:   make(map[ckey.ContextKey]metricStat),
+		log:       log,
+		enabled:   atomic.NewBool(false),
+		Stats:     make(map[ckey.ContextKey]metricStat),
+		RuleStats: make(map[string]ruleStat),
 		metricsCounts: metricsCountBuckets{
 			counts:     [5]uint64{0, 0, 0, 0, 0},
 			metricChan: make(chan struct{}),
@@ -92,13 +101,13 @@ type metricsCountBuckets struct {
 
 // FormatDebugStats returns a printable version of debug stats.
 func FormatDebugStats(stats []byte) (string, error) {
-	var dogStats map[uint64]metricStat
+	var dogStats map[ckey.ContextKey]metricStat
 	if err := json.Unmarshal(stats, &dogStats); err != nil {
 		return "", err
 	}
 
 	// put metrics in order: first is the more frequent
-	order := make([]uint64, len(dogStats))
+	order := make([]ckey.ContextKey, len(dogStats))
 	i := 0
 	for metric := range dogStats {
 		order[i] = metric
@@ -128,6 +137,39 @@ func FormatDebugStats(stats []byte) (string, error) {
 	return buf.String(), nil
 }
 
+// FormatRuleStats returns a printable version of the DogStatsD rules stats.
+func FormatRuleStats(stats []byte) (string, error) {
+	var ruleStats map[string]ruleStat
+	if err := json.Unmarshal(stats, &ruleStats); err != nil {
+		return "", err
+	}
+
+	// put rules in order: first is the more frequent
+	rules := make([]string, 0, len(ruleStats))
+	for rule := range ruleStats {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return ruleStats[rules[i]].Count > ruleStats[rules[j]].Count
+	})
+
+	buf := bytes.NewBuffer(nil)
+
+	header := fmt.Sprintf("%-40s | %-10s | %-20s\n", "Rule", "Hits", "Last Seen")
+	buf.Write([]byte(header))
+	buf.Write([]byte(strings.Repeat("-", len(header)) + "\n"))
+	for _, rule := range rules {
+		stats := ruleStats[rule]
+		buf.Write([]byte(fmt.Sprintf("%-40s | %-10d | %-20v\n", rule, stats.Count, stats.LastSeen)))
+	}
+
+	if len(ruleStats) == 0 {
+		buf.Write([]byte("No metrics dropped or modified by a rule yet."))
+	}
+
+	return buf.String(), nil
+}
+
 // storeMetricStats stores stats on the given metric sample.
 //
 // It can help troubleshooting clients with bad behaviors.
@@ -160,6 +202,23 @@ func (d *serverDebug) StoreMetricStats(sample metrics.MetricSample) {
 	d.metricsCounts.metricChan <- struct{}{}
 }
 
+// StoreRuleHit stores a hit of the given DogStatsD rule, i.e. the rule dropped or
+// modified a metric sample.
+func (d *serverDebug) StoreRuleHit(rule string) {
+	if !d.enabled.Load() {
+		return
+	}
+
+	now := d.clock.Now()
+	d.Lock()
+	defer d.Unlock()
+
+	rs := d.RuleStats[rule]
+	rs.Count++
+	rs.LastSeen = now
+	d.RuleStats[rule] = rs
+}
+
 // SetMetricStatsEnabled enables or disables metric stats
 func (d *serverDebug) SetMetricStatsEnabled(enable bool) {
 	d.Lock()
@@ -235,6 +294,13 @@ func (d *serverDebug) GetJSONDebugStats() ([]byte, error) {
 	return json.Marshal(d.Stats)
 }
 
+// GetJSONRuleStats returns jsonified DogStatsD rules statistics.
+func (d *serverDebug) GetJSONRuleStats() ([]byte, error) {
+	d.Lock()
+	defer d.Unlock()
+	return json.Marshal(d.RuleStats)
+}
+
 func (d *serverDebug) IsDebugEnabled() bool {
 	return d.enabled.Load()
 }
@@ -159,3 +159,44 @@ func TestDebugStats(t *testing.T) {
 		require.Equal(t, hash4, hash5)
 	})
 }
+
+func TestDebugRuleStats(t *testing.T) {
+	runWithComponent(t, func(c Component) {
+		d := c.(*serverDebug)
+
+		clk := clock.NewMock()
+		d.clock = clk
+
+		// hits are ignored while the stats are disabled
+		d.StoreRuleHit("drop-debug")
+
+		d.SetMetricStatsEnabled(true)
+		d.StoreRuleHit("drop-debug")
+		d.StoreRuleHit("strip-request-id")
+		clk.Add(10 * time.Millisecond)
+		d.StoreRuleHit("drop-debug")
+		d.StoreMetricStats(metrics.MetricSample{Name: "some.metric1", Tags: make([]string, 0)})
+
+		// the metrics stats keep their shape
+		data, err := d.GetJSONDebugStats()
+		require.NoError(t, err, "cannot get debug stats")
+		var stats map[ckey.ContextKey]metricStat
+		require.NoError(t, json.Unmarshal(data, &stats), "data is not valid")
+		require.Len(t, stats, 1)
+
+		data, err = d.GetJSONRuleStats()
+		require.NoError(t, err, "cannot get rule stats")
+		var ruleStats map[string]ruleStat
+		require.NoError(t, json.Unmarshal(data, &ruleStats), "data is not valid")
+		require.Len(t, ruleStats, 2)
+		assert.Equal(t, uint64(2), ruleStats["drop-debug"].Count)
+		assert.Equal(t, uint64(1), ruleStats["strip-request-id"].Count)
+		assert.True(t, ruleStats["drop-debug"].LastSeen.After(ruleStats["strip-request-id"].LastSeen))
+
+		formatted, err := FormatRuleStats(data)
+		require.NoError(t, err)
+		assert.Regexp(t, `drop-debug\s+\| 2 `, formatted)
+
+		d.SetMetricStatsEnabled(false)
+	})
+}
@@ -24,6 +24,9 @@ func newMockServerDebug(deps dependencies) Component {
 func (d *mockServerDebug) StoreMetricStats(sample metrics.MetricSample) {
 }
 
+func (d *mockServerDebug) StoreRuleHit(rule string) {
+}
+
 func (d *mockServerDebug) SetMetricStatsEnabled(enable bool) {
 	d.enabled.Store(enable)
 }
@@ -32,6 +35,10 @@ func (d *mockServerDebug) GetJSONDebugStats() ([]byte, error) {
 	return []byte{}, nil
 }
 
+func (d *mockServerDebug) GetJSONRuleStats() ([]byte, error) {
+	return []byte{}, nil
+}
+
 func (d *mockServerDebug) IsDebugEnabled() bool {
 	return d.enabled.Load()
 }
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// tagKeys is a set of tag keys
+type tagKeys map[string]struct{}
+
+// aggregationKeys lists, per metric name, the tags removed from the samples
+// before their context is resolved, so that the samples only differing by these
+// tags are aggregated into the same context.
+//
+// aggregationKeys is read-only once built and can be shared between samplers.
+type aggregationKeys struct {
+	dropTagsByName map[string]tagKeys
+}
+
+func newAggregationKeys(keys []config.AggregationKey) *aggregationKeys {
+	dropTagsByName := make(map[string]tagKeys)
+	for _, key := range keys {
+		if key.MetricName == "" || len(key.DropTags) == 0 {
+			log.Warnf("Ignoring aggregation key for metric %q: both metric_name and drop_tags are required", key.MetricName)
+			continue
+		}
+		dropTags, ok := dropTagsByName[key.MetricName]
+		if !ok {
+			dropTags = make(tagKeys)
+			dropTagsByName[key.MetricName] = dropTags
+		}
+		for _, tag := range key.DropTags {
+			dropTags[tag] = struct{}{}
+		}
+	}
+	if len(dropTagsByName) == 0 {
+		return nil
+	}
+	return &aggregationKeys{dropTagsByName: dropTagsByName}
+}
+
+// aggregationKeysFromConfig returns the aggregation keys configured with
+// `aggregator_aggregation_keys`, or nil when there is none.
+func aggregationKeysFromConfig() *aggregationKeys {
+	keys, err := config.GetAggregationKeys()
+	if err != nil {
+		log.Errorf("Could not load the aggregation keys: %v", err)
+		return nil
+	}
+	return newAggregationKeys(keys)
+}
+
+// dropTags returns the keys of the tags removed from the samples of the given
+// metric, or nil when they are aggregated as is.
+func (a *aggregationKeys) dropTags(name string) tagKeys {
+	if a == nil {
+		return nil
+	}
+	return a.dropTagsByName[name]
+}
+
+// strip removes the tags whose key is in the set.
+func (k tagKeys) strip(tb *tagset.HashingTagsAccumulator) {
+	tb.Retain(func(tag string) bool {
+		key := tag
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key = tag[:i]
+		}
+		_, drop := k[key]
+		return !drop
+	})
+}
+
+// aggregatedGauges holds the last value of each source of the gauges whose
+// tags are stripped, so that they are summed instead of overwriting each other.
+type aggregatedGauges map[int64]map[ckey.ContextKey]map[ckey.ContextKey]float64
+
+func (g aggregatedGauges) sample(bucketStart int64, contextKey, sourceKey ckey.ContextKey, value float64) {
+	bucket, ok := g[bucketStart]
+	if !ok {
+		bucket = make(map[ckey.ContextKey]map[ckey.ContextKey]float64)
+		g[bucketStart] = bucket
+	}
+	sources, ok := bucket[contextKey]
+	if !ok {
+		sources = make(map[ckey.ContextKey]float64)
+		bucket[contextKey] = sources
+	}
+	sources[sourceKey] = value
+}
+
+// flush calls fn with the sum of the sources of each gauge of the bucket, and
+// forgets the bucket.
+func (g aggregatedGauges) flush(bucketStart int64, fn func(contextKey ckey.ContextKey, value float64)) {
+	for contextKey, sources := range g[bucketStart] {
+		var sum float64
+		for _, value := range sources {
+			sum += value
+		}
+		fn(contextKey, sum)
+	}
+	delete(g, bucketStart)
+}
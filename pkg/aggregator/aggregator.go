@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
 	"github.com/DataDog/datadog-agent/pkg/epforwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -168,6 +169,7 @@ func init() {
 	aggregatorExpvars.Set("ServiceCheck", &aggregatorServiceCheck)
 	aggregatorExpvars.Set("Event", &aggregatorEvent)
 	aggregatorExpvars.Set("HostnameUpdate", &aggregatorHostnameUpdate)
+	aggregatorExpvars.Set("ContextLimits", expvar.Func(func() interface{} { return limiter.Offenders() }))
 	aggregatorExpvars.Set("OrchestratorMetadata", &aggregatorOrchestratorMetadata)
 	aggregatorExpvars.Set("OrchestratorMetadataErrors", &aggregatorOrchestratorMetadataErrors)
 	aggregatorExpvars.Set("OrchestratorManifests", &aggregatorOrchestratorManifests)
@@ -472,6 +474,7 @@ func (agg *BufferedAggregator) getSeriesAndSketches(
 		for _, s := range checkSeries {
 			seriesSink.Append(s)
 		}
+		checkSampler.sendLimiterTelemetry(float64(before.Unix()), seriesSink, agg.hostname)
 
 		for _, sk := range sketches {
 			sketchesSink.Append(sk)
@@ -894,5 +897,6 @@ func (agg *BufferedAggregator) handleRegisterSampler(id check.ID) {
 		config.Datadog.GetBool("check_sampler_expire_metrics"),
 		config.Datadog.GetDuration("check_sampler_stateful_metric_expiration_time"),
 		agg.tagsStore,
+		contextLimitsFromConfig(1, []string{"check_id:" + string(id)}),
 	)
 }
@@ -29,11 +29,11 @@ type CheckSampler struct {
 }
 
 // newCheckSampler returns a newly initialized CheckSampler
-func newCheckSampler(expirationCount int, expireMetrics bool, statefulTimeout time.Duration, cache *tags.Store) *CheckSampler {
+func newCheckSampler(expirationCount int, expireMetrics bool, statefulTimeout time.Duration, cache *tags.Store, limits contextLimits) *CheckSampler {
 	return &CheckSampler{
 		series:          make([]*metrics.Serie, 0),
 		sketches:        make(metrics.SketchSeriesList, 0),
-		contextResolver: newCountBasedContextResolver(expirationCount, cache),
+		contextResolver: newCountBasedContextResolver(expirationCount, cache, limits),
 		metrics:         metrics.NewCheckMetrics(expireMetrics, statefulTimeout),
 		sketchMap:       make(sketchMap),
 		lastBucketValue: make(map[ckey.ContextKey]int64),
@@ -41,7 +41,10 @@ func newCheckSampler(expirationCount int, expireMetrics bool, statefulTimeout ti
 }
 
 func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
-	contextKey := cs.contextResolver.trackContext(metricSample)
+	contextKey, ok := cs.contextResolver.trackContext(metricSample)
+	if !ok {
+		return
+	}
 
 	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1); err != nil {
 		log.Debugf("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
@@ -81,7 +84,10 @@ func (cs *CheckSampler) addBucket(bucket *metrics.HistogramBucket) {
 		return
 	}
 
-	contextKey := cs.contextResolver.trackContext(bucket)
+	contextKey, ok := cs.contextResolver.trackContext(bucket)
+	if !ok {
+		return
+	}
 
 	// if the bucket is monotonic and we have already seen the bucket we only send the delta
 	if bucket.Monotonic {
@@ -190,6 +196,12 @@ func (cs *CheckSampler) flush() (metrics.Series, metrics.SketchSeriesList) {
 	return series, sketches
 }
 
+// sendLimiterTelemetry sends the origins and metric names which went over their limit
+// since the last call.
+func (cs *CheckSampler) sendLimiterTelemetry(timestamp float64, series metrics.SerieSink, hostname string) {
+	cs.contextResolver.sendLimiterTelemetry(timestamp, series, hostname, nil)
+}
+
 func (cs *CheckSampler) release() {
 	cs.contextResolver.release()
 }
@@ -9,7 +9,9 @@ import (
 	"fmt"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 )
@@ -22,6 +24,8 @@ type Context struct {
 	taggerTags *tags.Entry
 	metricTags *tags.Entry
 	noIndex    bool
+	// limitKey is set when the context is accounted for by the limiter
+	limitKey *limiter.Key
 }
 
 // Tags returns tags for the context.
@@ -34,6 +38,43 @@ func (c *Context) release() {
 	c.metricTags.Release()
 }
 
+// overflowTag replaces the metric tags of the contexts going over a limit when
+// they are folded into an overflow context.
+const overflowTag = "context_overflow:true"
+
+// contextLimits configures the number of contexts a context resolver tracks per
+// origin and per metric name. A limit of 0 disables it.
+type contextLimits struct {
+	origin     int
+	metricName int
+	// fold folds the contexts over a limit into an overflow context, with the
+	// same name and origin, instead of dropping them
+	fold bool
+	// defaultOrigin are the tags naming the origin of the contexts without tagger
+	// tags. When empty, these contexts are only limited per metric name.
+	defaultOrigin []string
+}
+
+// contextLimitsFromConfig returns the limits of the context resolvers, splitting
+// them between the given number of resolvers sharing the same contexts.
+func contextLimitsFromConfig(resolvers int, defaultOrigin []string) contextLimits {
+	split := func(limit int) int {
+		if limit <= 0 || resolvers <= 1 {
+			return limit
+		}
+		if limit = limit / resolvers; limit < 1 {
+			return 1
+		}
+		return limit
+	}
+	return contextLimits{
+		origin:        split(config.Datadog.GetInt("aggregator_context_limits.per_origin")),
+		metricName:    split(config.Datadog.GetInt("aggregator_context_limits.per_metric_name")),
+		fold:          config.Datadog.GetString("aggregator_context_limits.overflow_action") == "fold",
+		defaultOrigin: defaultOrigin,
+	}
+}
+
 // contextResolver allows tracking and expiring contexts
 type contextResolver struct {
 	contextsByKey map[ckey.ContextKey]*Context
@@ -42,6 +83,9 @@ type contextResolver struct {
 	keyGenerator  *ckey.KeyGenerator
 	taggerBuffer  *tagset.HashingTagsAccumulator
 	metricBuffer  *tagset.HashingTagsAccumulator
+	// limiter is nil when the number of contexts is not limited
+	limiter *limiter.Limiter
+	limits  contextLimits
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -49,7 +93,7 @@ func (cr *contextResolver) generateContextKey(metricSampleContext metrics.Metric
 	return cr.keyGenerator.GenerateWithTags2(metricSampleContext.GetName(), metricSampleContext.GetHost(), cr.taggerBuffer, cr.metricBuffer)
 }
 
-func newContextResolver(cache *tags.Store) *contextResolver {
+func newContextResolver(cache *tags.Store, limits contextLimits) *contextResolver {
 	return &contextResolver{
 		contextsByKey: make(map[ckey.ContextKey]*Context),
 		countsByMtype: make([]uint64, metrics.NumMetricTypes),
@@ -57,31 +101,91 @@ func newContextResolver(cache *tags.Store) *contextResolver {
 		keyGenerator:  ckey.NewKeyGenerator(),
 		taggerBuffer:  tagset.NewHashingTagsAccumulator(),
 		metricBuffer:  tagset.NewHashingTagsAccumulator(),
+		limiter:       limiter.New(limits.origin, limits.metricName),
+		limits:        limits,
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer)                  // tags here are not sorted and can contain duplicates
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false if the context goes over the limits and the sample must be dropped.
+func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) (ckey.ContextKey, bool) {
+	contextKey, _, ok := cr.trackAggregatedContext(metricSampleContext, nil)
+	return contextKey, ok
+}
+
+// trackAggregatedContext tracks the context of the metricSample once the tags whose key is in
+// dropTags are removed. It also returns the sourceKey, the contextKey the metricSample would have
+// with all its tags.
+func (cr *contextResolver) trackAggregatedContext(metricSampleContext metrics.MetricSampleContext, dropTags tagKeys) (contextKey, sourceKey ckey.ContextKey, ok bool) {
+	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer) // tags here are not sorted and can contain duplicates
+	if dropTags != nil {
+		sourceKey, _, _ = cr.generateContextKey(metricSampleContext)
+		dropTags.strip(cr.taggerBuffer)
+		dropTags.strip(cr.metricBuffer)
+	}
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
+	if dropTags == nil {
+		sourceKey = contextKey
+	}
 
-	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		mtype := metricSampleContext.GetMetricType()
-		cr.contextsByKey[contextKey] = &Context{
-			Name:       metricSampleContext.GetName(),
-			taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
-			metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
-			Host:       metricSampleContext.GetHost(),
-			mtype:      mtype,
-			noIndex:    metricSampleContext.IsNoIndex(),
-		}
-		cr.countsByMtype[mtype]++
+	ok = true
+	if _, found := cr.contextsByKey[contextKey]; !found {
+		contextKey, ok = cr.newContext(metricSampleContext, contextKey, taggerKey, metricKey)
 	}
 
 	cr.taggerBuffer.Reset()
 	cr.metricBuffer.Reset()
 
-	return contextKey
+	return contextKey, sourceKey, ok
+}
+
+// newContext tracks a new context, checking the limits first.
+func (cr *contextResolver) newContext(metricSampleContext metrics.MetricSampleContext, contextKey ckey.ContextKey, taggerKey, metricKey ckey.TagsKey) (ckey.ContextKey, bool) {
+	name := metricSampleContext.GetName()
+	taggerTags := cr.tagsCache.Insert(taggerKey, cr.taggerBuffer)
+
+	var limitKey *limiter.Key
+	if cr.limiter != nil {
+		key := limiter.Key{Name: name}
+		originTags := taggerTags.Tags()
+		if len(originTags) == 0 {
+			originTags = cr.limits.defaultOrigin
+		}
+		if len(originTags) > 0 {
+			key.Origin = taggerKey
+			key.HasOrigin = true
+		}
+
+		if cr.limiter.Track(key, contextKey, originTags) {
+			limitKey = &key
+		} else if !cr.limits.fold {
+			taggerTags.Release()
+			return contextKey, false
+		} else {
+			// the overflow contexts are not accounted for, there is at most one per
+			// metric name and origin
+			cr.metricBuffer.Reset()
+			cr.metricBuffer.Append(overflowTag)
+			contextKey, _, metricKey = cr.generateContextKey(metricSampleContext)
+			if _, found := cr.contextsByKey[contextKey]; found {
+				taggerTags.Release()
+				return contextKey, true
+			}
+		}
+	}
+
+	mtype := metricSampleContext.GetMetricType()
+	cr.contextsByKey[contextKey] = &Context{
+		Name:       name,
+		taggerTags: taggerTags,
+		metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
+		Host:       metricSampleContext.GetHost(),
+		mtype:      mtype,
+		noIndex:    metricSampleContext.IsNoIndex(),
+		limitKey:   limitKey,
+	}
+	cr.countsByMtype[mtype]++
+	return contextKey, true
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -100,6 +204,9 @@ func (cr *contextResolver) removeKeys(expiredContextKeys []ckey.ContextKey) {
 
 		if context != nil {
 			cr.countsByMtype[context.mtype]--
+			if context.limitKey != nil {
+				cr.limiter.Remove(*context.limitKey)
+			}
 			context.release()
 		}
 	}
@@ -141,15 +248,26 @@ func (c *contextResolver) sendOriginTelemetry(timestamp float64, series metrics.
 	}
 }
 
+// sendLimiterTelemetry sends the origins and metric names which went over their limit
+// since the last call.
+func (cr *contextResolver) sendLimiterTelemetry(timestamp float64, series metrics.SerieSink, hostname string, constTags []string) {
+	if cr.limiter == nil {
+		return
+	}
+	if offenders := cr.limiter.Flush(); len(offenders) > 0 {
+		limiter.SendTelemetry(offenders, timestamp, series, hostname, constTags)
+	}
+}
+
 // timestampContextResolver allows tracking and expiring contexts based on time.
 type timestampContextResolver struct {
 	resolver      *contextResolver
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, limits contextLimits) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache),
+		resolver:      newContextResolver(cache, limits),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -166,10 +284,20 @@ func (cr *timestampContextResolver) updateTrackedContext(contextKey ckey.Context
 }
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
-	cr.lastSeenByKey[contextKey] = currentTimestamp
-	return contextKey
+// It returns false if the context goes over the limits and the sample must be dropped.
+func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) (ckey.ContextKey, bool) {
+	contextKey, _, ok := cr.trackAggregatedContext(metricSampleContext, currentTimestamp, nil)
+	return contextKey, ok
+}
+
+// trackAggregatedContext is like trackContext, removing the tags whose key is in dropTags first.
+// It also returns the contextKey the metricSample would have with all its tags.
+func (cr *timestampContextResolver) trackAggregatedContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64, dropTags tagKeys) (ckey.ContextKey, ckey.ContextKey, bool) {
+	contextKey, sourceKey, ok := cr.resolver.trackAggregatedContext(metricSampleContext, dropTags)
+	if ok {
+		cr.lastSeenByKey[contextKey] = currentTimestamp
+	}
+	return contextKey, sourceKey, ok
 }
 
 func (cr *timestampContextResolver) length() int {
@@ -211,6 +339,10 @@ func (cr *timestampContextResolver) sendOriginTelemetry(timestamp float64, serie
 	cr.resolver.sendOriginTelemetry(timestamp, series, hostname, tags)
 }
 
+func (cr *timestampContextResolver) sendLimiterTelemetry(timestamp float64, series metrics.SerieSink, hostname string, tags []string) {
+	cr.resolver.sendLimiterTelemetry(timestamp, series, hostname, tags)
+}
+
 // countBasedContextResolver allows tracking and expiring contexts based on the number
 // of calls of `expireContexts`.
 type countBasedContextResolver struct {
@@ -220,9 +352,9 @@ type countBasedContextResolver struct {
 	expireCountInterval int64
 }
 
-func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *countBasedContextResolver {
+func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store, limits contextLimits) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache),
+		resolver:            newContextResolver(cache, limits),
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -230,10 +362,13 @@ func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *c
 }
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
-	cr.expireCountByKey[contextKey] = cr.expireCount
-	return contextKey
+// It returns false if the context goes over the limits and the sample must be dropped.
+func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) (ckey.ContextKey, bool) {
+	contextKey, ok := cr.resolver.trackContext(metricSampleContext)
+	if ok {
+		cr.expireCountByKey[contextKey] = cr.expireCount
+	}
+	return contextKey, ok
 }
 
 func (cr *countBasedContextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -255,6 +390,10 @@ func (cr *countBasedContextResolver) expireContexts() []ckey.ContextKey {
 	return keys
 }
 
+func (cr *countBasedContextResolver) sendLimiterTelemetry(timestamp float64, series metrics.SerieSink, hostname string, tags []string) {
+	cr.resolver.sendLimiterTelemetry(timestamp, series, hostname, tags)
+}
+
 func (cr *countBasedContextResolver) release() {
 	cr.resolver.release()
 }
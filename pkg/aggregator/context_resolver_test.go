@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
@@ -78,12 +79,12 @@ func testTrackContext(t *testing.T, store *tags.Store) {
 		SampleRate: 1,
 	}
 
-	contextResolver := newContextResolver(store)
+	contextResolver := newContextResolver(store, contextLimits{})
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1)
-	contextKey2 := contextResolver.trackContext(&mSample2)
-	contextKey3 := contextResolver.trackContext(&mSample3)
+	contextKey1, _ := contextResolver.trackContext(&mSample1)
+	contextKey2, _ := contextResolver.trackContext(&mSample2)
+	contextKey3, _ := contextResolver.trackContext(&mSample3)
 
 	// When we look up the 2 keys, they return the correct contexts
 	context1 := contextResolver.contextsByKey[contextKey1]
@@ -122,11 +123,11 @@ func testExpireContexts(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store)
+	contextResolver := newTimestampContextResolver(store, contextLimits{})
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1, 4)
-	contextKey2 := contextResolver.trackContext(&mSample2, 6)
+	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
+	contextKey2, _ := contextResolver.trackContext(&mSample2, 6)
 
 	// With an expireTimestap of 3, both contexts are still valid
 	assert.Len(t, contextResolver.expireContexts(3, nil), 0)
@@ -166,11 +167,11 @@ func testExpireContextsWithKeep(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store)
+	contextResolver := newTimestampContextResolver(store, contextLimits{})
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1, 4)
-	contextKey2 := contextResolver.trackContext(&mSample2, 7)
+	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
+	contextKey2, _ := contextResolver.trackContext(&mSample2, 7)
 
 	keeperCalled := 0
 	keep := true
@@ -220,13 +221,13 @@ func testCountBasedExpireContexts(t *testing.T, store *tags.Store) {
 	mSample1 := metrics.MetricSample{Name: "my.metric.name1"}
 	mSample2 := metrics.MetricSample{Name: "my.metric.name2"}
 	mSample3 := metrics.MetricSample{Name: "my.metric.name3"}
-	contextResolver := newCountBasedContextResolver(2, store)
+	contextResolver := newCountBasedContextResolver(2, store, contextLimits{})
 
-	contextKey1 := contextResolver.trackContext(&mSample1)
-	contextKey2 := contextResolver.trackContext(&mSample2)
+	contextKey1, _ := contextResolver.trackContext(&mSample1)
+	contextKey2, _ := contextResolver.trackContext(&mSample2)
 	require.Len(t, contextResolver.expireContexts(), 0)
 
-	contextKey3 := contextResolver.trackContext(&mSample3)
+	contextKey3, _ := contextResolver.trackContext(&mSample3)
 	contextResolver.trackContext(&mSample2)
 	require.Len(t, contextResolver.expireContexts(), 0)
 
@@ -244,9 +245,9 @@ func TestCountBasedExpireContexts(t *testing.T) {
 }
 
 func testTagDeduplication(t *testing.T, store *tags.Store) {
-	resolver := newContextResolver(store)
+	resolver := newContextResolver(store, contextLimits{})
 
-	ckey := resolver.trackContext(&metrics.MetricSample{
+	ckey, _ := resolver.trackContext(&metrics.MetricSample{
 		Name: "foo",
 		Tags: []string{"bar", "bar"},
 	})
@@ -265,22 +266,22 @@ func (s *mockSink) Append(ms *metrics.Serie) {
 }
 
 type mockSample struct {
-	name string
+	name       string
 	taggerTags []string
 	metricTags []string
 }
 
-func (s *mockSample) GetName() string { return s.name }
-func (s *mockSample) GetHost() string { return "noop" }
+func (s *mockSample) GetName() string                   { return s.name }
+func (s *mockSample) GetHost() string                   { return "noop" }
 func (s *mockSample) GetMetricType() metrics.MetricType { return metrics.GaugeType }
-func (s *mockSample) IsNoIndex() bool { return false }
+func (s *mockSample) IsNoIndex() bool                   { return false }
 func (s *mockSample) GetTags(tb, mb tagset.TagsAccumulator) {
 	tb.Append(s.taggerTags...)
 	mb.Append(s.metricTags...)
 }
 
 func TestOriginTelemetry(t *testing.T) {
-	r := newContextResolver(tags.NewStore(true, "test"))
+	r := newContextResolver(tags.NewStore(true, "test"), contextLimits{})
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"ook"}})
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"eek"}})
 	r.trackContext(&mockSample{"foo", []string{"bar"}, []string{"ook"}})
@@ -291,22 +292,110 @@ func TestOriginTelemetry(t *testing.T) {
 	r.sendOriginTelemetry(ts, &sink, "test", []string{"test"})
 
 	assert.ElementsMatch(t, sink, []*metrics.Serie{{
-		Name: "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
-		Host: "test",
-		Tags: tagset.NewCompositeTags([]string{"test"}, []string{"foo"}),
-		MType: metrics.APIGaugeType,
-		Points: []metrics.Point{{Ts: ts, Value: 2.0 }},
+		Name:   "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
+		Host:   "test",
+		Tags:   tagset.NewCompositeTags([]string{"test"}, []string{"foo"}),
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: ts, Value: 2.0}},
 	}, {
-		Name: "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
-		Host: "test",
-		Tags: tagset.NewCompositeTags([]string{"test"}, []string{"bar"}),
-		MType: metrics.APIGaugeType,
-		Points: []metrics.Point{{Ts: ts, Value: 2.0 }},
+		Name:   "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
+		Host:   "test",
+		Tags:   tagset.NewCompositeTags([]string{"test"}, []string{"bar"}),
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: ts, Value: 2.0}},
 	}, {
-		Name: "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
-		Host: "test",
-		Tags: tagset.NewCompositeTags([]string{"test"}, []string{"baz"}),
-		MType: metrics.APIGaugeType,
-		Points: []metrics.Point{{Ts: ts, Value: 1.0 }},
+		Name:   "datadog.agent.aggregator.dogstatsd_contexts_by_origin",
+		Host:   "test",
+		Tags:   tagset.NewCompositeTags([]string{"test"}, []string{"baz"}),
+		MType:  metrics.APIGaugeType,
+		Points: []metrics.Point{{Ts: ts, Value: 1.0}},
 	}})
 }
+
+func testContextLimits(t *testing.T, store *tags.Store) {
+	r := newContextResolver(store, contextLimits{origin: 2, metricName: 3})
+
+	track := func(name string, origin string, tag string) bool {
+		var taggerTags []string
+		if origin != "" {
+			taggerTags = []string{origin}
+		}
+		_, ok := r.trackContext(&mockSample{name, taggerTags, []string{tag}})
+		return ok
+	}
+
+	// per origin
+	assert.True(t, track("foo", "pod:a", "1"))
+	assert.True(t, track("bar", "pod:a", "2"))
+	assert.False(t, track("foo", "pod:a", "3"))
+	assert.False(t, track("foo", "pod:a", "3"), "a rejected context is only counted once")
+	assert.True(t, track("foo", "pod:a", "1"), "existing contexts are still accepted")
+	assert.True(t, track("foo", "pod:b", "3"))
+
+	// per metric name, contexts without origin are not limited per origin
+	assert.True(t, track("foo", "", "4"))
+	assert.False(t, track("foo", "", "5"))
+	assert.Len(t, r.contextsByKey, 4)
+
+	// removing contexts frees room
+	var fooA ckey.ContextKey
+	for key, cx := range r.contextsByKey {
+		if cx.Name == "foo" && len(cx.taggerTags.Tags()) > 0 && cx.taggerTags.Tags()[0] == "pod:a" {
+			fooA = key
+		}
+	}
+	r.removeKeys([]ckey.ContextKey{fooA})
+	assert.True(t, track("foo", "pod:a", "3"))
+
+	sink := mockSink{}
+	r.sendLimiterTelemetry(1672835152.0, &sink, "test", []string{"test"})
+	require.Len(t, sink, 2)
+	tagsOf := func(s *metrics.Serie) []string {
+		var tags []string
+		s.Tags.ForEach(func(tag string) { tags = append(tags, tag) })
+		return tags
+	}
+	for _, serie := range sink {
+		assert.Equal(t, limiter.LimitedMetricName, serie.Name)
+		assert.Equal(t, 1.0, serie.Points[0].Value)
+	}
+	assert.ElementsMatch(t, [][]string{
+		{"test", "limit:origin", "pod:a"},
+		{"test", "limit:metric_name", "metric_name:foo"},
+	}, [][]string{tagsOf(sink[0]), tagsOf(sink[1])})
+
+	// the counts are reset after each flush
+	sink = mockSink{}
+	r.sendLimiterTelemetry(1672835162.0, &sink, "test", nil)
+	assert.Len(t, sink, 0)
+}
+func TestContextLimits(t *testing.T) {
+	testWithTagsStore(t, testContextLimits)
+}
+
+func testContextLimitsFold(t *testing.T, store *tags.Store) {
+	r := newContextResolver(store, contextLimits{origin: 1, fold: true, defaultOrigin: []string{"check_id:test"}})
+
+	key1, ok := r.trackContext(&mockSample{"foo", nil, []string{"1"}})
+	assert.True(t, ok)
+	key2, ok := r.trackContext(&mockSample{"foo", nil, []string{"2"}})
+	assert.True(t, ok)
+	key3, ok := r.trackContext(&mockSample{"foo", nil, []string{"3"}})
+	assert.True(t, ok)
+
+	assert.NotEqual(t, key1, key2)
+	assert.Equal(t, key2, key3, "the contexts over the limit share the overflow context")
+	assert.Len(t, r.contextsByKey, 2)
+	metrics.AssertCompositeTagsEqual(t, tagset.CompositeTagsFromSlice([]string{overflowTag}), r.contextsByKey[key2].Tags())
+	assert.Nil(t, r.contextsByKey[key2].limitKey)
+
+	// the overflow context isn't accounted for
+	r.removeKeys([]ckey.ContextKey{key2})
+	_, ok = r.trackContext(&mockSample{"foo", nil, []string{"4"}})
+	assert.True(t, ok)
+	assert.Len(t, r.contextsByKey, 2)
+	assert.Equal(t, []limiter.Offender{{Origin: []string{"check_id:test"}, Rejected: 3}}, r.limiter.Flush())
+}
+func TestContextLimitsFold(t *testing.T) {
+	testWithTagsStore(t, testContextLimitsFold)
+}
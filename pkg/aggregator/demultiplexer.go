@@ -27,6 +27,14 @@ var demultiplexerInstance Demultiplexer
 
 var demultiplexerInstanceMu sync.Mutex
 
+// GetDemultiplexer returns the shared global demultiplexer instance,
+// nil if it has not been initialized.
+func GetDemultiplexer() Demultiplexer {
+	demultiplexerInstanceMu.Lock()
+	defer demultiplexerInstanceMu.Unlock()
+	return demultiplexerInstance
+}
+
 // Demultiplexer is composed of multiple samplers (check and time/dogstatsd)
 // a shared forwarder, the event platform forwarder, orchestrator data buffers
 // and other data that need to be sent to the forwarders.
@@ -186,11 +186,12 @@ func initAgentDemultiplexer(options AgentDemultiplexerOptions, hostname string)
 	log.Debug("the Demultiplexer will use", statsdPipelinesCount, "pipelines")
 
 	statsdWorkers := make([]*timeSamplerWorker, statsdPipelinesCount)
+	aggKeys := aggregationKeysFromConfig()
 
 	for i := 0; i < statsdPipelinesCount; i++ {
 		// the sampler
 		tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), fmt.Sprintf("timesampler #%d", i))
-		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, agg.hostname)
+		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, contextLimitsFromConfig(statsdPipelinesCount, nil), aggKeys, agg.hostname)
 
 		// its worker (process loop + flush/serialization mechanism)
 
@@ -419,7 +420,9 @@ func (d *AgentDemultiplexer) Stop(flush bool) {
 
 	d.dataOutputs.sharedSerializer = nil
 	d.senders = nil
+	demultiplexerInstanceMu.Lock()
 	demultiplexerInstance = nil
+	demultiplexerInstanceMu.Unlock()
 }
 
 // ForceFlushToSerializer triggers the execution of a flush from all data of samplers
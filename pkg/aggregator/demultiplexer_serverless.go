@@ -43,7 +43,7 @@ func InitAndStartServerlessDemultiplexer(domainResolvers map[string]resolver.Dom
 	metricSamplePool := metrics.NewMetricSamplePool(MetricSamplePoolBatchSize)
 	tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), "timesampler")
 
-	statsdSampler := NewTimeSampler(TimeSamplerID(0), bucketSize, tagsStore, "")
+	statsdSampler := NewTimeSampler(TimeSamplerID(0), bucketSize, tagsStore, contextLimitsFromConfig(1, nil), aggregationKeysFromConfig(), "")
 	flushAndSerializeInParallel := NewFlushAndSerializeInParallel(config.Datadog)
 	statsdWorker := newTimeSamplerWorker(statsdSampler, DefaultFlushInterval, bufferSize, metricSamplePool, flushAndSerializeInParallel, tagsStore)
 
@@ -59,7 +59,9 @@ func InitAndStartServerlessDemultiplexer(domainResolvers map[string]resolver.Dom
 	}
 
 	// set the global instance
+	demultiplexerInstanceMu.Lock()
 	demultiplexerInstance = demux
+	demultiplexerInstanceMu.Unlock()
 
 	// start routines
 	go demux.Run()
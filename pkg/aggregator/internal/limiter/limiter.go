@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package limiter limits the number of contexts tracked by the aggregator per
+// origin and per metric name.
+package limiter
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+// LimitedMetricName is the name of the internal metric counting the contexts
+// rejected by the limiter, tagged with the origin or the metric name at fault.
+const LimitedMetricName = "datadog.agent.aggregator.contexts_limited"
+
+// Key identifies the metric name and the origin a context is accounted for.
+type Key struct {
+	Name string
+	// Origin is the key of the tags identifying the origin, only set when HasOrigin is true
+	Origin    ckey.TagsKey
+	HasOrigin bool
+}
+
+type originEntry struct {
+	tags     []string
+	contexts int
+	rejected uint64
+}
+
+type metricEntry struct {
+	contexts int
+	rejected uint64
+}
+
+// Limiter counts the contexts of each origin and metric name, and rejects the
+// new contexts going over the limits.
+//
+// Limiter is not thread-safe, each context resolver has its own.
+type Limiter struct {
+	originLimit int
+	metricLimit int
+	origins     map[ckey.TagsKey]*originEntry
+	metrics     map[string]*metricEntry
+	// rejected holds the contexts rejected since the last flush, so that each of
+	// them is counted once no matter how many samples it gets
+	rejected map[ckey.ContextKey]struct{}
+}
+
+// New returns a Limiter allowing up to originLimit contexts per origin and up to
+// metricLimit contexts per metric name. A limit of 0 disables it, and New returns
+// nil when both are disabled.
+func New(originLimit, metricLimit int) *Limiter {
+	if originLimit <= 0 && metricLimit <= 0 {
+		return nil
+	}
+	return &Limiter{
+		originLimit: originLimit,
+		metricLimit: metricLimit,
+		origins:     make(map[ckey.TagsKey]*originEntry),
+		metrics:     make(map[string]*metricEntry),
+		rejected:    make(map[ckey.ContextKey]struct{}),
+	}
+}
+
+// Track accounts for a new context, and returns false if it goes over one of
+// the limits, in which case the context is not accounted for. A rejected context
+// is only counted once until the next Flush. originTags are the tags naming the
+// origin in the telemetry; the slice is retained and must not be modified
+// afterwards.
+func (l *Limiter) Track(key Key, contextKey ckey.ContextKey, originTags []string) bool {
+	m := l.metrics[key.Name]
+	if m == nil {
+		m = &metricEntry{}
+		l.metrics[key.Name] = m
+	}
+	var o *originEntry
+	if key.HasOrigin {
+		o = l.origins[key.Origin]
+		if o == nil {
+			o = &originEntry{tags: originTags}
+			l.origins[key.Origin] = o
+		}
+	}
+
+	if o != nil && l.originLimit > 0 && o.contexts >= l.originLimit {
+		if l.reject(contextKey) {
+			o.rejected++
+		}
+		return false
+	}
+	if l.metricLimit > 0 && m.contexts >= l.metricLimit {
+		if l.reject(contextKey) {
+			m.rejected++
+		}
+		return false
+	}
+
+	delete(l.rejected, contextKey)
+	m.contexts++
+	if o != nil {
+		o.contexts++
+	}
+	return true
+}
+
+// reject remembers the context as rejected, and returns false if it already was
+// since the last flush.
+func (l *Limiter) reject(contextKey ckey.ContextKey) bool {
+	if _, ok := l.rejected[contextKey]; ok {
+		return false
+	}
+	l.rejected[contextKey] = struct{}{}
+	return true
+}
+
+// Remove stops accounting for a context previously accepted by Track.
+func (l *Limiter) Remove(key Key) {
+	if m := l.metrics[key.Name]; m != nil {
+		m.contexts--
+		if m.contexts <= 0 && m.rejected == 0 {
+			delete(l.metrics, key.Name)
+		}
+	}
+	if !key.HasOrigin {
+		return
+	}
+	if o := l.origins[key.Origin]; o != nil {
+		o.contexts--
+		if o.contexts <= 0 && o.rejected == 0 {
+			delete(l.origins, key.Origin)
+		}
+	}
+}
+
+// Offender is an origin or a metric name which went over its limit.
+type Offender struct {
+	// Origin is set for the origins, with the tags naming them
+	Origin []string
+	// Name is set for the metric names
+	Name     string
+	Rejected uint64
+}
+
+// Flush returns the origins and metric names which went over their limit since
+// the last call, and resets their counts of rejected contexts.
+func (l *Limiter) Flush() []Offender {
+	l.rejected = make(map[ckey.ContextKey]struct{})
+
+	var offenders []Offender
+	for key, o := range l.origins {
+		if o.rejected > 0 {
+			offenders = append(offenders, Offender{Origin: o.tags, Rejected: o.rejected})
+			o.rejected = 0
+		}
+		if o.contexts <= 0 {
+			delete(l.origins, key)
+		}
+	}
+	for name, m := range l.metrics {
+		if m.rejected > 0 {
+			offenders = append(offenders, Offender{Name: name, Rejected: m.rejected})
+			m.rejected = 0
+		}
+		if m.contexts <= 0 {
+			delete(l.metrics, name)
+		}
+	}
+	return offenders
+}
+
+// SendTelemetry flushes the offenders to the series sink as the LimitedMetricName
+// metric, and records them for the agent status.
+func SendTelemetry(offenders []Offender, timestamp float64, series metrics.SerieSink, hostname string, constTags []string) {
+	for _, offender := range offenders {
+		var tags tagset.CompositeTags
+		if offender.Name != "" {
+			tags = tagset.NewCompositeTags(constTags, []string{"limit:metric_name", "metric_name:" + offender.Name})
+		} else {
+			tags = tagset.NewCompositeTags(constTags, append([]string{"limit:origin"}, offender.Origin...))
+		}
+		series.Append(&metrics.Serie{
+			Name:   LimitedMetricName,
+			Host:   hostname,
+			Tags:   tags,
+			MType:  metrics.APICountType,
+			Points: []metrics.Point{{Ts: timestamp, Value: float64(offender.Rejected)}},
+		})
+		recordOffender(offender)
+	}
+}
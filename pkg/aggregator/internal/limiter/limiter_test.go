@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package limiter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewDisabled(t *testing.T) {
+	assert.Nil(t, New(0, 0))
+	assert.NotNil(t, New(1, 0))
+	assert.NotNil(t, New(0, 1))
+}
+
+func TestTrackOrigin(t *testing.T) {
+	l := New(2, 0)
+	keyA := Key{Name: "foo", Origin: ckey.TagsKey(1), HasOrigin: true}
+	keyB := Key{Name: "foo", Origin: ckey.TagsKey(2), HasOrigin: true}
+
+	assert.True(t, l.Track(keyA, 1, []string{"pod:a"}))
+	assert.True(t, l.Track(keyA, 2, []string{"pod:a"}))
+	assert.False(t, l.Track(keyA, 3, []string{"pod:a"}))
+	assert.True(t, l.Track(keyB, 4, []string{"pod:b"}))
+	// no limit on the contexts without origin
+	assert.True(t, l.Track(Key{Name: "foo"}, 5, nil))
+
+	assert.Equal(t, []Offender{{Origin: []string{"pod:a"}, Rejected: 1}}, l.Flush())
+	assert.Empty(t, l.Flush())
+
+	l.Remove(keyA)
+	assert.True(t, l.Track(keyA, 3, []string{"pod:a"}))
+	assert.False(t, l.Track(keyA, 6, []string{"pod:a"}))
+}
+
+func TestTrackMetricName(t *testing.T) {
+	l := New(0, 2)
+
+	assert.True(t, l.Track(Key{Name: "foo"}, 1, nil))
+	assert.True(t, l.Track(Key{Name: "foo"}, 2, nil))
+	assert.False(t, l.Track(Key{Name: "foo"}, 3, nil))
+	assert.False(t, l.Track(Key{Name: "foo"}, 4, nil))
+	assert.True(t, l.Track(Key{Name: "bar"}, 5, nil))
+
+	assert.Equal(t, []Offender{{Name: "foo", Rejected: 2}}, l.Flush())
+
+	l.Remove(Key{Name: "foo"})
+	l.Remove(Key{Name: "foo"})
+	l.Remove(Key{Name: "bar"})
+	assert.Empty(t, l.Flush())
+	assert.Empty(t, l.metrics)
+}
+
+func TestTrackRejectedOnce(t *testing.T) {
+	l := New(0, 1)
+
+	assert.True(t, l.Track(Key{Name: "foo"}, 1, nil))
+	// every sample of a rejected context tracks it again
+	for i := 0; i < 3; i++ {
+		assert.False(t, l.Track(Key{Name: "foo"}, 2, nil))
+	}
+	assert.False(t, l.Track(Key{Name: "foo"}, 3, nil))
+	assert.Equal(t, []Offender{{Name: "foo", Rejected: 2}}, l.Flush())
+
+	// the contexts are counted again after a flush
+	assert.False(t, l.Track(Key{Name: "foo"}, 2, nil))
+	assert.Equal(t, []Offender{{Name: "foo", Rejected: 1}}, l.Flush())
+
+	// a rejected context is accepted once there is room
+	assert.False(t, l.Track(Key{Name: "foo"}, 2, nil))
+	l.Remove(Key{Name: "foo"})
+	assert.True(t, l.Track(Key{Name: "foo"}, 2, nil))
+	assert.Empty(t, l.rejected)
+}
+
+func TestSendTelemetry(t *testing.T) {
+	series := metrics.Series{}
+
+	SendTelemetry([]Offender{
+		{Name: "foo", Rejected: 3},
+		{Origin: []string{"pod:a"}, Rejected: 1},
+	}, 10, &series, "host", []string{"sampler_id:0"})
+
+	require.Len(t, series, 2)
+	assert.Equal(t, LimitedMetricName, series[0].Name)
+	assert.Equal(t, metrics.APICountType, series[0].MType)
+	assert.ElementsMatch(t, []string{"sampler_id:0", "limit:metric_name", "metric_name:foo"}, series[0].Tags.UnsafeToReadOnlySliceString())
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 3}}, series[0].Points)
+	assert.ElementsMatch(t, []string{"sampler_id:0", "limit:origin", "pod:a"}, series[1].Tags.UnsafeToReadOnlySliceString())
+
+	offenders := Offenders()
+	assert.Equal(t, uint64(3), offenders["metric_name:foo"])
+	assert.Equal(t, uint64(1), offenders["origin:pod:a"])
+}
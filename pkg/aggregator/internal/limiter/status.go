@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package limiter
+
+import (
+	"strings"
+	"sync"
+)
+
+// maxRecordedOffenders bounds the number of offenders reported in the agent status.
+const maxRecordedOffenders = 100
+
+var (
+	offendersMu sync.Mutex
+	offenders   = make(map[string]uint64)
+)
+
+// String returns a human-readable name of the offender.
+func (o Offender) String() string {
+	if o.Name != "" {
+		return "metric_name:" + o.Name
+	}
+	return "origin:" + strings.Join(o.Origin, ",")
+}
+
+func recordOffender(o Offender) {
+	name := o.String()
+	offendersMu.Lock()
+	defer offendersMu.Unlock()
+	if _, ok := offenders[name]; !ok && len(offenders) >= maxRecordedOffenders {
+		return
+	}
+	offenders[name] += o.Rejected
+}
+
+// Offenders returns the number of contexts rejected for each of the origins and
+// metric names which went over their limit since the agent started.
+func Offenders() map[string]uint64 {
+	offendersMu.Lock()
+	defer offendersMu.Unlock()
+	res := make(map[string]uint64, len(offenders))
+	for name, rejected := range offenders {
+		res[name] = rejected
+	}
+	return res
+}
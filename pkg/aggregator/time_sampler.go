@@ -33,6 +33,9 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	// aggregationKeys is nil when no tag is removed from the samples
+	aggregationKeys  *aggregationKeys
+	aggregatedGauges aggregatedGauges
 
 	// id is a number to differentiate multiple time samplers
 	// since we start running more than one with the demultiplexer introduction
@@ -42,7 +45,7 @@ type TimeSampler struct {
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
-func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, hostname string) *TimeSampler {
+func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, limits contextLimits, aggKeys *aggregationKeys, hostname string) *TimeSampler {
 	if interval == 0 {
 		interval = bucketSize
 	}
@@ -51,10 +54,12 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, hostnam
 
 	s := &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache),
+		contextResolver:             newTimestampContextResolver(cache, limits),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		aggregationKeys:             aggKeys,
+		aggregatedGauges:            make(aggregatedGauges),
 		id:                          id,
 		hostname:                    hostname,
 	}
@@ -77,7 +82,11 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 	}
 
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	dropTags := s.aggregationKeys.dropTags(metricSample.Name)
+	contextKey, sourceKey, ok := s.contextResolver.trackAggregatedContext(metricSample, timestamp, dropTags)
+	if !ok {
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
@@ -95,6 +104,13 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 			s.counterLastSampledByContext[contextKey] = timestamp
 		}
 
+		// The gauges of the different sources sharing the same context once their
+		// tags are removed are summed when the bucket is flushed
+		if metricSample.Mtype == metrics.GaugeType && dropTags != nil {
+			s.aggregatedGauges.sample(bucketStart, contextKey, sourceKey, metricSample.Value)
+			return
+		}
+
 		// Add sample to bucket
 		if err := bucketMetrics.AddSample(contextKey, metricSample, timestamp, s.interval, nil); err != nil {
 			log.Debugf("TimeSampler #%d Ignoring sample '%s' on host '%s' and tags '%s': %s", s.id, metricSample.Name, metricSample.Host, metricSample.Tags, err)
@@ -127,6 +143,8 @@ func (s *TimeSampler) flushSeries(cutoffTime int64, series metrics.SerieSink) {
 				continue
 			}
 
+			s.aggregatedGaugesSample(bucketTimestamp, contextMetrics)
+
 			// Add a 0 sample to all the counters that are not expired.
 			// It is ok to add 0 samples to a counter that was already sampled for real in the bucket, since it won't change its value
 			s.countersSampleZeroValue(bucketTimestamp, contextMetrics, counterContextsToDelete)
@@ -238,6 +256,7 @@ func (s *TimeSampler) flush(timestamp float64, series metrics.SerieSink, sketche
 	if config.Datadog.GetBool("telemetry.enabled") && config.Datadog.GetBool("telemetry.dogstatsd_origin") {
 		s.sendOriginTelemetry(timestamp, series)
 	}
+	s.contextResolver.sendLimiterTelemetry(timestamp, series, s.hostname, []string{fmt.Sprintf("sampler_id:%d", s.id)})
 }
 
 // flushContextMetrics flushes the contextMetrics inside contextMetricsFlusher, handles its errors,
@@ -285,6 +304,21 @@ func (s *TimeSampler) countersSampleZeroValue(timestamp int64, contextMetrics me
 	}
 }
 
+// aggregatedGaugesSample adds the sum of the sources of the aggregated gauges of the bucket to contextMetrics.
+func (s *TimeSampler) aggregatedGaugesSample(bucketTimestamp int64, contextMetrics metrics.ContextMetrics) {
+	s.aggregatedGauges.flush(bucketTimestamp, func(contextKey ckey.ContextKey, value float64) {
+		sample := &metrics.MetricSample{
+			Value:      value,
+			Mtype:      metrics.GaugeType,
+			SampleRate: 1,
+			Timestamp:  float64(bucketTimestamp),
+		}
+		if err := contextMetrics.AddSample(contextKey, sample, float64(bucketTimestamp), s.interval, nil); err != nil {
+			log.Debugf("TimeSampler #%d Ignoring aggregated gauge on context key '%v': %s", s.id, contextKey, err)
+		}
+	})
+}
+
 func (s *TimeSampler) sendOriginTelemetry(timestamp float64, series metrics.SerieSink) {
 	// If multiple samplers are used, this avoids the need to
 	// aggregate the stats agent-side, and allows us to see amount of
@@ -18,6 +18,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
@@ -33,7 +34,7 @@ func generateSerieContextKey(serie *metrics.Serie) ckey.ContextKey {
 }
 
 func testTimeSampler() *TimeSampler {
-	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), "host")
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), contextLimits{}, nil, "host")
 	return sampler
 }
 
@@ -513,6 +514,67 @@ func TestBucketSamplingWithSketchAndSeries(t *testing.T) {
 	testWithTagsStore(t, testBucketSamplingWithSketchAndSeries)
 }
 
+func testAggregationKeys(t *testing.T, store *tags.Store) {
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, store, contextLimits{}, newAggregationKeys([]config.AggregationKey{
+		{MetricName: "my.gauge", DropTags: []string{"pod_name"}},
+		{MetricName: "my.count", DropTags: []string{"pod_name"}},
+		{MetricName: "my.distribution", DropTags: []string{"pod_name"}},
+	}), "host")
+
+	sample := func(name string, mtype metrics.MetricType, value float64, pod string) {
+		sampler.sample(&metrics.MetricSample{
+			Name:       name,
+			Value:      value,
+			Mtype:      mtype,
+			Tags:       []string{"env:prod", "pod_name:" + pod},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+	sample("my.gauge", metrics.GaugeType, 1, "a")
+	sample("my.gauge", metrics.GaugeType, 3, "a")
+	sample("my.gauge", metrics.GaugeType, 5, "b")
+	sample("my.count", metrics.CounterType, 20, "a")
+	sample("my.count", metrics.CounterType, 30, "b")
+	sample("my.distribution", metrics.DistributionType, 1, "a")
+	sample("my.distribution", metrics.DistributionType, 2, "b")
+	sample("my.other", metrics.GaugeType, 1, "a")
+	sample("my.other", metrics.GaugeType, 1, "b")
+
+	series, sketches := flushSerie(sampler, 12360.0)
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Name+series[i].Tags.Join(",") < series[j].Name+series[j].Tags.Join(",")
+	})
+	require.Len(t, series, 4)
+	metrics.AssertSerieEqual(t, &metrics.Serie{
+		Name:     "my.count",
+		Tags:     tagset.CompositeTagsFromSlice([]string{"env:prod"}),
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 5}},
+		MType:    metrics.APIRateType,
+		Interval: 10,
+	}, series[0])
+	metrics.AssertSerieEqual(t, &metrics.Serie{
+		Name:     "my.gauge",
+		Tags:     tagset.CompositeTagsFromSlice([]string{"env:prod"}),
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 8}},
+		MType:    metrics.APIGaugeType,
+		Interval: 10,
+	}, series[1])
+	assert.Equal(t, "my.other", series[2].Name)
+	assert.Equal(t, "my.other", series[3].Name)
+
+	require.Len(t, sketches, 1)
+	assert.Equal(t, "my.distribution", sketches[0].Name)
+	assert.ElementsMatch(t, []string{"env:prod"}, sketches[0].Tags.UnsafeToReadOnlySliceString())
+	require.Len(t, sketches[0].Points, 1)
+	assert.Equal(t, int64(2), sketches[0].Points[0].Sketch.Basic.Cnt)
+
+	assert.Empty(t, sampler.aggregatedGauges)
+}
+func TestAggregationKeys(t *testing.T) {
+	testWithTagsStore(t, testAggregationKeys)
+}
+
 func benchmarkTimeSampler(b *testing.B, store *tags.Store) {
 	sampler := testTimeSampler()
 
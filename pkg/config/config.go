@@ -197,6 +197,23 @@ type MetricMapping struct {
 	Tags      map[string]string `mapstructure:"tags" json:"tags"`
 }
 
+// DogstatsdRule represents a rule applied by DogStatsD to the metric samples it receives
+type DogstatsdRule struct {
+	Name      string            `mapstructure:"name" json:"name"`
+	Match     string            `mapstructure:"match" json:"match"`
+	Action    string            `mapstructure:"action" json:"action"`
+	Tags      []string          `mapstructure:"tags" json:"tags"`
+	Rename    map[string]string `mapstructure:"rename" json:"rename"`
+	MaxValues int               `mapstructure:"max_values" json:"max_values"`
+}
+
+// AggregationKey lists the tags the aggregator removes from the samples of a
+// metric before aggregating them
+type AggregationKey struct {
+	MetricName string   `mapstructure:"metric_name" json:"metric_name"`
+	DropTags   []string `mapstructure:"drop_tags" json:"drop_tags"`
+}
+
 // Endpoint represent a datadog endpoint
 type Endpoint struct {
 	Site   string `mapstructure:"site" json:"site"`
@@ -446,6 +463,19 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("aggregator_stop_timeout", 2)
 	config.BindEnvAndSetDefault("aggregator_buffer_size", 100)
 	config.BindEnvAndSetDefault("aggregator_use_tags_store", true)
+	// Limits on the number of contexts tracked per origin and per metric name, 0 disables them.
+	// The contexts over a limit are dropped, or folded into an overflow context when overflow_action is "fold".
+	config.BindEnvAndSetDefault("aggregator_context_limits.per_origin", 0)
+	config.BindEnvAndSetDefault("aggregator_context_limits.per_metric_name", 0)
+	config.BindEnvAndSetDefault("aggregator_context_limits.overflow_action", "drop")
+	config.BindEnv("aggregator_aggregation_keys")
+	config.SetEnvKeyTransformer("aggregator_aggregation_keys", func(in string) interface{} {
+		var keys []AggregationKey
+		if err := json.Unmarshal([]byte(in), &keys); err != nil {
+			log.Errorf(`"aggregator_aggregation_keys" can not be parsed: %v`, err)
+		}
+		return keys
+	})
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_chan_size", 200)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_buffer_size", 4000)
@@ -505,6 +535,15 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
 	config.BindEnvAndSetDefault("dogstatsd_port", 8125)    // Notice: 0 means UDP port closed
 	config.BindEnvAndSetDefault("dogstatsd_pipe_name", "") // experimental and not officially supported for now.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0)   // Notice: 0 means TCP listener disabled
+	config.BindEnvAndSetDefault("dogstatsd_http_port", 0)  // Notice: 0 means HTTP listener disabled
+	// Options are: newline, length_prefixed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_framing", "newline")
+	// TLS settings of the TCP and HTTP listeners; TLS is disabled unless a certificate is set.
+	// Clients are required to present a certificate signed by the client CA, when set.
+	config.BindEnvAndSetDefault("dogstatsd_tls_cert_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tls_key_file", "")
+	config.BindEnvAndSetDefault("dogstatsd_tls_client_ca_file", "")
 	// Experimental and not officially supported for now.
 	// Options are: udp, uds, named_pipe
 	config.BindEnvAndSetDefault("dogstatsd_eol_required", []string{})
@@ -574,6 +613,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_rules")
+	config.SetEnvKeyTransformer("dogstatsd_rules", func(in string) interface{} {
+		var rules []DogstatsdRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"dogstatsd_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -939,6 +987,15 @@ func InitConfig(config Config) {
 	// It may be useful to increase it when logs writing is slowed down, that
 	// could happen while serializing large objects on log lines.
 	config.BindEnvAndSetDefault("logs_config.aggregation_timeout", 1000)
+	// The maximum size in bytes of a line reassembled from the partial chunks written by the
+	// container runtimes, larger lines are split into several messages. When unset or 0, the
+	// maximum size of a message is used.
+	config.BindEnvAndSetDefault("logs_config.max_partial_line_size", 0)
+	// Payloads which can not be sent because all the reliable destinations are failing are
+	// stored on disk and replayed once the intake is reachable again, up to this size in bytes.
+	config.BindEnvAndSetDefault("logs_config.storage_max_size_in_bytes", 0) // 0 means disabled.
+	// Defaults to `<logs_config.run_path>/logs_to_retry` when empty.
+	config.BindEnvAndSetDefault("logs_config.storage_path", "")
 	// Time in seconds
 	config.BindEnvAndSetDefault("logs_config.file_scan_period", 10.0)
 
@@ -2016,6 +2073,38 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetDogstatsdRules returns the rules applied by DogStatsD to the metric samples it receives
+func GetDogstatsdRules() ([]DogstatsdRule, error) {
+	return getDogstatsdRulesConfig(Datadog)
+}
+
+func getDogstatsdRulesConfig(config Config) ([]DogstatsdRule, error) {
+	var rules []DogstatsdRule
+	if config.IsSet("dogstatsd_rules") {
+		err := config.UnmarshalKey("dogstatsd_rules", &rules)
+		if err != nil {
+			return []DogstatsdRule{}, log.Errorf("Could not parse dogstatsd_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
+// GetAggregationKeys returns the tags the aggregator removes from the DogStatsD metrics before aggregating them
+func GetAggregationKeys() ([]AggregationKey, error) {
+	return getAggregationKeysConfig(Datadog)
+}
+
+func getAggregationKeysConfig(config Config) ([]AggregationKey, error) {
+	var keys []AggregationKey
+	if config.IsSet("aggregator_aggregation_keys") {
+		err := config.UnmarshalKey("aggregator_aggregation_keys", &keys)
+		if err != nil {
+			return []AggregationKey{}, log.Errorf("Could not parse aggregator_aggregation_keys: %v", err)
+		}
+	}
+	return keys, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
@@ -1094,6 +1094,75 @@ func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	assert.Equal(t, mappings, expected)
 }
 
+func TestDogstatsdRulesOk(t *testing.T) {
+	datadogYaml := `
+dogstatsd_rules:
+  - name: "drop-debug"
+    match: "debug.*"
+    action: "drop"
+  - name: "rename-env"
+    action: "rename_tags"
+    rename:
+      environment: env
+  - name: "limit-users"
+    match: "app.*"
+    action: "limit_tag_values"
+    tags: ["user"]
+    max_values: 100
+`
+	testConfig := setupConfFromYAML(datadogYaml)
+
+	rules, err := getDogstatsdRulesConfig(testConfig)
+
+	expectedRules := []DogstatsdRule{
+		{Name: "drop-debug", Match: "debug.*", Action: "drop"},
+		{Name: "rename-env", Action: "rename_tags", Rename: map[string]string{"environment": "env"}},
+		{Name: "limit-users", Match: "app.*", Action: "limit_tag_values", Tags: []string{"user"}, MaxValues: 100},
+	}
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, expectedRules, rules)
+}
+
+func TestDogstatsdRulesError(t *testing.T) {
+	testConfig := setupConfFromYAML(`
+dogstatsd_rules:
+  - abc
+`)
+	rules, err := getDogstatsdRulesConfig(testConfig)
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Could not parse dogstatsd_rules")
+	assert.Empty(t, rules)
+}
+
+func TestDogstatsdRulesEnv(t *testing.T) {
+	t.Setenv("DD_DOGSTATSD_RULES", `[{"name":"strip-ids","action":"drop_tags","tags":["request_id"]}]`)
+	expected := []DogstatsdRule{
+		{Name: "strip-ids", Action: "drop_tags", Tags: []string{"request_id"}},
+	}
+	rules, _ := GetDogstatsdRules()
+	assert.Equal(t, expected, rules)
+}
+
+func TestAggregationKeysOk(t *testing.T) {
+	testConfig := setupConfFromYAML(`
+aggregator_aggregation_keys:
+  - metric_name: "app.requests"
+    drop_tags: ["pod_name", "container_id"]
+`)
+	keys, err := getAggregationKeysConfig(testConfig)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []AggregationKey{{MetricName: "app.requests", DropTags: []string{"pod_name", "container_id"}}}, keys)
+}
+
+func TestAggregationKeysEnv(t *testing.T) {
+	t.Setenv("DD_AGGREGATOR_AGGREGATION_KEYS", `[{"metric_name":"app.requests","drop_tags":["pod_name"]}]`)
+	keys, _ := GetAggregationKeys()
+	assert.Equal(t, []AggregationKey{{MetricName: "app.requests", DropTags: []string{"pod_name"}}}, keys)
+}
+
 func TestGetValidHostAliasesWithConfig(t *testing.T) {
 	config := setupConfFromYAML(`host_aliases: ["foo", "-bar"]`)
 	assert.EqualValues(t, getValidHostAliasesWithConfig(config), []string{"foo"})
@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var httpTelemetry = newListenerTelemetry("http", "HTTP")
+
+const (
+	// httpPath is the path of the endpoint accepting batches of DogStatsD messages.
+	httpPath = "/v1/dogstatsd"
+	// httpMaxRequestBytes is the maximum size of a request body, once decompressed.
+	httpMaxRequestBytes = 10 * 1024 * 1024
+	// headerContainerID is the header with which clients may set the container
+	// they run in, as done by the tracers with the trace agent.
+	headerContainerID = "Datadog-Container-ID"
+)
+
+// HTTPListener implements the StatsdListener interface for HTTP, with optional TLS.
+// It accepts POST requests whose body is a batch of newline separated DogStatsD
+// messages, optionally gzip compressed, and sends back packets ready to be processed.
+// Messages are tagged with the origin of their request, set with the
+// Datadog-Container-ID header or with the client TLS certificate.
+type HTTPListener struct {
+	listener                net.Listener
+	server                  *http.Server
+	packetsBuffer           *packets.Buffer
+	sharedPacketPoolManager *packets.PoolManager
+	bufferSize              int
+	trafficCapture          replay.Component // Currently ignored
+
+	// assemblers holds the assemblers of the requests. They are flushed at the
+	// end of each request, so they have no flush timer.
+	assemblers sync.Pool
+}
+
+// NewHTTPListener returns an idle HTTP Statsd listener
+func NewHTTPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture replay.Component) (*HTTPListener, error) {
+	tlsConfig, err := tlsConfigFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-http: %s", err)
+	}
+	ln, err := net.Listen("tcp", listenAddr(config.Datadog.GetInt("dogstatsd_http_port")))
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+	listener := &HTTPListener{
+		listener: ln,
+		packetsBuffer: packets.NewBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+			flushTimeout, packetOut),
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		bufferSize:              config.Datadog.GetInt("dogstatsd_buffer_size"),
+		trafficCapture:          capture,
+	}
+	listener.assemblers.New = func() interface{} {
+		return packets.NewAssembler(0, listener.packetsBuffer, listener.sharedPacketPoolManager, packets.HTTP)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(httpPath, listener.handleRequest)
+	listener.server = &http.Server{
+		Handler:      mux,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	log.Debugf("dogstatsd-http: %s successfully initialized (tls=%t)", ln.Addr(), tlsConfig != nil)
+	return listener, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *HTTPListener) Listen() {
+	log.Infof("dogstatsd-http: starting to listen on %s", l.listener.Addr())
+	var err error
+	if l.server.TLSConfig != nil {
+		// certificates are already loaded in the TLS configuration
+		err = l.server.ServeTLS(l.listener, "", "")
+	} else {
+		err = l.server.Serve(l.listener)
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("dogstatsd-http: error serving requests: %v", err)
+	}
+}
+
+// handleRequest handles a batch of DogStatsD messages.
+func (l *HTTPListener) handleRequest(w http.ResponseWriter, req *http.Request) {
+	t1 := time.Now()
+	defer func() {
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "http")
+	}()
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			httpTelemetry.onReadError()
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	origin := originFromTLS(req.TLS)
+	if id := req.Header.Get(headerContainerID); id != "" {
+		origin = containers.BuildTaggerEntityName(id)
+	}
+	assembler := l.assemblers.Get().(*packets.Assembler)
+	assembler.SetOrigin(origin)
+
+	n, err := l.readMessages(body, assembler)
+	assembler.Flush()
+	l.assemblers.Put(assembler)
+	if err != nil {
+		log.Debugf("dogstatsd-http: error reading request from %s: %v", req.RemoteAddr, err)
+		httpTelemetry.onReadError()
+		status := http.StatusBadRequest
+		if errors.Is(err, bufio.ErrTooLong) || errors.Is(err, errRequestTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		// the messages read so far are kept
+		http.Error(w, err.Error(), status)
+		return
+	}
+	httpTelemetry.onReadSuccess(n)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+var errRequestTooLarge = fmt.Errorf("request body is larger than %d bytes", httpMaxRequestBytes)
+
+// readMessages adds the messages found in r to the assembler, returning the number of
+// bytes read.
+func (l *HTTPListener) readMessages(r io.Reader, assembler *packets.Assembler) (int, error) {
+	lr := &io.LimitedReader{R: r, N: httpMaxRequestBytes + 1}
+	scanner := bufio.NewScanner(lr)
+	scanner.Buffer(make([]byte, 0, 4096), l.bufferSize)
+	for scanner.Scan() {
+		if lr.N == 0 {
+			// the body is too large, and the line may be truncated
+			return httpMaxRequestBytes, errRequestTooLarge
+		}
+		if line := scanner.Bytes(); len(line) > 0 {
+			assembler.AddMessage(line)
+		}
+	}
+	return int(httpMaxRequestBytes + 1 - lr.N), scanner.Err()
+}
+
+// Stop closes the HTTP listener and stops listening
+func (l *HTTPListener) Stop() {
+	if err := l.server.Close(); err != nil {
+		log.Debugf("dogstatsd-http: error closing server: %v", err)
+	}
+	l.packetsBuffer.Close()
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+//go:build !windows
+// +build !windows
+
+package listeners
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+func newTestHTTPListener(t *testing.T) (*HTTPListener, chan packets.Packets, int) {
+	port, err := getAvailableTCPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_http_port", port)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+
+	packetsChannel := make(chan packets.Packets, 10)
+	s, err := NewHTTPListener(packetsChannel, packetPoolManagerUDP, nil)
+	require.NoError(t, err)
+	go s.Listen()
+	return s, packetsChannel, port
+}
+
+func TestHTTPReceive(t *testing.T) {
+	s, packetsChannel, port := newTestHTTPListener(t)
+	defer s.Stop()
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", port, httpPath)
+
+	t.Run("lines", func(t *testing.T) {
+		resp, err := http.Post(url, "text/plain", strings.NewReader("daemon:666|g\n\ncounter:1|c|#a:b\nlast:2|c"))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		messages, packet := receiveMessages(t, packetsChannel, 3)
+		assert.Equal(t, []string{"daemon:666|g", "counter:1|c|#a:b", "last:2|c"}, messages)
+		assert.Equal(t, packets.HTTP, packet.Source)
+		assert.Equal(t, packets.NoOrigin, packet.Origin)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		var body bytes.Buffer
+		gz := gzip.NewWriter(&body)
+		gz.Write([]byte("daemon:666|g\n"))
+		gz.Close()
+		req, err := http.NewRequest(http.MethodPost, url, &body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set(headerContainerID, "abc123")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		messages, packet := receiveMessages(t, packetsChannel, 1)
+		assert.Equal(t, []string{"daemon:666|g"}, messages)
+		assert.Equal(t, "container_id://abc123", packet.Origin)
+	})
+
+	t.Run("method", func(t *testing.T) {
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+
+	t.Run("too-long", func(t *testing.T) {
+		line := strings.Repeat("a", config.Datadog.GetInt("dogstatsd_buffer_size")+1)
+		resp, err := http.Post(url, "text/plain", strings.NewReader("daemon:666|g\n"+line))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+		// the messages preceding the invalid one are kept
+		messages, _ := receiveMessages(t, packetsChannel, 1)
+		assert.Equal(t, []string{"daemon:666|g"}, messages)
+	})
+
+	select {
+	case pkts := <-packetsChannel:
+		assert.FailNow(t, "unexpected packets", "%v", pkts)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestHTTPReceiveTLS(t *testing.T) {
+	certs := newTestCertificates(t)
+	defer useTestCertificates(certs, true)()
+	s, packetsChannel, port := newTestHTTPListener(t)
+	defer s.Stop()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: certs.client}}
+	resp, err := client.Post(fmt.Sprintf("https://127.0.0.1:%d%s", port, httpPath), "text/plain", strings.NewReader("daemon:666|g"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	messages, packet := receiveMessages(t, packetsChannel, 1)
+	assert.Equal(t, []string{"daemon:666|g"}, messages)
+	assert.Equal(t, "container_id://abc123", packet.Origin)
+}
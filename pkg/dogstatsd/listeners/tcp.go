@@ -0,0 +1,253 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var tcpTelemetry = newListenerTelemetry("tcp", "TCP")
+
+const (
+	// tcpFramingNewline frames TCP streams with a newline after each message.
+	tcpFramingNewline = "newline"
+	// tcpFramingLengthPrefixed frames TCP streams with the length of each frame,
+	// as a little-endian uint32, before its content.
+	tcpFramingLengthPrefixed = "length_prefixed"
+)
+
+// TCPListener implements the StatsdListener interface for TCP, with optional TLS.
+// It accepts connections on a given port and sends back packets ready to be
+// processed.
+// Messages are tagged with the origin of their connection when clients present a
+// TLS certificate.
+type TCPListener struct {
+	listener                net.Listener
+	packetsBuffer           *packets.Buffer
+	sharedPacketPoolManager *packets.PoolManager
+	flushTimeout            time.Duration
+	bufferSize              int
+	lengthPrefixed          bool
+	trafficCapture          replay.Component
+
+	mu    sync.Mutex // guards conns
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup // waits for the connections handlers
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture replay.Component) (*TCPListener, error) {
+	var lengthPrefixed bool
+	switch framing := config.Datadog.GetString("dogstatsd_tcp_framing"); framing {
+	case tcpFramingNewline:
+	case tcpFramingLengthPrefixed:
+		lengthPrefixed = true
+	default:
+		return nil, fmt.Errorf("dogstatsd-tcp: unknown framing %q, expected %q or %q", framing, tcpFramingNewline, tcpFramingLengthPrefixed)
+	}
+	tlsConfig, err := tlsConfigFromConfig()
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-tcp: %s", err)
+	}
+
+	ln, err := net.Listen("tcp", listenAddr(config.Datadog.GetInt("dogstatsd_tcp_port")))
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+	listener := &TCPListener{
+		listener: ln,
+		packetsBuffer: packets.NewBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+			flushTimeout, packetOut),
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		flushTimeout:            flushTimeout,
+		bufferSize:              config.Datadog.GetInt("dogstatsd_buffer_size"),
+		lengthPrefixed:          lengthPrefixed,
+		trafficCapture:          capture,
+		conns:                   make(map[net.Conn]struct{}),
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized (tls=%t)", ln.Addr(), tlsConfig != nil)
+	return listener, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			continue
+		}
+		l.mu.Lock()
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+		l.wg.Add(1)
+		go l.handleConnection(conn)
+	}
+}
+
+// handleConnection reads the messages sent over conn until it gets closed.
+func (l *TCPListener) handleConnection(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		l.wg.Done()
+	}()
+	log.Debugf("dogstatsd-tcp: new connection from %s", conn.RemoteAddr())
+
+	origin := packets.NoOrigin
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			log.Debugf("dogstatsd-tcp: TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+			tcpTelemetry.onReadError()
+			return
+		}
+		state := tlsConn.ConnectionState()
+		origin = originFromTLS(&state)
+	}
+
+	// each connection has its own assembler, so that packets never mix the
+	// messages of different origins
+	assembler := packets.NewAssembler(l.flushTimeout, l.packetsBuffer, l.sharedPacketPoolManager, packets.TCP)
+	assembler.SetOrigin(origin)
+	defer func() {
+		assembler.Flush()
+		assembler.Close()
+	}()
+
+	var err error
+	if l.lengthPrefixed {
+		err = l.readFrames(conn, assembler)
+	} else {
+		err = l.readLines(conn, assembler)
+	}
+	switch {
+	case err == nil, errors.Is(err, net.ErrClosed):
+		log.Debugf("dogstatsd-tcp: client %s disconnected", conn.RemoteAddr())
+	default:
+		log.Errorf("dogstatsd-tcp: error reading from %s: %v", conn.RemoteAddr(), err)
+		tcpTelemetry.onReadError()
+	}
+}
+
+// readLines reads newline-terminated messages from conn. It returns nil once the
+// client has closed the connection.
+func (l *TCPListener) readLines(conn net.Conn, assembler *packets.Assembler) error {
+	buffer := make([]byte, l.bufferSize)
+	start := 0
+	// discarding is set while skipping the rest of a message larger than the buffer
+	discarding := false
+	t1 := time.Now()
+	for {
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "tcp")
+		n, err := conn.Read(buffer[start:])
+		t1 = time.Now()
+		end := start + n
+		if n > 0 {
+			tcpTelemetry.onReadSuccess(n)
+		}
+		if discarding && end > 0 {
+			// start is 0 while discarding, the whole buffer was just read
+			if i := bytes.IndexByte(buffer[:end], '\n'); i >= 0 {
+				end = copy(buffer, buffer[i+1:end])
+				discarding = false
+			} else {
+				end = 0
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				// the last message may not be terminated
+				if end > 0 {
+					replay.EnqueueCopy(l.trafficCapture, buffer[:end])
+					assembler.AddMessage(buffer[:end])
+				}
+				return nil
+			}
+			return err
+		}
+		// When there is no '\n', the message is partial and size is 0.
+		size := bytes.LastIndexByte(buffer[:end], '\n') + 1
+		if size > 0 {
+			replay.EnqueueCopy(l.trafficCapture, buffer[:size-1])
+			// packetAssembler merges multiple packets together and sends them when its buffer is full
+			assembler.AddMessage(buffer[:size-1])
+		}
+		start = copy(buffer, buffer[size:end])
+		if start >= len(buffer) {
+			// the message is bigger than the buffer, drop it
+			log.Debugf("dogstatsd-tcp: dropping message larger than %d bytes from %s", len(buffer), conn.RemoteAddr())
+			start = 0
+			discarding = true
+		}
+	}
+}
+
+// readFrames reads length-prefixed frames from conn. It returns nil once the client
+// has closed the connection.
+func (l *TCPListener) readFrames(conn net.Conn, assembler *packets.Assembler) error {
+	buffer := make([]byte, l.bufferSize)
+	var header [4]byte
+	t1 := time.Now()
+	for {
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "tcp")
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		t1 = time.Now()
+		size := binary.LittleEndian.Uint32(header[:])
+		if size > uint32(len(buffer)) {
+			// we can't skip the frame without reading it, close the connection
+			return fmt.Errorf("frame of %d bytes is larger than the maximum of %d bytes", size, len(buffer))
+		}
+		if _, err := io.ReadFull(conn, buffer[:size]); err != nil {
+			return err
+		}
+		tcpTelemetry.onReadSuccess(int(size) + len(header))
+		if size > 0 {
+			replay.EnqueueCopy(l.trafficCapture, buffer[:size])
+			assembler.AddMessage(buffer[:size])
+		}
+	}
+}
+
+// Stop closes the TCP listener and its connections, and stops listening
+func (l *TCPListener) Stop() {
+	l.listener.Close()
+	l.mu.Lock()
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+	l.packetsBuffer.Close()
+}
@@ -0,0 +1,292 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+//go:build !windows
+// +build !windows
+
+package listeners
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+// getAvailableTCPPort requests a random port number and makes sure it is available
+func getAvailableTCPPort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return -1, fmt.Errorf("can't find an available tcp port: %s", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// receiveMessages reads packets from packetsChannel until n messages are received.
+func receiveMessages(t *testing.T, packetsChannel chan packets.Packets, n int) ([]string, *packets.Packet) {
+	var (
+		messages []string
+		last     *packets.Packet
+	)
+	for len(messages) < n {
+		select {
+		case pkts := <-packetsChannel:
+			for _, p := range pkts {
+				messages = append(messages, strings.Split(string(p.Contents), "\n")...)
+				last = p
+			}
+		case <-time.After(2 * time.Second):
+			require.FailNow(t, "Timeout on receive channel", "received %v", messages)
+		}
+	}
+	return messages, last
+}
+
+func newTestTCPListener(t *testing.T, framing string) (*TCPListener, chan packets.Packets, int) {
+	port, err := getAvailableTCPPort()
+	require.NoError(t, err)
+	config.Datadog.SetDefault("dogstatsd_tcp_port", port)
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", framing)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+
+	packetsChannel := make(chan packets.Packets, 10)
+	s, err := NewTCPListener(packetsChannel, packetPoolManagerUDP, nil)
+	require.NoError(t, err)
+	go s.Listen()
+	return s, packetsChannel, port
+}
+
+func TestNewTCPListenerUnknownFraming(t *testing.T) {
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", "xml")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_framing", tcpFramingNewline)
+	s, err := NewTCPListener(nil, packetPoolManagerUDP, nil)
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestTCPReceiveNewline(t *testing.T) {
+	s, packetsChannel, port := newTestTCPListener(t, tcpFramingNewline)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	// messages may be split across writes, and the last one may not be terminated
+	for _, chunk := range []string{"daemon:666|g|#sometag1:some", "value1\ncounter:1|c\n", "last:2|c"} {
+		_, err = conn.Write([]byte(chunk))
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn.Close()
+
+	messages, packet := receiveMessages(t, packetsChannel, 3)
+	assert.Equal(t, []string{"daemon:666|g|#sometag1:somevalue1", "counter:1|c", "last:2|c"}, messages)
+	assert.Equal(t, packets.TCP, packet.Source)
+	assert.Equal(t, packets.NoOrigin, packet.Origin)
+}
+
+func TestTCPDropsMessageLargerThanBuffer(t *testing.T) {
+	config.Datadog.SetDefault("dogstatsd_buffer_size", 16)
+	defer config.Datadog.SetDefault("dogstatsd_buffer_size", 8192)
+	s, packetsChannel, port := newTestTCPListener(t, tcpFramingNewline)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	// the rest of the dropped message must not be parsed as a new message
+	for _, chunk := range []string{"toolong:1|c|#abcdef", "ghijklmnopqrstuvwxyz", "0123\ncounter:1|c\n"} {
+		_, err = conn.Write([]byte(chunk))
+		require.NoError(t, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn.Close()
+
+	messages, _ := receiveMessages(t, packetsChannel, 1)
+	assert.Equal(t, []string{"counter:1|c"}, messages)
+}
+
+func TestTCPReceiveLengthPrefixed(t *testing.T) {
+	s, packetsChannel, port := newTestTCPListener(t, tcpFramingLengthPrefixed)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, msg := range []string{"daemon:666|g", "counter:1|c\nother:2|c"} {
+		frame := make([]byte, 4+len(msg))
+		binary.LittleEndian.PutUint32(frame, uint32(len(msg)))
+		copy(frame[4:], msg)
+		_, err = conn.Write(frame)
+		require.NoError(t, err)
+	}
+
+	messages, packet := receiveMessages(t, packetsChannel, 3)
+	assert.Equal(t, []string{"daemon:666|g", "counter:1|c", "other:2|c"}, messages)
+	assert.Equal(t, packets.TCP, packet.Source)
+}
+
+func TestTCPStopClosesConnections(t *testing.T) {
+	s, _, port := newTestTCPListener(t, tcpFramingNewline)
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer conn.Close()
+	// wait for the connection to be accepted
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.conns) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+// testCertificates holds the paths to a CA, a server certificate signed by it, and
+// the TLS configuration of a client with a certificate for the common name "abc123".
+type testCertificates struct {
+	caFile, certFile, keyFile string
+	client                    *tls.Config
+}
+
+func newTestCertificates(t *testing.T) testCertificates {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	certs := testCertificates{
+		caFile:   filepath.Join(dir, "ca.pem"),
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+	}
+	require.NoError(t, os.WriteFile(certs.caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+	serverCert, serverKey := issue(2, "dogstatsd", x509.ExtKeyUsageServerAuth)
+	require.NoError(t, os.WriteFile(certs.certFile, serverCert, 0600))
+	require.NoError(t, os.WriteFile(certs.keyFile, serverKey, 0600))
+
+	clientCert, clientKey := issue(3, "abc123", x509.ExtKeyUsageClientAuth)
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	certs.client = &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{pair},
+	}
+	return certs
+}
+
+func useTestCertificates(certs testCertificates, clientCA bool) func() {
+	config.Datadog.SetDefault("dogstatsd_tls_cert_file", certs.certFile)
+	config.Datadog.SetDefault("dogstatsd_tls_key_file", certs.keyFile)
+	if clientCA {
+		config.Datadog.SetDefault("dogstatsd_tls_client_ca_file", certs.caFile)
+	}
+	return func() {
+		config.Datadog.SetDefault("dogstatsd_tls_cert_file", "")
+		config.Datadog.SetDefault("dogstatsd_tls_key_file", "")
+		config.Datadog.SetDefault("dogstatsd_tls_client_ca_file", "")
+	}
+}
+
+func TestTCPReceiveTLS(t *testing.T) {
+	certs := newTestCertificates(t)
+	defer useTestCertificates(certs, true)()
+	s, packetsChannel, port := newTestTCPListener(t, tcpFramingNewline)
+	defer s.Stop()
+	addr := "127.0.0.1:" + strconv.Itoa(port)
+
+	t.Run("origin", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", addr, certs.client)
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("daemon:666|g\n"))
+		require.NoError(t, err)
+		conn.Close()
+
+		messages, packet := receiveMessages(t, packetsChannel, 1)
+		assert.Equal(t, []string{"daemon:666|g"}, messages)
+		assert.Equal(t, "container_id://abc123", packet.Origin)
+	})
+
+	t.Run("no-client-certificate", func(t *testing.T) {
+		cfg := certs.client.Clone()
+		cfg.Certificates = nil
+		conn, err := tls.Dial("tcp", addr, cfg)
+		if err == nil {
+			// with TLS 1.3 the handshake error is only reported on read
+			conn.Write([]byte("daemon:666|g\n"))
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		assert.Error(t, err)
+		select {
+		case pkts := <-packetsChannel:
+			assert.FailNow(t, "unexpected packets", "%v", pkts)
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+}
+
+func TestTLSConfigFromConfig(t *testing.T) {
+	cfg, err := tlsConfigFromConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	defer useTestCertificates(testCertificates{certFile: "/does/not/exist.pem", keyFile: "/does/not/exist.key"}, false)()
+	_, err = tlsConfigFromConfig()
+	assert.Error(t, err)
+}
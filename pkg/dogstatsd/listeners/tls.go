@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+)
+
+// listenAddr returns the address the network listeners should bind to for the given
+// port, honouring dogstatsd_non_local_traffic.
+func listenAddr(port int) string {
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") {
+		// Listen to all network interfaces
+		return fmt.Sprintf(":%d", port)
+	}
+	return net.JoinHostPort(config.GetBindHost(), fmt.Sprint(port))
+}
+
+// tlsConfigFromConfig returns the TLS configuration of the TCP and HTTP listeners,
+// or nil if TLS is not enabled. When a client CA is configured, clients are required
+// to present a certificate signed by it.
+func tlsConfigFromConfig() (*tls.Config, error) {
+	certFile := config.Datadog.GetString("dogstatsd_tls_cert_file")
+	keyFile := config.Datadog.GetString("dogstatsd_tls_key_file")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can't load TLS certificate: %s", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if caFile := config.Datadog.GetString("dogstatsd_tls_client_ca_file"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read TLS client CA: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in TLS client CA %s", caFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// originFromTLS returns the origin of the messages sent over a TLS connection having
+// the given state. Clients identify the container they run in with the common name
+// of their certificate.
+func originFromTLS(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return packets.NoOrigin
+	}
+	if cn := state.PeerCertificates[0].Subject.CommonName; cn != "" {
+		return containers.BuildTaggerEntityName(cn)
+	}
+	return packets.NoOrigin
+}
@@ -40,7 +40,7 @@ type UDPListener struct {
 	packetsBuffer   *packets.Buffer
 	packetAssembler *packets.Assembler
 	buffer          []byte
-	trafficCapture  replay.Component // Currently ignored
+	trafficCapture  replay.Component
 }
 
 // NewUDPListener returns an idle UDP Statsd listener
@@ -113,6 +113,8 @@ func (l *UDPListener) Listen() {
 			udpBytes.Add(int64(n))
 			tlmUDPPacketsBytes.Add(float64(n))
 
+			replay.EnqueueCopy(l.trafficCapture, l.buffer[:n])
+
 			// packetAssembler merges multiple packets together and sends them when its buffer is full
 			l.packetAssembler.AddMessage(l.buffer[:n])
 		}
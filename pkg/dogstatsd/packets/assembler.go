@@ -23,10 +23,13 @@ type Assembler struct {
 	flushTimer              *time.Ticker
 	closeChannel            chan struct{}
 	packetSourceType        SourceType
+	origin                  string
 	sync.Mutex
 }
 
-// NewAssembler creates a new Assembler instance using the specified flush duration, buffer and pool manager
+// NewAssembler creates a new Assembler instance using the specified flush duration, buffer and pool manager.
+// When the flush duration is not positive, the assembler is never flushed on a timer and Flush must
+// be called once the messages are added.
 func NewAssembler(flushTimer time.Duration, packetsBuffer *Buffer, sharedPacketPoolManager *PoolManager, packetSourceType SourceType) *Assembler {
 	packetAssembler := &Assembler{
 		// retrieve an available packet from the packet pool,
@@ -34,11 +37,13 @@ func NewAssembler(flushTimer time.Duration, packetsBuffer *Buffer, sharedPacketP
 		packet:                  sharedPacketPoolManager.Get().(*Packet),
 		sharedPacketPoolManager: sharedPacketPoolManager,
 		packetsBuffer:           packetsBuffer,
-		flushTimer:              time.NewTicker(flushTimer),
 		packetSourceType:        packetSourceType,
 		closeChannel:            make(chan struct{}),
 	}
-	go packetAssembler.flushLoop()
+	if flushTimer > 0 {
+		packetAssembler.flushTimer = time.NewTicker(flushTimer)
+		go packetAssembler.flushLoop()
+	}
 	return packetAssembler
 }
 
@@ -55,6 +60,14 @@ func (p *Assembler) flushLoop() {
 	}
 }
 
+// SetOrigin sets the origin of the packets assembled from now on. It allows
+// connection based listeners to tag the messages of each connection.
+func (p *Assembler) SetOrigin(origin string) {
+	p.Lock()
+	p.origin = origin
+	p.Unlock()
+}
+
 // AddMessage adds a new dogstatsd message to the buffer
 func (p *Assembler) AddMessage(message []byte) {
 	p.Lock()
@@ -77,6 +90,7 @@ func (p *Assembler) flush() {
 	}
 	p.packet.Contents = p.packet.Buffer[:p.packetLength]
 	p.packet.Source = p.packetSourceType
+	p.packet.Origin = p.origin
 	p.packetsBuffer.Append(p.packet)
 	// retrieve an available packet from the packet pool,
 	// which will be pushed back by the server when processed.
@@ -84,9 +98,19 @@ func (p *Assembler) flush() {
 	p.packetLength = 0
 }
 
+// Flush sends the messages added so far to the packets buffer.
+func (p *Assembler) Flush() {
+	p.Lock()
+	p.flush()
+	p.Unlock()
+}
+
 // Close closes the packet assembler
 func (p *Assembler) Close() {
 	p.Lock()
+	if p.flushTimer != nil {
+		p.flushTimer.Stop()
+	}
 	close(p.closeChannel)
 	p.Unlock()
 }
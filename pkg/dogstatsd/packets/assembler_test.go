@@ -162,3 +162,17 @@ func BenchmarkBufferFlush(b *testing.B) {
 		}
 	}
 }
+
+func TestPacketBufferNoFlushTimer(t *testing.T) {
+	out := make(chan Packets, 16)
+	psb := NewBuffer(1, 1*time.Hour, out)
+	pb := NewAssembler(0, psb, NewPoolManager(NewPool(sampleBatchSize)), HTTP)
+	defer pb.Close()
+	assert.Nil(t, pb.flushTimer)
+
+	pb.AddMessage([]byte("test"))
+	pb.Flush()
+	packets := <-out
+	assert.Equal(t, []byte("test"), packets[0].Contents)
+	assert.Equal(t, HTTP, packets[0].Source)
+}
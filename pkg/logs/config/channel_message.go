@@ -17,6 +17,14 @@ type ChannelMessage struct {
 	// Used in the Serverless Agent
 	Lambda  *Lambda
 	IsError bool
+	// Optional. Override the service, source and status of the message
+	// Used by the OTLP logs ingestion
+	Service string
+	Source  string
+	Status  string
+	// Optional. Tags added to the tags of the source
+	// Used by the OTLP logs ingestion
+	Tags []string
 }
 
 // Lambda is a struct storing information about the Lambda function and function execution.
@@ -313,3 +313,19 @@ func TaggerWarmupDuration() time.Duration {
 func AggregationTimeout() time.Duration {
 	return defaultLogsConfigKeys().aggregationTimeout()
 }
+
+// MaxPartialLineSize is the maximum size of a line reassembled from partial chunks, 0 when
+// the maximum size of a message applies.
+func MaxPartialLineSize() int {
+	return defaultLogsConfigKeys().maxPartialLineSize()
+}
+
+// StoragePath is the directory where the payloads which could not be sent are stored
+func StoragePath() string {
+	return defaultLogsConfigKeys().storagePath()
+}
+
+// StorageMaxSizeInBytes is the maximum size of the payloads stored on disk, 0 disables the storage
+func StorageMaxSizeInBytes() int64 {
+	return defaultLogsConfigKeys().storageMaxSizeInBytes()
+}
@@ -7,6 +7,7 @@ package config
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"time"
 
 	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
@@ -259,6 +260,22 @@ func (l *LogsConfigKeys) aggregationTimeout() time.Duration {
 	return l.getConfig().GetDuration(l.getConfigKey("aggregation_timeout")) * time.Millisecond
 }
 
+func (l *LogsConfigKeys) maxPartialLineSize() int {
+	return l.getConfig().GetInt(l.getConfigKey("max_partial_line_size"))
+}
+
+func (l *LogsConfigKeys) storagePath() string {
+	storagePath := l.getConfig().GetString(l.getConfigKey("storage_path"))
+	if storagePath == "" {
+		storagePath = filepath.Join(l.getConfig().GetString(l.getConfigKey("run_path")), "logs_to_retry")
+	}
+	return storagePath
+}
+
+func (l *LogsConfigKeys) storageMaxSizeInBytes() int64 {
+	return l.getConfig().GetInt64(l.getConfigKey("storage_max_size_in_bytes"))
+}
+
 func (l *LogsConfigKeys) useV2API() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_v2_api"))
 }
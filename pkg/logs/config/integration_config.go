@@ -7,8 +7,11 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
@@ -23,6 +26,7 @@ const (
 	JournaldType      = "journald"
 	WindowsEventType  = "windows_event"
 	StringChannelType = "string_channel"
+	SyslogType        = "syslog"
 
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
@@ -30,6 +34,13 @@ const (
 	UTF16LE string = "utf-16-le"
 	// SHIFTJIS for Shift JIS (Japanese) encoding
 	SHIFTJIS string = "shift-jis"
+
+	// NoCompression disables the detection of compressed files
+	NoCompression string = "none"
+	// GzipCompression for gzip compressed files
+	GzipCompression string = "gzip"
+	// ZstdCompression for zstd compressed files
+	ZstdCompression string = "zstd"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -41,9 +52,17 @@ type LogsConfig struct {
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
 	Path        string // File, Journald
 
+	Protocol    string `mapstructure:"protocol" json:"protocol"`           // Syslog
+	TLSCertFile string `mapstructure:"tls_cert_file" json:"tls_cert_file"` // Syslog
+	TLSKeyFile  string `mapstructure:"tls_key_file" json:"tls_key_file"`   // Syslog
+	TLSCAFile   string `mapstructure:"tls_ca_file" json:"tls_ca_file"`     // Syslog
+
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
 	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	// Compression is detected from the file extension when empty.
+	Compression      string `mapstructure:"compression" json:"compression"`             // File
+	BackfillArchives bool   `mapstructure:"backfill_archives" json:"backfill_archives"` // File
 
 	ConfigId           string   `mapstructure:"config_id" json:"config_id"`                   // Journald
 	IncludeSystemUnits []string `mapstructure:"include_units" json:"include_units"`           // Journald
@@ -53,6 +72,14 @@ type LogsConfig struct {
 	IncludeMatches     []string `mapstructure:"include_matches" json:"include_matches"`       // Journald
 	ExcludeMatches     []string `mapstructure:"exclude_matches" json:"exclude_matches"`       // Journald
 	ContainerMode      bool     `mapstructure:"container_mode" json:"container_mode"`         // Journald
+	// IncludeFields and ExcludeFields map journal fields to a pattern their value must, or must not, match.
+	IncludeFields map[string]string `mapstructure:"include_fields" json:"include_fields"` // Journald
+	ExcludeFields map[string]string `mapstructure:"exclude_fields" json:"exclude_fields"` // Journald
+	// FieldsAsTags and FieldsAsAttributes map journal fields to the tag, or attribute, holding their value.
+	FieldsAsTags       map[string]string `mapstructure:"fields_as_tags" json:"fields_as_tags"`             // Journald
+	FieldsAsAttributes map[string]string `mapstructure:"fields_as_attributes" json:"fields_as_attributes"` // Journald
+	// Since is the duration, or the RFC 3339 date, from which the journal is tailed when no cursor is stored.
+	Since string `mapstructure:"since" json:"since"` // Journald
 
 	Image string // Docker
 	Label string // Docker
@@ -85,6 +112,100 @@ type LogsConfig struct {
 	AutoMultiLine               *bool   `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
 	AutoMultiLineSampleSize     int     `mapstructure:"auto_multi_line_sample_size" json:"auto_multi_line_sample_size"`
 	AutoMultiLineMatchThreshold float64 `mapstructure:"auto_multi_line_match_threshold" json:"auto_multi_line_match_threshold"`
+
+	// Deduplication collapses the identical consecutive messages of the source, disabled when nil.
+	Deduplication *DeduplicationConfig `mapstructure:"deduplication" json:"deduplication"`
+	// Sampling keeps only a ratio of the messages of the source, disabled when nil.
+	Sampling *SamplingConfig `mapstructure:"sampling" json:"sampling"`
+	// RateLimit caps the number of messages per second of the source, disabled when nil.
+	RateLimit *RateLimitConfig `mapstructure:"rate_limit" json:"rate_limit"`
+}
+
+// SamplingConfig configures the ratio of messages kept for a source.
+type SamplingConfig struct {
+	// Rate is the ratio of messages kept, between 0 and 1.
+	Rate float64 `mapstructure:"rate" json:"rate"`
+	// Pattern restricts the sampling to the messages matching it, all messages are sampled when empty.
+	Pattern string `mapstructure:"pattern" json:"pattern"`
+	// TODO: should be moved out
+	Regex *regexp.Regexp
+}
+
+// validate checks the sampling parameters and compiles the pattern.
+func (c *SamplingConfig) validate() error {
+	if c.Rate < 0 || c.Rate > 1 {
+		return fmt.Errorf("sampling rate must be between 0 and 1")
+	}
+	if c.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid sampling pattern %s: %v", c.Pattern, err)
+	}
+	c.Regex = re
+	return nil
+}
+
+// RateLimitConfig configures the token bucket capping the number of messages of a source.
+type RateLimitConfig struct {
+	// LogsPerSecond is the rate at which the bucket is refilled.
+	LogsPerSecond float64 `mapstructure:"logs_per_second" json:"logs_per_second"`
+	// Burst is the capacity of the bucket, it defaults to LogsPerSecond.
+	Burst int `mapstructure:"burst" json:"burst"`
+}
+
+// BurstSize returns the capacity of the token bucket.
+func (c *RateLimitConfig) BurstSize() int {
+	if c.Burst > 0 {
+		return c.Burst
+	}
+	if burst := int(math.Ceil(c.LogsPerSecond)); burst > 0 {
+		return burst
+	}
+	return 1
+}
+
+// validate checks the rate limit parameters.
+func (c *RateLimitConfig) validate() error {
+	if c.LogsPerSecond <= 0 {
+		return fmt.Errorf("rate limit logs_per_second must be greater than 0")
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("rate limit burst must not be negative")
+	}
+	return nil
+}
+
+// DeduplicationConfig configures the suppression of bursts of identical consecutive messages.
+type DeduplicationConfig struct {
+	// Window is the maximum duration in seconds during which identical messages are collapsed.
+	Window int `mapstructure:"window" json:"window"`
+	// MaskPattern matches the parts of the messages, timestamps for instance, ignored when comparing them.
+	MaskPattern string `mapstructure:"mask_pattern" json:"mask_pattern"`
+	// TODO: should be moved out
+	MaskRegex *regexp.Regexp
+}
+
+// WindowDuration returns the deduplication window.
+func (c *DeduplicationConfig) WindowDuration() time.Duration {
+	return time.Duration(c.Window) * time.Second
+}
+
+// validate checks the deduplication parameters and compiles the mask pattern.
+func (c *DeduplicationConfig) validate() error {
+	if c.Window <= 0 {
+		return fmt.Errorf("deduplication window must be greater than 0")
+	}
+	if c.MaskPattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(c.MaskPattern)
+	if err != nil {
+		return fmt.Errorf("invalid deduplication mask pattern %s: %v", c.MaskPattern, err)
+	}
+	c.MaskRegex = re
+	return nil
 }
 
 // Dump dumps the contents of this struct to a string, for debugging purposes.
@@ -110,12 +231,21 @@ func (c *LogsConfig) Dump(multiline bool) string {
 	case UDPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+	case SyslogType:
+		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
+		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Protocol: %#v,"), c.Protocol)
+		fmt.Fprintf(&b, ws("TLSCertFile: %#v,"), c.TLSCertFile)
+		fmt.Fprintf(&b, ws("TLSKeyFile: %#v,"), c.TLSKeyFile)
+		fmt.Fprintf(&b, ws("TLSCAFile: %#v,"), c.TLSCAFile)
 	case FileType:
 		fmt.Fprintf(&b, ws("Path: %#v,"), c.Path)
 		fmt.Fprintf(&b, ws("Encoding: %#v,"), c.Encoding)
 		fmt.Fprintf(&b, ws("Identifier: %#v,"), c.Identifier)
 		fmt.Fprintf(&b, ws("ExcludePaths: %#v,"), c.ExcludePaths)
 		fmt.Fprintf(&b, ws("TailingMode: %#v,"), c.TailingMode)
+		fmt.Fprintf(&b, ws("Compression: %#v,"), c.Compression)
+		fmt.Fprintf(&b, ws("BackfillArchives: %#v,"), c.BackfillArchives)
 	case DockerType, ContainerdType:
 		fmt.Fprintf(&b, ws("Image: %#v,"), c.Image)
 		fmt.Fprintf(&b, ws("Label: %#v,"), c.Label)
@@ -128,6 +258,11 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprintf(&b, ws("IncludeUserUnits: %#v,"), c.IncludeUserUnits)
 		fmt.Fprintf(&b, ws("ExcludeUserUnits: %#v,"), c.ExcludeUserUnits)
 		fmt.Fprintf(&b, ws("ContainerMode: %t,"), c.ContainerMode)
+		fmt.Fprintf(&b, ws("IncludeFields: %#v,"), c.IncludeFields)
+		fmt.Fprintf(&b, ws("ExcludeFields: %#v,"), c.ExcludeFields)
+		fmt.Fprintf(&b, ws("FieldsAsTags: %#v,"), c.FieldsAsTags)
+		fmt.Fprintf(&b, ws("FieldsAsAttributes: %#v,"), c.FieldsAsAttributes)
+		fmt.Fprintf(&b, ws("Since: %#v,"), c.Since)
 	case WindowsEventType:
 		fmt.Fprintf(&b, ws("ChannelPath: %#v,"), c.ChannelPath)
 		fmt.Fprintf(&b, ws("Query: %#v,"), c.Query)
@@ -148,7 +283,22 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprint(&b, ws("AutoMultiLine: nil,"))
 	}
 	fmt.Fprintf(&b, ws("AutoMultiLineSampleSize: %d,"), c.AutoMultiLineSampleSize)
-	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f}"), c.AutoMultiLineMatchThreshold)
+	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f,"), c.AutoMultiLineMatchThreshold)
+	if c.Deduplication != nil {
+		fmt.Fprintf(&b, ws("Deduplication: {Window: %d, MaskPattern: %#v},"), c.Deduplication.Window, c.Deduplication.MaskPattern)
+	} else {
+		fmt.Fprint(&b, ws("Deduplication: nil,"))
+	}
+	if c.Sampling != nil {
+		fmt.Fprintf(&b, ws("Sampling: {Rate: %f, Pattern: %#v},"), c.Sampling.Rate, c.Sampling.Pattern)
+	} else {
+		fmt.Fprint(&b, ws("Sampling: nil,"))
+	}
+	if c.RateLimit != nil {
+		fmt.Fprintf(&b, ws("RateLimit: {LogsPerSecond: %f, Burst: %d}}"), c.RateLimit.LogsPerSecond, c.RateLimit.Burst)
+	} else {
+		fmt.Fprint(&b, ws("RateLimit: nil}"))
+	}
 	return b.String()
 }
 
@@ -209,10 +359,40 @@ func (c *LogsConfig) Validate() error {
 		if err != nil {
 			return err
 		}
+		switch c.Compression {
+		case "", NoCompression, GzipCompression, ZstdCompression:
+		default:
+			return fmt.Errorf("invalid compression '%v' for %v", c.Compression, c.Path)
+		}
 	case c.Type == TCPType && c.Port == 0:
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case c.Type == SyslogType:
+		err := c.validateSyslog()
+		if err != nil {
+			return err
+		}
+	case c.Type == JournaldType:
+		err := c.validateJournald()
+		if err != nil {
+			return err
+		}
+	}
+	if c.Deduplication != nil {
+		if err := c.Deduplication.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Sampling != nil {
+		if err := c.Sampling.validate(); err != nil {
+			return err
+		}
+	}
+	if c.RateLimit != nil {
+		if err := c.RateLimit.validate(); err != nil {
+			return err
+		}
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
@@ -221,6 +401,66 @@ func (c *LogsConfig) Validate() error {
 	return CompileProcessingRules(c.ProcessingRules)
 }
 
+func (c *LogsConfig) validateSyslog() error {
+	if c.Port == 0 {
+		return fmt.Errorf("syslog source must have a port")
+	}
+	switch c.Protocol {
+	case "", TCPType:
+		if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+			return fmt.Errorf("syslog source must have both a tls_cert_file and a tls_key_file to use TLS")
+		}
+		if c.TLSCAFile != "" && c.TLSCertFile == "" {
+			return fmt.Errorf("syslog source must have a tls_cert_file and a tls_key_file to verify the clients with tls_ca_file")
+		}
+	case UDPType:
+		if c.TLSCertFile != "" || c.TLSKeyFile != "" || c.TLSCAFile != "" {
+			return fmt.Errorf("TLS is not supported for udp syslog sources")
+		}
+	default:
+		return fmt.Errorf("invalid syslog protocol '%v', must be %v or %v", c.Protocol, TCPType, UDPType)
+	}
+	return nil
+}
+
+func (c *LogsConfig) validateJournald() error {
+	for _, fields := range []map[string]string{c.IncludeFields, c.ExcludeFields} {
+		for field, pattern := range fields {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("invalid pattern %s for the journal field %s: %v", pattern, field, err)
+			}
+		}
+	}
+	if _, err := c.JournaldSince(time.Now()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// JournaldSince returns the date from which the journal is tailed when no cursor is stored,
+// a zero time is returned when Since is not set.
+func (c *LogsConfig) JournaldSince(now time.Time) (time.Time, error) {
+	if c.Since == "" {
+		return time.Time{}, nil
+	}
+	if duration, err := time.ParseDuration(c.Since); err == nil {
+		if duration < 0 {
+			return time.Time{}, fmt.Errorf("since must be a positive duration: %s", c.Since)
+		}
+		return now.Add(-duration), nil
+	}
+	since, err := time.Parse(time.RFC3339, c.Since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("since must be a duration or a RFC 3339 date: %s", c.Since)
+	}
+	return since, nil
+}
+
+// TLSEnabled returns true if the source must accept TLS connections.
+func (c *LogsConfig) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
 func (c *LogsConfig) validateTailingMode() error {
 	mode, found := TailingModeFromString(c.TailingMode)
 	if !found && c.TailingMode != "" {
@@ -11,6 +11,7 @@ package config
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -20,10 +21,18 @@ import (
 func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 	validConfigs := []*LogsConfig{
 		{Type: FileType, Path: "/var/log/foo.log"},
+		{Type: FileType, Path: "/var/log/foo.log", BackfillArchives: true},
+		{Type: FileType, Path: "/var/log/foo.log.*", Compression: GzipCompression},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: SyslogType, Port: 514},
+		{Type: SyslogType, Port: 514, Protocol: UDPType},
+		{Type: SyslogType, Port: 6514, TLSCertFile: "/etc/cert.pem", TLSKeyFile: "/etc/key.pem", TLSCAFile: "/etc/ca.pem"},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
+		{Type: JournaldType, IncludeFields: map[string]string{"PRIORITY": "^[0-3]$"}, ExcludeFields: map[string]string{"_COMM": "cron"}},
+		{Type: JournaldType, Since: "1h"},
+		{Type: JournaldType, Since: "2023-03-01T10:00:00Z"},
 	}
 
 	for _, config := range validConfigs {
@@ -36,8 +45,18 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 	invalidConfigs := []*LogsConfig{
 		{},
 		{Type: FileType},
+		{Type: FileType, Path: "/var/log/foo.log.bz2", Compression: "bzip2"},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: SyslogType},
+		{Type: SyslogType, Port: 514, Protocol: "http"},
+		{Type: SyslogType, Port: 6514, TLSCertFile: "/etc/cert.pem"},
+		{Type: SyslogType, Port: 6514, TLSCAFile: "/etc/ca.pem"},
+		{Type: SyslogType, Port: 6514, Protocol: UDPType, TLSCertFile: "/etc/cert.pem", TLSKeyFile: "/etc/key.pem"},
+		{Type: JournaldType, IncludeFields: map[string]string{"PRIORITY": "("}},
+		{Type: JournaldType, ExcludeFields: map[string]string{"_COMM": "["}},
+		{Type: JournaldType, Since: "-1h"},
+		{Type: JournaldType, Since: "yesterday"},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -86,3 +105,52 @@ func TestConfigDump(t *testing.T) {
 	dump := config.Dump(true)
 	assert.Contains(t, dump, `Path: "/var/log/foo.log",`)
 }
+
+func TestValidateDeduplication(t *testing.T) {
+	config := LogsConfig{Type: TCPType, Port: 1234, Deduplication: &DeduplicationConfig{Window: 5, MaskPattern: "^[0-9:]+"}}
+	assert.Nil(t, config.Validate())
+	assert.NotNil(t, config.Deduplication.MaskRegex)
+	assert.Equal(t, 5*time.Second, config.Deduplication.WindowDuration())
+
+	config = LogsConfig{Type: TCPType, Port: 1234, Deduplication: &DeduplicationConfig{}}
+	assert.NotNil(t, config.Validate())
+
+	config = LogsConfig{Type: TCPType, Port: 1234, Deduplication: &DeduplicationConfig{Window: 5, MaskPattern: "(?=abf)"}}
+	assert.NotNil(t, config.Validate())
+}
+
+func TestValidateSamplingAndRateLimit(t *testing.T) {
+	config := LogsConfig{Type: TCPType, Port: 1234, Sampling: &SamplingConfig{Rate: 0.1, Pattern: "DEBUG"}, RateLimit: &RateLimitConfig{LogsPerSecond: 200}}
+	assert.Nil(t, config.Validate())
+	assert.True(t, config.Sampling.Regex.MatchString("DEBUG hello"))
+	assert.Equal(t, 200, config.RateLimit.BurstSize())
+
+	config = LogsConfig{Type: TCPType, Port: 1234, Sampling: &SamplingConfig{Rate: 1.5}}
+	assert.NotNil(t, config.Validate())
+
+	config = LogsConfig{Type: TCPType, Port: 1234, RateLimit: &RateLimitConfig{}}
+	assert.NotNil(t, config.Validate())
+
+	config = LogsConfig{Type: TCPType, Port: 1234, RateLimit: &RateLimitConfig{LogsPerSecond: 0.5}}
+	assert.Nil(t, config.Validate())
+	assert.Equal(t, 1, config.RateLimit.BurstSize())
+}
+
+func TestJournaldSince(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	since, err := (&LogsConfig{}).JournaldSince(now)
+	assert.Nil(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = (&LogsConfig{Since: "2h"}).JournaldSince(now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), since)
+
+	since, err = (&LogsConfig{Since: "2023-02-28T08:30:00Z"}).JournaldSince(now)
+	assert.Nil(t, err)
+	assert.True(t, since.Equal(time.Date(2023, 2, 28, 8, 30, 0, 0, time.UTC)))
+
+	_, err = (&LogsConfig{Since: "last week"}).JournaldSince(now)
+	assert.NotNil(t, err)
+}
@@ -16,6 +16,22 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+	LogToMetric    = "log_to_metric"
+)
+
+// Metric types generated by log_to_metric rules
+const (
+	MetricTypeCount        = "count"
+	MetricTypeDistribution = "distribution"
+)
+
+// Structured processing rule types, applied to a single key of a JSON log line
+const (
+	JSONExcludeAtMatch = "json_exclude_at_match"
+	JSONDropKey        = "json_drop_key"
+	JSONRenameKey      = "json_rename_key"
+	JSONHashKey        = "json_hash_key"
+	JSONMaskValue      = "json_mask_value"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,16 +41,38 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Key is the dot-separated path of the JSON field targeted by a structured rule.
+	Key string
+	// RenameTo is the dot-separated path a json_rename_key rule moves the field to.
+	RenameTo string `mapstructure:"rename_to" json:"rename_to"`
+	// MetricName is the name of the metric generated by a log_to_metric rule.
+	MetricName string `mapstructure:"metric_name" json:"metric_name"`
+	// MetricType is the type of the metric generated by a log_to_metric rule, count by default.
+	MetricType string `mapstructure:"metric_type" json:"metric_type"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
 }
 
+// IsStructured returns true if the rule applies to a key of a JSON log line
+// rather than to the raw content.
+func (r *ProcessingRule) IsStructured() bool {
+	switch r.Type {
+	case JSONExcludeAtMatch, JSONDropKey, JSONRenameKey, JSONHashKey, JSONMaskValue:
+		return true
+	}
+	return false
+}
+
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
 // - a valid type
 // - a valid pattern that compiles
+// Structured rules must also have a key, a json_rename_key rule must have a rename_to,
+// and the pattern is optional for every structured rule but json_exclude_at_match.
+// A log_to_metric rule must have a metric name, a supported metric type and,
+// for distributions, a pattern with a capture group for the value.
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -44,6 +82,17 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
 			break
+		case LogToMetric:
+			if err := validateLogToMetricRule(rule); err != nil {
+				return err
+			}
+		case JSONExcludeAtMatch, JSONDropKey, JSONRenameKey, JSONHashKey, JSONMaskValue:
+			if rule.Key == "" {
+				return fmt.Errorf("no key provided for processing rule: %s", rule.Name)
+			}
+			if rule.Type == JSONRenameKey && rule.RenameTo == "" {
+				return fmt.Errorf("no rename_to provided for processing rule: %s", rule.Name)
+			}
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -51,6 +100,9 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		if rule.Pattern == "" {
+			if rule.IsStructured() && rule.Type != JSONExcludeAtMatch {
+				continue
+			}
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
 		_, err := regexp.Compile(rule.Pattern)
@@ -61,17 +113,40 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 	return nil
 }
 
+// validateLogToMetricRule checks the metric parameters of a log_to_metric rule.
+func validateLogToMetricRule(rule *ProcessingRule) error {
+	if rule.MetricName == "" {
+		return fmt.Errorf("no metric_name provided for processing rule: %s", rule.Name)
+	}
+	switch rule.MetricType {
+	case "", MetricTypeCount:
+		return nil
+	case MetricTypeDistribution:
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil || re.NumSubexp() == 0 {
+			return fmt.Errorf("pattern %s of processing rule %s must capture the distribution value", rule.Pattern, rule.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("metric_type %s is not supported for processing rule: %s", rule.MetricType, rule.Name)
+	}
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		if rule.IsStructured() && rule.Pattern == "" {
+			rule.Placeholder = []byte(rule.ReplacePlaceholder)
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, LogToMetric:
 			rule.Regex = re
-		case MaskSequences:
+		case MaskSequences, JSONExcludeAtMatch, JSONDropKey, JSONRenameKey, JSONHashKey, JSONMaskValue:
 			rule.Regex = re
 			rule.Placeholder = []byte(rule.ReplacePlaceholder)
 		case MultiLine:
@@ -34,3 +34,47 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateStructuredRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "drop", Type: JSONDropKey, Key: "user.email"},
+		{Name: "rename", Type: JSONRenameKey, Key: "lvl", RenameTo: "level"},
+		{Name: "hash", Type: JSONHashKey, Key: "user.id", Pattern: "^[0-9]+$"},
+		{Name: "mask", Type: JSONMaskValue, Key: "token", ReplacePlaceholder: "[masked]"},
+		{Name: "exclude", Type: JSONExcludeAtMatch, Key: "level", Pattern: "debug"},
+	}
+	assert.Nil(t, ValidateProcessingRules(validRules))
+	assert.Nil(t, CompileProcessingRules(validRules))
+	assert.Nil(t, validRules[0].Regex)
+	assert.NotNil(t, validRules[2].Regex)
+	assert.Equal(t, []byte("[masked]"), validRules[3].Placeholder)
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no_key", Type: JSONDropKey},
+		{Name: "no_rename_to", Type: JSONRenameKey, Key: "lvl"},
+		{Name: "no_pattern", Type: JSONExcludeAtMatch, Key: "level"},
+		{Name: "invalid_pattern", Type: JSONMaskValue, Key: "token", Pattern: "(?=abf)"},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestValidateLogToMetricRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "count", Type: LogToMetric, MetricName: "app.errors", Pattern: "level=error"},
+		{Name: "distribution", Type: LogToMetric, MetricName: "app.duration", MetricType: MetricTypeDistribution, Pattern: "duration=([0-9]+)"},
+	}
+	assert.Nil(t, ValidateProcessingRules(validRules))
+	assert.Nil(t, CompileProcessingRules(validRules))
+	assert.NotNil(t, validRules[0].Regex)
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no_metric_name", Type: LogToMetric, Pattern: "level=error"},
+		{Name: "no_capture_group", Type: LogToMetric, MetricName: "app.duration", MetricType: MetricTypeDistribution, Pattern: "duration=[0-9]+"},
+		{Name: "unknown_metric_type", Type: LogToMetric, MetricName: "app.errors", MetricType: "gauge", Pattern: "level=error"},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
@@ -146,7 +146,11 @@ func NewDecoderWithFraming(source *sources.ReplaceableSource, parser parsers.Par
 	// construct the lineParser, wrapping the parser
 	var lineParser LineParser
 	if parser.SupportsPartialLine() {
-		lineParser = NewMultiLineParser(lineHandler.process, config.AggregationTimeout(), parser, lineLimit)
+		partialLineLimit := config.MaxPartialLineSize()
+		if partialLineLimit <= 0 {
+			partialLineLimit = lineLimit
+		}
+		lineParser = NewMultiLineParser(lineHandler.process, config.AggregationTimeout(), parser, partialLineLimit)
 	} else {
 		lineParser = NewSingleLineParser(lineHandler.process, parser)
 	}
@@ -6,9 +6,13 @@
 package decoder
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
 
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
@@ -21,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func InitializeDecoderForTest(source *sources.LogSource, parser parsers.Parser) *Decoder {
@@ -338,3 +343,81 @@ func TestDecoderWithMultilineKubernetes(t *testing.T) {
 	assert.Equal(t, message.StatusError, output.Status)
 	assert.Equal(t, "2019-06-06T16:35:55.930852913Z", output.Timestamp)
 }
+
+// TestDecoderPartialLinesCorpus decodes log files written by container runtimes with lines
+// split into partial chunks, and checks the reassembled lines against the expected ones.
+func TestDecoderPartialLineLimit(t *testing.T) {
+	partialLineLimit := func() int {
+		d := InitializeDecoderForTest(sources.NewLogSource("", &config.LogsConfig{}), dockerfile.New())
+		return d.lineParser.(*MultiLineParser).lineLimit
+	}
+
+	// the maximum size of a message applies by default
+	assert.Equal(t, defaultContentLenLimit, partialLineLimit())
+
+	coreConfig.Datadog.Set("logs_config.max_partial_line_size", 512000)
+	defer coreConfig.Datadog.Set("logs_config.max_partial_line_size", 0)
+	assert.Equal(t, 512000, partialLineLimit())
+}
+
+func TestDecoderPartialLinesCorpus(t *testing.T) {
+	corpus := []struct {
+		name   string
+		parser parsers.Parser
+	}{
+		// containerd splits lines at 16KiB
+		{"containerd", kubernetes.New()},
+		// CRI-O splits lines at 8KiB, stdout and stderr chunks are interleaved
+		{"crio", kubernetes.New()},
+		// docker json-file splits lines at 16KiB, partial chunks have no trailing newline
+		{"docker", dockerfile.New()},
+	}
+
+	for _, c := range corpus {
+		input, err := os.ReadFile(filepath.Join("testdata", "partial_lines", c.name+".log"))
+		require.NoError(t, err)
+		rawExpected, err := os.ReadFile(filepath.Join("testdata", "partial_lines", c.name+".expected.json"))
+		require.NoError(t, err)
+		var expected []struct {
+			Content string `json:"content"`
+			Status  string `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(rawExpected, &expected))
+
+		// the content is read in chunks of various sizes, as tailers do
+		for _, readSize := range []int{7, 4096, len(input)} {
+			t.Run(c.name, func(t *testing.T) {
+				d := InitializeDecoderForTest(sources.NewLogSource("", &config.LogsConfig{}), c.parser)
+				d.Start()
+				go func() {
+					for i := 0; i < len(input); i += readSize {
+						d.InputChan <- NewInput(input[i:min(i+readSize, len(input))])
+					}
+					d.Stop()
+				}()
+
+				var rawDataLen int
+				var outputs []*Message
+				for output := range d.OutputChan {
+					rawDataLen += output.RawDataLen
+					outputs = append(outputs, output)
+				}
+
+				require.Len(t, outputs, len(expected))
+				for i, output := range outputs {
+					assert.Equal(t, expected[i].Content, string(output.Content), "line %d", i)
+					assert.Equal(t, expected[i].Status, output.Status, "line %d", i)
+				}
+				// the offsets tracked by the tailers must match the size of the file
+				assert.Equal(t, len(input), rawDataLen)
+			})
+		}
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -10,9 +10,13 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+var tlmPartialLinesTruncated = telemetry.NewCounter("logs_decoder", "partial_lines_truncated",
+	nil, "Number of lines reassembled from partial chunks sent before being complete because they reached the size limit")
+
 // LineParser handles decoded lines, parsing them into decoder.Message's using
 // an embedded parsers.Parser.
 type LineParser interface {
@@ -62,16 +66,49 @@ func (p *SingleLineParser) process(content []byte, rawDataLen int) {
 }
 
 // MultiLineParser makes sure that chunked lines are properly put together.
+//
+// Container runtimes split long lines into partial chunks, the chunks are reassembled
+// here, before the line handler, so that multiline and auto multiline detection work on
+// whole lines:
+//   - chunks are reassembled by stream, identified by the status of the parsed chunks,
+//     so that interleaved stdout and stderr chunks are not mixed together.
+//   - raw lines longer than the framer content limit are broken into several frames
+//     by the framer, only the first frame holds the runtime header so the following
+//     frames are appended as is to the line of the first frame.
+//   - a reassembled line is sent as soon as it reaches lineLimit, even if it is not
+//     complete, and the truncation is counted.
 type MultiLineParser struct {
 	outputFn     func(*Message)
-	buffer       *bytes.Buffer
 	flushTimeout time.Duration
 	flushTimer   *time.Timer
 	parser       parsers.Parser
-	rawDataLen   int
 	lineLimit    int
-	status       string
-	timestamp    string
+
+	// lines are the lines being reassembled, by stream, in the order of their first chunk.
+	lines []*partialLine
+
+	// continued is the line continued by the next frame when the framer broke a
+	// raw line, and continuedIsPartial is the partial flag of that raw line.
+	continued          *partialLine
+	continuedIsPartial bool
+
+	// chunks are the raw chunks which have not been accounted in the raw data length
+	// of a message yet, in input order.
+	chunks []*rawChunk
+}
+
+// partialLine is a line being reassembled.
+type partialLine struct {
+	buffer    *bytes.Buffer
+	chunks    []*rawChunk
+	status    string
+	timestamp string
+}
+
+// rawChunk is a frame received from the framer.
+type rawChunk struct {
+	rawDataLen int
+	sent       bool
 }
 
 // NewMultiLineParser returns a new MultiLineParser.
@@ -83,7 +120,6 @@ func NewMultiLineParser(
 ) *MultiLineParser {
 	return &MultiLineParser{
 		outputFn:     outputFn,
-		buffer:       bytes.NewBuffer(nil),
 		flushTimeout: flushTimeout,
 		flushTimer:   nil,
 		lineLimit:    lineLimit,
@@ -92,40 +128,73 @@ func NewMultiLineParser(
 }
 
 func (p *MultiLineParser) flushChan() <-chan time.Time {
-	if p.flushTimer != nil && p.buffer.Len() > 0 {
+	if p.flushTimer != nil && p.hasBufferedData() {
 		return p.flushTimer.C
 	}
 	return nil
 }
 
 func (p *MultiLineParser) flush() {
-	p.sendLine()
+	for _, line := range p.lines {
+		p.sendLine(line)
+	}
 }
 
 // process buffers and aggregates partial lines
 func (p *MultiLineParser) process(content []byte, rawDataLen int) {
-	if p.flushTimer != nil && p.buffer.Len() > 0 {
+	if p.flushTimer != nil && p.hasBufferedData() {
 		// stop the flush timer, as we now have data
 		if !p.flushTimer.Stop() {
 			<-p.flushTimer.C
 		}
 	}
-	msg, err := p.parser.Parse(content)
-	if err != nil {
-		log.Debug(err)
-	}
-	// track the raw data length and the timestamp so that the agent tails
+
+	// track the raw data length of the chunk so that the agent tails
 	// from the right place at restart
-	p.rawDataLen += rawDataLen
-	p.timestamp = msg.Timestamp
-	p.status = msg.Status
-	p.buffer.Write(msg.Content)
+	chunk := &rawChunk{rawDataLen: rawDataLen}
+	p.chunks = append(p.chunks, chunk)
 
-	if !msg.IsPartial || p.buffer.Len() >= p.lineLimit {
+	// the framer outputs the content without the framing data, the frames with
+	// no framing data are the ones broken because they reached its content limit.
+	broken := rawDataLen > 0 && len(content) == rawDataLen
+
+	var line *partialLine
+	var complete bool
+	if p.continued != nil {
+		// the frame is the remainder of a broken raw line, it has no header
+		line = p.continued
+		p.append(line, chunk, content)
+		if !broken {
+			p.continued = nil
+			complete = !p.continuedIsPartial
+		}
+	} else {
+		msg, err := p.parser.Parse(content)
+		if err != nil {
+			log.Debug(err)
+		}
+		line = p.line(msg.Status)
+		line.status = msg.Status
+		line.timestamp = msg.Timestamp
+		p.append(line, chunk, msg.Content)
+		if broken {
+			p.continued = line
+			p.continuedIsPartial = msg.IsPartial
+		} else {
+			complete = !msg.IsPartial
+		}
+	}
+
+	if complete {
 		// the current chunk marks the end of an aggregated line
-		p.sendLine()
+		p.sendLine(line)
+	} else if line.buffer.Len() >= p.lineLimit {
+		// the line is too long, send what has been reassembled so far
+		tlmPartialLinesTruncated.Inc()
+		p.sendLine(line)
 	}
-	if p.buffer.Len() > 0 {
+
+	if p.hasBufferedData() {
 		// since there's buffered data, start the flush timer to flush it
 		if p.flushTimer == nil {
 			p.flushTimer = time.NewTimer(p.flushTimeout)
@@ -135,16 +204,54 @@ func (p *MultiLineParser) process(content []byte, rawDataLen int) {
 	}
 }
 
-// sendBuffer forwards the content stored in the buffer
-func (p *MultiLineParser) sendLine() {
+// line returns the line being reassembled for the stream with the given status.
+func (p *MultiLineParser) line(status string) *partialLine {
+	for _, line := range p.lines {
+		if line.status == status {
+			return line
+		}
+	}
+	line := &partialLine{buffer: bytes.NewBuffer(nil), status: status}
+	p.lines = append(p.lines, line)
+	return line
+}
+
+// append appends the content of a chunk to a line.
+func (p *MultiLineParser) append(line *partialLine, chunk *rawChunk, content []byte) {
+	line.chunks = append(line.chunks, chunk)
+	line.buffer.Write(content)
+}
+
+// hasBufferedData returns true if some chunks have not been sent yet.
+func (p *MultiLineParser) hasBufferedData() bool {
+	for _, line := range p.lines {
+		if len(line.chunks) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// sendLine forwards the content reassembled for a line, the raw data length of the
+// message only accounts for the chunks preceding the first chunk still buffered.
+func (p *MultiLineParser) sendLine(line *partialLine) {
 	defer func() {
-		p.buffer.Reset()
-		p.rawDataLen = 0
+		line.buffer.Reset()
+		line.chunks = line.chunks[:0]
 	}()
 
-	content := make([]byte, p.buffer.Len())
-	copy(content, p.buffer.Bytes())
-	if len(content) > 0 || p.rawDataLen > 0 {
-		p.outputFn(NewMessage(content, p.status, p.rawDataLen, p.timestamp))
+	for _, chunk := range line.chunks {
+		chunk.sent = true
+	}
+	rawDataLen := 0
+	for len(p.chunks) > 0 && p.chunks[0].sent {
+		rawDataLen += p.chunks[0].rawDataLen
+		p.chunks = p.chunks[1:]
+	}
+
+	content := make([]byte, line.buffer.Len())
+	copy(content, line.buffer.Bytes())
+	if len(content) > 0 || rawDataLen > 0 {
+		p.outputFn(NewMessage(content, line.status, rawDataLen, line.timestamp))
 	}
 }
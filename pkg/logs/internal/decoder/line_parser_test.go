@@ -14,7 +14,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/kubernetes"
 )
 
 const header = "HEADER"
@@ -155,3 +157,83 @@ func TestMultilineParserLimit(t *testing.T) {
 	assert.Equal(t, "aaaa", string(message.Content))
 	assert.Equal(t, message.RawDataLen, 13)
 }
+
+func TestMultilineParserInterleavedStreams(t *testing.T) {
+	p := kubernetes.New()
+	timeout := 1000 * time.Millisecond
+	contentLenLimit := 256 * 100
+
+	outputFn, outputChan := lineParserChans()
+	lineParser := NewMultiLineParser(outputFn, timeout, p, contentLenLimit)
+
+	stdoutPartial := "2023-03-01T10:00:00.000000001Z stdout P one "
+	stderrFull := "2023-03-01T10:00:00.000000002Z stderr F error"
+	stdoutFull := "2023-03-01T10:00:00.000000003Z stdout F line"
+	lineParser.process([]byte(stdoutPartial), len(stdoutPartial)+1)
+	lineParser.process([]byte(stderrFull), len(stderrFull)+1)
+	lineParser.process([]byte(stdoutFull), len(stdoutFull)+1)
+
+	message := <-outputChan
+	assert.Equal(t, "error", string(message.Content))
+	assert.Equal(t, "error", message.Status)
+	// the stdout chunk preceding the stderr line is still buffered
+	assert.Equal(t, 0, message.RawDataLen)
+
+	message = <-outputChan
+	assert.Equal(t, "one line", string(message.Content))
+	assert.Equal(t, "info", message.Status)
+	assert.Equal(t, "2023-03-01T10:00:00.000000003Z", message.Timestamp)
+	assert.Equal(t, len(stdoutPartial)+len(stderrFull)+len(stdoutFull)+3, message.RawDataLen)
+}
+
+func TestMultilineParserBrokenFrames(t *testing.T) {
+	p := kubernetes.New()
+	timeout := 1000 * time.Millisecond
+	contentLenLimit := 256 * 100
+	frameLimit := 40
+
+	outputFn, outputChan := lineParserChans()
+	lineParser := NewMultiLineParser(outputFn, timeout, p, contentLenLimit)
+	fr := framer.NewFramer(lineParser.process, framer.UTF8Newline, frameLimit)
+
+	// the raw lines are longer than the framer limit, only the first frame holds the header
+	long := strings.Repeat("x", 100)
+	input := "2023-03-01T10:00:00.000000001Z stdout P " + long + "\n" +
+		"2023-03-01T10:00:00.000000002Z stdout F end of " + long + "\n" +
+		"2023-03-01T10:00:00.000000003Z stderr F short\n"
+	fr.Process([]byte(input))
+
+	message := <-outputChan
+	assert.Equal(t, long+"end of "+long, string(message.Content))
+	assert.Equal(t, "info", message.Status)
+	assert.Equal(t, strings.Index(input, "\n2023-03-01T10:00:00.000000003Z")+1, message.RawDataLen)
+
+	message = <-outputChan
+	assert.Equal(t, "short", string(message.Content))
+	assert.Equal(t, "error", message.Status)
+}
+
+func TestMultilineParserLimitWithBrokenFrames(t *testing.T) {
+	p := kubernetes.New()
+	timeout := 1000 * time.Millisecond
+	contentLenLimit := 32
+
+	outputFn, outputChan := lineParserChans()
+	lineParser := NewMultiLineParser(outputFn, timeout, p, contentLenLimit)
+	fr := framer.NewFramer(lineParser.process, framer.UTF8Newline, 50)
+
+	// the header is 40 bytes long, the framer outputs frames of 50 bytes until the end of line
+	header := "2023-03-01T10:00:00.000000001Z stdout F "
+	input := header + strings.Repeat("a", 80) + strings.Repeat("b", 20) + "\n"
+	fr.Process([]byte(input))
+
+	// the line is sent once it reaches the limit
+	message := <-outputChan
+	assert.Equal(t, strings.Repeat("a", 60), string(message.Content))
+	assert.Equal(t, 100, message.RawDataLen)
+
+	// and the remainder is sent when the line ends
+	message = <-outputChan
+	assert.Equal(t, strings.Repeat("a", 20)+strings.Repeat("b", 20), string(message.Content))
+	assert.Equal(t, len(input)-100, message.RawDataLen)
+}
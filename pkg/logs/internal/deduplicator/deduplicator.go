@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package deduplicator
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+// ExpirationCheckPeriod is the period at which the pending messages should be
+// checked against the deduplication window of their source.
+const ExpirationCheckPeriod = 200 * time.Millisecond
+
+// originKey identifies a stream of consecutive messages.
+type originKey struct {
+	source     *sources.LogSource
+	identifier string
+}
+
+// pendingMessage is a burst of identical messages, whose first occurrence has
+// already been forwarded.
+type pendingMessage struct {
+	// msg is the last repeat of the first occurrence, nil until it is repeated
+	msg       *message.Message
+	content   []byte
+	repeats   int
+	firstSeen time.Time
+}
+
+// A Deduplicator collapses the identical consecutive messages of the sources
+// having a deduplication config. The first occurrence of a message is forwarded
+// right away, and its repeats within the window are held and forwarded as a
+// single message carrying their number. The messages of the other sources are
+// forwarded as is, so that the deduplication costs nothing when no source is
+// configured with it.
+//
+// A Deduplicator runs in the goroutine of its caller and is not safe for
+// concurrent use.
+type Deduplicator struct {
+	outputFn func(*message.Message)
+	pending  map[originKey]*pendingMessage
+}
+
+// New returns an initialized Deduplicator forwarding the messages to outputFn.
+func New(outputFn func(*message.Message)) *Deduplicator {
+	return &Deduplicator{
+		outputFn: outputFn,
+		pending:  make(map[originKey]*pendingMessage),
+	}
+}
+
+// Process forwards the message, or holds it when it repeats the previous message of its origin.
+func (d *Deduplicator) Process(msg *message.Message, now time.Time) {
+	if msg.Origin == nil || msg.Origin.LogSource == nil || msg.Origin.LogSource.Config.Deduplication == nil {
+		d.outputFn(msg)
+		return
+	}
+	dedupConfig := msg.Origin.LogSource.Config.Deduplication
+	key := originKey{source: msg.Origin.LogSource, identifier: msg.Origin.Identifier}
+	content := msg.Content
+	if dedupConfig.MaskRegex != nil {
+		content = dedupConfig.MaskRegex.ReplaceAll(content, nil)
+	}
+
+	if pending, found := d.pending[key]; found {
+		if bytes.Equal(pending.content, content) && now.Sub(pending.firstSeen) < dedupConfig.WindowDuration() {
+			// the latest message is kept so that its offset is the one registered by the auditor
+			pending.msg = msg
+			pending.repeats++
+			return
+		}
+		d.forward(key, pending)
+	}
+	d.outputFn(msg)
+	d.pending[key] = &pendingMessage{
+		content:   content,
+		firstSeen: now,
+	}
+}
+
+// ForwardExpired forwards the pending messages whose deduplication window has elapsed.
+func (d *Deduplicator) ForwardExpired(now time.Time) {
+	for key, pending := range d.pending {
+		if now.Sub(pending.firstSeen) >= key.source.Config.Deduplication.WindowDuration() {
+			d.forward(key, pending)
+		}
+	}
+}
+
+// ForwardAll forwards all the pending messages.
+func (d *Deduplicator) ForwardAll() {
+	for key, pending := range d.pending {
+		d.forward(key, pending)
+	}
+}
+
+// forward sends the repeats of a pending message to outputFn as a single message
+// carrying their number, if any.
+func (d *Deduplicator) forward(key originKey, pending *pendingMessage) {
+	delete(d.pending, key)
+	if pending.msg == nil {
+		return
+	}
+	if pending.repeats > 1 {
+		pending.msg.RepeatCount = pending.repeats
+		metrics.LogsDeduplicated.Add(int64(pending.repeats - 1))
+		metrics.TlmLogsDeduplicated.Add(float64(pending.repeats - 1))
+	}
+	d.outputFn(pending.msg)
+}
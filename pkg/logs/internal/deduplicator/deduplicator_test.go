@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package deduplicator
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func newDeduplicator() (*Deduplicator, chan *message.Message) {
+	outputChan := make(chan *message.Message, 10)
+	return New(func(msg *message.Message) { outputChan <- msg }), outputChan
+}
+
+func newSource(dedupConfig *config.DeduplicationConfig) *sources.LogSource {
+	return sources.NewLogSource("", &config.LogsConfig{Deduplication: dedupConfig})
+}
+
+func TestForwardWithoutDeduplicationConfig(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(nil)
+
+	now := time.Now()
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now)
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now)
+
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+	assert.Len(t, d.pending, 0)
+}
+
+func TestCollapseIdenticalConsecutiveMessages(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(&config.DeduplicationConfig{Window: 10})
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		msg := message.NewMessageWithSource([]byte("panic: boom"), "", source, 0)
+		msg.Origin.Offset = string(rune('0' + i))
+		d.Process(msg, now)
+	}
+	// the first occurrence is forwarded right away, the repeats are held
+	assert.Len(t, outputChan, 1)
+	msg := <-outputChan
+	assert.Equal(t, []byte("panic: boom"), msg.Content)
+	assert.Equal(t, 0, msg.RepeatCount)
+	assert.Equal(t, "0", msg.Origin.Offset)
+
+	d.Process(message.NewMessageWithSource([]byte("restarting"), "", source, 0), now)
+	assert.Len(t, outputChan, 2)
+	msg = <-outputChan
+	assert.Equal(t, []byte("panic: boom"), msg.Content)
+	assert.Equal(t, 3, msg.RepeatCount)
+	assert.Equal(t, "3", msg.Origin.Offset)
+	msg = <-outputChan
+	assert.Equal(t, []byte("restarting"), msg.Content)
+	assert.Equal(t, 0, msg.RepeatCount)
+
+	// nothing is held for a message which is not repeated
+	d.ForwardAll()
+	assert.Len(t, outputChan, 0)
+}
+
+func TestSingleRepeat(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(&config.DeduplicationConfig{Window: 10})
+
+	now := time.Now()
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now)
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now)
+	d.ForwardAll()
+
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+}
+
+func TestCollapseWithMaskPattern(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(&config.DeduplicationConfig{Window: 10, MaskRegex: regexp.MustCompile(`^\S+ `)})
+
+	now := time.Now()
+	d.Process(message.NewMessageWithSource([]byte("10:00:01 connection refused"), "", source, 0), now)
+	d.Process(message.NewMessageWithSource([]byte("10:00:02 connection refused"), "", source, 0), now)
+	d.Process(message.NewMessageWithSource([]byte("10:00:03 connection refused"), "", source, 0), now)
+	d.ForwardAll()
+
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, []byte("10:00:01 connection refused"), (<-outputChan).Content)
+	msg := <-outputChan
+	assert.Equal(t, []byte("10:00:03 connection refused"), msg.Content)
+	assert.Equal(t, 2, msg.RepeatCount)
+}
+
+func TestWindowExpiration(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(&config.DeduplicationConfig{Window: 1})
+
+	now := time.Now()
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now)
+	assert.Len(t, outputChan, 1)
+	<-outputChan
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now.Add(400*time.Millisecond))
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now.Add(500*time.Millisecond))
+	d.ForwardExpired(now.Add(900 * time.Millisecond))
+	assert.Len(t, outputChan, 0)
+
+	d.ForwardExpired(now.Add(time.Second))
+	assert.Len(t, outputChan, 1)
+	assert.Equal(t, 2, (<-outputChan).RepeatCount)
+	assert.Len(t, d.pending, 0)
+
+	// a message received after the window starts a new burst, forwarded right away
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now.Add(time.Second))
+	d.Process(message.NewMessageWithSource([]byte("hello"), "", source, 0), now.Add(3*time.Second))
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+}
+
+func TestSeparateOrigins(t *testing.T) {
+	d, outputChan := newDeduplicator()
+	source := newSource(&config.DeduplicationConfig{Window: 10})
+
+	now := time.Now()
+	first := message.NewMessageWithSource([]byte("hello"), "", source, 0)
+	first.Origin.Identifier = "container-1"
+	second := message.NewMessageWithSource([]byte("hello"), "", source, 0)
+	second.Origin.Identifier = "container-2"
+	d.Process(first, now)
+	d.Process(second, now)
+
+	assert.Len(t, d.pending, 2)
+	assert.Len(t, outputChan, 2)
+}
@@ -38,6 +38,9 @@ const (
 	// headers are included in the log frame.  The size in those headers is not
 	// consulted.  The result does not include the trailing newlines.
 	DockerStream
+
+	// Syslog stream, RFC 6587.  Frames are either octet-counted or newline-terminated.
+	Syslog
 )
 
 // Framer gets chunks of bytes (via Process(..)) and uses an
@@ -64,6 +67,11 @@ type Framer struct {
 	// Over this size, the framer will break the bytes into individual frames
 	// of this size with no delimiting.
 	contentLenLimit int
+
+	// rawLenLimit is the buffered length over which the framer breaks the bytes
+	// into frames of contentLenLimit bytes when the matcher found no frame.  It
+	// leaves room for the header of the framings whose frames have one.
+	rawLenLimit int
 }
 
 // NewFramer initializes a Framer.
@@ -71,7 +79,8 @@ type Framer struct {
 // The framer will break the input stream into messages using the given framing.
 //
 // Content longer than the given limit will be broken into frames at
-// that length, regardless of framing.
+// that length, regardless of framing.  As such frames hold no framing
+// data, their raw data length is the length of their content.
 //
 // Each frame will be passed to outputFn, including both the content of the frame
 // itself and the number of raw bytes matched to represent that frame.  In general,
@@ -82,6 +91,7 @@ func NewFramer(
 	contentLenLimit int,
 ) *Framer {
 	var matcher FrameMatcher
+	rawLenLimit := contentLenLimit
 	switch framing {
 	case UTF8Newline:
 		matcher = &oneByteNewLineMatcher{contentLenLimit}
@@ -95,6 +105,11 @@ func NewFramer(
 		matcher = &oneByteNewLineMatcher{contentLenLimit}
 	case DockerStream:
 		matcher = &dockerStreamMatcher{contentLenLimit}
+	case Syslog:
+		matcher = &syslogMatcher{newLineMatcher: oneByteNewLineMatcher{contentLenLimit}}
+		// an octet-counted frame is only truncated once its length, the space and
+		// contentLenLimit bytes of its message are available
+		rawLenLimit = contentLenLimit + maxMsgLenDigits + 1
 	default:
 		panic(fmt.Sprintf("unknown framing %d", framing))
 	}
@@ -106,6 +121,7 @@ func NewFramer(
 		buffer:          bytes.Buffer{},
 		bytesFramed:     0,
 		contentLenLimit: contentLenLimit,
+		rawLenLimit:     rawLenLimit,
 	}
 }
 
@@ -133,6 +149,7 @@ func (fr *Framer) Process(inBuf []byte) {
 	fr.buffer.Write(inBuf)
 	end := fr.buffer.Len()
 	contentLenLimit := fr.contentLenLimit
+	rawLenLimit := fr.rawLenLimit
 
 	for {
 		if framed == end {
@@ -141,10 +158,16 @@ func (fr *Framer) Process(inBuf []byte) {
 		buf := fr.buffer.Bytes()[framed:]
 
 		content, rawDataLen := fr.matcher.FindFrame(buf, seen-framed)
+		if content == nil && rawDataLen > 0 {
+			// the matcher discarded these bytes
+			framed += rawDataLen
+			seen = framed
+			continue
+		}
 		if content == nil {
-			// if the matcher was asked to match more than contentLenLimit,
+			// if the matcher was asked to match more than rawLenLimit,
 			// chop off contentLenLimit raw bytes and output them
-			if len(buf) >= contentLenLimit {
+			if len(buf) >= rawLenLimit {
 				content, rawDataLen = buf[:contentLenLimit], contentLenLimit
 			} else {
 				// matcher didn't find a frame, so leave the remainder in
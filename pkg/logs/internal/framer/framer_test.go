@@ -113,6 +113,16 @@ func TestLineBreaking(t *testing.T) {
 		t.Run("one-byte chunks", test(framing, chunk(utf16, 1), lines, lens))
 	})
 
+	t.Run("Syslog", func(t *testing.T) {
+		syslog := []byte("<13>1 - - - - - - first\n17 <13>1 - - - - - -20 <13>1 - - - - - - b\n<13>line\n")
+		lines := []string{"<13>1 - - - - - - first", "<13>1 - - - - - -", "<13>1 - - - - - - b", "<13>line"}
+		lens := []int{24, 20, 23, 9}
+		framing := Syslog
+		t.Run("one chunk", test(framing, chunk(syslog, len(syslog)), lines, lens))
+		t.Run("one-byte chunks", test(framing, chunk(syslog, 1), lines, lens))
+		t.Run("five-byte chunks", test(framing, chunk(syslog, 5), lines, lens))
+	})
+
 	dockerChunk := func(stream byte, data []byte) []byte {
 		header := [8]byte{stream}
 		binary.BigEndian.PutUint32(header[4:8], uint32(len(data)))
@@ -261,6 +271,29 @@ func TestContentLenLimit(t *testing.T) {
 	})
 }
 
+func TestSyslogContentLenLimit(t *testing.T) {
+	// an oversized octet-counted frame, followed by a normal one and a non-transparent one
+	input := []byte("25 abcdefghijklmnopqrstuvwxy5 hello<13>ok\n")
+	lines := []string{"abcdefghij", "hello", "<13>ok"}
+	lens := []int{13, 7, 7}
+	for _, size := range []int{len(input), 1, 2, 5, 12} {
+		t.Run(fmt.Sprintf("%d-byte chunks", size), func(t *testing.T) {
+			gotContent := []string{}
+			gotLens := []int{}
+			outputFn := func(content []byte, rawDataLen int) {
+				gotContent = append(gotContent, string(content))
+				gotLens = append(gotLens, rawDataLen)
+			}
+			fr := NewFramer(outputFn, Syslog, 10)
+			for _, chunk := range chunk(input, size) {
+				fr.Process(chunk)
+			}
+			require.Equal(t, lines, gotContent)
+			require.Equal(t, lens, gotLens)
+		})
+	}
+}
+
 func TestLineBreakIncomingData(t *testing.T) {
 	outputFn, outputChan := framerOutput()
 	framer := NewFramer(outputFn, UTF8Newline, contentLenLimit)
@@ -9,7 +9,8 @@ package framer
 type FrameMatcher interface {
 	// Find a frame in a prefix of buf, and return the slice containing the content
 	// of that frame, together with the total number of bytes in that frame.  Return
-	// `nil, 0` when no complete frame is present in buf.
+	// `nil, 0` when no complete frame is present in buf, or `nil, n` to discard the
+	// first n bytes of buf without producing a frame.
 	//
 	// The `seen` argument is the length of `buf` last time this function was called,
 	// and can be used to avoid repeating work when looking for a frame terminator.
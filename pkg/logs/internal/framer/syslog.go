@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// maxMsgLenDigits is the maximum number of digits of the length of an octet-counted frame.
+const maxMsgLenDigits = 9
+
+// syslogMatcher implements EndLineMatcher for syslog streams as defined in RFC 6587.
+// Frames using the octet-counting method, 'MSG-LEN SP SYSLOG-MSG', are broken using
+// their length, the other frames are expected to be terminated by a newline.
+//
+// Octet-counted frames longer than contentLenLimit are truncated as soon as
+// contentLenLimit bytes of their message are available, and the rest of their
+// message is discarded.
+type syslogMatcher struct {
+	newLineMatcher oneByteNewLineMatcher
+
+	// discard is the number of bytes of a truncated frame left to discard.
+	discard int
+}
+
+// FindFrame implements EndLineMatcher#FindFrame.
+func (s *syslogMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	if s.discard > 0 {
+		n := s.discard
+		if n > len(buf) {
+			n = len(buf)
+		}
+		s.discard -= n
+		return nil, n
+	}
+
+	digits := 0
+	for digits < len(buf) && digits <= maxMsgLenDigits && buf[digits] >= '0' && buf[digits] <= '9' {
+		digits++
+	}
+	switch {
+	case digits == 0 || digits > maxMsgLenDigits:
+		return s.newLineMatcher.FindFrame(buf, seen)
+	case digits == len(buf):
+		// wait for the rest of the length
+		return nil, 0
+	case buf[digits] != ' ' || buf[0] == '0':
+		return s.newLineMatcher.FindFrame(buf, seen)
+	}
+
+	msgLen, err := strconv.Atoi(string(buf[:digits]))
+	if err != nil {
+		return s.newLineMatcher.FindFrame(buf, seen)
+	}
+	if limit := s.newLineMatcher.contentLenLimit; msgLen > limit {
+		frameLen := digits + 1 + limit
+		if len(buf) < frameLen {
+			return nil, 0
+		}
+		s.discard = msgLen - limit
+		return buf[digits+1 : frameLen], frameLen
+	}
+	frameLen := digits + 1 + msgLen
+	if len(buf) < frameLen {
+		return nil, 0
+	}
+	return bytes.TrimSuffix(buf[digits+1:frameLen], []byte{'\n'}), frameLen
+}
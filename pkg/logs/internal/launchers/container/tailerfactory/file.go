@@ -128,6 +128,9 @@ func (tf *factory) makeDockerFileSource(source *sources.LogSource) (*sources.Log
 		Source:          sourceName,
 		Tags:            source.Config.Tags,
 		ProcessingRules: source.Config.ProcessingRules,
+		Deduplication:   source.Config.Deduplication,
+		Sampling:        source.Config.Sampling,
+		RateLimit:       source.Config.RateLimit,
 	})
 
 	// inform the file launcher that it should expect docker-formatted content
@@ -211,6 +214,9 @@ func (tf *factory) makeK8sFileSource(source *sources.LogSource) (*sources.LogSou
 			Source:          sourceName,
 			Tags:            source.Config.Tags,
 			ProcessingRules: source.Config.ProcessingRules,
+			Deduplication:   source.Config.Deduplication,
+			Sampling:        source.Config.Sampling,
+			RateLimit:       source.Config.RateLimit,
 		})
 
 	switch source.Config.Type {
@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/internal/tailers/file"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxArchiveTailers is the maximum number of archives read at the same time,
+	// the other archives are queued.
+	maxArchiveTailers = 4
+
+	// archiveRotationTimeout is how long the launcher looks for the archive of
+	// a rotated file, once its tailer has finished, to read the content the rotated
+	// tailer could not read.
+	archiveRotationTimeout = 5 * time.Minute
+)
+
+// archive is an archive waiting to be read.
+type archive struct {
+	file *tailer.File
+	// offset is the decompressed offset to start reading from, the offset
+	// stored in the registry is used when fromRegistry is true.
+	offset       int64
+	fromRegistry bool
+}
+
+// archiveTailer is a tailer reading an archive.
+type archiveTailer struct {
+	file   *tailer.File
+	tailer *tailer.Tailer
+}
+
+// pendingRotation is a rotated file whose archive has not been found yet.
+type pendingRotation struct {
+	file        *tailer.File
+	tailer      *tailer.Tailer
+	fingerprint []byte
+	// finishedAt is when the rotated tailer was first seen finished
+	finishedAt time.Time
+}
+
+// archivesOf returns the paths of the archives of the file at path, i.e. the compressed
+// files named after it, e.g. "app.log.1.gz" or "app.log-20230301.zst" for "app.log".
+func archivesOf(path string) []string {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		log.Debugf("Could not list the archives of %s: %v", path, err)
+		return nil
+	}
+	var archives []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == base || !strings.HasPrefix(name, base) {
+			continue
+		}
+		if tailer.CompressionFromPath(name) != tailer.NoCompression {
+			archives = append(archives, filepath.Join(dir, name))
+		}
+	}
+	return archives
+}
+
+// backfillArchives queues the archives of the file which have not been read yet.
+func (s *Launcher) backfillArchives(file *tailer.File) {
+	for _, path := range archivesOf(file.Path) {
+		archiveFile := tailer.NewFile(path, file.Source.UnderlyingSource(), file.IsWildcardPath)
+		if s.isArchiveHandled(archiveFile) {
+			continue
+		}
+		log.Infof("Backfilling archive %s of %s", path, file.Path)
+		s.queueArchive(archive{file: archiveFile, fromRegistry: true})
+	}
+}
+
+// trackRotation keeps track of a rotated file to read the end of its content from
+// its archive, in case the file is compressed before the rotated tailer read it all.
+func (s *Launcher) trackRotation(rotated *tailer.Tailer, file *tailer.File) {
+	fingerprint := rotated.Fingerprint()
+	if len(fingerprint) == 0 {
+		return
+	}
+	s.pendingRotations[file.GetScanKey()] = &pendingRotation{
+		file:        file,
+		tailer:      rotated,
+		fingerprint: fingerprint,
+	}
+}
+
+// scanArchives cleans up the finished archive tailers, looks for the archives of the
+// rotated files and starts reading the queued archives.
+func (s *Launcher) scanArchives() {
+	for scanKey, a := range s.archiveTailers {
+		if a.tailer.IsFinished() {
+			s.markArchiveIngested(a.file.Path, scanKey)
+			delete(s.archiveTailers, scanKey)
+		}
+	}
+	for scanKey := range s.ingestedArchives {
+		if _, err := os.Stat(s.ingestedArchives[scanKey].path); err != nil {
+			// the archive has been removed
+			delete(s.ingestedArchives, scanKey)
+		}
+	}
+
+	for scanKey, rotation := range s.pendingRotations {
+		// the rotated tailer keeps reading until close_timeout, its offset is only
+		// final once it has finished
+		if !rotation.tailer.IsFinished() {
+			continue
+		}
+		if rotation.finishedAt.IsZero() {
+			rotation.finishedAt = time.Now()
+		}
+		if time.Since(rotation.finishedAt) > archiveRotationTimeout {
+			delete(s.pendingRotations, scanKey)
+			continue
+		}
+		for _, path := range archivesOf(rotation.file.Path) {
+			archiveFile := tailer.NewFile(path, rotation.file.Source.UnderlyingSource(), rotation.file.IsWildcardPath)
+			if s.isArchiveHandled(archiveFile) || !tailer.ArchiveHasPrefix(path, archiveFile.Compression, rotation.fingerprint) {
+				continue
+			}
+			// the archive content starts with the rotated file content, resume
+			// reading where the rotated tailer stopped
+			offset := rotation.tailer.DecodedOffset()
+			log.Infof("Found archive %s of rotated file %s, reading it from offset %d", path, rotation.file.Path, offset)
+			s.queueArchive(archive{file: archiveFile, offset: offset})
+			delete(s.pendingRotations, scanKey)
+			break
+		}
+	}
+
+	for len(s.archiveQueue) > 0 && len(s.archiveTailers) < maxArchiveTailers {
+		next := s.archiveQueue[0]
+		s.archiveQueue = s.archiveQueue[1:]
+		s.startArchiveTailer(next)
+	}
+}
+
+// queueArchive queues an archive to be read.
+func (s *Launcher) queueArchive(a archive) {
+	for _, queued := range s.archiveQueue {
+		if queued.file.GetScanKey() == a.file.GetScanKey() {
+			return
+		}
+	}
+	s.archiveQueue = append(s.archiveQueue, a)
+}
+
+// startArchiveTailer starts a tailer reading an archive once.
+func (s *Launcher) startArchiveTailer(a archive) {
+	tailer := s.createTailer(a.file, s.pipelineProvider.NextPipelineChan())
+
+	offset, whence := a.offset, io.SeekStart
+	if a.fromRegistry {
+		var err error
+		offset, whence, err = Position(s.registry, tailer.Identifier(), config.Beginning)
+		if err != nil {
+			log.Warnf("Could not recover offset for archive with path %v: %v", a.file.Path, err)
+		}
+	}
+
+	log.Infof("Starting a new tailer for archive: %s (offset: %d, whence: %d)", a.file.Path, offset, whence)
+	if err := tailer.Start(offset, whence); err != nil {
+		log.Warn(err)
+		return
+	}
+	s.archiveTailers[a.file.GetScanKey()] = &archiveTailer{file: a.file, tailer: tailer}
+}
+
+// isArchiveHandled returns true if the file is an archive which is being read,
+// waiting to be read or which has been read entirely.
+func (s *Launcher) isArchiveHandled(file *tailer.File) bool {
+	scanKey := file.GetScanKey()
+	if _, isTailed := s.tailers[scanKey]; isTailed {
+		return true
+	}
+	if _, isTailed := s.archiveTailers[scanKey]; isTailed {
+		return true
+	}
+	for _, queued := range s.archiveQueue {
+		if queued.file.GetScanKey() == scanKey {
+			return true
+		}
+	}
+	ingested, found := s.ingestedArchives[scanKey]
+	if !found {
+		return false
+	}
+	fi, err := os.Stat(file.Path)
+	return err == nil && fi.ModTime().Equal(ingested.modTime)
+}
+
+// markArchiveIngested records that an archive has been read entirely so that
+// it is not read again while it is not modified.
+func (s *Launcher) markArchiveIngested(path string, scanKey string) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	s.ingestedArchives[scanKey] = ingestedArchive{path: path, modTime: fi.ModTime()}
+}
+
+// ingestedArchive is an archive which has been read entirely.
+type ingestedArchive struct {
+	path    string
+	modTime time.Time
+}
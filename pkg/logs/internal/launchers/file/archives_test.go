@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	coreConfig "github.com/DataDog/datadog-agent/pkg/config"
+	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/internal/tailers/file"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func writeGzipArchive(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func receiveContent(t *testing.T, outputChan chan *message.Message) string {
+	select {
+	case msg := <-outputChan:
+		return string(msg.Content)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	return ""
+}
+
+func newArchivesTestLauncher() *Launcher {
+	launcher := NewLauncher(10, 20*time.Millisecond, false, 10*time.Second, "by_name")
+	launcher.pipelineProvider = mock.NewMockProvider()
+	launcher.registry = auditor.NewRegistry()
+	return launcher
+}
+
+// setCloseTimeout shortens the time the rotated tailers keep reading their file.
+func setCloseTimeout(t *testing.T, seconds int) {
+	previous := coreConfig.Datadog.Get("logs_config.close_timeout")
+	coreConfig.Datadog.Set("logs_config.close_timeout", seconds)
+	t.Cleanup(func() { coreConfig.Datadog.Set("logs_config.close_timeout", previous) })
+}
+
+func TestArchivesOf(t *testing.T) {
+	testDir := t.TempDir()
+	for _, name := range []string{"app.log", "app.log.1", "app.log.2.gz", "app.log-20230301.zst", "other.log.1.gz", "app.log.3.gz.tmp"} {
+		require.NoError(t, os.WriteFile(filepath.Join(testDir, name), nil, 0644))
+	}
+
+	archives := archivesOf(filepath.Join(testDir, "app.log"))
+	sort.Strings(archives)
+	assert.Equal(t, []string{filepath.Join(testDir, "app.log-20230301.zst"), filepath.Join(testDir, "app.log.2.gz")}, archives)
+	assert.Empty(t, archivesOf(filepath.Join(testDir, "missing", "app.log")))
+}
+
+func TestLauncherBackfillArchives(t *testing.T) {
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("current\n"), 0644))
+	writeGzipArchive(t, path+".1.gz", "archived\n")
+
+	launcher := newArchivesTestLauncher()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning", BackfillArchives: true})
+	launcher.addSource(source)
+	defer launcher.cleanup()
+
+	assert.Len(t, launcher.tailers, 1)
+	assert.Len(t, launcher.archiveTailers, 1)
+	assert.ElementsMatch(t, []string{"current", "archived"}, []string{receiveContent(t, outputChan), receiveContent(t, outputChan)})
+
+	// the archive is not read again once it has been read entirely
+	archiveTailer := launcher.archiveTailers[path+".1.gz"].tailer
+	assert.Eventually(t, archiveTailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+	launcher.activeSources = append(launcher.activeSources, source)
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+	assert.Contains(t, launcher.ingestedArchives, path+".1.gz")
+
+	launcher.backfillArchives(tailer.NewFile(path, source, false))
+	assert.Len(t, launcher.archiveQueue, 0)
+	assert.Len(t, launcher.archiveTailers, 0)
+}
+
+func TestLauncherReadsConfiguredArchive(t *testing.T) {
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "app.log.gz")
+	writeGzipArchive(t, path, "first\nsecond\n")
+
+	launcher := newArchivesTestLauncher()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	// the default tailing mode is "end", which an archive can not be tailed from
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	launcher.addSource(source)
+	defer launcher.cleanup()
+
+	require.Len(t, launcher.tailers, 1)
+	assert.Equal(t, "first", receiveContent(t, outputChan))
+	assert.Equal(t, "second", receiveContent(t, outputChan))
+}
+
+func TestLauncherReadsArchiveOfRotatedFile(t *testing.T) {
+	setCloseTimeout(t, 1)
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\nsecond\n"), 0644))
+
+	launcher := newArchivesTestLauncher()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning", BackfillArchives: true})
+	launcher.addSource(source)
+	defer launcher.cleanup()
+
+	assert.Equal(t, "first", receiveContent(t, outputChan))
+	assert.Equal(t, "second", receiveContent(t, outputChan))
+	rotated := launcher.tailers[path]
+	assert.Eventually(t, func() bool { return rotated.DecodedOffset() == int64(len("first\nsecond\n")) }, 5*time.Second, 10*time.Millisecond)
+
+	// the file is rotated and compressed before the tailer reads its last line
+	require.NoError(t, os.Rename(path, path+".1"))
+	writeGzipArchive(t, path+".1.gz", "first\nsecond\nthird\n")
+	require.NoError(t, os.Remove(path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("fourth\n"), 0644))
+
+	launcher.activeSources = append(launcher.activeSources, source)
+	launcher.scan()
+	assert.NotSame(t, rotated, launcher.tailers[path])
+	assert.Equal(t, "fourth", receiveContent(t, outputChan))
+
+	// the archive is only read once the rotated tailer has finished
+	assert.Len(t, launcher.pendingRotations, 1)
+	assert.Len(t, launcher.archiveTailers, 0)
+	assert.Eventually(t, rotated.IsFinished, 5*time.Second, 10*time.Millisecond)
+	launcher.scan()
+	assert.Len(t, launcher.pendingRotations, 0)
+	assert.Len(t, launcher.archiveTailers, 1)
+
+	// only the line the rotated tailer did not read is read from the archive
+	assert.Equal(t, "third", receiveContent(t, outputChan))
+}
+
+func TestLauncherReadsArchiveOfRotatedFileAfterRotatedTailer(t *testing.T) {
+	setCloseTimeout(t, 1)
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0644))
+
+	launcher := newArchivesTestLauncher()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning", BackfillArchives: true})
+	launcher.addSource(source)
+	defer launcher.cleanup()
+
+	assert.Equal(t, "first", receiveContent(t, outputChan))
+	rotated := launcher.tailers[path]
+
+	// the file is rotated, the rotated tailer keeps reading it
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("new\n"), 0644))
+	launcher.activeSources = append(launcher.activeSources, source)
+	launcher.scan()
+	assert.Len(t, launcher.pendingRotations, 1)
+	assert.Equal(t, "new", receiveContent(t, outputChan))
+
+	// lines are appended to the rotated file after the rotation is detected,
+	// and it is compressed once the rotated tailer has read them
+	f, err := os.OpenFile(path+".1", os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("second\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "second", receiveContent(t, outputChan))
+	writeGzipArchive(t, path+".1.gz", "first\nsecond\nthird\n")
+	require.NoError(t, os.Remove(path+".1"))
+
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 0)
+	assert.Eventually(t, rotated.IsFinished, 5*time.Second, 10*time.Millisecond)
+	launcher.scan()
+	assert.Len(t, launcher.archiveTailers, 1)
+
+	// the lines read by the rotated tailer are not read again from the archive
+	assert.Equal(t, "third", receiveContent(t, outputChan))
+	select {
+	case msg := <-outputChan:
+		t.Fatalf("unexpected message %q", msg.Content)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestLauncherSkipsArchiveOfAnotherFile(t *testing.T) {
+	testDir := t.TempDir()
+	path := filepath.Join(testDir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0644))
+
+	launcher := newArchivesTestLauncher()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning", BackfillArchives: true})
+	launcher.addSource(source)
+	defer launcher.cleanup()
+	assert.Equal(t, "first", receiveContent(t, outputChan))
+
+	// the archive does not hold the content of the rotated file
+	require.NoError(t, os.Rename(path, path+".1"))
+	writeGzipArchive(t, path+".2.gz", "older\n")
+	require.NoError(t, os.WriteFile(path, []byte("second\n"), 0644))
+
+	launcher.activeSources = append(launcher.activeSources, source)
+	launcher.scan()
+	assert.Len(t, launcher.pendingRotations, 1)
+	assert.Len(t, launcher.archiveTailers, 0)
+	assert.Equal(t, "second", receiveContent(t, outputChan))
+}
@@ -45,6 +45,12 @@ type Launcher struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// archiveTailers are the tailers reading archives outside of the scanned files,
+	// either backfilled or found after a rotation. They stop once the archive is read.
+	archiveTailers   map[string]*archiveTailer
+	archiveQueue     []archive
+	ingestedArchives map[string]ingestedArchive
+	pendingRotations map[string]*pendingRotation
 }
 
 // NewLauncher returns a new launcher.
@@ -69,6 +75,9 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
 		scanPeriod:             scanPeriod,
+		archiveTailers:         make(map[string]*archiveTailer),
+		ingestedArchives:       make(map[string]ingestedArchive),
+		pendingRotations:       make(map[string]*pendingRotation),
 	}
 }
 
@@ -114,6 +123,10 @@ func (s *Launcher) cleanup() {
 		stopper.Add(tailer)
 		delete(s.tailers, scanKey)
 	}
+	for scanKey, a := range s.archiveTailers {
+		stopper.Add(a.tailer)
+		delete(s.archiveTailers, scanKey)
+	}
 	stopper.Stop()
 }
 
@@ -143,10 +156,20 @@ func (s *Launcher) scan() {
 		scanKey := file.GetScanKey()
 		tailer, isTailed := s.tailers[scanKey]
 		if isTailed && tailer.IsFinished() {
+			if tailer.IsArchive() {
+				// the archive has been read entirely, it must not be read again
+				s.markArchiveIngested(file.Path, scanKey)
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
 
+		// Archives are not rotated, they are read once.
+		if isTailed && tailer.IsArchive() {
+			filesTailed[scanKey] = true
+			continue
+		}
+
 		// If the file is currently being tailed, check for rotation and handle it appropriately.
 		if isTailed {
 			didRotate, err := tailer.DidRotate()
@@ -181,10 +204,17 @@ func (s *Launcher) scan() {
 	tailersLen := len(s.tailers)
 	log.Debugf("After stopping tailers, there are %d tailers running.\n", tailersLen)
 
+	// Read the archives before starting new tailers, so that an archive found after a
+	// rotation is not read again from its beginning by a tailer.
+	s.scanArchives()
+
 	for _, file := range files {
 		scanKey := file.GetScanKey()
 		_, isTailed := s.tailers[scanKey]
 		if !isTailed && tailersLen < s.tailingLimit {
+			if file.Compression != tailer.NoCompression && s.isArchiveHandled(file) {
+				continue
+			}
 			// create a new tailer tailing from the beginning of the file if no offset has been recorded
 			succeeded := s.startNewTailer(file, config.Beginning)
 			if !succeeded {
@@ -255,7 +285,12 @@ func (s *Launcher) launchTailers(source *sources.LogSource) {
 		}
 
 		s.startNewTailer(file, mode)
+
+		if source.Config.BackfillArchives && file.Compression == tailer.NoCompression {
+			s.backfillArchives(file)
+		}
 	}
+	s.scanArchives()
 }
 
 // startNewTailer creates a new tailer, making it tail from the last committed offset, the beginning or the end of the file,
@@ -270,7 +305,13 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 
 	var offset int64
 	var whence int
-	mode := s.handleTailingModeChange(tailer.Identifier(), m)
+	var mode config.TailingMode
+	if tailer.IsArchive() {
+		// an archive has no end to tail from, it is read from the beginning or the registered offset
+		mode = config.Beginning
+	} else {
+		mode = s.handleTailingModeChange(tailer.Identifier(), m)
+	}
 
 	offset, whence, err := Position(s.registry, tailer.Identifier(), mode)
 	if err != nil {
@@ -320,6 +361,9 @@ func (s *Launcher) stopTailer(scanKey string, tailer *tailer.Tailer) {
 // returns true if the new tailer is up and running, false if an error occurred
 func (s *Launcher) restartTailerAfterFileRotation(tailer *tailer.Tailer, file *tailer.File) bool {
 	log.Info("Log rotation happened to ", file.Path)
+	if file.Source.Config().BackfillArchives {
+		s.trackRotation(tailer, file)
+	}
 	tailer.StopAfterFileRotation()
 	tailer = s.createRotatedTailer(tailer, file, tailer.GetDetectedPattern())
 	// force reading file from beginning since it has been log-rotated
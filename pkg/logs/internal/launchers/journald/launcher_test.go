@@ -31,6 +31,7 @@ func (m *MockJournal) SeekTail() error                            { return nil }
 func (m *MockJournal) SeekHead() error                            { return nil }
 func (m *MockJournal) Wait(timeout time.Duration) int             { return 0 }
 func (m *MockJournal) SeekCursor(cursor string) error             { return nil }
+func (m *MockJournal) SeekRealtimeUsec(usec uint64) error         { return nil }
 func (m *MockJournal) NextSkip(skip uint64) (uint64, error)       { return 0, nil }
 func (m *MockJournal) Close() error                               { return nil }
 func (m *MockJournal) Next() (uint64, error)                      { return 0, nil }
@@ -6,12 +6,23 @@
 package listener
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -66,3 +77,58 @@ func TestTCPDoesNotTruncateMessagesThatAreBiggerThanTheReadBufferSize(t *testing
 
 	listener.Stop()
 }
+
+func TestTCPShouldReceiveSyslogMessagesOverTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Port: tcpTestPort, TLSCertFile: certFile, TLSKeyFile: keyFile})
+	listener := NewTCPListener(pp, source, 9000)
+	listener.Start()
+
+	// plain TCP connections can not send logs
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()))
+	require.Nil(t, err)
+	fmt.Fprintf(conn, "<13>1 - - - - - - plain\n")
+	conn.Close()
+
+	tlsConn, err := tls.Dial("tcp", fmt.Sprintf("%s", listener.listener.Addr()), &tls.Config{InsecureSkipVerify: true})
+	require.Nil(t, err)
+	fmt.Fprintf(tlsConn, "27 <13>1 - - - - - - encrypted")
+	msg := <-msgChan
+	assert.Equal(t, `{"message":"encrypted","syslog":{"facility":1,"severity":5,"version":1}}`, string(msg.Content))
+	assert.Equal(t, message.StatusNotice, msg.GetStatus())
+
+	tlsConn.Close()
+	listener.Stop()
+}
+
+func TestTCPShouldFailWithInvalidTLSConfig(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.SyslogType, Port: tcpTestPort, TLSCertFile: "/does/not/exist", TLSKeyFile: "/does/not/exist"})
+	listener := NewTCPListener(pp, source, 9000)
+	listener.Start()
+	assert.True(t, source.Status.IsError())
+}
+
+// writeCertificate writes a self-signed certificate and its key in a temporary directory.
+func writeCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey}), 0600))
+	return certFile, keyFile
+}
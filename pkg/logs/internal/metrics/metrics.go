@@ -25,6 +25,18 @@ var (
 	TlmLogsProcessed = telemetry.NewCounter("logs", "processed",
 		nil, "Total number of processed logs")
 
+	// LogsDeduplicated is the total number of logs collapsed by the deduplication stage.
+	LogsDeduplicated = expvar.Int{}
+	// TlmLogsDeduplicated is the total number of logs collapsed by the deduplication stage.
+	TlmLogsDeduplicated = telemetry.NewCounter("logs", "deduplicated",
+		nil, "Total number of logs collapsed by the deduplication stage")
+
+	// LogsMetricsGenerated is the total number of metric samples generated from logs.
+	LogsMetricsGenerated = expvar.Int{}
+	// TlmLogsMetricsGenerated is the total number of metric samples generated from logs.
+	TlmLogsMetricsGenerated = telemetry.NewCounter("logs", "metrics_generated",
+		nil, "Total number of metric samples generated from logs")
+
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
 	// TlmLogsSent is the total number of sent logs.
@@ -67,6 +79,8 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsDeduplicated", &LogsDeduplicated)
+	LogsExpvars.Set("LogsMetricsGenerated", &LogsMetricsGenerated)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
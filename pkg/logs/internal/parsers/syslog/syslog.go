@@ -0,0 +1,350 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a parser for syslog messages following RFC 5424 or RFC 3164.
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const (
+	// nilValue is the value of the RFC 5424 header fields which are not set.
+	nilValue = "-"
+	// maxTagLen is the maximum length of a RFC 3164 tag.
+	maxTagLen = 48
+)
+
+var (
+	errMissingPRI = errors.New("cannot parse the syslog message: missing PRI")
+	errInvalidSD  = errors.New("cannot parse the syslog message: invalid structured data")
+
+	// utf8BOM may prefix the MSG part of RFC 5424 messages.
+	utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+	// severityStatuses maps the syslog severities to a logs status.
+	severityStatuses = [...]string{
+		message.StatusEmergency,
+		message.StatusAlert,
+		message.StatusCritical,
+		message.StatusError,
+		message.StatusWarning,
+		message.StatusNotice,
+		message.StatusInfo,
+		message.StatusDebug,
+	}
+
+	// timeNow is used to guess the year of RFC 3164 timestamps, it is replaced in tests.
+	timeNow = time.Now
+)
+
+// New creates a new parser that parses syslog messages.
+//
+// RFC 5424 messages follow the pattern
+// '<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG',
+// for example: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+//
+// RFC 3164 messages follow the pattern '<PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG',
+// for example: `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`
+//
+// The header fields are sent as attributes of the message under the `syslog` key
+// and the severity of the message is used as its status.
+func New() parsers.Parser {
+	return &syslogFormat{}
+}
+
+type syslogFormat struct{}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(msg []byte) (parsers.Message, error) {
+	msg = bytes.TrimSuffix(msg, []byte{'\r'})
+	if len(msg) == 0 {
+		return parsers.Message{Content: msg}, nil
+	}
+	entry, err := parse(msg)
+	if err != nil {
+		return parsers.Message{Content: msg, Status: message.StatusInfo}, err
+	}
+	content, err := entry.encode()
+	if err != nil {
+		return parsers.Message{Content: msg, Status: message.StatusInfo}, err
+	}
+	var timestamp string
+	if !entry.timestamp.IsZero() {
+		timestamp = entry.timestamp.UTC().Format(config.DateFormat)
+	}
+	return parsers.Message{
+		Content:   content,
+		Status:    severityStatuses[entry.severity],
+		Timestamp: timestamp,
+	}, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// syslogMessage holds the fields of a syslog message.
+type syslogMessage struct {
+	facility       int
+	severity       int
+	version        int
+	timestamp      time.Time
+	hostname       string
+	appname        string
+	procid         string
+	msgid          string
+	structuredData map[string]map[string]string
+	message        []byte
+}
+
+// parse parses a RFC 5424 message, or a RFC 3164 message if no version follows the PRI.
+func parse(msg []byte) (*syslogMessage, error) {
+	pri, rest, err := parsePRI(msg)
+	if err != nil {
+		return nil, err
+	}
+	entry := &syslogMessage{
+		facility: pri / 8,
+		severity: pri % 8,
+	}
+	if version, rest, ok := parseVersion(rest); ok {
+		entry.version = version
+		return entry, entry.parseRFC5424(rest)
+	}
+	entry.parseRFC3164(rest)
+	return entry, nil
+}
+
+// parsePRI parses the '<PRI>' prefix of the message.
+func parsePRI(msg []byte) (int, []byte, error) {
+	if len(msg) < 3 || msg[0] != '<' {
+		return 0, nil, errMissingPRI
+	}
+	end := bytes.IndexByte(msg[:min(len(msg), 5)], '>')
+	if end < 2 {
+		return 0, nil, errMissingPRI
+	}
+	pri, err := strconv.Atoi(string(msg[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, errMissingPRI
+	}
+	return pri, msg[end+1:], nil
+}
+
+// parseVersion parses the version following the PRI of RFC 5424 messages.
+func parseVersion(msg []byte) (int, []byte, bool) {
+	sp := bytes.IndexByte(msg[:min(len(msg), 4)], ' ')
+	if sp < 1 || msg[0] == '0' {
+		return 0, nil, false
+	}
+	version, err := strconv.Atoi(string(msg[:sp]))
+	if err != nil {
+		return 0, nil, false
+	}
+	return version, msg[sp+1:], true
+}
+
+// parseRFC5424 parses the header, structured data and content of a RFC 5424 message.
+func (m *syslogMessage) parseRFC5424(msg []byte) error {
+	var timestamp string
+	for _, field := range []*string{&timestamp, &m.hostname, &m.appname, &m.procid, &m.msgid} {
+		*field, msg = nextField(msg)
+		if *field == nilValue {
+			*field = ""
+		}
+	}
+	if timestamp != "" {
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			return err
+		}
+		m.timestamp = t
+	}
+
+	switch {
+	case bytes.HasPrefix(msg, []byte(nilValue)):
+		msg = msg[len(nilValue):]
+	case len(msg) > 0 && msg[0] == '[':
+		var err error
+		if m.structuredData, msg, err = parseStructuredData(msg); err != nil {
+			return err
+		}
+	}
+	if len(msg) > 0 && msg[0] == ' ' {
+		msg = msg[1:]
+	}
+	m.message = bytes.TrimPrefix(msg, utf8BOM)
+	return nil
+}
+
+// parseStructuredData parses the structured data elements, '[SD-ID PARAM-NAME="PARAM-VALUE" ...]',
+// and returns the remaining of the message.
+func parseStructuredData(msg []byte) (map[string]map[string]string, []byte, error) {
+	structuredData := make(map[string]map[string]string)
+	for len(msg) > 0 && msg[0] == '[' {
+		end := bytes.IndexAny(msg, " ]")
+		if end < 0 {
+			return nil, nil, errInvalidSD
+		}
+		params := make(map[string]string)
+		structuredData[string(msg[1:end])] = params
+		msg = msg[end:]
+		for {
+			if len(msg) == 0 {
+				return nil, nil, errInvalidSD
+			}
+			if msg[0] == ']' {
+				msg = msg[1:]
+				break
+			}
+			msg = bytes.TrimLeft(msg, " ")
+			eq := bytes.Index(msg, []byte(`="`))
+			if eq < 1 {
+				return nil, nil, errInvalidSD
+			}
+			name := string(msg[:eq])
+			value, rest, ok := parseParamValue(msg[eq+2:])
+			if !ok {
+				return nil, nil, errInvalidSD
+			}
+			params[name] = value
+			msg = rest
+		}
+	}
+	return structuredData, msg, nil
+}
+
+// parseParamValue parses a quoted parameter value in which '"', '\' and ']' are escaped,
+// the opening quote has already been consumed.
+func parseParamValue(msg []byte) (string, []byte, bool) {
+	value := make([]byte, 0, len(msg))
+	for i := 0; i < len(msg); i++ {
+		switch c := msg[i]; {
+		case c == '"':
+			return string(value), msg[i+1:], true
+		case c == '\\' && i+1 < len(msg) && (msg[i+1] == '"' || msg[i+1] == '\\' || msg[i+1] == ']'):
+			value = append(value, msg[i+1])
+			i++
+		default:
+			value = append(value, c)
+		}
+	}
+	return "", nil, false
+}
+
+// parseRFC3164 parses the header and content of a RFC 3164 message,
+// the header fields which can not be parsed are considered to be part of the content.
+func (m *syslogMessage) parseRFC3164(msg []byte) {
+	if len(msg) > len(time.Stamp) && msg[len(time.Stamp)] == ' ' {
+		if t, err := time.ParseInLocation(time.Stamp, string(msg[:len(time.Stamp)]), time.Local); err == nil {
+			// the year is not part of the timestamp, the current year is used unless
+			// the message would then come from the future, around the new year.
+			now := timeNow()
+			t = t.AddDate(now.Year(), 0, 0)
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m.timestamp = t
+			msg = msg[len(time.Stamp)+1:]
+		}
+	} else if field, rest := nextField(msg); len(field) > 0 {
+		// some senders use RFC 3339 timestamps
+		if t, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			m.timestamp = t
+			msg = rest
+		}
+	}
+
+	// the hostname is not always sent, a field ending with ':' or containing '[' is the tag.
+	if field, rest := nextField(msg); len(field) > 0 && bytes.IndexAny([]byte(field), ":[") < 0 && len(rest) > 0 {
+		m.hostname = field
+		msg = rest
+	}
+
+	m.message = msg
+	end := bytes.IndexAny(msg[:min(len(msg), maxTagLen+1)], ":[ ")
+	if end < 1 || msg[end] == ' ' {
+		return
+	}
+	appname, rest := string(msg[:end]), msg[end:]
+	var procid string
+	if rest[0] == '[' {
+		closing := bytes.IndexByte(rest, ']')
+		if closing < 0 {
+			return
+		}
+		procid, rest = string(rest[1:closing]), rest[closing+1:]
+	}
+	if len(rest) == 0 || rest[0] != ':' {
+		return
+	}
+	m.appname, m.procid = appname, procid
+	m.message = bytes.TrimPrefix(rest[1:], []byte{' '})
+}
+
+// nextField returns the field at the beginning of msg and the remaining of the message.
+func nextField(msg []byte) (string, []byte) {
+	sp := bytes.IndexByte(msg, ' ')
+	if sp < 0 {
+		return string(msg), nil
+	}
+	return string(msg[:sp]), msg[sp+1:]
+}
+
+// encode returns the JSON content of the message, the header fields are sent under the `syslog` key.
+func (m *syslogMessage) encode() ([]byte, error) {
+	attributes := map[string]interface{}{
+		"facility": m.facility,
+		"severity": m.severity,
+	}
+	if m.version > 0 {
+		attributes["version"] = m.version
+	}
+	if !m.timestamp.IsZero() {
+		attributes["timestamp"] = m.timestamp.Format(time.RFC3339Nano)
+	}
+	for key, value := range map[string]string{
+		"hostname": m.hostname,
+		"appname":  m.appname,
+		"procid":   m.procid,
+		"msgid":    m.msgid,
+	} {
+		if value != "" {
+			attributes[key] = value
+		}
+	}
+	if len(m.structuredData) > 0 {
+		attributes["structured_data"] = m.structuredData
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(map[string]interface{}{
+		"message": string(m.message),
+		"syslog":  attributes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestSyslogParserRFC5424(t *testing.T) {
+	msg, err := New().Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication\]"][meta seq="1"] An application event`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "2003-10-11T22:14:15.003000000Z", msg.Timestamp)
+	assert.JSONEq(t, `{
+		"message": "An application event",
+		"syslog": {
+			"facility": 20,
+			"severity": 5,
+			"version": 1,
+			"timestamp": "2003-10-11T22:14:15.003Z",
+			"hostname": "mymachine.example.com",
+			"appname": "evntslog",
+			"procid": "1234",
+			"msgid": "ID47",
+			"structured_data": {
+				"exampleSDID@32473": {"iut": "3", "eventSource": "App\"lication]"},
+				"meta": {"seq": "1"}
+			}
+		}
+	}`, string(msg.Content))
+}
+
+func TestSyslogParserRFC5424NilValues(t *testing.T) {
+	msg, err := New().Parse([]byte("<11>1 - - - - - - \xef\xbb\xbfdisk <full>\r"))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusError, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Equal(t, `{"message":"disk <full>","syslog":{"facility":1,"severity":3,"version":1}}`, string(msg.Content))
+
+	msg, err = New().Parse([]byte("<14>1 - host app - - -"))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"message":"","syslog":{"facility":1,"severity":6,"version":1,"hostname":"host","appname":"app"}}`, string(msg.Content))
+}
+
+func TestSyslogParserRFC3164(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	timeNow = func() time.Time { return time.Date(2023, 3, 1, 0, 0, 0, 0, time.Local) }
+
+	msg, err := New().Parse([]byte(`<34>Feb  5 22:14:15 mymachine su[123]: 'su root' failed for lonvick`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	timestamp := time.Date(2023, 2, 5, 22, 14, 15, 0, time.Local)
+	assert.Equal(t, timestamp.UTC().Format("2006-01-02T15:04:05.000000000Z"), msg.Timestamp)
+	assert.JSONEq(t, `{
+		"message": "'su root' failed for lonvick",
+		"syslog": {
+			"facility": 4,
+			"severity": 2,
+			"timestamp": "`+timestamp.Format(time.RFC3339Nano)+`",
+			"hostname": "mymachine",
+			"appname": "su",
+			"procid": "123"
+		}
+	}`, string(msg.Content))
+
+	// messages from the end of the previous year
+	msg, err = New().Parse([]byte(`<13>Dec 31 23:59:59 sshd: session closed`))
+	assert.Nil(t, err)
+	timestamp = time.Date(2022, 12, 31, 23, 59, 59, 0, time.Local)
+	assert.JSONEq(t, `{
+		"message": "session closed",
+		"syslog": {
+			"facility": 1,
+			"severity": 5,
+			"timestamp": "`+timestamp.Format(time.RFC3339Nano)+`",
+			"appname": "sshd"
+		}
+	}`, string(msg.Content))
+}
+
+func TestSyslogParserRFC3164WithoutHeader(t *testing.T) {
+	msg, err := New().Parse([]byte(`<190>2023-03-01T10:00:00Z fw01 connection accepted`))
+	assert.Nil(t, err)
+	assert.Equal(t, message.StatusInfo, msg.Status)
+	assert.Equal(t, "2023-03-01T10:00:00.000000000Z", msg.Timestamp)
+	assert.JSONEq(t, `{
+		"message": "connection accepted",
+		"syslog": {"facility": 23, "severity": 6, "timestamp": "2023-03-01T10:00:00Z", "hostname": "fw01"}
+	}`, string(msg.Content))
+}
+
+func TestSyslogParserShouldFailWithInvalidInput(t *testing.T) {
+	for _, log := range []string{
+		"no priority",
+		"<>1 - - - - - -",
+		"<192>1 - - - - - -",
+		"<13>1 not-a-timestamp - - - - -",
+		`<13>1 - - - - - [id key="value] msg`,
+	} {
+		msg, err := New().Parse([]byte(log))
+		assert.NotNil(t, err, log)
+		assert.Equal(t, []byte(log), msg.Content)
+		assert.Equal(t, message.StatusInfo, msg.Status)
+	}
+}
+
+func TestSyslogParserShouldHandleEmptyMessage(t *testing.T) {
+	msg, err := New().Parse([]byte{})
+	assert.Nil(t, err)
+	assert.Len(t, msg.Content, 0)
+}
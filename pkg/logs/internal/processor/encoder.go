@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"strconv"
 	"unicode"
 	"unicode/utf8"
 
@@ -17,6 +18,14 @@ type Encoder interface {
 	Encode(msg *message.Message, redactedMsg []byte) ([]byte, error)
 }
 
+// repeatCountKey is the attribute holding the number of repeats a deduplicated message stands for.
+const repeatCountKey = "repeat_count"
+
+// repeatCountTag returns the tag holding the number of repeats of the message.
+func repeatCountTag(msg *message.Message) string {
+	return repeatCountKey + ":" + strconv.Itoa(msg.RepeatCount)
+}
+
 // toValidUtf8 ensures all characters are UTF-8.
 func toValidUtf8(msg []byte) string {
 	if utf8.Valid(msg) {
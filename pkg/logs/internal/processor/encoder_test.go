@@ -223,6 +223,46 @@ func TestJsonEncoder(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 }
 
+func TestEncodersRepeatCount(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{Source: "Source", Tags: []string{"foo:bar"}})
+	msg := newMessage([]byte("message"), source, message.StatusError)
+	msg.RepeatCount = 3
+
+	jsonMessage, err := JSONEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	jsonLog := &jsonPayload{}
+	assert.Nil(t, json.Unmarshal(jsonMessage, jsonLog))
+	assert.Equal(t, 3, jsonLog.RepeatCount)
+
+	serverlessMessage, err := JSONServerlessEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	serverlessLog := &jsonServerlessPayload{}
+	assert.Nil(t, json.Unmarshal(serverlessMessage, serverlessLog))
+	assert.Equal(t, 3, serverlessLog.RepeatCount)
+
+	proto, err := ProtoEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	protoLog := &pb.Log{}
+	assert.Nil(t, protoLog.Unmarshal(proto))
+	assert.Equal(t, []string{"foo:bar", "repeat_count:3"}, protoLog.Tags)
+	assert.Equal(t, []string{"foo:bar"}, source.Config.Tags)
+
+	raw, err := RawEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), `[dd ddsource="Source"][dd ddtags="foo:bar"][dd repeat_count="3"] message`)
+
+	// the messages which are not repeated have no count
+	msg.RepeatCount = 0
+	proto, err = ProtoEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	protoLog = &pb.Log{}
+	assert.Nil(t, protoLog.Unmarshal(proto))
+	assert.Equal(t, []string{"foo:bar"}, protoLog.Tags)
+	raw, err = RawEncoder.Encode(msg, msg.Content)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(raw), "repeat_count")
+}
+
 func TestEncoderToValidUTF8(t *testing.T) {
 	assert.Equal(t, "a�z", toValidUtf8([]byte("a\xfez")))
 	assert.Equal(t, "a��z", toValidUtf8([]byte("a\xc0\xafz")))
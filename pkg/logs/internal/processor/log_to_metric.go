@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	aggmetrics "github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// distributionValueGroup is the name of the capture group holding the value of a distribution,
+// the first capture group is used when the pattern does not define it.
+const distributionValueGroup = "value"
+
+// metricSampleSink receives the metric samples generated from the log lines.
+type metricSampleSink interface {
+	AggregateSample(sample aggmetrics.MetricSample)
+}
+
+// getSampleSink returns the sink of the processor, or the global demultiplexer if none was set.
+func (p *Processor) getSampleSink() metricSampleSink {
+	if p.sampleSink != nil {
+		return p.sampleSink
+	}
+	if demux := aggregator.GetDemultiplexer(); demux != nil {
+		return demux
+	}
+	return nil
+}
+
+// applyLogToMetricRule generates a metric sample if the content matches the rule.
+// The named capture groups of the pattern, except the value of a distribution, are added as tags.
+func (p *Processor) applyLogToMetricRule(rule *config.ProcessingRule, msg *message.Message, content []byte) {
+	matches := rule.Regex.FindSubmatch(content)
+	if matches == nil {
+		return
+	}
+	sink := p.getSampleSink()
+	if sink == nil {
+		return
+	}
+
+	sample := aggmetrics.MetricSample{
+		Name:       rule.MetricName,
+		Value:      1,
+		Mtype:      aggmetrics.CounterType,
+		Tags:       logToMetricTags(msg),
+		Host:       msg.GetHostname(),
+		SampleRate: 1,
+		Timestamp:  float64(time.Now().UnixNano()) / float64(time.Second),
+	}
+
+	valueIndex := 0
+	if rule.MetricType == config.MetricTypeDistribution {
+		valueIndex = rule.Regex.SubexpIndex(distributionValueGroup)
+		if valueIndex < 0 {
+			valueIndex = 1
+		}
+		value, err := strconv.ParseFloat(string(matches[valueIndex]), 64)
+		if err != nil {
+			log.Debugf("Unable to parse the value of metric %s from processing rule %s: %v", rule.MetricName, rule.Name, err)
+			return
+		}
+		sample.Value = value
+		sample.Mtype = aggmetrics.DistributionType
+	}
+
+	for i, name := range rule.Regex.SubexpNames() {
+		if name == "" || i == valueIndex || matches[i] == nil {
+			continue
+		}
+		sample.Tags = append(sample.Tags, name+":"+string(matches[i]))
+	}
+
+	metrics.LogsMetricsGenerated.Add(1)
+	metrics.TlmLogsMetricsGenerated.Inc()
+	sink.AggregateSample(sample)
+}
+
+// logToMetricTags returns the tags of the metrics generated from a message.
+func logToMetricTags(msg *message.Message) []string {
+	tags := append([]string{}, msg.Origin.Tags()...)
+	if service := msg.Origin.Service(); service != "" {
+		tags = append(tags, "service:"+service)
+	}
+	if source := msg.Origin.Source(); source != "" {
+		tags = append(tags, "source:"+source)
+	}
+	return tags
+}
@@ -8,11 +8,13 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/deduplicator"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
@@ -26,12 +28,14 @@ type Processor struct {
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	sampleSink                metricSampleSink
+	deduplicator              *deduplicator.Deduplicator
 	mu                        sync.Mutex
 }
 
 // New returns an initialized Processor.
 func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
-	return &Processor{
+	p := &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
 		processingRules:           processingRules,
@@ -39,6 +43,10 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
 	}
+	// the messages of the sources with a deduplication config are collapsed
+	// before being processed
+	p.deduplicator = deduplicator.New(p.processMessage)
+	return p
 }
 
 // Start starts the Processor.
@@ -53,7 +61,8 @@ func (p *Processor) Stop() {
 	<-p.done
 }
 
-// Flush processes synchronously the messages that this processor has to process.
+// Flush processes synchronously the messages that this processor has to process,
+// including the ones held by the deduplication.
 func (p *Processor) Flush(ctx context.Context) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -61,33 +70,49 @@ func (p *Processor) Flush(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			if len(p.inputChan) == 0 {
+		case msg, isOpen := <-p.inputChan:
+			if !isOpen {
+				p.deduplicator.ForwardAll()
 				return
 			}
-			msg := <-p.inputChan
-			p.processMessage(msg)
+			p.deduplicator.Process(msg, time.Now())
+		default:
+			p.deduplicator.ForwardAll()
+			return
 		}
 	}
 }
 
 // run starts the processing of the inputChan
 func (p *Processor) run() {
+	ticker := time.NewTicker(deduplicator.ExpirationCheckPeriod)
 	defer func() {
+		ticker.Stop()
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		p.processMessage(msg)
-		p.mu.Lock() // block here if we're trying to flush synchronously
-		//nolint:staticcheck
-		p.mu.Unlock()
+	for {
+		select {
+		case msg, isOpen := <-p.inputChan:
+			p.mu.Lock() // block here if we're trying to flush synchronously
+			if !isOpen {
+				p.deduplicator.ForwardAll()
+				p.mu.Unlock()
+				return
+			}
+			p.deduplicator.Process(msg, time.Now())
+			p.mu.Unlock()
+		case now := <-ticker.C:
+			p.mu.Lock()
+			p.deduplicator.ForwardExpired(now)
+			p.mu.Unlock()
+		}
 	}
 }
 
 func (p *Processor) processMessage(msg *message.Message) {
 	metrics.LogsDecoded.Add(1)
 	metrics.TlmLogsDecoded.Inc()
-	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess {
+	if shouldProcess, redactedMsg := p.applyRedactingRules(msg); shouldProcess && p.applySamplingRules(msg, redactedMsg) {
 		metrics.LogsProcessed.Add(1)
 		metrics.TlmLogsProcessed.Inc()
 
@@ -109,7 +134,25 @@ func (p *Processor) processMessage(msg *message.Message) {
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 	content := msg.Content
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
+	// structured is only decoded when a structured rule is met, and encoded
+	// back before a raw rule or at the end if it has been modified.
+	var structured *structuredContent
 	for _, rule := range rules {
+		if rule.IsStructured() {
+			if structured == nil {
+				structured = newStructuredContent(content)
+			}
+			if !structured.apply(rule) {
+				return false, nil
+			}
+			continue
+		}
+		if structured != nil {
+			if structured.modified {
+				content = structured.encode(content)
+			}
+			structured = nil
+		}
 		switch rule.Type {
 		case config.ExcludeAtMatch:
 			if rule.Regex.Match(content) {
@@ -121,7 +164,12 @@ func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+		case config.LogToMetric:
+			p.applyLogToMetricRule(rule, msg, content)
 		}
 	}
+	if structured != nil && structured.modified {
+		content = structured.encode(content)
+	}
 	return true, content
 }
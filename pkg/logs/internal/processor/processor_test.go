@@ -6,14 +6,18 @@
 package processor
 
 import (
+	"context"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
 func TestExclusion(t *testing.T) {
@@ -140,6 +144,150 @@ func TestTruncate(t *testing.T) {
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
 
+func TestStructuredRules(t *testing.T) {
+	p := &Processor{}
+
+	var shouldProcess bool
+	var redactedMessage []byte
+
+	source := newStructuredSource(&config.ProcessingRule{Type: config.JSONDropKey, Key: "user.email"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"msg":"hello","user":{"email":"bob@datadoghq.com","id":12}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"msg":"hello","user":{"id":12}}`), redactedMessage)
+
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONRenameKey, Key: "lvl", RenameTo: "log.level"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"lvl":"info"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"log":{"level":"info"}}`), redactedMessage)
+
+	// a rename through a value which is not an object leaves the message unchanged
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"log":"app","lvl":"info"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"log":"app","lvl":"info"}`), redactedMessage)
+
+	// the path is not found through a value which is not an object
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONDropKey, Key: "user.email"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"user":"bob"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"user":"bob"}`), redactedMessage)
+
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONHashKey, Key: "email"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"email":"bob"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"email":"81b637d8fcd2c6da6359e6963113a1170de795e4b725b84d1e0b4cfd9ec58ce9"}`), redactedMessage)
+
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONMaskValue, Key: "token", ReplacePlaceholder: "[masked]"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"token":1234567890123456789}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"token":"[masked]"}`), redactedMessage)
+
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONMaskValue, Key: "card", Pattern: "[0-9]{12}", ReplacePlaceholder: "XXXX"})
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"card":"4323124312341234","amount":12.50}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"amount":12.50,"card":"XXXX1234"}`), redactedMessage)
+
+	source = newStructuredSource(&config.ProcessingRule{Type: config.JSONExcludeAtMatch, Key: "level", Pattern: "^debug$"})
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"level":"debug","msg":"level info"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"level":"info","msg":"level debug"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"level":"info","msg":"level debug"}`), redactedMessage)
+
+	// structured rules do not apply to non-JSON content
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte("level=debug"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("level=debug"), redactedMessage)
+}
+
+func TestStructuredRulesWithRawRules(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{newProcessingRule("mask_sequences", "[ip]", "[0-9]+\\.[0-9]+\\.[0-9]+\\.[0-9]+")}}
+
+	source := newStructuredSource(
+		&config.ProcessingRule{Type: config.JSONDropKey, Key: "password"},
+		newProcessingRule("exclude_at_match", "", "healthcheck"),
+		&config.ProcessingRule{Type: config.JSONRenameKey, Key: "client", RenameTo: "network.client.ip"},
+	)
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"client":"10.0.0.1","password":"hunter2"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"network":{"client":{"ip":"[ip]"}}}`), redactedMessage)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"path":"/healthcheck","password":"hunter2"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+}
+
+func TestLogToMetric(t *testing.T) {
+	sink := &sampleRecorder{}
+	p := &Processor{sampleSink: sink}
+
+	source := newStructuredSource(
+		&config.ProcessingRule{Type: config.LogToMetric, MetricName: "app.errors", Pattern: "level=(?P<level>error|fatal)"},
+		&config.ProcessingRule{Type: config.LogToMetric, MetricName: "app.duration", MetricType: config.MetricTypeDistribution, Pattern: "duration_ms=([0-9.]+)"},
+		newProcessingRule("exclude_at_match", "", "level=error"),
+	)
+	source.Config.Service = "billing"
+	source.Config.Tags = []string{"env:prod"}
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("level=error duration_ms=12.5"), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+	assert.Len(t, sink.samples, 2)
+	assert.Equal(t, "app.errors", sink.samples[0].Name)
+	assert.Equal(t, metrics.CounterType, sink.samples[0].Mtype)
+	assert.Equal(t, 1.0, sink.samples[0].Value)
+	assert.ElementsMatch(t, []string{"env:prod", "service:billing", "level:error"}, sink.samples[0].Tags)
+	assert.Equal(t, "app.duration", sink.samples[1].Name)
+	assert.Equal(t, metrics.DistributionType, sink.samples[1].Mtype)
+	assert.Equal(t, 12.5, sink.samples[1].Value)
+	assert.ElementsMatch(t, []string{"env:prod", "service:billing"}, sink.samples[1].Tags)
+
+	sink.samples = nil
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("level=info"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Len(t, sink.samples, 0)
+}
+
+func TestSampling(t *testing.T) {
+	p := &Processor{}
+
+	source := sources.NewLogSource("", &config.LogsConfig{Sampling: &config.SamplingConfig{Rate: 0, Regex: regexp.MustCompile("DEBUG")}})
+	assert.Equal(t, false, p.applySamplingRules(newMessage([]byte("DEBUG hello"), source, ""), []byte("DEBUG hello")))
+	assert.Equal(t, true, p.applySamplingRules(newMessage([]byte("INFO hello"), source, ""), []byte("INFO hello")))
+	assert.Equal(t, int64(1), source.SampledOut.Get())
+
+	source = sources.NewLogSource("", &config.LogsConfig{Sampling: &config.SamplingConfig{Rate: 1}})
+	assert.Equal(t, true, p.applySamplingRules(newMessage([]byte("DEBUG hello"), source, ""), []byte("DEBUG hello")))
+	assert.Equal(t, int64(0), source.SampledOut.Get())
+}
+
+func TestRateLimit(t *testing.T) {
+	p := &Processor{}
+
+	parent := sources.NewLogSource("", &config.LogsConfig{RateLimit: &config.RateLimitConfig{LogsPerSecond: 0.001, Burst: 2}})
+	source := sources.NewLogSource("", parent.Config)
+	source.ParentSource = parent
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, true, p.applySamplingRules(newMessage([]byte("hello"), source, ""), []byte("hello")))
+	}
+	assert.Equal(t, false, p.applySamplingRules(newMessage([]byte("hello"), source, ""), []byte("hello")))
+	assert.Equal(t, int64(1), source.RateLimited.Get())
+	assert.Equal(t, int64(1), parent.RateLimited.Get())
+	assert.Equal(t, []string{"1"}, parent.GetInfoStatus()["Rate Limited"])
+
+	// sources without sampling nor rate limit keep all messages
+	source = sources.NewLogSource("", &config.LogsConfig{})
+	assert.Nil(t, source.RateLimiter)
+	assert.Equal(t, true, p.applySamplingRules(newMessage([]byte("hello"), source, ""), []byte("hello")))
+}
+
+type sampleRecorder struct {
+	samples []metrics.MetricSample
+}
+
+func (r *sampleRecorder) AggregateSample(sample metrics.MetricSample) {
+	r.samples = append(r.samples, sample)
+}
+
 func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.ProcessingRule {
 	return &config.ProcessingRule{
 		Type:               ruleType,
@@ -155,6 +303,56 @@ func newSource(ruleType, replacePlaceholder, pattern string) sources.LogSource {
 	return sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newProcessingRule(ruleType, replacePlaceholder, pattern)}}}
 }
 
+func newStructuredSource(rules ...*config.ProcessingRule) sources.LogSource {
+	for _, rule := range rules {
+		rule.Name = "test"
+	}
+	if err := config.CompileProcessingRules(rules); err != nil {
+		panic(err)
+	}
+	return sources.LogSource{Config: &config.LogsConfig{ProcessingRules: rules}}
+}
+
 func newMessage(content []byte, source *sources.LogSource, status string) *message.Message {
 	return message.NewMessageWithSource(content, status, source, 0)
 }
+
+func TestDeduplicationFlushAndStop(t *testing.T) {
+	outputChan := make(chan *message.Message, 10)
+	p := New(make(chan *message.Message, 10), outputChan, nil, RawEncoder, diagnostic.NewBufferedMessageReceiver())
+	source := sources.NewLogSource("", &config.LogsConfig{Deduplication: &config.DeduplicationConfig{Window: 60}})
+
+	for i := 0; i < 3; i++ {
+		p.inputChan <- message.NewMessageWithSource([]byte("hello"), "", source, 0)
+	}
+	p.Flush(context.Background())
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+	assert.Equal(t, 2, (<-outputChan).RepeatCount)
+
+	p.Start()
+	p.inputChan <- message.NewMessageWithSource([]byte("world"), "", source, 0)
+	p.inputChan <- message.NewMessageWithSource([]byte("world"), "", source, 0)
+	p.inputChan <- message.NewMessageWithSource([]byte("world"), "", source, 0)
+	p.Stop()
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, 0, (<-outputChan).RepeatCount)
+	assert.Equal(t, 2, (<-outputChan).RepeatCount)
+}
+
+func TestFlushWhileRunning(t *testing.T) {
+	outputChan := make(chan *message.Message, 1000)
+	p := New(make(chan *message.Message, 10), outputChan, nil, RawEncoder, diagnostic.NewBufferedMessageReceiver())
+	source := sources.NewLogSource("", &config.LogsConfig{Deduplication: &config.DeduplicationConfig{Window: 60}})
+	p.Start()
+	defer p.Stop()
+
+	// Flush must not wait for a message already taken by the processing goroutine
+	for i := 0; i < 100; i++ {
+		p.inputChan <- message.NewMessageWithSource([]byte("hello"), "", source, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		p.Flush(ctx)
+		assert.NoError(t, ctx.Err())
+		cancel()
+	}
+}
@@ -20,6 +20,11 @@ type protoEncoder struct{}
 
 // Encode encodes a message into a protobuf byte array.
 func (p *protoEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, error) {
+	tags := msg.Origin.Tags()
+	if msg.RepeatCount > 0 {
+		// the protobuf format has no attribute for it, the tags of the origin must not be modified
+		tags = append(tags[:len(tags):len(tags)], repeatCountTag(msg))
+	}
 	return (&pb.Log{
 		Message:   toValidUtf8(redactedMsg),
 		Status:    msg.GetStatus(),
@@ -27,6 +32,6 @@ func (p *protoEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte,
 		Hostname:  msg.GetHostname(),
 		Service:   msg.Origin.Service(),
 		Source:    msg.Origin.Source(),
-		Tags:      msg.Origin.Tags(),
+		Tags:      tags,
 	}).Marshal()
 }
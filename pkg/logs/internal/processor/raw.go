@@ -7,6 +7,7 @@ package processor
 
 import (
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -54,6 +55,9 @@ func (r *rawEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, e
 
 		// Tags
 		tagsPayload := msg.Origin.TagsPayload()
+		if msg.RepeatCount > 0 {
+			tagsPayload = append(tagsPayload, []byte("[dd "+repeatCountKey+"=\""+strconv.Itoa(msg.RepeatCount)+"\"]")...)
+		}
 		if len(tagsPayload) > 0 {
 			extraContent = append(extraContent, tagsPayload...)
 		} else {
@@ -65,6 +69,7 @@ func (r *rawEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, e
 
 	}
 
+	// the messages already formatted are sent as is, without their repeat count
 	return redactedMsg, nil
 }
 
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"math/rand"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// applySamplingRules returns false if the message is dropped by the sampling
+// or the rate limit of its source, the drops are reported to the source status.
+func (p *Processor) applySamplingRules(msg *message.Message, content []byte) bool {
+	source := msg.Origin.LogSource
+	if sampling := source.Config.Sampling; sampling != nil {
+		if (sampling.Regex == nil || sampling.Regex.Match(content)) && rand.Float64() >= sampling.Rate {
+			source.RecordSampledOut()
+			return false
+		}
+	}
+	if source.RateLimiter != nil && !source.RateLimiter.Allow() {
+		source.RecordRateLimited()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// structuredContent holds a JSON log line decoded once so that consecutive
+// structured processing rules do not have to parse the content again.
+type structuredContent struct {
+	fields map[string]interface{}
+	// valid is false when the content is not a JSON object, structured rules are then ignored.
+	valid bool
+	// modified is true when a rule updated the fields and the content must be encoded again.
+	modified bool
+}
+
+// newStructuredContent decodes the content of a log line.
+func newStructuredContent(content []byte) *structuredContent {
+	s := &structuredContent{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&s.fields); err == nil && s.fields != nil {
+		s.valid = true
+	}
+	return s
+}
+
+// apply applies a structured rule to the fields and returns false
+// if the message must be excluded.
+func (s *structuredContent) apply(rule *config.ProcessingRule) bool {
+	if !s.valid {
+		return true
+	}
+	parent, key, found := s.lookup(rule.Key, false)
+	if !found {
+		return true
+	}
+	value := parent[key]
+	if rule.Type == config.JSONExcludeAtMatch {
+		return !rule.Regex.MatchString(stringValue(value))
+	}
+	if rule.Regex != nil && !rule.Regex.MatchString(stringValue(value)) {
+		return true
+	}
+	switch rule.Type {
+	case config.JSONDropKey:
+		delete(parent, key)
+	case config.JSONRenameKey:
+		newParent, newKey, ok := s.lookup(rule.RenameTo, true)
+		if !ok {
+			// the new path goes through a value which is not an object
+			return true
+		}
+		delete(parent, key)
+		newParent[newKey] = value
+	case config.JSONHashKey:
+		sum := sha256.Sum256([]byte(stringValue(value)))
+		parent[key] = hex.EncodeToString(sum[:])
+	case config.JSONMaskValue:
+		if rule.Regex == nil {
+			parent[key] = rule.ReplacePlaceholder
+		} else {
+			parent[key] = rule.Regex.ReplaceAllString(stringValue(value), rule.ReplacePlaceholder)
+		}
+	}
+	s.modified = true
+	return true
+}
+
+// lookup walks the dot-separated path and returns the object holding the last
+// key of the path. When create is true, missing intermediate objects are created.
+// The path is not found when an intermediate key holds a value which is not an object.
+func (s *structuredContent) lookup(path string, create bool) (map[string]interface{}, string, bool) {
+	keys := strings.Split(path, ".")
+	current := s.fields
+	for _, key := range keys[:len(keys)-1] {
+		value, exists := current[key]
+		if !exists && create {
+			next := make(map[string]interface{})
+			current[key] = next
+			current = next
+			continue
+		}
+		next, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		current = next
+	}
+	last := keys[len(keys)-1]
+	if _, exists := current[last]; !exists && !create {
+		return nil, "", false
+	}
+	return current, last, true
+}
+
+// encode returns the JSON representation of the fields.
+func (s *structuredContent) encode(content []byte) []byte {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s.fields); err != nil {
+		return content
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// stringValue returns the value as a string, the JSON representation is used for non-string values.
+func stringValue(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
@@ -67,7 +67,14 @@ func (t *Tailer) run() {
 	// Loop terminates when the channel is closed.
 	for logline := range t.inputChan {
 		origin := message.NewOrigin(t.source)
-		origin.SetService(getServiceName())
+		if logline.Service != "" {
+			origin.SetService(logline.Service)
+		} else {
+			origin.SetService(getServiceName())
+		}
+		if logline.Source != "" {
+			origin.SetSource(logline.Source)
+		}
 
 		t.source.Config.ChannelTagsMutex.Lock()
 		// while access to this field is controlled by the mutex, the slice it
@@ -77,7 +84,9 @@ func (t *Tailer) run() {
 		t.source.Config.ChannelTagsMutex.Unlock()
 
 		// add additional tags (beyond those from t.source.Config.Tags) to the agent
-		if len(channelTags) > 0 {
+		if len(logline.Tags) > 0 {
+			origin.SetTags(append(append([]string{}, channelTags...), logline.Tags...))
+		} else if len(channelTags) > 0 {
 			origin.SetTags(channelTags)
 		}
 
@@ -87,14 +96,18 @@ func (t *Tailer) run() {
 
 func buildMessage(logline *config.ChannelMessage, origin *message.Origin) *message.Message {
 	status := message.StatusInfo
-	if logline.IsError {
+	if logline.Status != "" {
+		status = logline.Status
+	} else if logline.IsError {
 		status = message.StatusError
 	}
 
 	if logline.Lambda != nil {
 		return message.NewMessageFromLambda(logline.Content, origin, status, logline.Timestamp, logline.Lambda.ARN, logline.Lambda.RequestID, time.Now().UnixNano())
 	}
-	return message.NewMessage(logline.Content, origin, status, time.Now().UnixNano())
+	msg := message.NewMessage(logline.Content, origin, status, time.Now().UnixNano())
+	msg.Timestamp = logline.Timestamp
+	return msg
 }
 
 func getServiceName() string {
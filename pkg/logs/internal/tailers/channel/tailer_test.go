@@ -13,6 +13,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 )
 
 func TestComputeServiceNameOrderOfPrecedent(t *testing.T) {
@@ -67,3 +68,37 @@ func TestBuildErrorMessage(t *testing.T) {
 	assert.Equal(t, "bababang", string(builtMessage.Content))
 	assert.Equal(t, message.StatusError, builtMessage.GetStatus())
 }
+
+func TestBuildMessageWithOverrides(t *testing.T) {
+	logline := &config.ChannelMessage{
+		Content:   []byte("bababang"),
+		Timestamp: time.Date(2010, 01, 01, 01, 01, 01, 00, time.UTC),
+		Status:    message.StatusWarning,
+		IsError:   true,
+	}
+	origin := &message.Origin{}
+	builtMessage := buildMessage(logline, origin)
+	assert.Equal(t, message.StatusWarning, builtMessage.GetStatus())
+	assert.Equal(t, logline.Timestamp, builtMessage.Timestamp)
+}
+
+func TestTailerOverridesOrigin(t *testing.T) {
+	inputChan := make(chan *config.ChannelMessage, 1)
+	outputChan := make(chan *message.Message, 1)
+	source := sources.NewLogSource("", &config.LogsConfig{ChannelTags: []string{"env:prod"}})
+	tailer := NewTailer(source, inputChan, outputChan)
+	tailer.Start()
+
+	inputChan <- &config.ChannelMessage{
+		Content: []byte("bababang"),
+		Service: "billing",
+		Source:  "otlp_log_ingestion",
+		Tags:    []string{"version:1.2"},
+	}
+	msg := <-outputChan
+	tailer.WaitFlush()
+
+	assert.Equal(t, "billing", msg.Origin.Service())
+	assert.Equal(t, "otlp_log_ingestion", msg.Origin.Source())
+	assert.ElementsMatch(t, []string{"env:prod", "version:1.2"}, msg.Origin.Tags())
+}
@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Compression is the compression format of a file.
+type Compression int
+
+const (
+	// NoCompression is used for plain text files, which are tailed.
+	NoCompression Compression = iota
+	// Gzip is used for gzip archives.
+	Gzip
+	// Zstd is used for zstd archives.
+	Zstd
+)
+
+// fingerprintSize is the number of bytes used to match a rotated file with its archive.
+const fingerprintSize = 256
+
+// compressionFromConfig returns the compression of the file at path, the compression
+// is detected from the file extension unless it is set in the configuration.
+func compressionFromConfig(path string, setting string) Compression {
+	switch setting {
+	case config.NoCompression:
+		return NoCompression
+	case config.GzipCompression:
+		return Gzip
+	case config.ZstdCompression:
+		return Zstd
+	}
+	return CompressionFromPath(path)
+}
+
+// CompressionFromPath returns the compression of the file at path from its extension.
+func CompressionFromPath(path string) Compression {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return Gzip
+	case ".zst", ".zstd":
+		return Zstd
+	}
+	return NoCompression
+}
+
+// newDecompressor returns a reader decompressing r.
+func newDecompressor(compression Compression, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Zstd:
+		return zstd.NewReader(r), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %d", compression)
+}
+
+// IsArchive returns true if the tailer reads a compressed file.  Archives are read once,
+// from the decompressed offset to their end, and the tailer then finishes.
+func (t *Tailer) IsArchive() bool {
+	return t.file.Compression != NoCompression
+}
+
+// DecodedOffset returns the offset in the file at which the latest decoded message ends,
+// for archives this is an offset in the decompressed content.
+func (t *Tailer) DecodedOffset() int64 {
+	return t.decodedOffset.Load()
+}
+
+// Fingerprint returns the first bytes of the tailed file, they are used to identify
+// the archive of the file once it has been rotated and compressed.  It returns nil
+// when the file is not held open by the tailer.
+func (t *Tailer) Fingerprint() []byte {
+	if t.osFile == nil || t.IsArchive() {
+		return nil
+	}
+	buf := make([]byte, fingerprintSize)
+	n, err := t.osFile.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		log.Debugf("Could not read the fingerprint of %s: %v", t.file.Path, err)
+		return nil
+	}
+	return buf[:n]
+}
+
+// ArchiveHasPrefix returns true if the decompressed content of the archive at path
+// starts with prefix.
+func ArchiveHasPrefix(path string, compression Compression, prefix []byte) bool {
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	r, err := newDecompressor(compression, f)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	buf := make([]byte, len(prefix))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return false
+	}
+	return bytes.Equal(buf, prefix)
+}
+
+// setupArchive opens the archive and skips its content up to the decompressed offset.
+func (t *Tailer) setupArchive(offset int64, whence int) error {
+	log.Info("Opening archive", t.file.Path, "for tailer key", t.file.GetScanKey())
+	f, err := filesystem.OpenShared(t.fullpath)
+	if err != nil {
+		return err
+	}
+	r, err := newDecompressor(t.file.Compression, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	// archives can not be seeked, the content before the offset is decompressed and discarded
+	var skipped int64
+	if whence == io.SeekEnd {
+		skipped, err = io.Copy(io.Discard, r)
+	} else {
+		skipped, err = io.CopyN(io.Discard, r, offset)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		r.Close()
+		f.Close()
+		return err
+	}
+
+	t.osFile = f
+	t.archive = r
+	t.lastReadOffset.Store(skipped)
+	t.decodedOffset.Store(skipped)
+	return nil
+}
+
+// readArchive reads the next chunk of the decompressed archive, it returns io.EOF
+// once the whole archive has been read.
+func (t *Tailer) readArchive() (int, error) {
+	inBuf := make([]byte, 4096)
+	n, err := t.archive.Read(inBuf)
+	if err != nil && err != io.EOF {
+		t.file.Source.Status().Error(err)
+		return 0, log.Error("Unexpected error occurred while reading archive: ", err)
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+	t.lastReadOffset.Add(int64(n))
+	return n, nil
+}
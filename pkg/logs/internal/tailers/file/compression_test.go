@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DataDog/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func writeArchive(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var w io.WriteCloser
+	switch CompressionFromPath(path) {
+	case Gzip:
+		w = gzip.NewWriter(f)
+	case Zstd:
+		w = zstd.NewWriter(f)
+	default:
+		t.Fatalf("%s is not an archive", path)
+	}
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func newArchiveTailer(path string, outputChan chan *message.Message) *Tailer {
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	file := NewFile(path, source, false)
+	return NewTailer(outputChan, file, 10*time.Millisecond, decoder.NewDecoderFromSource(file.Source))
+}
+
+func TestCompressionFromPath(t *testing.T) {
+	assert.Equal(t, NoCompression, CompressionFromPath("/var/log/app.log"))
+	assert.Equal(t, NoCompression, CompressionFromPath("/var/log/app.log.1"))
+	assert.Equal(t, Gzip, CompressionFromPath("/var/log/app.log.1.gz"))
+	assert.Equal(t, Gzip, CompressionFromPath("/var/log/app.log.GZ"))
+	assert.Equal(t, Zstd, CompressionFromPath("/var/log/app.log-20230301.zst"))
+	assert.Equal(t, Zstd, CompressionFromPath("/var/log/app.log.zstd"))
+
+	assert.Equal(t, Gzip, compressionFromConfig("/var/log/app.archive", config.GzipCompression))
+	assert.Equal(t, NoCompression, compressionFromConfig("/var/log/app.log.gz", config.NoCompression))
+	assert.Equal(t, Zstd, compressionFromConfig("/var/log/app.log.zst", ""))
+}
+
+func TestTailerReadsArchives(t *testing.T) {
+	lines := []string{"first line\n", "second line\n", "third line\n"}
+	content := lines[0] + lines[1] + lines[2]
+
+	for _, name := range []string{"app.log.1.gz", "app.log.1.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeArchive(t, path, content)
+
+			outputChan := make(chan *message.Message, 10)
+			tailer := newArchiveTailer(path, outputChan)
+			assert.True(t, tailer.IsArchive())
+			require.NoError(t, tailer.StartFromBeginning())
+
+			offset := 0
+			for _, line := range lines {
+				msg := <-outputChan
+				offset += len(line)
+				assert.Equal(t, line[:len(line)-1], string(msg.Content))
+				assert.Equal(t, strconv.Itoa(offset), msg.Origin.Offset)
+				assert.Equal(t, "file:"+path, msg.Origin.Identifier)
+			}
+
+			// the tailer finishes once the archive is read
+			assert.Eventually(t, tailer.IsFinished, time.Second, 10*time.Millisecond)
+			assert.Equal(t, int64(len(content)), tailer.DecodedOffset())
+			tailer.Stop()
+		})
+	}
+}
+
+func TestTailerReadsArchiveFromDecompressedOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	writeArchive(t, path, "first line\nsecond line\n")
+
+	outputChan := make(chan *message.Message, 10)
+	tailer := newArchiveTailer(path, outputChan)
+	require.NoError(t, tailer.Start(int64(len("first line\n")), io.SeekStart))
+
+	msg := <-outputChan
+	assert.Equal(t, "second line", string(msg.Content))
+	assert.Equal(t, strconv.Itoa(len("first line\nsecond line\n")), msg.Origin.Offset)
+	assert.Eventually(t, tailer.IsFinished, time.Second, 10*time.Millisecond)
+	tailer.Stop()
+
+	// nothing is read when starting at the end of the archive
+	tailer = newArchiveTailer(path, outputChan)
+	require.NoError(t, tailer.Start(0, io.SeekEnd))
+	assert.Eventually(t, tailer.IsFinished, time.Second, 10*time.Millisecond)
+	tailer.Stop()
+	assert.Len(t, outputChan, 0)
+}
+
+func TestTailerFailsOnInvalidArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	require.NoError(t, os.WriteFile(path, []byte("not compressed\n"), 0644))
+
+	tailer := newArchiveTailer(path, make(chan *message.Message, 10))
+	assert.Error(t, tailer.StartFromBeginning())
+}
+
+func TestArchiveHasPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.zst")
+	writeArchive(t, path, "first line\nsecond line\n")
+
+	assert.True(t, ArchiveHasPrefix(path, Zstd, []byte("first line\n")))
+	assert.False(t, ArchiveHasPrefix(path, Zstd, []byte("second line\n")))
+	assert.False(t, ArchiveHasPrefix(path, Zstd, []byte("first line\nsecond line\nthird line\n")))
+	assert.False(t, ArchiveHasPrefix(path, Gzip, []byte("first line\n")))
+}
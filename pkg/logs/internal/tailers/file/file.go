@@ -22,14 +22,23 @@ type File struct {
 
 	// Source is the ReplaceableSource that led to this File.
 	Source *sources.ReplaceableSource
+
+	// Compression is the compression of the file, compressed files are
+	// read once instead of being tailed.
+	Compression Compression
 }
 
 // NewFile returns a new File
 func NewFile(path string, source *sources.LogSource, isWildcardPath bool) *File {
+	var compression string
+	if source != nil && source.Config != nil {
+		compression = source.Config.Compression
+	}
 	return &File{
 		Path:           path,
 		Source:         sources.NewReplaceableSource(source),
 		IsWildcardPath: isWildcardPath,
+		Compression:    compressionFromConfig(path, compression),
 	}
 }
 
@@ -57,6 +57,9 @@ type Tailer struct {
 	// is platform-specific.
 	osFile *os.File
 
+	// archive is the decompressed content of osFile when the file is an archive.
+	archive io.ReadCloser
+
 	// tags are the tags to be attached to each log message, excluding tags provided
 	// by the tag provider.
 	tags []string
@@ -231,13 +234,23 @@ func (t *Tailer) StopAfterFileRotation() {
 // until it is closed or the tailer is stopped.
 func (t *Tailer) readForever() {
 	defer func() {
+		if t.archive != nil {
+			t.archive.Close()
+		}
 		t.osFile.Close()
 		t.decoder.Stop()
 		log.Info("Closed", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.Source().BytesRead.Get(), "bytes and", t.decoder.GetLineCount(), "lines")
 	}()
 
 	for {
-		n, err := t.read()
+		var n int
+		var err error
+		if t.archive != nil {
+			// archives are read once, the tailer finishes at the end of the archive
+			n, err = t.readArchive()
+		} else {
+			n, err = t.read()
+		}
 		if err != nil {
 			return
 		}
@@ -283,13 +296,16 @@ func (t *Tailer) forwardMessages() {
 		close(t.done)
 	}()
 	for output := range t.decoder.OutputChan {
-		offset := t.decodedOffset.Load() + int64(output.RawDataLen)
+		decodedOffset := t.decodedOffset.Load() + int64(output.RawDataLen)
+		t.decodedOffset.Store(decodedOffset)
+		// the decoded offset keeps being tracked after a rotation so that the
+		// archive of the rotated file can be read from where the tailer stopped
+		offset := decodedOffset
 		identifier := t.Identifier()
 		if t.didFileRotate.Load() {
 			offset = 0
 			identifier = ""
 		}
-		t.decodedOffset.Store(offset)
 		origin := message.NewOrigin(t.file.Source.UnderlyingSource())
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
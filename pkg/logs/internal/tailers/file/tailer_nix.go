@@ -28,6 +28,10 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	// adds metadata to enable users to filter logs by filename
 	t.tags = t.buildTailerTags()
 
+	if t.IsArchive() {
+		return t.setupArchive(offset, whence)
+	}
+
 	log.Info("Opening", t.file.Path, "for tailer key", t.file.GetScanKey())
 	f, err := filesystem.OpenShared(fullpath)
 	if err != nil {
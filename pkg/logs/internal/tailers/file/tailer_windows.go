@@ -30,6 +30,10 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	// adds metadata to enable users to filter logs by filename
 	t.tags = t.buildTailerTags()
 
+	if t.IsArchive() {
+		return t.setupArchive(offset, whence)
+	}
+
 	log.Info("Opening ", t.fullpath)
 	f, err := filesystem.OpenShared(t.fullpath)
 	if err != nil {
@@ -22,6 +22,7 @@ type Journal interface {
 	SeekHead() error
 	Wait(timeout time.Duration) int
 	SeekCursor(cursor string) error
+	SeekRealtimeUsec(usec uint64) error
 	NextSkip(skip uint64) (uint64, error)
 	Close() error
 	Next() (uint64, error)
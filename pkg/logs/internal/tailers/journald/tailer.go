@@ -13,6 +13,8 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-systemd/sdjournal"
@@ -34,13 +36,25 @@ type Tailer struct {
 	source     *sources.LogSource
 	outputChan chan *message.Message
 	journal    Journal
-	exclude    struct {
+	include    struct {
+		fields map[string]*regexp.Regexp
+	}
+	exclude struct {
 		systemUnits map[string]bool
 		userUnits   map[string]bool
 		matches     map[string]map[string]bool
+		fields      map[string]*regexp.Regexp
 	}
-	stop chan struct{}
-	done chan struct{}
+	fieldsAsTags       []fieldMapping
+	fieldsAsAttributes []fieldMapping
+	stop               chan struct{}
+	done               chan struct{}
+}
+
+// fieldMapping maps a journal field to the name of the tag or attribute holding its value.
+type fieldMapping struct {
+	field string
+	name  string
 }
 
 // NewTailer returns a new tailer.
@@ -156,9 +170,46 @@ func (t *Tailer) setup() error {
 		t.exclude.matches[key][value] = true
 	}
 
+	var err error
+	if t.include.fields, err = compileFieldPatterns(config.IncludeFields); err != nil {
+		return err
+	}
+	if t.exclude.fields, err = compileFieldPatterns(config.ExcludeFields); err != nil {
+		return err
+	}
+	t.fieldsAsTags = newFieldMappings(config.FieldsAsTags)
+	t.fieldsAsAttributes = newFieldMappings(config.FieldsAsAttributes)
+
 	return nil
 }
 
+// compileFieldPatterns compiles the patterns the values of the journal fields are matched against.
+func compileFieldPatterns(patterns map[string]string) (map[string]*regexp.Regexp, error) {
+	fields := make(map[string]*regexp.Regexp, len(patterns))
+	for field, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s for the journal field %s: %s", pattern, field, err)
+		}
+		fields[field] = re
+	}
+	return fields, nil
+}
+
+// newFieldMappings returns the mappings sorted by field, the name defaults to
+// the lowercased field without its leading underscores, e.g. "_COMM" becomes "comm".
+func newFieldMappings(names map[string]string) []fieldMapping {
+	mappings := make([]fieldMapping, 0, len(names))
+	for field, name := range names {
+		if name == "" {
+			name = strings.ToLower(strings.TrimLeft(field, "_"))
+		}
+		mappings = append(mappings, fieldMapping{field: field, name: name})
+	}
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].field < mappings[j].field })
+	return mappings
+}
+
 // seek seeks to the cursor if it is not empty or the end of the journal,
 // returns an error if the operation failed.
 func (t *Tailer) seek(cursor string) error {
@@ -182,7 +233,16 @@ func (t *Tailer) seek(cursor string) error {
 		return err
 	}
 
-	// If there is no cursor and an option is not forced, use the config setting
+	// If there is no cursor and an option is not forced, use the since setting
+	since, err := t.source.Config.JournaldSince(time.Now())
+	if err != nil {
+		return err
+	}
+	if !since.IsZero() {
+		return t.journal.SeekRealtimeUsec(uint64(since.UnixMicro()))
+	}
+
+	// Otherwise use the tailing mode setting
 	if mode == config.Beginning {
 		return t.journal.SeekHead()
 	}
@@ -233,6 +293,17 @@ func (t *Tailer) tail() {
 // shouldDrop returns true if the entry should be dropped,
 // returns false otherwise.
 func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
+	for field, re := range t.include.fields {
+		if value, ok := entry.Fields[field]; !ok || !re.MatchString(value) {
+			return true
+		}
+	}
+	for field, re := range t.exclude.fields {
+		if value, ok := entry.Fields[field]; ok && re.MatchString(value) {
+			return true
+		}
+	}
+
 	for key, values := range t.exclude.matches {
 		if value, ok := entry.Fields[key]; ok {
 			if _, contains := values[value]; contains {
@@ -268,11 +339,15 @@ func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
 // A journal entry has different fields that may vary depending on its nature,
 // for more information, see https://www.freedesktop.org/software/systemd/man/systemd.journal-fields.html.
 func (t *Tailer) toMessage(entry *sdjournal.JournalEntry) *message.Message {
-	return message.NewMessage(t.getContent(entry), t.getOrigin(entry), t.getStatus(entry), time.Now().UnixNano())
+	// the content is computed last since it removes the message and the fields promoted to attributes from the entry.
+	origin := t.getOrigin(entry)
+	status := t.getStatus(entry)
+	return message.NewMessage(t.getContent(entry), origin, status, time.Now().UnixNano())
 }
 
 // getContent returns all the fields of the entry as a json-string,
-// remapping "MESSAGE" into "message" and bundling all the other keys in a "journald" attribute.
+// remapping "MESSAGE" into "message", the fields promoted to attributes into their attribute
+// and bundling all the other keys in a "journald" attribute.
 // ex:
 //   - journal-entry:
 //     {
@@ -295,6 +370,12 @@ func (t *Tailer) getContent(entry *sdjournal.JournalEntry) []byte {
 		payload["message"] = message
 		delete(fields, sdjournal.SD_JOURNAL_FIELD_MESSAGE)
 	}
+	for _, mapping := range t.fieldsAsAttributes {
+		if value, exists := fields[mapping.field]; exists {
+			payload[mapping.name] = value
+			delete(fields, mapping.field)
+		}
+	}
 	payload["journald"] = fields
 
 	content, err := json.Marshal(payload)
@@ -357,6 +438,15 @@ func (t *Tailer) getTags(entry *sdjournal.JournalEntry) []string {
 	if t.isContainerEntry(entry) {
 		tags = t.getContainerTags(t.getContainerID(entry))
 	}
+	if len(t.fieldsAsTags) > 0 {
+		// copy the container tags which may be shared
+		tags = append(make([]string, 0, len(tags)+len(t.fieldsAsTags)), tags...)
+		for _, mapping := range t.fieldsAsTags {
+			if value, exists := entry.Fields[mapping.field]; exists {
+				tags = append(tags, mapping.name+":"+value)
+			}
+		}
+	}
 	return tags
 }
 
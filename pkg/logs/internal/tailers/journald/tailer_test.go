@@ -24,12 +24,13 @@ import (
 )
 
 type MockJournal struct {
-	m        *sync.Mutex
-	seekTail int
-	seekHead int
-	cursor   string
-	entry    *sdjournal.JournalEntry
-	next     uint64
+	m            *sync.Mutex
+	seekTail     int
+	seekHead     int
+	cursor       string
+	realtimeUsec uint64
+	entry        *sdjournal.JournalEntry
+	next         uint64
 }
 
 func (m *MockJournal) AddMatch(match string) error {
@@ -53,6 +54,10 @@ func (m *MockJournal) SeekCursor(cursor string) error {
 	m.cursor = cursor
 	return nil
 }
+func (m *MockJournal) SeekRealtimeUsec(usec uint64) error {
+	m.realtimeUsec = usec
+	return nil
+}
 func (m *MockJournal) NextSkip(skip uint64) (uint64, error) {
 	return 0, nil
 }
@@ -241,6 +246,96 @@ func TestShouldDropEntry(t *testing.T) {
 
 }
 
+func TestShouldDropEntryWithFieldPatterns(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{
+		IncludeFields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_PRIORITY: "^[0-4]$",
+			"CUSTOM_FIELD":                      "^(foo|bar)",
+		},
+		ExcludeFields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_COMM: "^cron$",
+		},
+	})
+	tailer := NewTailer(source, nil, nil)
+	assert.Nil(t, tailer.setup())
+
+	assert.False(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "3",
+				sdjournal.SD_JOURNAL_FIELD_COMM:     "sshd",
+				"CUSTOM_FIELD":                      "foobar",
+			},
+		}))
+
+	// the priority does not match
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "6",
+				"CUSTOM_FIELD":                      "foobar",
+			},
+		}))
+
+	// an included field is missing
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "3",
+			},
+		}))
+
+	// the command is excluded
+	assert.True(t, tailer.shouldDrop(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY: "3",
+				sdjournal.SD_JOURNAL_FIELD_COMM:     "cron",
+				"CUSTOM_FIELD":                      "bar",
+			},
+		}))
+
+	source = sources.NewLogSource("", &config.LogsConfig{IncludeFields: map[string]string{"CUSTOM_FIELD": "("}})
+	tailer = NewTailer(source, nil, nil)
+	assert.NotNil(t, tailer.setup())
+}
+
+func TestFieldsAsTagsAndAttributes(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{
+		FieldsAsTags: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_COMM:     "",
+			sdjournal.SD_JOURNAL_FIELD_HOSTNAME: "host",
+			"MISSING_FIELD":                     "",
+		},
+		FieldsAsAttributes: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_SYSLOG_IDENTIFIER: "",
+			"REQUEST_ID": "request.id",
+		},
+	})
+	tailer := NewTailer(source, nil, &MockJournal{m: &sync.Mutex{}})
+	assert.Nil(t, tailer.setup())
+
+	msg := tailer.toMessage(
+		&sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_MESSAGE:           "foo",
+				sdjournal.SD_JOURNAL_FIELD_COMM:              "sshd",
+				sdjournal.SD_JOURNAL_FIELD_HOSTNAME:          "web-1",
+				sdjournal.SD_JOURNAL_FIELD_SYSLOG_IDENTIFIER: "sshd",
+				"REQUEST_ID": "42",
+			},
+		})
+
+	assert.Equal(t, []string{"comm:sshd", "host:web-1"}, msg.Origin.Tags())
+	assert.Equal(t, "sshd", msg.Origin.Service())
+	assert.JSONEq(t, `{
+		"message": "foo",
+		"syslog_identifier": "sshd",
+		"request.id": "42",
+		"journald": {"_COMM": "sshd", "_HOSTNAME": "web-1"}
+	}`, string(msg.Content))
+}
+
 func TestApplicationName(t *testing.T) {
 	source := sources.NewLogSource("", &config.LogsConfig{})
 	tailer := NewTailer(source, nil, nil)
@@ -431,6 +526,9 @@ func TestTailingMode(t *testing.T) {
 		{"no cursor - force tail", &config.LogsConfig{TailingMode: "forceEnd"}, "", &MockJournal{m: m, seekTail: 1}},
 		{"no cursor - seek head", &config.LogsConfig{TailingMode: "beginning"}, "", &MockJournal{m: m, seekHead: 1}},
 		{"no cursor - seek tail", &config.LogsConfig{TailingMode: "end"}, "", &MockJournal{m: m, seekTail: 1}},
+		{"no cursor - since", &config.LogsConfig{TailingMode: "beginning", Since: "2023-03-01T10:00:00Z"}, "", &MockJournal{m: m, realtimeUsec: 1677664800000000}},
+		{"has cursor - since", &config.LogsConfig{Since: "2023-03-01T10:00:00Z"}, "123", &MockJournal{m: m, cursor: "123"}},
+		{"no cursor - force tail - since", &config.LogsConfig{TailingMode: "forceEnd", Since: "2023-03-01T10:00:00Z"}, "", &MockJournal{m: m, seekTail: 1}},
 	}
 
 	for _, tt := range tests {
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -40,6 +41,25 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardSyslogMessages(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	tailer := NewTailer(sources.NewLogSource("", &config.LogsConfig{Type: config.SyslogType}), r, msgChan, read)
+	tailer.Start()
+
+	w.Write([]byte("40 <11>1 2023-03-01T10:00:00Z - - - - - foo<14>1 - - - - - - bar\n"))
+	msg := <-msgChan
+	assert.Equal(t, `{"message":"foo","syslog":{"facility":1,"severity":3,"timestamp":"2023-03-01T10:00:00Z","version":1}}`, string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), msg.Timestamp)
+	msg = <-msgChan
+	assert.Equal(t, `{"message":"bar","syslog":{"facility":1,"severity":6,"version":1}}`, string(msg.Content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	assert.True(t, msg.Timestamp.IsZero())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
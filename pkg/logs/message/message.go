@@ -37,6 +37,10 @@ type Message struct {
 	// Optional.
 	// Used in the Serverless Agent
 	Lambda *Lambda
+	// Optional.
+	// Number of repeats of a message collapsed into this one by the deduplication stage, the
+	// first occurrence of the message being sent on its own.
+	RepeatCount int
 }
 
 // Lambda is a struct storing information about the Lambda function and function execution.
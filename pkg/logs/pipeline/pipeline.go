@@ -8,6 +8,8 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -17,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Pipeline processes and sends messages to the backend
@@ -57,7 +60,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	strategy := getStrategy(strategyInput, senderInput, flushChan, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	logsSender = sender.NewSenderWithDiskBuffer(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize, getDiskBuffer(endpoints, serverless, pipelineID))
 
 	inputChan := make(chan *message.Message, config.ChanSize)
 	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
@@ -91,6 +94,22 @@ func (p *Pipeline) Flush(ctx context.Context) {
 	p.processor.Flush(ctx) // flush messages in the processor into the sender
 }
 
+// getDiskBuffer returns the disk buffer of the pipeline, or nil when the payloads are not stored on disk.
+func getDiskBuffer(endpoints *config.Endpoints, serverless bool, pipelineID int) *sender.DiskBuffer {
+	maxSize := config.StorageMaxSizeInBytes()
+	if maxSize <= 0 || serverless || !endpoints.UseHTTP {
+		return nil
+	}
+	// the storage is shared between the pipelines
+	storagePath := filepath.Join(config.StoragePath(), strconv.Itoa(pipelineID))
+	diskBuffer, err := sender.NewDiskBuffer(storagePath, maxSize/config.NumberOfPipelines, strconv.Itoa(pipelineID))
+	if err != nil {
+		log.Errorf("Could not store logs payloads on disk in %s: %v", storagePath, err)
+		return nil
+	}
+	return diskBuffer
+}
+
 func getDestinations(endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, pipelineID int) *client.Destinations {
 	reliable := []client.Destination{}
 	additionals := []client.Destination{}
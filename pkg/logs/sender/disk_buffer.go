@@ -0,0 +1,272 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	diskBufferExtension     = ".logs"
+	diskBufferTempExtension = ".tmp"
+	diskBufferFormatVersion = 1
+)
+
+var (
+	tlmDiskBufferStored   = telemetry.NewCounter("logs_sender_disk_buffer", "payloads_stored", []string{"pipeline"}, "Payloads stored on disk")
+	tlmDiskBufferReplayed = telemetry.NewCounter("logs_sender_disk_buffer", "payloads_replayed", []string{"pipeline"}, "Payloads replayed from disk")
+	tlmDiskBufferEvicted  = telemetry.NewCounter("logs_sender_disk_buffer", "payloads_evicted", []string{"pipeline"}, "Payloads removed from disk to make room for newer payloads")
+	tlmDiskBufferSize     = telemetry.NewGauge("logs_sender_disk_buffer", "size_bytes", []string{"pipeline"}, "Size in bytes of the payloads stored on disk")
+)
+
+var errInvalidDiskPayload = errors.New("invalid payload on disk")
+
+// DiskBuffer is a bounded queue of payloads stored on disk, one file per payload. When the
+// queue is full, the oldest payloads waiting to be replayed are removed to make room for the
+// new ones. A replayed payload stays on disk until it is removed once delivered, so that it is
+// reloaded if the agent stops before.
+type DiskBuffer struct {
+	mu                 sync.Mutex
+	storagePath        string
+	maxSizeInBytes     int64
+	pipelineName       string
+	filenames          []string
+	replaying          map[*message.Payload]string
+	currentSizeInBytes int64
+}
+
+// NewDiskBuffer returns a new disk buffer storing payloads in storagePath, the payloads
+// stored by a previous run of the agent are reloaded.
+func NewDiskBuffer(storagePath string, maxSizeInBytes int64, pipelineName string) (*DiskBuffer, error) {
+	if err := os.MkdirAll(storagePath, 0700); err != nil {
+		return nil, err
+	}
+	b := &DiskBuffer{
+		storagePath:    storagePath,
+		maxSizeInBytes: maxSizeInBytes,
+		pipelineName:   pipelineName,
+		replaying:      make(map[*message.Payload]string),
+	}
+	if err := b.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+	if len(b.filenames) > 0 {
+		log.Infof("Reloaded %d payloads (%d bytes) stored on disk for pipeline %s", len(b.filenames), b.currentSizeInBytes, pipelineName)
+	}
+	return b, nil
+}
+
+// IsEmpty returns true if no payload is waiting to be replayed.
+func (b *DiskBuffer) IsEmpty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.filenames) == 0
+}
+
+// Store writes the payload to disk, it returns once the payload is durably stored.
+func (b *DiskBuffer) Store(payload *message.Payload) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := encodeDiskPayload(payload)
+	size := int64(len(data))
+	if size > b.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big to be stored on disk. Current:%v Maximum:%v", size, b.maxSizeInBytes)
+	}
+	for len(b.filenames) > 0 && b.currentSizeInBytes+size > b.maxSizeInBytes {
+		log.Warnf("Maximum disk space for logs payloads is reached. Removing %s", b.filenames[0])
+		if err := b.removeOldest(); err != nil {
+			return err
+		}
+		tlmDiskBufferEvicted.Inc(b.pipelineName)
+	}
+
+	// the payload is written to a temporary file which is renamed once synced, so that
+	// a partially written payload is never reloaded
+	prefix := fmt.Sprintf("%020d_", time.Now().UnixNano())
+	file, err := os.CreateTemp(b.storagePath, prefix+"*"+diskBufferTempExtension)
+	if err != nil {
+		return err
+	}
+	tempName := file.Name()
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+	filename := strings.TrimSuffix(tempName, diskBufferTempExtension) + diskBufferExtension
+	if err := os.Rename(tempName, filename); err != nil {
+		_ = os.Remove(tempName)
+		return err
+	}
+
+	b.filenames = append(b.filenames, filename)
+	b.currentSizeInBytes += size
+	tlmDiskBufferStored.Inc(b.pipelineName)
+	tlmDiskBufferSize.Set(float64(b.currentSizeInBytes), b.pipelineName)
+	return nil
+}
+
+// Next returns the oldest payload waiting to be replayed, it returns nil when there is none.
+// The payload stays on disk until it is removed once delivered, or requeued if it could not be
+// sent. Payloads which can not be read are removed.
+func (b *DiskBuffer) Next() *message.Payload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.filenames) > 0 {
+		filename := b.filenames[0]
+		data, err := os.ReadFile(filename)
+		if err == nil {
+			var payload *message.Payload
+			if payload, err = decodeDiskPayload(data); err == nil {
+				b.filenames = b.filenames[1:]
+				b.replaying[payload] = filename
+				return payload
+			}
+		}
+		log.Errorf("Cannot read the payload stored in %s, removing it: %v", filename, err)
+		if err := b.removeOldest(); err != nil {
+			log.Errorf("Cannot remove %s: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+// Requeue puts back a payload returned by Next which could not be sent, so that it is the next
+// one replayed.
+func (b *DiskBuffer) Requeue(payload *message.Payload) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	filename, found := b.replaying[payload]
+	if !found {
+		return
+	}
+	delete(b.replaying, payload)
+	b.filenames = append([]string{filename}, b.filenames...)
+}
+
+// Remove removes a payload returned by Next from disk once it has been delivered, it returns
+// false if the payload was not replayed from the buffer.
+func (b *DiskBuffer) Remove(payload *message.Payload) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	filename, found := b.replaying[payload]
+	if !found {
+		return false
+	}
+	delete(b.replaying, payload)
+	if err := b.removeFile(filename); err != nil {
+		log.Errorf("Cannot remove %s: %v", filename, err)
+	}
+	tlmDiskBufferReplayed.Inc(b.pipelineName)
+	return true
+}
+
+func (b *DiskBuffer) removeOldest() error {
+	filename := b.filenames[0]
+
+	// remove the file from b.filenames also in case of error to not fail on the next call
+	b.filenames = b.filenames[1:]
+	return b.removeFile(filename)
+}
+
+func (b *DiskBuffer) removeFile(filename string) error {
+	defer func() {
+		tlmDiskBufferSize.Set(float64(b.currentSizeInBytes), b.pipelineName)
+	}()
+
+	fi, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil {
+		return err
+	}
+	b.currentSizeInBytes -= fi.Size()
+	return nil
+}
+
+func (b *DiskBuffer) reloadExistingFiles() error {
+	entries, err := os.ReadDir(b.storagePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(b.storagePath, entry.Name())
+		switch filepath.Ext(entry.Name()) {
+		case diskBufferTempExtension:
+			// the agent stopped while writing this payload
+			_ = os.Remove(path)
+		case diskBufferExtension:
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			b.currentSizeInBytes += info.Size()
+			b.filenames = append(b.filenames, path)
+		}
+	}
+	// file names start with the time the payloads were stored
+	sort.Strings(b.filenames)
+	tlmDiskBufferSize.Set(float64(b.currentSizeInBytes), b.pipelineName)
+	return nil
+}
+
+// encodeDiskPayload serializes a payload, the messages are not stored as the auditor
+// is updated as soon as the payload is stored.
+func encodeDiskPayload(payload *message.Payload) []byte {
+	var buf bytes.Buffer
+	buf.Grow(1 + 4 + len(payload.Encoding) + 8 + len(payload.Encoded))
+	buf.WriteByte(diskBufferFormatVersion)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(payload.Encoding)))
+	buf.WriteString(payload.Encoding)
+	_ = binary.Write(&buf, binary.BigEndian, uint64(payload.UnencodedSize))
+	buf.Write(payload.Encoded)
+	return buf.Bytes()
+}
+
+func decodeDiskPayload(data []byte) (*message.Payload, error) {
+	r := bytes.NewReader(data)
+	version, err := r.ReadByte()
+	if err != nil || version != diskBufferFormatVersion {
+		return nil, errInvalidDiskPayload
+	}
+	var encodingLen uint32
+	if err := binary.Read(r, binary.BigEndian, &encodingLen); err != nil || int64(encodingLen) > int64(r.Len()) {
+		return nil, errInvalidDiskPayload
+	}
+	encoding := make([]byte, encodingLen)
+	if _, err := io.ReadFull(r, encoding); err != nil {
+		return nil, errInvalidDiskPayload
+	}
+	var unencodedSize uint64
+	if err := binary.Read(r, binary.BigEndian, &unencodedSize); err != nil {
+		return nil, errInvalidDiskPayload
+	}
+	return &message.Payload{
+		Encoded:       data[len(data)-r.Len():],
+		Encoding:      string(encoding),
+		UnencodedSize: int(unencodedSize),
+	}, nil
+}
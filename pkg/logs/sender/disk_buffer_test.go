@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newDiskPayload(content string) *message.Payload {
+	return &message.Payload{
+		Encoded:       []byte(content),
+		Encoding:      "gzip",
+		UnencodedSize: len(content) * 2,
+	}
+}
+
+func TestDiskBufferStoreAndReplay(t *testing.T) {
+	buffer, err := NewDiskBuffer(t.TempDir(), 1000, "0")
+	require.NoError(t, err)
+	assert.True(t, buffer.IsEmpty())
+	assert.Nil(t, buffer.Next())
+
+	require.NoError(t, buffer.Store(newDiskPayload("first")))
+	require.NoError(t, buffer.Store(newDiskPayload("second")))
+	assert.False(t, buffer.IsEmpty())
+
+	// payloads are replayed oldest first
+	first := buffer.Next()
+	assert.Equal(t, newDiskPayload("first"), first)
+	buffer.Requeue(first)
+	first = buffer.Next()
+	assert.Equal(t, newDiskPayload("first"), first)
+	second := buffer.Next()
+	assert.Equal(t, newDiskPayload("second"), second)
+	assert.True(t, buffer.IsEmpty())
+	assert.Nil(t, buffer.Next())
+
+	// replayed payloads stay on disk until they are delivered
+	files, err := os.ReadDir(buffer.storagePath)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.True(t, buffer.Remove(first))
+	assert.False(t, buffer.Remove(first))
+	assert.False(t, buffer.Remove(newDiskPayload("second")))
+	assert.True(t, buffer.Remove(second))
+	files, err = os.ReadDir(buffer.storagePath)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Equal(t, int64(0), buffer.currentSizeInBytes)
+}
+
+func TestDiskBufferEvictsOldestPayloads(t *testing.T) {
+	payloadSize := int64(len(encodeDiskPayload(newDiskPayload("payload-0"))))
+	buffer, err := NewDiskBuffer(t.TempDir(), 2*payloadSize, "0")
+	require.NoError(t, err)
+
+	require.NoError(t, buffer.Store(newDiskPayload("payload-0")))
+	require.NoError(t, buffer.Store(newDiskPayload("payload-1")))
+	require.NoError(t, buffer.Store(newDiskPayload("payload-2")))
+	assert.Len(t, buffer.filenames, 2)
+	assert.Equal(t, 2*payloadSize, buffer.currentSizeInBytes)
+
+	// payloads larger than the buffer are not stored
+	assert.Error(t, buffer.Store(newDiskPayload(string(make([]byte, 3*payloadSize)))))
+	assert.Len(t, buffer.filenames, 2)
+	assert.Equal(t, newDiskPayload("payload-1"), buffer.Next())
+}
+
+func TestDiskBufferReloadsExistingPayloads(t *testing.T) {
+	storagePath := t.TempDir()
+	buffer, err := NewDiskBuffer(storagePath, 1000, "0")
+	require.NoError(t, err)
+	require.NoError(t, buffer.Store(newDiskPayload("first")))
+	require.NoError(t, buffer.Store(newDiskPayload("second")))
+
+	// partially written payloads are removed
+	tempFile := filepath.Join(storagePath, "00000000000000000000_1"+diskBufferTempExtension)
+	require.NoError(t, os.WriteFile(tempFile, []byte("partial"), 0600))
+
+	buffer, err = NewDiskBuffer(storagePath, 1000, "0")
+	require.NoError(t, err)
+	assert.NoFileExists(t, tempFile)
+	assert.Len(t, buffer.filenames, 2)
+	assert.Equal(t, newDiskPayload("first"), buffer.Next())
+}
+
+func TestDiskBufferRemovesInvalidPayloads(t *testing.T) {
+	buffer, err := NewDiskBuffer(t.TempDir(), 1000, "0")
+	require.NoError(t, err)
+	require.NoError(t, buffer.Store(newDiskPayload("first")))
+	require.NoError(t, buffer.Store(newDiskPayload("second")))
+	require.NoError(t, os.WriteFile(buffer.filenames[0], []byte{0xff}, 0600))
+
+	assert.Equal(t, newDiskPayload("second"), buffer.Next())
+	assert.Empty(t, buffer.filenames)
+	assert.Len(t, buffer.replaying, 1)
+}
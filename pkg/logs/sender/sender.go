@@ -12,6 +12,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -20,6 +21,15 @@ var (
 	tlmSendWaitTime    = telemetry.NewCounter("logs_sender", "send_wait", []string{}, "Time spent waiting for all sends to finish")
 )
 
+const (
+	// diskBufferReplayInterval is the interval at which the payloads stored on disk are replayed.
+	diskBufferReplayInterval = time.Second
+	// diskBufferReplayBatchSize is the maximum number of payloads stored on disk replayed at each
+	// interval. Besides, one stored payload is replayed after each new payload sent, so that the
+	// backlog drains in proportion to the throughput.
+	diskBufferReplayBatchSize = 100
+)
+
 // Sender sends logs to different destinations. Destinations can be either
 // reliable or unreliable. The sender ensures that logs are sent to at least
 // one reliable destination and will block the pipeline if they are in an
@@ -27,20 +37,31 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+// When a disk buffer is set, the payloads are stored on disk instead of blocking
+// the pipeline while all the reliable destinations are failing, and replayed
+// once a reliable destination recovers. A replayed payload is removed from disk
+// once a reliable destination has delivered it.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
+	diskBuffer   *DiskBuffer
 	done         chan struct{}
 	bufferSize   int
 }
 
 // NewSender returns a new sender.
 func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int) *Sender {
+	return NewSenderWithDiskBuffer(inputChan, outputChan, destinations, bufferSize, nil)
+}
+
+// NewSenderWithDiskBuffer returns a new sender storing the payloads it can not send in diskBuffer.
+func NewSenderWithDiskBuffer(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, diskBuffer *DiskBuffer) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
+		diskBuffer:   diskBuffer,
 		done:         make(chan struct{}),
 		bufferSize:   bufferSize,
 	}
@@ -59,51 +80,41 @@ func (s *Sender) Stop() {
 }
 
 func (s *Sender) run() {
-	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, s.outputChan, s.bufferSize)
+	output := s.outputChan
+	var delivered chan *message.Payload
+	var forwarded chan struct{}
+	if s.diskBuffer != nil {
+		delivered = make(chan *message.Payload, s.bufferSize)
+		forwarded = make(chan struct{})
+		go s.forwardDelivered(delivered, forwarded)
+		output = delivered
+	}
+	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, output, s.bufferSize)
 
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
-		var startInUse = time.Now()
-
-		sent := false
-		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					sent = true
-				}
-			}
+	var replayTicker <-chan time.Time
+	if s.diskBuffer != nil {
+		ticker := time.NewTicker(diskBufferReplayInterval)
+		defer ticker.Stop()
+		replayTicker = ticker.C
+	}
 
-			if !sent {
-				// Throttle the poll loop while waiting for a send to succeed
-				// This will only happen when all reliable destinations
-				// are blocked so logs have no where to go.
-				time.Sleep(100 * time.Millisecond)
+	for running := true; running; {
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				running = false
+				break
 			}
-		}
-
-		for i, destSender := range reliableDestinations {
-			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
-				if !destSender.NonBlockingSend(payload) {
-					tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
-					tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
-				}
+			s.send(payload, reliableDestinations, unreliableDestinations)
+			if s.diskBuffer != nil {
+				s.replay(reliableDestinations, 1)
 			}
+		case <-replayTicker:
+			s.replay(reliableDestinations, diskBufferReplayBatchSize)
 		}
-
-		// Attempt to send to unreliable destinations
-		for i, destSender := range unreliableDestinations {
-			if !destSender.NonBlockingSend(payload) {
-				tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
-				tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
-			}
-		}
-
-		inUse := float64(time.Since(startInUse) / time.Millisecond)
-		tlmSendWaitTime.Add(inUse)
 	}
 
 	// Cleanup the destinations
@@ -114,9 +125,110 @@ func (s *Sender) run() {
 		destSender.Stop()
 	}
 	close(sink)
+	if delivered != nil {
+		close(delivered)
+		<-forwarded
+	}
 	s.done <- struct{}{}
 }
 
+// forwardDelivered forwards the payloads delivered by the reliable destinations to the
+// auditor, the replayed ones are removed from disk now that they are delivered.
+func (s *Sender) forwardDelivered(delivered chan *message.Payload, forwarded chan struct{}) {
+	defer close(forwarded)
+	for payload := range delivered {
+		s.diskBuffer.Remove(payload)
+		s.outputChan <- payload
+	}
+}
+
+func (s *Sender) send(payload *message.Payload, reliableDestinations []*DestinationSender, unreliableDestinations []*DestinationSender) {
+	var startInUse = time.Now()
+
+	stored := false
+	for !sendToReliable(payload, reliableDestinations) {
+		if s.store(payload) {
+			stored = true
+			break
+		}
+		// Throttle the poll loop while waiting for a send to succeed
+		// This will only happen when all reliable destinations
+		// are blocked so logs have no where to go.
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if stored {
+		// The payload is replayed to the reliable destinations once they recover,
+		// the auditor can be updated now that the payload is on disk.
+		s.outputChan <- payload
+	}
+
+	// Attempt to send to unreliable destinations
+	for i, destSender := range unreliableDestinations {
+		if !destSender.NonBlockingSend(payload) {
+			tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
+			tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
+		}
+	}
+
+	inUse := float64(time.Since(startInUse) / time.Millisecond)
+	tlmSendWaitTime.Add(inUse)
+}
+
+// sendToReliable sends the payload to the reliable destinations, it returns false
+// if all of them are retrying.
+func sendToReliable(payload *message.Payload, reliableDestinations []*DestinationSender) bool {
+	sent := false
+	for _, destSender := range reliableDestinations {
+		if destSender.Send(payload) {
+			sent = true
+		}
+	}
+	if !sent {
+		return false
+	}
+
+	for i, destSender := range reliableDestinations {
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !destSender.lastSendSucceeded {
+			if !destSender.NonBlockingSend(payload) {
+				tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
+				tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
+			}
+		}
+	}
+	return true
+}
+
+// store stores the payload in the disk buffer, it returns false if there is no
+// disk buffer or if the payload could not be stored.
+func (s *Sender) store(payload *message.Payload) bool {
+	if s.diskBuffer == nil {
+		return false
+	}
+	if err := s.diskBuffer.Store(payload); err != nil {
+		log.Warnf("Could not store the payload on disk: %v", err)
+		return false
+	}
+	return true
+}
+
+// replay sends up to max payloads stored on disk, oldest first, until the reliable
+// destinations are retrying again. The payloads are removed from disk once delivered.
+func (s *Sender) replay(reliableDestinations []*DestinationSender, max int) {
+	for i := 0; i < max; i++ {
+		payload := s.diskBuffer.Next()
+		if payload == nil {
+			return
+		}
+		if !sendToReliable(payload, reliableDestinations) {
+			s.diskBuffer.Requeue(payload)
+			return
+		}
+	}
+}
+
 // Drains the output channel from destinations that don't update the auditor.
 func additionalDestinationsSink(bufferSize int) chan *message.Payload {
 	sink := make(chan *message.Payload, bufferSize)
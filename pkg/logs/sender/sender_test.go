@@ -6,7 +6,9 @@
 package sender
 
 import (
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -301,3 +303,156 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+func TestSenderStoresPayloadsOnDiskWhenReliableFails(t *testing.T) {
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	reliableRespond := make(chan int)
+	reliableServer := http.NewTestServerWithOptions(500, 0, true, reliableRespond)
+
+	storagePath := t.TempDir()
+	diskBuffer, err := NewDiskBuffer(storagePath, 1000, "0")
+	assert.NoError(t, err)
+
+	destinations := client.NewDestinations([]client.Destination{reliableServer.Destination}, nil)
+
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 0, diskBuffer)
+	sender.Start()
+
+	stuck := &message.Payload{Encoded: []byte("stuck")}
+	input <- stuck
+
+	<-reliableRespond // let it respond 500 once
+	<-reliableRespond // its in a loop now, once we respond 500 a second time we know the sender has marked the endpoint as retrying
+
+	// the payload is stored on disk instead of blocking the pipeline, and the auditor is updated
+	stored := &message.Payload{Encoded: []byte("stored"), Encoding: "identity"}
+	input <- stored
+	assert.Equal(t, stored, <-output)
+	files, err := os.ReadDir(storagePath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	// Recover the server
+	reliableServer.ChangeStatus(200)
+	for {
+		if (<-reliableRespond) == 200 {
+			break
+		}
+	}
+	assert.Equal(t, stuck, <-output)
+
+	// the stored payload is replayed
+	<-reliableRespond
+	replayed := <-output
+	assert.Equal(t, stored.Encoded, replayed.Encoded)
+	assert.Equal(t, stored.Encoding, replayed.Encoding)
+	assert.Eventually(t, func() bool {
+		files, err := os.ReadDir(storagePath)
+		return err == nil && len(files) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	reliableServer.Stop()
+	sender.Stop()
+}
+
+func TestSenderRemovesReplayedPayloadsOnceDelivered(t *testing.T) {
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	reliableRespond := make(chan int)
+	reliableServer := http.NewTestServerWithOptions(500, 0, true, reliableRespond)
+
+	storagePath := t.TempDir()
+	diskBuffer, err := NewDiskBuffer(storagePath, 1000, "0")
+	assert.NoError(t, err)
+	assert.NoError(t, diskBuffer.Store(&message.Payload{Encoded: []byte("stored"), Encoding: "identity"}))
+
+	destinations := client.NewDestinations([]client.Destination{reliableServer.Destination}, nil)
+
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 0, diskBuffer)
+	sender.Start()
+
+	// the stored payload is replayed but not delivered, it stays on disk
+	<-reliableRespond
+	<-reliableRespond
+	assert.Never(t, func() bool {
+		files, err := os.ReadDir(storagePath)
+		return err != nil || len(files) == 0
+	}, 500*time.Millisecond, 10*time.Millisecond)
+	assert.True(t, diskBuffer.IsEmpty())
+
+	// it is removed from disk once delivered
+	reliableServer.ChangeStatus(200)
+	for {
+		if (<-reliableRespond) == 200 {
+			break
+		}
+	}
+	assert.Equal(t, []byte("stored"), (<-output).Encoded)
+	assert.Eventually(t, func() bool {
+		files, err := os.ReadDir(storagePath)
+		return err == nil && len(files) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	reliableServer.Stop()
+	sender.Stop()
+}
+
+func TestSenderReplaysPayloadsOnDiskUnderContinuousInput(t *testing.T) {
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	reliableServer := http.NewTestServer(200)
+
+	storagePath := t.TempDir()
+	diskBuffer, err := NewDiskBuffer(storagePath, 10000, "0")
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, diskBuffer.Store(&message.Payload{Encoded: []byte("stored"), Encoding: "identity"}))
+	}
+
+	destinations := client.NewDestinations([]client.Destination{reliableServer.Destination}, nil)
+
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 0, diskBuffer)
+	sender.Start()
+
+	stop := make(chan struct{})
+	inputDone := make(chan struct{})
+	go func() {
+		defer close(inputDone)
+		for {
+			select {
+			case <-stop:
+				return
+			case input <- &message.Payload{Encoded: []byte("live"), Encoding: "identity"}:
+			}
+		}
+	}()
+
+	// the stored payloads are replayed along with the new ones, not only once the input is idle
+	replayed := 0
+	for i := 0; i < 20; i++ {
+		if string((<-output).Encoded) == "stored" {
+			replayed++
+		}
+	}
+	assert.GreaterOrEqual(t, replayed, 10)
+
+	close(stop)
+	<-inputDone
+	stopped := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-output:
+			case <-stopped:
+				return
+			}
+		}
+	}()
+	sender.Stop()
+	close(stopped)
+	reliableServer.Stop()
+}
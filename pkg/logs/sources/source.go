@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/status"
 	"github.com/DataDog/datadog-agent/pkg/util"
@@ -49,6 +51,11 @@ type LogSource struct {
 	LatencyStats     *util.StatsTracker
 	BytesRead        *status.CountInfo
 	hiddenFromStatus bool
+	// RateLimiter caps the rate of the messages of this source, nil when the source is not rate limited
+	RateLimiter *rate.Limiter
+	// SampledOut and RateLimited count the messages dropped by the sampling and the rate limit of the source
+	SampledOut  *status.CountInfo
+	RateLimited *status.CountInfo
 }
 
 // NewLogSource creates a new log source.
@@ -67,6 +74,15 @@ func NewLogSource(name string, cfg *config.LogsConfig) *LogSource {
 	}
 	source.RegisterInfo(source.BytesRead)
 	source.RegisterInfo(source.LatencyStats)
+	if cfg != nil && cfg.Sampling != nil {
+		source.SampledOut = status.NewCountInfo("Sampled Out")
+		source.RegisterInfo(source.SampledOut)
+	}
+	if cfg != nil && cfg.RateLimit != nil {
+		source.RateLimiter = rate.NewLimiter(rate.Limit(cfg.RateLimit.LogsPerSecond), cfg.RateLimit.BurstSize())
+		source.RateLimited = status.NewCountInfo("Rate Limited")
+		source.RegisterInfo(source.RateLimited)
+	}
 	return source
 }
 
@@ -165,6 +181,28 @@ func (s *LogSource) RecordBytes(n int64) {
 	}
 }
 
+// RecordSampledOut reports a message dropped by the sampling of the source.
+func (s *LogSource) RecordSampledOut() {
+	recordDropped(s.SampledOut)
+	if s.ParentSource != nil {
+		recordDropped(s.ParentSource.SampledOut)
+	}
+}
+
+// RecordRateLimited reports a message dropped by the rate limit of the source.
+func (s *LogSource) RecordRateLimited() {
+	recordDropped(s.RateLimited)
+	if s.ParentSource != nil {
+		recordDropped(s.ParentSource.RateLimited)
+	}
+}
+
+func recordDropped(count *status.CountInfo) {
+	if count != nil {
+		count.Add(1)
+	}
+}
+
 // Dump provides a dump of the LogSource contents, for debugging purposes.  If
 // multiline is true, the result contains newlines for readability.
 func (s *LogSource) Dump(multiline bool) string {
@@ -140,6 +140,10 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 	switch c.Type {
 	case config.TCPType, config.UDPType:
 		dictionary["Port"] = c.Port
+	case config.SyslogType:
+		dictionary["Port"] = c.Port
+		dictionary["Protocol"] = c.Protocol
+		dictionary["TLS"] = c.TLSEnabled()
 	case config.FileType:
 		dictionary["Path"] = c.Path
 		dictionary["TailingMode"] = c.TailingMode
@@ -155,6 +159,7 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 		dictionary["ExcludeUserUnits"] = strings.Join(c.ExcludeUserUnits, ", ")
 		dictionary["IncludeMatches"] = strings.Join(c.IncludeMatches, ", ")
 		dictionary["ExcludeMatches"] = strings.Join(c.ExcludeMatches, ", ")
+		dictionary["Since"] = c.Since
 	case config.WindowsEventType:
 		dictionary["ChannelPath"] = c.ChannelPath
 		dictionary["Query"] = c.Query
@@ -88,3 +88,10 @@ func newMeasuredCache(opts cacheOptions) *measuredCache {
 	go c.statsLoop()
 	return &c
 }
+
+// cacheKey returns the key under which the obfuscation of the query in is cached for the given
+// kind of query, so that equal queries of different kinds do not share cache entries. The SQL
+// queries are cached under their own value.
+func cacheKey(kind, in string) string {
+	return kind + "\x00" + in
+}
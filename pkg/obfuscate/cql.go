@@ -0,0 +1,334 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// cqlCacheKind identifies the CQL queries in the query cache.
+const cqlCacheKind = "cql"
+
+// cqlUUID matches the UUID and TimeUUID literals, which are not quoted in CQL.
+var cqlUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// ObfuscateCQLString obfuscates the given Cassandra CQL query by replacing its literals with "?":
+// strings, numbers, durations, UUIDs, blobs, booleans and collection literals (lists, sets, maps,
+// tuples of literals and user-defined types). The prepared statement markers ("?" and ":name")
+// are kept, e.g. "UPDATE users USING TTL 86400 SET tags = {'a', 'b'} WHERE id = :id" becomes
+// "UPDATE users USING TTL ? SET tags = ? WHERE id = :id". Comments are removed and whitespace
+// is normalized.
+func (o *Obfuscator) ObfuscateCQLString(in string) (*ObfuscatedQuery, error) {
+	key := cacheKey(cqlCacheKind, in)
+	if v, ok := o.queryCache.Get(key); ok {
+		return v.(*ObfuscatedQuery), nil
+	}
+	oq, err := obfuscateCQL(in, o.opts.SQL.TableNames)
+	if err != nil {
+		return nil, err
+	}
+	o.queryCache.Set(key, oq, oq.Cost())
+	return oq, nil
+}
+
+type cqlTokenKind int
+
+const (
+	cqlIdentifier cqlTokenKind = iota
+	cqlQuotedIdentifier
+	cqlLiteral
+	cqlMarker
+	cqlOperator
+)
+
+type cqlToken struct {
+	kind cqlTokenKind
+	text string
+}
+
+// cqlScanner splits a CQL query into tokens, skipping whitespace and comments.
+type cqlScanner struct {
+	in   string
+	pos  int
+	last cqlToken
+	// started reports whether a token was already returned.
+	started bool
+}
+
+// next returns the next token, ok is false at the end of the query.
+func (s *cqlScanner) next() (tok cqlToken, ok bool, err error) {
+	if err := s.skipIgnored(); err != nil {
+		return tok, false, err
+	}
+	if s.pos >= len(s.in) {
+		return tok, false, nil
+	}
+	start := s.pos
+	c := s.in[s.pos]
+	switch {
+	case c == '\'':
+		if err := s.scanQuoted('\''); err != nil {
+			return tok, false, err
+		}
+		tok = cqlToken{kind: cqlLiteral}
+	case c == '$' && strings.HasPrefix(s.in[s.pos:], "$$"):
+		end := strings.Index(s.in[s.pos+2:], "$$")
+		if end < 0 {
+			return tok, false, errors.New("unexpected EOF in string")
+		}
+		s.pos += 2 + end + 2
+		tok = cqlToken{kind: cqlLiteral}
+	case c == '"':
+		if err := s.scanQuoted('"'); err != nil {
+			return tok, false, err
+		}
+		tok = cqlToken{kind: cqlQuotedIdentifier, text: s.in[start:s.pos]}
+	case c == '{' || (c == '[' && !s.afterValue()):
+		// set, map, user-defined type or list literal
+		if err := s.scanCollection(); err != nil {
+			return tok, false, err
+		}
+		tok = cqlToken{kind: cqlLiteral}
+	case c == '?':
+		s.pos++
+		tok = cqlToken{kind: cqlMarker, text: "?"}
+	case c == ':' && s.pos+1 < len(s.in) && isCQLIdentifierStart(s.in[s.pos+1]):
+		s.pos++
+		s.scanIdentifier()
+		tok = cqlToken{kind: cqlMarker, text: s.in[start:s.pos]}
+	case cqlUUID.MatchString(s.in[s.pos:]):
+		s.pos += 36
+		tok = cqlToken{kind: cqlLiteral}
+	case isDigit(rune(c)) || (c == '.' && s.peekDigit(1)) || (c == '-' && !s.afterValue() && (s.peekDigit(1) || s.peekIdentifier(1, "Infinity"))):
+		// numbers, blobs (0xcafe) and durations (1h30m, P1Y2M)
+		s.pos++
+		for s.pos < len(s.in) {
+			ch := s.in[s.pos]
+			if isCQLIdentifierChar(ch) || ch == '.' || ((ch == '+' || ch == '-') && (s.in[s.pos-1] == 'e' || s.in[s.pos-1] == 'E')) {
+				s.pos++
+				continue
+			}
+			break
+		}
+		tok = cqlToken{kind: cqlLiteral}
+	case isCQLIdentifierStart(c):
+		s.scanIdentifier()
+		text := s.in[start:s.pos]
+		switch strings.ToLower(text) {
+		case "true", "false", "nan", "infinity":
+			tok = cqlToken{kind: cqlLiteral}
+		default:
+			tok = cqlToken{kind: cqlIdentifier, text: text}
+		}
+	default:
+		s.pos++
+		if s.pos < len(s.in) && s.in[s.pos] == '=' && strings.IndexByte("<>!+-", c) >= 0 {
+			s.pos++
+		}
+		tok = cqlToken{kind: cqlOperator, text: s.in[start:s.pos]}
+	}
+	if tok.kind == cqlLiteral {
+		tok.text = "?"
+	}
+	s.last = tok
+	s.started = true
+	return tok, true, nil
+}
+
+// afterValue reports whether the last token ends a value, to tell a list literal from an element
+// access and a negative number from a subtraction.
+func (s *cqlScanner) afterValue() bool {
+	if !s.started {
+		return false
+	}
+	switch s.last.kind {
+	case cqlIdentifier, cqlQuotedIdentifier, cqlLiteral, cqlMarker:
+		return true
+	}
+	return s.last.text == ")" || s.last.text == "]"
+}
+
+func (s *cqlScanner) peekDigit(offset int) bool {
+	return s.pos+offset < len(s.in) && isDigit(rune(s.in[s.pos+offset]))
+}
+
+func (s *cqlScanner) peekIdentifier(offset int, ident string) bool {
+	return strings.HasPrefix(s.in[s.pos+offset:], ident)
+}
+
+func (s *cqlScanner) skipIgnored() error {
+	for s.pos < len(s.in) {
+		rest := s.in[s.pos:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r':
+			s.pos++
+		case strings.HasPrefix(rest, "--") || strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			s.pos += end
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return errors.New("unexpected EOF in comment")
+			}
+			s.pos += 2 + end + 2
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *cqlScanner) scanIdentifier() {
+	for s.pos < len(s.in) && isCQLIdentifierChar(s.in[s.pos]) {
+		s.pos++
+	}
+}
+
+// scanQuoted scans a string or a quoted identifier, where the quote is escaped by doubling it.
+func (s *cqlScanner) scanQuoted(quote byte) error {
+	s.pos++
+	for s.pos < len(s.in) {
+		if s.in[s.pos] == quote {
+			if s.pos+1 < len(s.in) && s.in[s.pos+1] == quote {
+				s.pos += 2
+				continue
+			}
+			s.pos++
+			return nil
+		}
+		s.pos++
+	}
+	return fmt.Errorf("unexpected EOF in quoted %c", quote)
+}
+
+// scanCollection scans a collection literal up to its closing bracket, including the nested
+// collections and the strings it holds.
+func (s *cqlScanner) scanCollection() error {
+	depth := 0
+	for s.pos < len(s.in) {
+		switch s.in[s.pos] {
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+			if depth == 0 {
+				s.pos++
+				return nil
+			}
+		case '\'', '"':
+			if err := s.scanQuoted(s.in[s.pos]); err != nil {
+				return err
+			}
+			continue
+		}
+		s.pos++
+	}
+	return errors.New("unexpected EOF in collection literal")
+}
+
+func isCQLIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isCQLIdentifierChar(c byte) bool {
+	return isCQLIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+func obfuscateCQL(in string, collectTableNames bool) (*ObfuscatedQuery, error) {
+	var (
+		s   = cqlScanner{in: in}
+		out []string
+		// parens holds the positions in out of the open parentheses.
+		parens   []int
+		metadata metadataFinderFilter
+		// table holds the name of the table being read, when collecting table names.
+		table     strings.Builder
+		readTable bool
+	)
+	storeTable := func() {
+		if table.Len() > 0 {
+			metadata.storeTableName(table.String())
+			table.Reset()
+		}
+		readTable = false
+	}
+	for {
+		tok, ok, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		if collectTableNames {
+			switch {
+			case readTable && (tok.kind == cqlIdentifier || tok.kind == cqlQuotedIdentifier) && (table.Len() == 0 || strings.HasSuffix(table.String(), ".")):
+				table.WriteString(tok.text)
+			case readTable && tok.text == "." && table.Len() > 0:
+				table.WriteByte('.')
+			default:
+				storeTable()
+				if tok.kind == cqlIdentifier {
+					switch strings.ToUpper(tok.text) {
+					case "FROM", "INTO", "UPDATE":
+						// SELECT ... FROM [tableName], INSERT INTO [tableName], UPDATE [tableName]
+						readTable = true
+					}
+				}
+			}
+		}
+		switch tok.text {
+		case "(":
+			parens = append(parens, len(out))
+		case ")":
+			if n := len(parens); n > 0 {
+				open := parens[n-1]
+				parens = parens[:n-1]
+				if isCQLLiteralGroup(out[open+1:]) {
+					// tuples and lists of values, e.g. "IN ( ?, ?, ? )"
+					out = append(out[:open+1], "?")
+				}
+			}
+		}
+		out = append(out, tok.text)
+	}
+	storeTable()
+	if len(out) == 0 {
+		return nil, errors.New("result is empty")
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(in))
+	for i, tok := range out {
+		if i > 0 && tok != "," && tok != ";" && tok != "." && out[i-1] != "." {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(tok)
+	}
+	return &ObfuscatedQuery{
+		Query:    sb.String(),
+		Metadata: metadata.Results(),
+	}, nil
+}
+
+// isCQLLiteralGroup reports whether the tokens only hold obfuscated literals and commas.
+func isCQLLiteralGroup(tokens []string) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	for _, tok := range tokens {
+		if tok != "?" && tok != "," {
+			return false
+		}
+	}
+	return true
+}
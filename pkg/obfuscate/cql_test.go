@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateCQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out, tables string
+	}{
+		{
+			in:     "SELECT * FROM ks.users WHERE id = 42 AND name = 'it''s' LIMIT 10",
+			out:    "SELECT * FROM ks.users WHERE id = ? AND name = ? LIMIT ?",
+			tables: "ks.users",
+		},
+		{
+			// UUID, collections and USING TTL
+			in:     "INSERT INTO users (id, tags, prefs) VALUES (123e4567-e89b-12d3-a456-426614174000, {'a', 'b'}, {'k': 'v', 'n': {1, 2}}) USING TTL 3600 AND TIMESTAMP 1234",
+			out:    "INSERT INTO users ( id, tags, prefs ) VALUES ( ? ) USING TTL ? AND TIMESTAMP ?",
+			tables: "users",
+		},
+		{
+			// lists, element access and prepared markers
+			in:     "UPDATE users USING TTL 86400 SET emails = emails + ['a@b.c'], prefs['k'] = 'v' WHERE id = ? AND name = :name IF EXISTS",
+			out:    "UPDATE users USING TTL ? SET emails = emails + ?, prefs [ ? ] = ? WHERE id = ? AND name = :name IF EXISTS",
+			tables: "users",
+		},
+		{
+			// negative numbers, durations, blobs, booleans, quoted identifiers and comments
+			in:     `SELECT "Name" FROM "Ks".users /* users */ WHERE d > -1.5e10 AND t < 1h30m AND b = 0xCAFE AND a = true -- comment`,
+			out:    `SELECT "Name" FROM "Ks".users WHERE d > ? AND t < ? AND b = ? AND a = ?`,
+			tables: `"Ks".users`,
+		},
+		{
+			// tuples
+			in:     "SELECT * FROM t WHERE (a, b) IN ((1, 'x'), (2, 'y')) AND c IN (1, 2, 3)",
+			out:    "SELECT * FROM t WHERE ( a, b ) IN ( ( ? ), ( ? ) ) AND c IN ( ? )",
+			tables: "t",
+		},
+		{
+			in:     "BEGIN BATCH INSERT INTO a (x) VALUES (1); UPDATE b SET y = y - 1 WHERE k = $$text$$; APPLY BATCH",
+			out:    "BEGIN BATCH INSERT INTO a ( x ) VALUES ( ? ); UPDATE b SET y = y - ? WHERE k = ?; APPLY BATCH",
+			tables: "a,b",
+		},
+	} {
+		oq, err := NewObfuscator(Config{SQL: SQLConfig{TableNames: true}}).ObfuscateCQLString(tt.in)
+		if assert.NoError(t, err, tt.in) {
+			assert.Equal(t, tt.out, oq.Query)
+			assert.Equal(t, tt.tables, oq.Metadata.TablesCSV)
+		}
+	}
+}
+
+func TestObfuscateCQLErrors(t *testing.T) {
+	for _, in := range []string{
+		"SELECT * FROM users WHERE name = 'unterminated",
+		"SELECT * FROM users WHERE tags = {'a', 'b'",
+		"SELECT * FROM users /* unterminated",
+		"-- only a comment",
+	} {
+		_, err := NewObfuscator(Config{}).ObfuscateCQLString(in)
+		assert.Error(t, err, in)
+	}
+}
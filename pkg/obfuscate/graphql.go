@@ -0,0 +1,292 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+)
+
+const (
+	// graphqlCacheKind identifies the GraphQL documents in the query cache.
+	graphqlCacheKind = "graphql"
+
+	unicodeBOM = "\ufeff"
+)
+
+// ObfuscateGraphQLString obfuscates the given GraphQL document by replacing the literal values
+// of arguments, object fields, list items and variable default values with "?". Variables,
+// field names, aliases, types and directives are kept. Comments are removed and whitespace is
+// normalized. Invalid documents are obfuscated on a best effort basis, e.g. the rest of an
+// unterminated string is replaced with "?".
+func (o *Obfuscator) ObfuscateGraphQLString(in string) string {
+	key := cacheKey(graphqlCacheKind, in)
+	if v, ok := o.queryCache.Get(key); ok {
+		return v.(string)
+	}
+	out := obfuscateGraphQL(in)
+	o.queryCache.Set(key, out, int64(len(out)))
+	return out
+}
+
+type graphqlTokenKind int
+
+const (
+	graphqlPunctuator graphqlTokenKind = iota
+	graphqlName
+	graphqlVariable  // $name
+	graphqlDirective // @name
+	graphqlSpread    // ...
+	graphqlNumber
+	graphqlString
+)
+
+type graphqlToken struct {
+	kind graphqlTokenKind
+	text string
+}
+
+// graphqlScanner splits a GraphQL document into tokens, skipping whitespace and comments.
+type graphqlScanner struct {
+	in  string
+	pos int
+}
+
+// next returns the next token, ok is false at the end of the document.
+func (s *graphqlScanner) next() (tok graphqlToken, ok bool) {
+	s.skipIgnored()
+	if s.pos >= len(s.in) {
+		return graphqlToken{}, false
+	}
+	start := s.pos
+	c := s.in[s.pos]
+	switch {
+	case c == '$' || c == '@':
+		s.pos++
+		s.scanName()
+		kind := graphqlVariable
+		if c == '@' {
+			kind = graphqlDirective
+		}
+		return graphqlToken{kind: kind, text: s.in[start:s.pos]}, true
+	case c == '.' && strings.HasPrefix(s.in[s.pos:], "..."):
+		s.pos += 3
+		return graphqlToken{kind: graphqlSpread, text: "..."}, true
+	case c == '"':
+		s.scanString()
+		return graphqlToken{kind: graphqlString, text: s.in[start:s.pos]}, true
+	case c == '-' || isDigit(rune(c)):
+		s.pos++
+		for s.pos < len(s.in) && (isGraphQLNameChar(s.in[s.pos]) || s.in[s.pos] == '.' ||
+			((s.in[s.pos] == '+' || s.in[s.pos] == '-') && (s.in[s.pos-1] == 'e' || s.in[s.pos-1] == 'E'))) {
+			s.pos++
+		}
+		return graphqlToken{kind: graphqlNumber, text: s.in[start:s.pos]}, true
+	case isGraphQLNameStart(c):
+		s.scanName()
+		return graphqlToken{kind: graphqlName, text: s.in[start:s.pos]}, true
+	default:
+		s.pos++
+		return graphqlToken{kind: graphqlPunctuator, text: s.in[start:s.pos]}, true
+	}
+}
+
+// skipIgnored skips whitespace, line terminators and comments. Commas are insignificant in
+// GraphQL but they are kept to preserve the readability of the document.
+func (s *graphqlScanner) skipIgnored() {
+	for s.pos < len(s.in) {
+		switch s.in[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		case '#':
+			for s.pos < len(s.in) && s.in[s.pos] != '\n' && s.in[s.pos] != '\r' {
+				s.pos++
+			}
+		default:
+			if strings.HasPrefix(s.in[s.pos:], unicodeBOM) {
+				// byte order mark
+				s.pos += len(unicodeBOM)
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (s *graphqlScanner) scanName() {
+	for s.pos < len(s.in) && isGraphQLNameChar(s.in[s.pos]) {
+		s.pos++
+	}
+}
+
+// scanString scans a string or a block string, up to the end of the document if it is unterminated.
+func (s *graphqlScanner) scanString() {
+	if strings.HasPrefix(s.in[s.pos:], `"""`) {
+		s.pos += 3
+		for s.pos < len(s.in) {
+			switch {
+			case strings.HasPrefix(s.in[s.pos:], `\"""`):
+				s.pos += 4
+			case strings.HasPrefix(s.in[s.pos:], `"""`):
+				s.pos += 3
+				return
+			default:
+				s.pos++
+			}
+		}
+		return
+	}
+	s.pos++
+	for s.pos < len(s.in) {
+		switch s.in[s.pos] {
+		case '\\':
+			s.pos += 2
+		case '"':
+			s.pos++
+			return
+		case '\n', '\r':
+			// strings can not span multiple lines
+			return
+		default:
+			s.pos++
+		}
+	}
+	s.pos = len(s.in)
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return isGraphQLNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// graphqlFrame is the kind of a bracket opened in a GraphQL document.
+type graphqlFrame int
+
+const (
+	graphqlSelectionSet graphqlFrame = iota
+	graphqlArguments
+	graphqlVariableDefinitions
+	graphqlObjectValue
+	graphqlListValue
+	graphqlListType
+)
+
+func obfuscateGraphQL(in string) string {
+	var (
+		s   = graphqlScanner{in: in}
+		out strings.Builder
+		// stack holds the brackets opened so far.
+		stack []graphqlFrame
+		// expectValue reports whether the next token starts a value.
+		expectValue bool
+		// operation reports whether an operation definition was started at the top level, so
+		// that the following parenthesis opens its variable definitions.
+		operation bool
+		last      graphqlToken
+	)
+	out.Grow(len(in))
+	top := func() (graphqlFrame, bool) {
+		if len(stack) == 0 {
+			return 0, false
+		}
+		return stack[len(stack)-1], true
+	}
+	for {
+		tok, ok := s.next()
+		if !ok {
+			break
+		}
+		frame, nested := top()
+		inValue := expectValue || (nested && frame == graphqlListValue)
+		text := tok.text
+		switch tok.kind {
+		case graphqlNumber, graphqlString:
+			text = "?"
+			expectValue = false
+		case graphqlName:
+			if inValue {
+				// boolean, null and enum values
+				text = "?"
+				expectValue = false
+			} else if !nested {
+				switch tok.text {
+				case "query", "mutation", "subscription":
+					operation = true
+				}
+			}
+		case graphqlVariable:
+			expectValue = false
+		case graphqlPunctuator:
+			switch tok.text {
+			case "(":
+				if !nested && operation {
+					stack = append(stack, graphqlVariableDefinitions)
+				} else {
+					stack = append(stack, graphqlArguments)
+				}
+			case "{":
+				if inValue {
+					stack = append(stack, graphqlObjectValue)
+				} else {
+					stack = append(stack, graphqlSelectionSet)
+					operation = false
+				}
+				expectValue = false
+			case "[":
+				if inValue {
+					stack = append(stack, graphqlListValue)
+				} else {
+					stack = append(stack, graphqlListType)
+				}
+				expectValue = false
+			case ")", "}", "]":
+				if nested {
+					stack = stack[:len(stack)-1]
+					if frame == graphqlVariableDefinitions {
+						operation = false
+					}
+				}
+				expectValue = false
+			case ":":
+				expectValue = nested && (frame == graphqlArguments || frame == graphqlObjectValue)
+			case "=":
+				expectValue = nested && frame == graphqlVariableDefinitions
+			}
+		}
+		if out.Len() > 0 && graphqlNeedsSpace(last, tok) {
+			out.WriteByte(' ')
+		}
+		out.WriteString(text)
+		last = tok
+	}
+	return out.String()
+}
+
+// graphqlNeedsSpace reports whether a space is written between the tokens prev and next.
+func graphqlNeedsSpace(prev, next graphqlToken) bool {
+	if prev.kind == graphqlPunctuator {
+		switch prev.text {
+		case "(", "[":
+			return false
+		}
+	}
+	if prev.kind == graphqlSpread && next.kind == graphqlName && next.text != "on" {
+		// fragment spread, e.g. "...UserFields"
+		return false
+	}
+	if next.kind == graphqlPunctuator {
+		switch next.text {
+		case ")", "]", ",", ":", "!":
+			return false
+		case "(":
+			// arguments and variable definitions, e.g. "user(id: ?)"
+			return prev.kind != graphqlName && prev.kind != graphqlDirective
+		}
+	}
+	return true
+}
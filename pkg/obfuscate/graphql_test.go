@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			`{ user(id: 42) { name } }`,
+			`{ user(id: ?) { name } }`,
+		},
+		{
+			// variables and their default values
+			`query GetUser($id: ID! = "42", $ids: [Int!] = [1, 2]) { user(id: $id) { name } }`,
+			`query GetUser($id: ID! = ?, $ids: [Int!] = [?, ?]) { user(id: $id) { name } }`,
+		},
+		{
+			// object, list, boolean, null and enum values
+			`mutation { createUser(input: {name: "x", admin: false, score: -1.5e3, tags: ["a"], manager: null, status: ACTIVE}) { id } }`,
+			`mutation { createUser(input: { name: ?, admin: ?, score: ?, tags: [?], manager: ?, status: ? }) { id } }`,
+		},
+		{
+			// aliases, directives, fragments and comments
+			"query Q($a: Boolean) @cached(ttl: 60) {\n  # the user\n  me: user(id: 1) @include(if: $a) {\n    ...UserFields\n    ... on Admin { level }\n  }\n}\nfragment UserFields on User { email }",
+			`query Q($a: Boolean) @cached(ttl: ?) { me: user(id: ?) @include(if: $a) { ...UserFields ... on Admin { level } } } fragment UserFields on User { email }`,
+		},
+		{
+			// block strings
+			`{ search(text: """a "quoted" \""" string""") { id } }`,
+			`{ search(text: ?) { id } }`,
+		},
+		{
+			// unterminated string
+			`{ search(text: "secret`,
+			`{ search(text: ?`,
+		},
+		{
+			`query`,
+			`query`,
+		},
+	} {
+		assert.Equal(t, tt.out, NewObfuscator(Config{}).ObfuscateGraphQLString(tt.in))
+	}
+}
+
+func TestObfuscateGraphQLCache(t *testing.T) {
+	o := NewObfuscator(Config{SQL: SQLConfig{Cache: true}})
+	defer o.Stop()
+	in := `{ user(id: 42) { name } }`
+	assert.Equal(t, `{ user(id: ?) { name } }`, o.ObfuscateGraphQLString(in))
+	o.queryCache.Wait()
+	v, ok := o.queryCache.Get(cacheKey(graphqlCacheKind, in))
+	assert.True(t, ok)
+	assert.Equal(t, `{ user(id: ?) { name } }`, v)
+	_, ok = o.queryCache.Get(in)
+	assert.False(t, ok)
+}
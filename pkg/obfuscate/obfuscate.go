@@ -100,6 +100,19 @@ type StatsClient interface {
 type SQLConfig struct {
 	// DBMS identifies the type of database management system (e.g. MySQL, Postgres, and SQL Server).
 	// Valid values for this can be found at https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/trace/semantic_conventions/database.md#connection-level-attributes
+	//
+	// It selects the SQL dialect understood by the tokenizer. When empty, a generic dialect is used.
+	// The dialects with a specific behavior are:
+	//   - DBMSMySQL: backticks delimit identifiers, double-quoted strings are obfuscated as string
+	//     literals, "#" starts a comment and "$" is an identifier character.
+	//   - DBMSPostgres: double quotes delimit identifiers, "$tag$" delimits dollar-quoted strings, "$1"
+	//     is a positional parameter and "#", "@" and "?" are parts of operators (e.g. JSON operators).
+	//   - DBMSSQLServer: brackets delimit identifiers, "#" starts temporary table names and "$" is an
+	//     identifier character.
+	//   - DBMSDynamoDB: "{...}" and "<<...>>" are PartiQL struct and bag literals.
+	//
+	// The delimited parts of qualified names are joined, e.g. "[dbo].[users]" becomes "dbo.users" in
+	// SQL Server, so that the table names are collected as a whole.
 	DBMS string `json:"dbms"`
 
 	// TableNames specifies whether the obfuscator should also extract the table names that a query addresses,
@@ -141,6 +154,13 @@ type SQLMetadata struct {
 	Commands []string `json:"commands"`
 	// Comments holds comments in an SQL statement.
 	Comments []string `json:"comments"`
+	// Operation holds the type of the SQL statement, e.g. SELECT, INSERT, CALL or EXEC. It is
+	// collected along with the commands, and is the outermost command of the statement, so that
+	// "WITH x AS (SELECT ...) INSERT INTO ..." is an INSERT.
+	Operation string `json:"operation"`
+	// Procedures holds the names of the stored procedures called by an SQL statement, e.g.
+	// "CALL get_user(?)" or "EXEC dbo.GetUser @id = ?". It is collected along with the commands.
+	Procedures []string `json:"procedures"`
 }
 
 // HTTPConfig holds the configuration settings for HTTP obfuscation.
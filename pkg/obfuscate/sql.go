@@ -34,6 +34,20 @@ type metadataFinderFilter struct {
 	commands []string
 	// comments keeps track of comments encountered by the filter.
 	comments []string
+	// procedures keeps track of the stored procedures called in the query.
+	procedures []string
+
+	// operation holds the outermost command of the query and operationDepth the number of
+	// parentheses it is enclosed in.
+	operation      string
+	operationDepth int
+	// depth holds the number of open parentheses.
+	depth int
+	// inStatement reports whether a statement was started, so that procedure calls are only
+	// recognized at its beginning.
+	inStatement bool
+	// seekProcedure reports whether the next identifier is the name of a called procedure.
+	seekProcedure bool
 }
 
 func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
@@ -44,11 +58,50 @@ func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte)
 		f.comments = append(f.comments, comment)
 	}
 	if f.collectCommands {
+		startsStatement := !f.inStatement
+		switch token {
+		case Comment, '{', '?', '=':
+			// e.g. JDBC escape sequences like "{? = call procedure-name(?)}"
+		default:
+			f.inStatement = token != ';'
+		}
 		switch token {
 		case Select, Update, Insert, Delete, Join, Alter, Drop, Create, Grant, Revoke, Commit, Begin, Truncate:
 			command := strings.ToUpper(token.String())
 			f.size += int64(len(command))
 			f.commands = append(f.commands, command)
+			if token != Join {
+				f.storeOperation(command)
+			}
+		case ID, DoubleQuotedString:
+			if f.seekProcedure {
+				if len(buffer) > 0 && buffer[0] == '@' {
+					// the return status variable, e.g. "EXEC @status = procedure-name"
+					break
+				}
+				name := f.identifier(buffer)
+				f.size += int64(len(name))
+				f.procedures = append(f.procedures, name)
+				f.seekProcedure = false
+				break
+			}
+			if !startsStatement || token != ID {
+				break
+			}
+			if command := procedureCommand(buffer); command != "" {
+				f.size += int64(len(command))
+				f.commands = append(f.commands, command)
+				f.storeOperation(command)
+				f.seekProcedure = true
+			}
+		case '(':
+			f.depth++
+		case ')':
+			if f.depth > 0 {
+				f.depth--
+			}
+		case ';':
+			f.seekProcedure = false
 		}
 	}
 	if f.collectTableNames {
@@ -66,19 +119,45 @@ func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte)
 		case Update, Into:
 			// UPDATE [tableName]
 			// INSERT INTO [tableName]
-			tableName := string(buffer)
-			if f.replaceDigits {
-				tableNameCopy := make([]byte, len(buffer))
-				copy(tableNameCopy, buffer)
-				tableName = string(replaceDigits(tableNameCopy))
-			}
-			f.storeTableName(tableName)
+			f.storeTableName(f.identifier(buffer))
 			return TableName, buffer, nil
 		}
 	}
 	return token, buffer, nil
 }
 
+// identifier returns the name held by buffer, with its digits replaced if configured to.
+func (f *metadataFinderFilter) identifier(buffer []byte) string {
+	if f.replaceDigits {
+		nameCopy := make([]byte, len(buffer))
+		copy(nameCopy, buffer)
+		return string(replaceDigits(nameCopy))
+	}
+	return string(buffer)
+}
+
+// storeOperation stores the given command as the operation of the query, unless a command enclosed
+// in fewer parentheses was found before.
+func (f *metadataFinderFilter) storeOperation(command string) {
+	if f.operation == "" || f.depth < f.operationDepth {
+		f.operation = command
+		f.operationDepth = f.depth
+	}
+}
+
+// procedureCommand returns the command calling a stored procedure that the identifier stands for,
+// or an empty string if it is not one.
+func procedureCommand(buffer []byte) string {
+	switch {
+	case len(buffer) == 4 && bytes.EqualFold(buffer, []byte("CALL")):
+		return "CALL"
+	case len(buffer) == 4 && bytes.EqualFold(buffer, []byte("EXEC")),
+		len(buffer) == 7 && bytes.EqualFold(buffer, []byte("EXECUTE")):
+		return "EXEC"
+	}
+	return ""
+}
+
 func (f *metadataFinderFilter) storeTableName(name string) {
 	if _, ok := f.tablesSeen[name]; ok {
 		return
@@ -98,10 +177,12 @@ func (f *metadataFinderFilter) storeTableName(name string) {
 // Results returns metadata collected by the filter for an SQL statement.
 func (f *metadataFinderFilter) Results() SQLMetadata {
 	return SQLMetadata{
-		Size:      f.size,
-		TablesCSV: f.tablesCSV.String(),
-		Commands:  f.commands,
-		Comments:  f.comments,
+		Size:       f.size + int64(len(f.operation)),
+		TablesCSV:  f.tablesCSV.String(),
+		Commands:   f.commands,
+		Comments:   f.comments,
+		Operation:  f.operation,
+		Procedures: f.procedures,
 	}
 }
 
@@ -114,6 +195,12 @@ func (f *metadataFinderFilter) Reset() {
 	f.tablesCSV.Reset()
 	f.commands = f.commands[:0]
 	f.comments = f.comments[:0]
+	f.procedures = f.procedures[:0]
+	f.operation = ""
+	f.operationDepth = 0
+	f.depth = 0
+	f.inStatement = false
+	f.seekProcedure = false
 }
 
 // discardFilter is a token filter which discards certain elements from a query, such as
@@ -189,7 +276,7 @@ func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (token
 		}
 	}
 	switch token {
-	case DollarQuotedString, String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence:
+	case DollarQuotedString, String, Number, Null, Variable, PreparedStatement, BooleanLiteral, EscapeSequence, CollectionLiteral:
 		return markFilteredGroupable(token), questionMark, nil
 	case '?':
 		// Cases like 'ARRAY [ ?, ? ]' should be collapsed into 'ARRAY [ ? ]'
@@ -296,14 +383,52 @@ func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
 // to quantize and obfuscate the given input SQL query string. Quantization removes some elements such as comments
 // and aliases and obfuscation attempts to hide sensitive information in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLStringWithOptions(in string, opts *SQLConfig) (*ObfuscatedQuery, error) {
-	if v, ok := o.queryCache.Get(in); ok {
+	key := in
+	if opts.DBMS != "" {
+		// the same query is obfuscated differently depending on its dialect
+		key = cacheKey(opts.DBMS, in)
+	}
+	if v, ok := o.queryCache.Get(key); ok {
 		return v.(*ObfuscatedQuery), nil
 	}
 	oq, err := o.obfuscateSQLString(in, opts)
 	if err != nil {
 		return oq, err
 	}
-	o.queryCache.Set(in, oq, oq.Cost())
+	o.queryCache.Set(key, oq, oq.Cost())
+	return oq, nil
+}
+
+// ObfuscateSQLStringForDBMS quantizes and obfuscates the given input SQL query string like ObfuscateSQLString,
+// using the SQL dialect of the given database management system (e.g. DBMSMySQL). An empty dbms uses the
+// configured one.
+func (o *Obfuscator) ObfuscateSQLStringForDBMS(in, dbms string) (*ObfuscatedQuery, error) {
+	if dbms == "" || dbms == o.opts.SQL.DBMS {
+		return o.ObfuscateSQLString(in)
+	}
+	opts := o.opts.SQL
+	opts.DBMS = dbms
+	return o.ObfuscateSQLStringWithOptions(in, &opts)
+}
+
+// partiqlCacheKind identifies the PartiQL statements in the query cache.
+const partiqlCacheKind = "partiql"
+
+// ObfuscatePartiQLString quantizes and obfuscates the given DynamoDB PartiQL statement using the SQL
+// obfuscator in PartiQL mode: in addition to strings and numbers, the struct ({...}), bag (<<...>>)
+// and list ([...]) literals are redacted, and the double-quoted strings are kept as identifiers.
+func (o *Obfuscator) ObfuscatePartiQLString(in string) (*ObfuscatedQuery, error) {
+	key := cacheKey(partiqlCacheKind, in)
+	if v, ok := o.queryCache.Get(key); ok {
+		return v.(*ObfuscatedQuery), nil
+	}
+	opts := o.opts.SQL
+	opts.DBMS = DBMSDynamoDB
+	oq, err := o.obfuscateSQLString(in, &opts)
+	if err != nil {
+		return oq, err
+	}
+	o.queryCache.Set(key, oq, oq.Cost())
 	return oq, nil
 }
 
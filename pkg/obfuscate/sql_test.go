@@ -159,6 +159,7 @@ SELECT * FROM clients WHERE (clients.first_name = 'Andy') LIMIT 1 BEGIN INSERT I
 			SQLMetadata{
 				TablesCSV: "clients,owners",
 				Commands:  []string{"SELECT", "BEGIN", "INSERT", "COMMIT"},
+				Operation: "SELECT",
 				Comments:  []string{"/* Multi-line comment */"},
 			},
 		},
@@ -178,6 +179,7 @@ GRANT USAGE, DELETE ON SCHEMA datadog TO datadog`,
 			SQLMetadata{
 				TablesCSV: "",
 				Commands:  []string{"GRANT", "DELETE"},
+				Operation: "GRANT",
 				Comments: []string{
 					"-- Single line comment",
 					"-- Another single line comment",
@@ -226,6 +228,7 @@ GROUP BY sales1828.product_key`,
 			SQLMetadata{
 				TablesCSV: "gosalesdw?.sls_order_method_dim,gosalesdw?.go_branch_dim,sales?",
 				Commands:  []string{"SELECT", "SELECT", "SELECT"},
+				Operation: "SELECT",
 				Comments:  []string{"/* Multi-line comment with line breaks */"},
 			},
 		},
@@ -263,6 +266,7 @@ SELECT clients.* FROM clients INNER JOIN posts ON posts.author_id = author.id AN
 			SQLMetadata{
 				TablesCSV: "d1",
 				Commands:  []string{"CREATE", "SELECT", "UPDATE"},
+				Operation: "CREATE",
 			},
 		},
 		{
@@ -279,6 +283,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 			SQLMetadata{
 				TablesCSV: "",
 				Commands:  []string{"SELECT"},
+				Operation: "SELECT",
 			},
 		},
 		{
@@ -293,6 +298,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 			SQLMetadata{
 				TablesCSV: "",
 				Commands:  []string{"ALTER", "DROP"},
+				Operation: "ALTER",
 			},
 		},
 		{
@@ -307,6 +313,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 			SQLMetadata{
 				TablesCSV: "datadog",
 				Commands:  []string{"REVOKE"},
+				Operation: "REVOKE",
 			},
 		},
 		{
@@ -321,6 +328,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 			SQLMetadata{
 				TablesCSV: "",
 				Commands:  []string{"TRUNCATE"},
+				Operation: "TRUNCATE",
 			},
 		},
 		{
@@ -342,11 +350,65 @@ TABLE T4 UNION CORRESPONDING TABLE T3`,
 			SQLMetadata{
 				TablesCSV: "P,T1,T2",
 				Commands:  []string{"SELECT", "SELECT", "SELECT"},
+				Operation: "SELECT",
 				Comments: []string{
 					"-- Testing explicit table SQL expression",
 				},
 			},
 		},
+		{
+			`CALL get_user(1, 'Andy')`,
+			"CALL get_user ( ? )",
+			SQLConfig{
+				CollectCommands: true,
+			},
+			SQLMetadata{
+				Commands:   []string{"CALL"},
+				Operation:  "CALL",
+				Procedures: []string{"get_user"},
+			},
+		},
+		{
+			`/* controller='users' */ EXEC @status = dbo.GetUser @id = 1; EXECUTE [dbo].[LogAccess] 'users'`,
+			"EXEC @status = dbo.GetUser @id = ? EXECUTE dbo.LogAccess ?",
+			SQLConfig{
+				DBMS:            DBMSSQLServer,
+				CollectCommands: true,
+				CollectComments: true,
+			},
+			SQLMetadata{
+				Commands:   []string{"EXEC", "EXEC"},
+				Comments:   []string{"/* controller='users' */"},
+				Operation:  "EXEC",
+				Procedures: []string{"dbo.GetUser", "dbo.LogAccess"},
+			},
+		},
+		{
+			`{? = call proc_4(?, ?)}`,
+			"{ ? = call proc_? ( ? ) }",
+			SQLConfig{
+				CollectCommands: true,
+				ReplaceDigits:   true,
+			},
+			SQLMetadata{
+				Commands:   []string{"CALL"},
+				Operation:  "CALL",
+				Procedures: []string{"proc_?"},
+			},
+		},
+		{
+			`WITH recent AS (SELECT id, exec FROM logs WHERE ts > 10) INSERT INTO archive SELECT * FROM recent`,
+			"WITH recent SELECT id, exec FROM logs WHERE ts > ? ) INSERT INTO archive SELECT * FROM recent",
+			SQLConfig{
+				TableNames:      true,
+				CollectCommands: true,
+			},
+			SQLMetadata{
+				TablesCSV: "logs,archive,recent",
+				Commands:  []string{"SELECT", "INSERT", "SELECT"},
+				Operation: "INSERT",
+			},
+		},
 	} {
 		t.Run("", func(t *testing.T) {
 			oq, err := NewObfuscator(Config{SQL: tt.cfg}).ObfuscateSQLString(tt.in)
@@ -355,6 +417,8 @@ TABLE T4 UNION CORRESPONDING TABLE T3`,
 			assert.Equal(tt.metadata.TablesCSV, oq.Metadata.TablesCSV)
 			assert.Equal(tt.metadata.Commands, oq.Metadata.Commands)
 			assert.Equal(tt.metadata.Comments, oq.Metadata.Comments)
+			assert.Equal(tt.metadata.Operation, oq.Metadata.Operation)
+			assert.Equal(tt.metadata.Procedures, oq.Metadata.Procedures)
 			// Cost() includes the query text size, exclude it to see if it matches the size the metadata filter collected.
 			assert.Equal(oq.Cost()-int64(len(oq.Query)), oq.Metadata.Size)
 		})
@@ -1285,6 +1349,51 @@ func TestObfuscatorDBMSBehavior(t *testing.T) {
 				TableNames: true,
 			},
 		},
+		{
+			"SELECT u.[first name] FROM [dbo].[users] u JOIN [dbo].orders o ON o.[user id] = u.id",
+			"SELECT u.first name FROM dbo.users u JOIN dbo.orders o ON o.user id = u.id",
+			"dbo.users,dbo.orders",
+			SQLConfig{
+				DBMS:       DBMSSQLServer,
+				TableNames: true,
+			},
+		},
+		{
+			"MERGE INTO t USING s ON t.id = s.id AND s.v = 'a' WHEN MATCHED THEN DELETE OUTPUT $action, deleted.id;",
+			"MERGE INTO t USING s ON t.id = s.id AND s.v = ? WHEN MATCHED THEN DELETE OUTPUT $action, deleted.id",
+			"t",
+			SQLConfig{
+				DBMS:       DBMSSQLServer,
+				TableNames: true,
+			},
+		},
+		{
+			"SELECT `u`.`id` FROM `db`.`users` `u` WHERE `u`.`name` IN (\"Andy\", 'Bob') # trailing comment",
+			"SELECT u.id FROM db.users u WHERE u.name IN ( ? )",
+			"db.users",
+			SQLConfig{
+				DBMS:       DBMSMySQL,
+				TableNames: true,
+			},
+		},
+		{
+			"SELECT price$usd FROM items WHERE id = 1",
+			"SELECT price$usd FROM items WHERE id = ?",
+			"items",
+			SQLConfig{
+				DBMS:       DBMSMySQL,
+				TableNames: true,
+			},
+		},
+		{
+			`SELECT "u"."data" #> '{a,b}' FROM "public"."users" "u" WHERE "u"."id" = $1 AND "u"."bio" <> $bio$it's $1$bio$`,
+			`SELECT u.data #> ? FROM public.users u WHERE u.id = ? AND u.bio <> ?`,
+			"public.users",
+			SQLConfig{
+				DBMS:       DBMSPostgres,
+				TableNames: true,
+			},
+		},
 	} {
 		t.Run(tt.cfg.DBMS, func(t *testing.T) {
 			oq, err := NewObfuscator(Config{SQL: tt.cfg}).ObfuscateSQLString(tt.in)
@@ -1295,6 +1404,61 @@ func TestObfuscatorDBMSBehavior(t *testing.T) {
 	}
 }
 
+func TestObfuscatePartiQL(t *testing.T) {
+	assert := assert.New(t)
+	for _, tt := range []struct {
+		in, out string
+		tables  string
+	}{
+		{
+			`SELECT * FROM "Orders" WHERE OrderID = 100 AND Items = ['a', 'b']`,
+			`SELECT * FROM Orders WHERE OrderID = ? AND Items = [ ? ]`,
+			"Orders",
+		},
+		{
+			`INSERT INTO "Music" VALUE {'Artist': 'Acme', 'Title': 'Part}iQL', 'Tags': <<'a', 'b'>>, 'Awards': {'Grammys': [2020, 2018]}}`,
+			`INSERT INTO Music VALUE ?`,
+			"Music",
+		},
+		{
+			`UPDATE "Music" SET AwardsWon=1 SET Details={'Grammys': 2} WHERE Artist='Acme Band' AND Title=?`,
+			`UPDATE Music SET AwardsWon = ? SET Details = ? WHERE Artist = ? AND Title = ?`,
+			"Music",
+		},
+		{
+			`SELECT * FROM "Orders" WHERE CustomerID IN <<1, 2>>`,
+			`SELECT * FROM Orders WHERE CustomerID IN ?`,
+			"Orders",
+		},
+	} {
+		oq, err := NewObfuscator(Config{SQL: SQLConfig{TableNames: true}}).ObfuscatePartiQLString(tt.in)
+		if assert.NoError(err, tt.in) {
+			assert.Equal(tt.out, oq.Query)
+			assert.Equal(tt.tables, oq.Metadata.TablesCSV)
+		}
+	}
+
+	_, err := NewObfuscator(Config{}).ObfuscatePartiQLString(`INSERT INTO "Music" VALUE {'Artist': 'Acme'`)
+	assert.Error(err)
+}
+
+func TestObfuscateSQLStringForDBMS(t *testing.T) {
+	assert := assert.New(t)
+	o := NewObfuscator(Config{SQL: SQLConfig{Cache: true}})
+	defer o.Stop()
+	in := `SELECT * FROM users WHERE name IN ("Andy", "Bob")`
+	for i := 0; i < 2; i++ {
+		// the second run reads the results from the cache, which must not mix up the dialects
+		oq, err := o.ObfuscateSQLString(in)
+		assert.NoError(err)
+		assert.Equal("SELECT * FROM users WHERE name IN ( Andy, Bob )", oq.Query)
+		oq, err = o.ObfuscateSQLStringForDBMS(in, DBMSMySQL)
+		assert.NoError(err)
+		assert.Equal("SELECT * FROM users WHERE name IN ( ? )", oq.Query)
+		o.queryCache.Wait()
+	}
+}
+
 func TestSQLTokenizerIgnoreEscapeFalse(t *testing.T) {
 	cases := []sqlTokenizerTestCase{
 		{
@@ -87,6 +87,9 @@ const (
 	JSONAllKeysExist   // ?&
 	JSONDelete         // #-
 
+	// CollectionLiteral is a DynamoDB PartiQL struct ({...}) or bag (<<...>>) literal.
+	CollectionLiteral
+
 	// FilteredGroupable specifies that the given token has been discarded by one of the
 	// token filters and that it is groupable together with consecutive FilteredGroupable
 	// tokens.
@@ -163,6 +166,7 @@ var tokenKindStrings = map[TokenKind]string{
 	JSONAnyKeysExist:             "JSONAnyKeysExist",
 	JSONAllKeysExist:             "JSONAllKeysExist",
 	JSONDelete:                   "JSONDelete",
+	CollectionLiteral:            "CollectionLiteral",
 }
 
 func (k TokenKind) String() string {
@@ -178,6 +182,10 @@ const (
 	DBMSSQLServer = "mssql"
 	// DBMSPostgres is a PostgreSQL Server
 	DBMSPostgres = "postgresql"
+	// DBMSDynamoDB is Amazon DynamoDB, queried with PartiQL statements
+	DBMSDynamoDB = "dynamodb"
+	// DBMSMySQL is a MySQL or MariaDB Server
+	DBMSMySQL = "mysql"
 )
 
 const escapeCharacter = '\\'
@@ -327,7 +335,7 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			return TokenKind(ch), tkn.bytes()
 		case '[':
 			if tkn.cfg.DBMS == DBMSSQLServer {
-				return tkn.scanString(']', DoubleQuotedString)
+				return tkn.scanQualifiedIdentifier(ch, DoubleQuotedString)
 			}
 			return TokenKind(ch), tkn.bytes()
 		case '.':
@@ -403,6 +411,13 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			}
 		case '<':
 			switch tkn.lastChar {
+			case '<':
+				if tkn.cfg.DBMS == DBMSDynamoDB {
+					// PartiQL bag literal
+					tkn.advance()
+					return tkn.scanCollectionLiteral()
+				}
+				return TokenKind(ch), tkn.bytes()
 			case '>':
 				tkn.advance()
 				return NE, []byte("<>")
@@ -455,8 +470,18 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 		case '\'':
 			return tkn.scanString(ch, String)
 		case '"':
+			switch tkn.cfg.DBMS {
+			case DBMSMySQL:
+				// double quotes delimit strings in MySQL, unless the ANSI_QUOTES mode is enabled
+				return tkn.scanString(ch, String)
+			case DBMSPostgres:
+				return tkn.scanQualifiedIdentifier(ch, DoubleQuotedString)
+			}
 			return tkn.scanString(ch, DoubleQuotedString)
 		case '`':
+			if tkn.cfg.DBMS == DBMSMySQL {
+				return tkn.scanQualifiedIdentifier(ch, ID)
+			}
 			return tkn.scanString(ch, ID)
 		case '%':
 			if tkn.lastChar == '(' {
@@ -475,6 +500,15 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				// want to cover for this use-case too (e.g. $1$some text$1$).
 				return tkn.scanPreparedStatement('$')
 			}
+			switch tkn.cfg.DBMS {
+			case DBMSMySQL, DBMSSQLServer:
+				// there are no dollar-quoted strings in MySQL and SQL Server, where '$' may be part
+				// of an identifier, e.g. the "$action" column of the MERGE statement output
+				for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '$' {
+					tkn.advance()
+				}
+				return ID, tkn.bytes()
+			}
 			kind, tok := tkn.scanDollarQuotedString()
 			if kind == DollarQuotedFunc {
 				// this is considered an embedded query, we should try and
@@ -503,6 +537,10 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			}
 			fallthrough
 		case '{':
+			if ch == '{' && tkn.cfg.DBMS == DBMSDynamoDB {
+				// PartiQL struct literal
+				return tkn.scanCollectionLiteral()
+			}
 			if tkn.pos == 1 || tkn.curlys > 0 {
 				// Do not fully obfuscate top-level SQL escape sequences like {{[?=]call procedure-name[([parameter][,parameter]...)]}.
 				// We want these to display a bit more context than just a plain '?'
@@ -583,6 +621,10 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	}
 
 	t := tkn.bytes()
+	if delim := tkn.identifierDelimiter(); delim != 0 && tkn.lastChar == delim && t[len(t)-1] == '.' {
+		// a qualified name followed by a quoted identifier, e.g. u.[first name]
+		return tkn.scanQualifiedName(append([]byte(nil), t...), delim, ID)
+	}
 	// Space allows us to upper-case identifiers 256 bytes long or less without allocating heap
 	// storage for them, since space is allocated on the stack. A size of 256 bytes was chosen
 	// based on the allowed length of sql identifiers in various sql implementations.
@@ -594,6 +636,74 @@ func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	return ID, t
 }
 
+// scanQualifiedIdentifier scans a quoted identifier whose opening delimiter was read, along with the
+// following parts of its qualified name, e.g. [dbo].[users] or `db`.users. The parts are returned
+// without their delimiters and joined by dots, e.g. "dbo.users", so that the qualified table names
+// are collected as a whole.
+func (tkn *SQLTokenizer) scanQualifiedIdentifier(delim rune, kind TokenKind) (TokenKind, []byte) {
+	kind, part := tkn.scanString(closingDelimiter(delim), kind)
+	if kind == LexError || tkn.lastChar != '.' {
+		return kind, part
+	}
+	// scanString reuses the underlying buffer, so the name is copied before scanning the next parts
+	name := append([]byte(nil), part...)
+	tkn.advance()
+	return tkn.scanQualifiedName(append(name, '.'), delim, kind)
+}
+
+// scanQualifiedName scans the parts of a qualified name following name, which ends with a dot. The
+// parts are either quoted by delim or unquoted.
+func (tkn *SQLTokenizer) scanQualifiedName(name []byte, delim rune, kind TokenKind) (TokenKind, []byte) {
+	for {
+		switch {
+		case tkn.lastChar == delim:
+			tkn.advance()
+			k, part := tkn.scanString(closingDelimiter(delim), kind)
+			if k == LexError {
+				return k, part
+			}
+			name = append(name, part...)
+		case isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '*':
+			start := tkn.off - utf8.RuneLen(tkn.lastChar)
+			for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || strings.ContainsRune("*$", tkn.lastChar) {
+				tkn.advance()
+			}
+			end := tkn.off
+			if tkn.lastChar != EndChar {
+				end -= utf8.RuneLen(tkn.lastChar)
+			}
+			name = append(name, tkn.buf[start:end]...)
+		}
+		if tkn.lastChar != '.' {
+			return kind, name
+		}
+		tkn.advance()
+		name = append(name, '.')
+	}
+}
+
+// identifierDelimiter returns the opening delimiter of the quoted identifiers whose qualified names
+// are scanned as a whole in the configured dialect, or 0 if there is none.
+func (tkn *SQLTokenizer) identifierDelimiter() rune {
+	switch tkn.cfg.DBMS {
+	case DBMSMySQL:
+		return '`'
+	case DBMSPostgres:
+		return '"'
+	case DBMSSQLServer:
+		return '['
+	}
+	return 0
+}
+
+// closingDelimiter returns the closing delimiter of a quoted identifier opened by delim.
+func closingDelimiter(delim rune) rune {
+	if delim == '[' {
+		return ']'
+	}
+	return delim
+}
+
 func (tkn *SQLTokenizer) scanVariableIdentifier(prefix rune) (TokenKind, []byte) {
 	for tkn.advance(); tkn.lastChar != ')' && tkn.lastChar != EndChar; tkn.advance() {
 	}
@@ -692,6 +802,44 @@ func (tkn *SQLTokenizer) scanEscapeSequence(braces rune) (TokenKind, []byte) {
 	return EscapeSequence, tkn.bytes()
 }
 
+// scanCollectionLiteral scans a PartiQL struct or bag literal whose opening delimiter was read,
+// up to its closing delimiter. The nested literals and the strings it holds are skipped.
+func (tkn *SQLTokenizer) scanCollectionLiteral() (TokenKind, []byte) {
+	depth := 1
+	for depth > 0 {
+		ch := tkn.lastChar
+		tkn.advance()
+		switch ch {
+		case EndChar:
+			tkn.setErr("unexpected EOF in collection literal")
+			return LexError, tkn.bytes()
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '<', '>':
+			if tkn.lastChar == ch {
+				tkn.advance()
+				if ch == '<' {
+					depth++
+				} else {
+					depth--
+				}
+			}
+		case '\'', '"':
+			for tkn.lastChar != ch {
+				if tkn.lastChar == EndChar {
+					tkn.setErr("unexpected EOF in string")
+					return LexError, tkn.bytes()
+				}
+				tkn.advance()
+			}
+			tkn.advance()
+		}
+	}
+	return CollectionLiteral, tkn.bytes()
+}
+
 func (tkn *SQLTokenizer) scanBindVar() (TokenKind, []byte) {
 	token := ValueArg
 	if tkn.lastChar == ':' {
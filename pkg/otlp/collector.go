@@ -28,6 +28,8 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/otlp/internal/logsagentexporter"
 	"github.com/DataDog/datadog-agent/pkg/otlp/internal/serializerexporter"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/util/flavor"
@@ -40,7 +42,7 @@ var (
 	pipelineError = atomic.NewError(nil)
 )
 
-func getComponents(s serializer.MetricSerializer) (
+func getComponents(s serializer.MetricSerializer, logsAgentChannel chan *logsconfig.ChannelMessage) (
 	otelcol.Factories,
 	error,
 ) {
@@ -58,11 +60,16 @@ func getComponents(s serializer.MetricSerializer) (
 		errs = append(errs, err)
 	}
 
-	exporters, err := exporter.MakeFactoryMap(
+	exporterFactories := []exporter.Factory{
 		otlpexporter.NewFactory(),
 		serializerexporter.NewFactory(s),
 		loggingexporter.NewFactory(),
-	)
+	}
+	if logsAgentChannel != nil {
+		exporterFactories = append(exporterFactories, logsagentexporter.NewFactory(logsAgentChannel))
+	}
+
+	exporters, err := exporter.MakeFactoryMap(exporterFactories...)
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -102,6 +109,8 @@ type PipelineConfig struct {
 	MetricsEnabled bool
 	// TracesEnabled states whether OTLP traces support is enabled.
 	TracesEnabled bool
+	// LogsEnabled states whether OTLP logs support is enabled.
+	LogsEnabled bool
 	// Debug contains debug configurations.
 	Debug map[string]interface{}
 	// Metrics contains configuration options for the serializer metrics exporter
@@ -146,13 +155,19 @@ type CollectorStatus struct {
 }
 
 // NewPipeline defines a new OTLP pipeline.
-func NewPipeline(cfg PipelineConfig, s serializer.MetricSerializer) (*Pipeline, error) {
+// The logs are sent to logsAgentChannel, OTLP logs support is disabled if it is nil.
+func NewPipeline(cfg PipelineConfig, s serializer.MetricSerializer, logsAgentChannel chan *logsconfig.ChannelMessage) (*Pipeline, error) {
 	buildInfo, err := getBuildInfo()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get build info: %w", err)
 	}
 
-	factories, err := getComponents(s)
+	if cfg.LogsEnabled && logsAgentChannel == nil {
+		log.Warn("OTLP logs ingestion is enabled but the logs agent is not running, OTLP logs will be ignored")
+		cfg.LogsEnabled = false
+	}
+
+	factories, err := getComponents(s, logsAgentChannel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get components: %w", err)
 	}
@@ -196,8 +211,8 @@ func (p *Pipeline) Stop() {
 }
 
 // BuildAndStart builds and starts an OTLP pipeline
-func BuildAndStart(ctx context.Context, cfg config.Config, s serializer.MetricSerializer) (*Pipeline, error) {
-	p, err := NewPipelineFromAgentConfig(cfg, s)
+func BuildAndStart(ctx context.Context, cfg config.Config, s serializer.MetricSerializer, logsAgentChannel chan *logsconfig.ChannelMessage) (*Pipeline, error) {
+	p, err := NewPipelineFromAgentConfig(cfg, s, logsAgentChannel)
 	if err != nil {
 		return nil, err
 	}
@@ -211,14 +226,14 @@ func BuildAndStart(ctx context.Context, cfg config.Config, s serializer.MetricSe
 	return p, nil
 }
 
-func NewPipelineFromAgentConfig(cfg config.Config, s serializer.MetricSerializer) (*Pipeline, error) {
+func NewPipelineFromAgentConfig(cfg config.Config, s serializer.MetricSerializer, logsAgentChannel chan *logsconfig.ChannelMessage) (*Pipeline, error) {
 	pcfg, err := FromAgentConfig(cfg)
 	if err != nil {
 		pipelineError.Store(fmt.Errorf("config error: %w", err))
 		return nil, pipelineError.Load()
 	}
 
-	p, err := NewPipeline(pcfg, s)
+	p, err := NewPipeline(pcfg, s, logsAgentChannel)
 	if err != nil {
 		pipelineError.Store(fmt.Errorf("failed to build pipeline: %w", err))
 		return nil, pipelineError.Load()
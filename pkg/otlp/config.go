@@ -79,7 +79,8 @@ func FromAgentConfig(cfg config.Config) (PipelineConfig, error) {
 
 	metricsEnabled := cfg.GetBool(config.OTLPMetricsEnabled)
 	tracesEnabled := cfg.GetBool(config.OTLPTracesEnabled)
-	if !metricsEnabled && !tracesEnabled {
+	logsEnabled := cfg.GetBool(config.OTLPLogsEnabled)
+	if !metricsEnabled && !tracesEnabled && !logsEnabled {
 		errs = append(errs, fmt.Errorf("at least one OTLP signal needs to be enabled"))
 	}
 	metricsConfig := readConfigSection(cfg, config.OTLPMetrics)
@@ -90,6 +91,7 @@ func FromAgentConfig(cfg config.Config) (PipelineConfig, error) {
 		TracePort:          tracePort,
 		MetricsEnabled:     metricsEnabled,
 		TracesEnabled:      tracesEnabled,
+		LogsEnabled:        logsEnabled,
 		Metrics:            metricsConfig.ToStringMap(),
 		Debug:              debugConfig.ToStringMap(),
 	}, multierr.Combine(errs...)
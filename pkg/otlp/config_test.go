@@ -362,6 +362,23 @@ func TestFromAgentConfigMetrics(t *testing.T) {
 	}
 }
 
+func TestFromAgentConfigLogs(t *testing.T) {
+	cfg, err := testutil.LoadConfig("./testdata/logs/enabled.yaml")
+	require.NoError(t, err)
+	pcfg, err := FromAgentConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, PipelineConfig{
+		OTLPReceiverConfig: testutil.OTLPConfigFromPorts("localhost", 5678, 0),
+		TracePort:          5003,
+		LogsEnabled:        true,
+		Metrics: map[string]interface{}{
+			"enabled":         false,
+			"tag_cardinality": "low",
+		},
+		Debug: map[string]interface{}{},
+	}, pcfg)
+}
+
 func TestFromAgentConfigDebug(t *testing.T) {
 	tests := []struct {
 		path      string
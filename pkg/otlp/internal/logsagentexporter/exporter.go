@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package logsagentexporter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/otlp/attributes"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const (
+	// serviceNameAttribute is the resource attribute holding the service of the logs.
+	serviceNameAttribute = "service.name"
+	// logSourceAttribute is the attribute overriding the source of the logs.
+	logSourceAttribute = "datadog.log.source"
+	// defaultLogSource is the source of the logs without logSourceAttribute.
+	defaultLogSource = "otlp_log_ingestion"
+)
+
+// exporter translates OTLP log records into logs agent messages
+// and sends them to the logs agent channel.
+type exporter struct {
+	logger           *zap.Logger
+	logsAgentChannel chan *config.ChannelMessage
+}
+
+func newExporter(logger *zap.Logger, logsAgentChannel chan *config.ChannelMessage) *exporter {
+	return &exporter{
+		logger:           logger,
+		logsAgentChannel: logsAgentChannel,
+	}
+}
+
+// ConsumeLogs implements the consumer.ConsumeLogsFunc interface.
+func (e *exporter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		resource := rl.Resource().Attributes()
+		tags := attributes.TagsFromAttributes(resource)
+		service := stringAttribute(resource, serviceNameAttribute)
+		source := stringAttribute(resource, logSourceAttribute)
+
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			lrs := sls.At(j).LogRecords()
+			for k := 0; k < lrs.Len(); k++ {
+				msg := translateLogRecord(lrs.At(k), service, source, tags)
+				select {
+				case e.logsAgentChannel <- msg:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// translateLogRecord builds the logs agent message of a log record.
+func translateLogRecord(lr plog.LogRecord, service, source string, tags []string) *config.ChannelMessage {
+	if recordSource := stringAttribute(lr.Attributes(), logSourceAttribute); recordSource != "" {
+		source = recordSource
+	}
+	if source == "" {
+		source = defaultLogSource
+	}
+
+	timestamp := lr.Timestamp()
+	if timestamp == 0 {
+		timestamp = lr.ObservedTimestamp()
+	}
+	var utcTime time.Time
+	if timestamp != 0 {
+		utcTime = timestamp.AsTime().UTC()
+	}
+
+	return &config.ChannelMessage{
+		Content:   logContent(lr),
+		Timestamp: utcTime,
+		Service:   service,
+		Source:    source,
+		Status:    logStatus(lr),
+		Tags:      tags,
+	}
+}
+
+// logContent returns the body of the log record, as a JSON object with the record attributes
+// and the trace context if the record has any so that they are parsed as log attributes.
+func logContent(lr plog.LogRecord) []byte {
+	body := lr.Body().AsString()
+	if lr.Attributes().Len() == 0 && lr.TraceID().IsEmpty() {
+		return []byte(body)
+	}
+
+	content := lr.Attributes().AsRaw()
+	delete(content, logSourceAttribute)
+	content["message"] = body
+	if traceID := lr.TraceID(); !traceID.IsEmpty() {
+		content["otel.trace_id"] = traceID.String()
+		content["dd.trace_id"] = strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10)
+	}
+	if spanID := lr.SpanID(); !spanID.IsEmpty() {
+		content["otel.span_id"] = spanID.String()
+		content["dd.span_id"] = strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10)
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return []byte(body)
+	}
+	return raw
+}
+
+// logStatus maps the severity of the log record to a logs agent status.
+func logStatus(lr plog.LogRecord) string {
+	switch severity := lr.SeverityNumber(); {
+	case severity >= plog.SeverityNumberFatal:
+		return message.StatusCritical
+	case severity >= plog.SeverityNumberError:
+		return message.StatusError
+	case severity >= plog.SeverityNumberWarn:
+		return message.StatusWarning
+	case severity >= plog.SeverityNumberInfo:
+		return message.StatusInfo
+	case severity >= plog.SeverityNumberTrace:
+		return message.StatusDebug
+	}
+
+	switch strings.ToLower(lr.SeverityText()) {
+	case "fatal", "critical":
+		return message.StatusCritical
+	case "error":
+		return message.StatusError
+	case "warn", "warning":
+		return message.StatusWarning
+	case "debug", "trace":
+		return message.StatusDebug
+	}
+	return message.StatusInfo
+}
+
+// stringAttribute returns the value of a string attribute, an empty string if it is not set.
+func stringAttribute(attrs pcommon.Map, key string) string {
+	if value, ok := attrs.Get(key); ok {
+		return value.Str()
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package logsagentexporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newLogs() (plog.Logs, plog.LogRecordSlice) {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	rl.Resource().Attributes().PutStr("deployment.environment", "prod")
+	return ld, rl.ScopeLogs().AppendEmpty().LogRecords()
+}
+
+func consume(t *testing.T, ld plog.Logs) []*config.ChannelMessage {
+	ch := make(chan *config.ChannelMessage, ld.LogRecordCount())
+	require.NoError(t, newExporter(zap.NewNop(), ch).ConsumeLogs(context.Background(), ld))
+	close(ch)
+	var msgs []*config.ChannelMessage
+	for msg := range ch {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestConsumeLogs(t *testing.T) {
+	ld, lrs := newLogs()
+	ts := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	lr := lrs.AppendEmpty()
+	lr.Body().SetStr("payment accepted")
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	lr.SetSeverityNumber(plog.SeverityNumberWarn2)
+
+	msgs := consume(t, ld)
+	require.Len(t, msgs, 1)
+	msg := msgs[0]
+	assert.Equal(t, []byte("payment accepted"), msg.Content)
+	assert.Equal(t, ts, msg.Timestamp)
+	assert.Equal(t, "checkout", msg.Service)
+	assert.Equal(t, defaultLogSource, msg.Source)
+	assert.Equal(t, message.StatusWarning, msg.Status)
+	assert.Contains(t, msg.Tags, "env:prod")
+}
+
+func TestConsumeLogsObservedTimestamp(t *testing.T) {
+	ld, lrs := newLogs()
+	ts := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	lrs.AppendEmpty().SetObservedTimestamp(pcommon.NewTimestampFromTime(ts))
+	lrs.AppendEmpty()
+
+	msgs := consume(t, ld)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, ts, msgs[0].Timestamp)
+	assert.True(t, msgs[1].Timestamp.IsZero())
+}
+
+func TestConsumeLogsSourceOverride(t *testing.T) {
+	ld, lrs := newLogs()
+	ld.ResourceLogs().At(0).Resource().Attributes().PutStr(logSourceAttribute, "nginx")
+	lrs.AppendEmpty().Body().SetStr("from resource")
+	lr := lrs.AppendEmpty()
+	lr.Body().SetStr("from record")
+	lr.Attributes().PutStr(logSourceAttribute, "haproxy")
+
+	msgs := consume(t, ld)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, "nginx", msgs[0].Source)
+	assert.Equal(t, []byte("from resource"), msgs[0].Content)
+	assert.Equal(t, "haproxy", msgs[1].Source)
+	assert.JSONEq(t, `{"message":"from record"}`, string(msgs[1].Content))
+}
+
+func TestConsumeLogsAttributesAndTraceContext(t *testing.T) {
+	ld, lrs := newLogs()
+	lr := lrs.AppendEmpty()
+	lr.Body().SetStr("payment accepted")
+	lr.Attributes().PutStr("http.method", "POST")
+	lr.Attributes().PutInt("http.status_code", 200)
+	lr.SetTraceID([16]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2})
+	lr.SetSpanID([8]byte{0, 0, 0, 0, 0, 0, 0, 3})
+
+	msgs := consume(t, ld)
+	require.Len(t, msgs, 1)
+	assert.JSONEq(t, `{
+		"message": "payment accepted",
+		"http.method": "POST",
+		"http.status_code": 200,
+		"otel.trace_id": "00000000000000010000000000000002",
+		"otel.span_id": "0000000000000003",
+		"dd.trace_id": "2",
+		"dd.span_id": "3"
+	}`, string(msgs[0].Content))
+}
+
+func TestLogStatus(t *testing.T) {
+	tests := []struct {
+		number   plog.SeverityNumber
+		text     string
+		expected string
+	}{
+		{number: plog.SeverityNumberTrace, expected: message.StatusDebug},
+		{number: plog.SeverityNumberDebug4, expected: message.StatusDebug},
+		{number: plog.SeverityNumberInfo, expected: message.StatusInfo},
+		{number: plog.SeverityNumberWarn, expected: message.StatusWarning},
+		{number: plog.SeverityNumberError3, expected: message.StatusError},
+		{number: plog.SeverityNumberFatal4, expected: message.StatusCritical},
+		{text: "ERROR", expected: message.StatusError},
+		{text: "warning", expected: message.StatusWarning},
+		{text: "unknown", expected: message.StatusInfo},
+		{expected: message.StatusInfo},
+	}
+	for _, test := range tests {
+		lr := plog.NewLogRecord()
+		lr.SetSeverityNumber(test.number)
+		lr.SetSeverityText(test.text)
+		assert.Equal(t, test.expected, logStatus(lr), "severity %v %q", test.number, test.text)
+	}
+}
+
+func TestConsumeLogsCanceled(t *testing.T) {
+	ld, lrs := newLogs()
+	lrs.AppendEmpty()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := newExporter(zap.NewNop(), make(chan *config.ChannelMessage)).ConsumeLogs(ctx, ld)
+	assert.ErrorIs(t, err, context.Canceled)
+}
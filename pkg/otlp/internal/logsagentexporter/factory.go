@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package logsagentexporter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	exp "go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+const (
+	// TypeStr defines the logs agent exporter type string.
+	TypeStr   = "logsagent"
+	stability = component.StabilityLevelAlpha
+)
+
+// exporterConfig defines configuration for the logs agent exporter.
+type exporterConfig struct {
+	// squash ensures fields are correctly decoded in embedded struct
+	exporterhelper.TimeoutSettings `mapstructure:",squash"`
+	exporterhelper.QueueSettings   `mapstructure:",squash"`
+}
+
+var _ component.Config = (*exporterConfig)(nil)
+
+func newDefaultConfig() component.Config {
+	return &exporterConfig{
+		// Disable timeout; we don't do any request on the ConsumeLogs call.
+		TimeoutSettings: exporterhelper.TimeoutSettings{Timeout: 0},
+		QueueSettings:   exporterhelper.NewDefaultQueueSettings(),
+	}
+}
+
+type factory struct {
+	logsAgentChannel chan *config.ChannelMessage
+}
+
+// NewFactory creates a new logs agent exporter factory, sending the logs to logsAgentChannel.
+func NewFactory(logsAgentChannel chan *config.ChannelMessage) exp.Factory {
+	f := &factory{logsAgentChannel}
+
+	return exp.NewFactory(
+		TypeStr,
+		newDefaultConfig,
+		exp.WithLogs(f.createLogsExporter, stability),
+	)
+}
+
+func (f *factory) createLogsExporter(ctx context.Context, params exp.CreateSettings, c component.Config) (exp.Logs, error) {
+	cfg := c.(*exporterConfig)
+
+	newExp := newExporter(params.Logger, f.logsAgentChannel)
+
+	return exporterhelper.NewLogsExporter(ctx, params, cfg, newExp.ConsumeLogs,
+		exporterhelper.WithQueue(cfg.QueueSettings),
+		exporterhelper.WithTimeout(cfg.TimeoutSettings),
+	)
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package logsagentexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestNewFactory(t *testing.T) {
+	factory := NewFactory(make(chan *config.ChannelMessage))
+	cfg := factory.CreateDefaultConfig()
+	assert.NoError(t, componenttest.CheckConfigStruct(cfg))
+	_, ok := factory.CreateDefaultConfig().(*exporterConfig)
+	assert.True(t, ok)
+}
+
+func TestNewLogsExporter(t *testing.T) {
+	factory := NewFactory(make(chan *config.ChannelMessage))
+	cfg := factory.CreateDefaultConfig()
+	set := exportertest.NewNopCreateSettings()
+	exp, err := factory.CreateLogsExporter(context.Background(), set, cfg)
+	assert.NoError(t, err)
+	assert.NotNil(t, exp)
+}
@@ -99,6 +99,29 @@ func buildMetricsMap(cfg PipelineConfig) (*confmap.Conf, error) {
 	return baseMap, err
 }
 
+// defaultLogsConfig is the logs OTLP pipeline configuration.
+const defaultLogsConfig string = `
+receivers:
+  otlp:
+
+processors:
+  batch:
+    timeout: 10s
+
+exporters:
+  logsagent:
+
+service:
+  telemetry:
+    metrics:
+      level: none
+  pipelines:
+    logs:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [logsagent]
+`
+
 func buildReceiverMap(otlpReceiverConfig map[string]interface{}) *confmap.Conf {
 	return confmap.NewFromStringMap(map[string]interface{}{
 		"receivers": map[string]interface{}{"otlp": otlpReceiverConfig},
@@ -122,6 +145,13 @@ func buildMap(cfg PipelineConfig) (*confmap.Conf, error) {
 		err = retMap.Merge(metricsMap)
 		errs = append(errs, err)
 	}
+	if cfg.LogsEnabled {
+		logsMap, err := configutils.NewMapFromYAMLString(defaultLogsConfig)
+		errs = append(errs, err)
+
+		err = retMap.Merge(logsMap)
+		errs = append(errs, err)
+	}
 	if cfg.shouldSetLoggingSection() {
 		m := map[string]interface{}{
 			"exporters": map[string]interface{}{
@@ -144,6 +174,14 @@ func buildMap(cfg PipelineConfig) (*confmap.Conf, error) {
 				m[key] = []interface{}{"logging"}
 			}
 		}
+		if cfg.LogsEnabled {
+			key := buildKey("service", "pipelines", "logs", "exporters")
+			if v, ok := retMap.Get(key).([]interface{}); ok {
+				m[key] = append(v, "logging")
+			} else {
+				m[key] = []interface{}{"logging"}
+			}
+		}
 		errs = append(errs, retMap.Merge(confmap.NewFromStringMap(m)))
 	}
 
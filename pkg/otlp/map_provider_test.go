@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/confmap"
 
+	logsconfig "github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/otlp/internal/testutil"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 )
@@ -359,6 +360,87 @@ func TestNewMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "only gRPC, only Logs",
+			pcfg: PipelineConfig{
+				OTLPReceiverConfig: testutil.OTLPConfigFromPorts("bindhost", 1234, 0),
+				LogsEnabled:        true,
+				Debug: map[string]interface{}{
+					"loglevel": "disabled",
+				},
+			},
+			ocfg: map[string]interface{}{
+				"receivers": map[string]interface{}{
+					"otlp": map[string]interface{}{
+						"protocols": map[string]interface{}{
+							"grpc": map[string]interface{}{
+								"endpoint": "bindhost:1234",
+							},
+						},
+					},
+				},
+				"processors": map[string]interface{}{
+					"batch": map[string]interface{}{
+						"timeout": "10s",
+					},
+				},
+				"exporters": map[string]interface{}{
+					"logsagent": interface{}(nil),
+				},
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{"level": "none"}},
+					"pipelines": map[string]interface{}{
+						"logs": map[string]interface{}{
+							"receivers":  []interface{}{"otlp"},
+							"processors": []interface{}{"batch"},
+							"exporters":  []interface{}{"logsagent"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "only gRPC, only Logs, logging info",
+			pcfg: PipelineConfig{
+				OTLPReceiverConfig: testutil.OTLPConfigFromPorts("bindhost", 1234, 0),
+				LogsEnabled:        true,
+				Debug: map[string]interface{}{
+					"loglevel": "info",
+				},
+			},
+			ocfg: map[string]interface{}{
+				"receivers": map[string]interface{}{
+					"otlp": map[string]interface{}{
+						"protocols": map[string]interface{}{
+							"grpc": map[string]interface{}{
+								"endpoint": "bindhost:1234",
+							},
+						},
+					},
+				},
+				"processors": map[string]interface{}{
+					"batch": map[string]interface{}{
+						"timeout": "10s",
+					},
+				},
+				"exporters": map[string]interface{}{
+					"logsagent": interface{}(nil),
+					"logging": map[string]interface{}{
+						"loglevel": "info",
+					},
+				},
+				"service": map[string]interface{}{
+					"telemetry": map[string]interface{}{"metrics": map[string]interface{}{"level": "none"}},
+					"pipelines": map[string]interface{}{
+						"logs": map[string]interface{}{
+							"receivers":  []interface{}{"otlp"},
+							"processors": []interface{}{"batch"},
+							"exporters":  []interface{}{"logsagent", "logging"},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "only HTTP, only metrics, logging debug",
 			pcfg: PipelineConfig{
@@ -513,6 +595,7 @@ func TestUnmarshal(t *testing.T) {
 		TracePort:          5001,
 		MetricsEnabled:     true,
 		TracesEnabled:      true,
+		LogsEnabled:        true,
 		Metrics: map[string]interface{}{
 			"delta_ttl":                                2000,
 			"resource_attributes_as_tags":              true,
@@ -525,7 +608,7 @@ func TestUnmarshal(t *testing.T) {
 		},
 	})
 	require.NoError(t, err)
-	components, err := getComponents(&serializer.MockSerializer{})
+	components, err := getComponents(&serializer.MockSerializer{}, make(chan *logsconfig.ChannelMessage))
 	require.NoError(t, err)
 
 	_, err = provider.Get(context.Background(), components)
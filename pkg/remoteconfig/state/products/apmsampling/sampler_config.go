@@ -14,6 +14,27 @@ type SamplerEnvConfig struct {
 	PrioritySamplerTargetTPS *float64 `json:"priority_sampler_target_TPS"`
 	ErrorsSamplerTargetTPS   *float64 `json:"errors_sampler_target_TPS"`
 	RareSamplerEnabled       *bool    `json:"rare_sampler_enabled"`
+	// SamplingRules replaces the sampling rules of the agent when set.
+	SamplingRules *[]SamplingRule `json:"sampling_rules"`
+}
+
+type SamplingRule struct {
+	Name         string            `json:"name"`
+	Match        SamplingRuleMatch `json:"match"`
+	SampleRate   float64           `json:"sample_rate"`
+	MaxPerSecond float64           `json:"max_per_second"`
+}
+
+type SamplingRuleMatch struct {
+	Service  string            `json:"service"`
+	Name     string            `json:"name"`
+	Resource string            `json:"resource"`
+	Tags     []SamplingRuleTag `json:"tags"`
+}
+
+type SamplingRuleTag struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
 type EnvAndConfig struct {
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/event"
 	"github.com/DataDog/datadog-agent/pkg/trace/filters"
 	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
@@ -49,16 +50,22 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	SpanRules             *filters.SpanRules
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
+	TailSampler           *tailSampler
+	RuleSampler           *ruleSampler
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
 	StatsWriter           *writer.StatsWriter
 	RemoteConfigHandler   *remoteconfighandler.RemoteConfigHandler
 	TelemetryCollector    telemetry.TelemetryCollector
 	DebugServer           *api.DebugServer
+	// Recorder records the processed chunks along with their sampling decision, to be
+	// inspected through the debug server.
+	Recorder *inspect.Recorder
 
 	// obfuscator is used to obfuscate sensitive data from various span
 	// tags based on their type.
@@ -92,15 +99,18 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 	if oconf.Statsd == nil {
 		oconf.Statsd = metrics.Client
 	}
+	recorder := inspect.NewRecorder(conf.InspectBufferSize)
 	agnt := &Agent{
 		Concentrator:          stats.NewConcentrator(conf, statsChan, time.Now()),
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsChan),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		SpanRules:             filters.NewSpanRules(conf.SpanRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(conf),
 		NoPrioritySampler:     sampler.NewNoPrioritySampler(conf),
+		RuleSampler:           newRuleSampler(conf.SamplingRules),
 		EventProcessor:        newEventProcessor(conf),
 		StatsWriter:           writer.NewStatsWriter(conf, statsChan, telemetryCollector),
 		obfuscator:            obfuscate.NewObfuscator(oconf),
@@ -108,12 +118,14 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		In:                    in,
 		conf:                  conf,
 		ctx:                   ctx,
-		DebugServer:           api.NewDebugServer(conf),
+		DebugServer:           api.NewDebugServer(conf, recorder),
+		Recorder:              recorder,
 	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt, telemetryCollector)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf)
-	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler)
+	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler, agnt.RuleSampler)
 	agnt.TraceWriter = writer.NewTraceWriter(conf, agnt.PrioritySampler, agnt.ErrorsSampler, agnt.RareSampler, telemetryCollector)
+	agnt.TailSampler = newTailSampler(conf.TailSampling, agnt.TraceWriter.In)
 	return agnt
 }
 
@@ -126,6 +138,7 @@ func (a *Agent) Run() {
 		a.PrioritySampler,
 		a.ErrorsSampler,
 		a.NoPrioritySampler,
+		a.RuleSampler,
 		a.EventProcessor,
 		a.OTLPReceiver,
 		a.RemoteConfigHandler,
@@ -134,6 +147,9 @@ func (a *Agent) Run() {
 		starter.Start()
 	}
 
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 	go a.TraceWriter.Run()
 	go a.StatsWriter.Run()
 
@@ -183,6 +199,10 @@ func (a *Agent) loop() {
 			if err := a.Receiver.Stop(); err != nil {
 				log.Error(err)
 			}
+			if a.TailSampler != nil {
+				// the buffered traces are decided before the trace writer is stopped
+				a.TailSampler.Stop()
+			}
 			for _, stopper := range []interface{ Stop() }{
 				a.Concentrator,
 				a.ClientStatsAggregator,
@@ -191,6 +211,7 @@ func (a *Agent) loop() {
 				a.PrioritySampler,
 				a.ErrorsSampler,
 				a.NoPrioritySampler,
+				a.RuleSampler,
 				a.RareSampler,
 				a.EventProcessor,
 				a.OTLPReceiver,
@@ -236,6 +257,8 @@ func (a *Agent) Process(p *api.Payload) {
 	defer timing.Since("datadog.trace_agent.internal.process_payload_ms", now)
 	ts := p.Source
 	ss := new(writer.SampledChunks)
+	// tailHeader holds the metadata of the payload for the chunks buffered by the tail sampler
+	var tailHeader *pb.TracerPayload
 	statsInput := stats.NewStatsInput(len(p.TracerPayload.Chunks), p.TracerPayload.ContainerID, p.ClientComputedStats, a.conf)
 
 	p.TracerPayload.Env = traceutil.NormalizeTag(p.TracerPayload.Env)
@@ -252,10 +275,12 @@ func (a *Agent) Process(p *api.Payload) {
 
 		tracen := int64(len(chunk.Spans))
 		ts.SpansReceived.Add(tracen)
+		ins := newChunkInspector(a.Recorder, chunk)
 		err := a.normalizeTrace(p.Source, chunk.Spans)
 		if err != nil {
 			log.Debugf("Dropping invalid trace: %s", err)
 			ts.SpansDropped.Add(tracen)
+			ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionFiltered, stepNormalizer)
 			p.RemoveChunk(i)
 			continue
 		}
@@ -263,10 +288,32 @@ func (a *Agent) Process(p *api.Payload) {
 		// Root span is used to carry some trace-level metadata, such as sampling rate and priority.
 		root := traceutil.GetRoot(chunk.Spans)
 		normalizeChunk(chunk, root)
+		ins.observe(stepNormalizer, chunk.Spans)
+		// The span rules are applied once the priority and origin are read from the root,
+		// as it may be dropped, and before the traces are sampled and the stats are computed.
+		spans := chunk.Spans
+		chunk.Spans = a.SpanRules.Apply(chunk.Spans)
+		ins.observe(stepSpanRules, chunk.Spans)
+		if n := int64(len(chunk.Spans)); n < tracen {
+			ts.SpansFiltered.Add(tracen - n)
+			ins.dropSpans(stepSpanRules, int(tracen-n))
+			if n == 0 {
+				log.Debugf("Trace rejected as all its spans were dropped by span rules.")
+				ts.TracesFiltered.Inc()
+				// the span rules leave the spans in place when all of them are dropped
+				chunk.Spans = spans
+				ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionFiltered, stepSpanRules)
+				p.RemoveChunk(i)
+				continue
+			}
+			tracen = n
+			root = traceutil.GetRoot(chunk.Spans)
+		}
 		if !a.Blacklister.Allows(root) {
 			log.Debugf("Trace rejected by ignore resources rules. root: %v", root)
 			ts.TracesFiltered.Inc()
 			ts.SpansFiltered.Add(tracen)
+			ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionFiltered, stepIgnoreResources)
 			p.RemoveChunk(i)
 			continue
 		}
@@ -275,6 +322,7 @@ func (a *Agent) Process(p *api.Payload) {
 			log.Debugf("Trace rejected as it fails to meet tag requirements. root: %v", root)
 			ts.TracesFiltered.Inc()
 			ts.SpansFiltered.Add(tracen)
+			ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionFiltered, stepFilterTags)
 			p.RemoveChunk(i)
 			continue
 		}
@@ -291,13 +339,18 @@ func (a *Agent) Process(p *api.Payload) {
 			if a.ModifySpan != nil {
 				a.ModifySpan(chunk, span)
 			}
+			// the global tags and the span modifications are not reported
+			ins.observeSpan("", span)
 			a.obfuscateSpan(span)
+			ins.observeSpan(obfuscatorStep(span), span)
 			a.Truncate(span)
+			ins.observeSpan(stepTruncator, span)
 			if p.ClientComputedTopLevel {
 				traceutil.UpdateTracerTopLevel(span)
 			}
 		}
 		a.Replacer.Replace(chunk.Spans)
+		ins.observe(stepReplaceTags, chunk.Spans)
 
 		a.setRootSpanTags(root)
 		if !p.ClientComputedTopLevel {
@@ -329,16 +382,35 @@ func (a *Agent) Process(p *api.Payload) {
 			statsInput.Traces = append(statsInput.Traces, pt)
 		}
 
-		numEvents, keep, filteredChunk := a.sample(now, ts, pt)
-		if !keep {
-			keep = sampler.ApplySpanSampling(chunk)
+		numEvents, keep, decidedBy, filteredChunk := a.sample(now, ts, pt)
+		if !keep && sampler.ApplySpanSampling(chunk) {
+			keep = true
+			decidedBy = decidedBySpanSampling
 		}
-		if !keep {
-			if numEvents == 0 {
-				// the trace was dropped and no analyzed span were kept
-				p.RemoveChunk(i)
-				continue
+		if !keep && numEvents == 0 {
+			// the trace was dropped and no analyzed span were kept
+			if a.TailSampler != nil && !isUserDrop(chunk) {
+				// the trace may still be kept once all its chunks are received
+				if tailHeader == nil {
+					tailHeader = payloadHeader(p.TracerPayload)
+				}
+				a.TailSampler.Add(now, tailHeader, chunk)
+				ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionBuffered, decidedByTailSampling)
+			} else {
+				ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionDropped, decidedBy)
 			}
+			p.RemoveChunk(i)
+			continue
+		}
+		if keep {
+			ins.record(a.Recorder, p.TracerPayload.Env, chunk, inspect.DecisionKept, decidedBy)
+		} else {
+			ins.record(a.Recorder, p.TracerPayload.Env, filteredChunk, inspect.DecisionKept, decidedByAnalyzedSpans)
+		}
+		if a.TailSampler != nil {
+			a.TailSampler.Observe(now, chunk)
+		}
+		if !keep {
 			// The sampler step filtered a subset of spans in the chunk. The new
 			// filtered chunk is added to the TracerPayload to be sent to
 			// TraceWriter. The complete chunk is still sent to the stats
@@ -464,6 +536,13 @@ func (a *Agent) ProcessStats(in pb.ClientStatsPayload, lang, tracerVersion strin
 	a.ClientStatsAggregator.In <- a.processStats(in, lang, tracerVersion)
 }
 
+// isUserDrop returns true if the chunk has been dropped by the user, such chunks are
+// never kept by the tail sampler.
+func isUserDrop(chunk *pb.TraceChunk) bool {
+	priority, hasPriority := sampler.GetSamplingPriority(chunk)
+	return hasPriority && priority < 0
+}
+
 func isManualUserDrop(priority sampler.SamplingPriority, pt traceutil.ProcessedTrace) bool {
 	if priority != sampler.PriorityUserDrop {
 		return false
@@ -475,8 +554,9 @@ func isManualUserDrop(priority sampler.SamplingPriority, pt traceutil.ProcessedT
 	return dm == manualSampling
 }
 
-// sample reports the number of events found in pt and whether the chunk should be kept as a trace.
-func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) (numEvents int64, keep bool, filteredChunk *pb.TraceChunk) {
+// sample reports the number of events found in pt and whether the chunk should be kept as a trace,
+// along with the name of the sampler which took the decision.
+func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) (numEvents int64, keep bool, decidedBy string, filteredChunk *pb.TraceChunk) {
 	priority, hasPriority := sampler.GetSamplingPriority(pt.TraceChunk)
 
 	if hasPriority {
@@ -486,15 +566,25 @@ func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedT
 	}
 	if a.conf.HasFeature("error_rare_sample_tracer_drop") {
 		if isManualUserDrop(priority, pt) {
-			return 0, false, nil
+			return 0, false, decidedByManualDrop, nil
 		}
 	} else { // This path to be deleted once manualUserDrop detection is available on all tracers for P < 1.
 		if priority < 0 {
-			return 0, false, nil
+			return 0, false, decidedByManualDrop, nil
 		}
 	}
 
-	sampled := a.runSamplers(now, pt, hasPriority)
+	var sampled bool
+	if !hasPriority || priority == sampler.PriorityAutoDrop || priority == sampler.PriorityAutoKeep {
+		// the sampling rules only apply to the traces without a user-set sampling priority
+		var rule string
+		if sampled, rule = a.RuleSampler.sample(now, pt.TraceChunk, pt.Root); rule != "" {
+			sampled, decidedBy = a.applySamplingRule(now, pt, hasPriority, sampled, rule)
+		}
+	}
+	if decidedBy == "" {
+		sampled, decidedBy = a.runSamplers(now, pt, hasPriority)
+	}
 
 	filteredChunk = pt.TraceChunk
 	if !sampled {
@@ -507,12 +597,44 @@ func (a *Agent) sample(now time.Time, ts *info.TagStats, pt traceutil.ProcessedT
 	ts.EventsExtracted.Add(numExtracted)
 	ts.EventsSampled.Add(numEvents)
 
-	return numEvents, sampled, filteredChunk
+	return numEvents, sampled, decidedBy, filteredChunk
+}
+
+// applySamplingRule applies the decision of a sampling rule to pt, setting the priority of the
+// chunk accordingly. The chunk is still counted by the PrioritySampler so that the rates fed back
+// to the tracers are not skewed, and the errors and rare traces dropped by the rule are still
+// caught by the ErrorsSampler and the RareSampler.
+func (a *Agent) applySamplingRule(now time.Time, pt traceutil.ProcessedTrace, hasPriority, keep bool, rule string) (bool, string) {
+	if hasPriority {
+		// only the counts of the PrioritySampler are used, the rule overrides its decision
+		a.PrioritySampler.Sample(now, pt.TraceChunk, pt.Root, pt.TracerEnv, pt.ClientDroppedP0sWeight)
+	}
+	priority := pt.TraceChunk.Priority
+	if keep {
+		pt.TraceChunk.Priority = int32(sampler.PriorityUserKeep)
+	} else {
+		pt.TraceChunk.Priority = int32(sampler.PriorityUserDrop)
+	}
+	// with the priority set by the rule, the RareSampler records the spans of the kept traces
+	// and catches the rare traces among the dropped ones
+	rare := hasPriority && a.RareSampler.Sample(now, pt.TraceChunk, pt.TracerEnv)
+	if keep {
+		return true, decidedByRuleSampler + ":" + rule
+	}
+	if traceContainsError(pt.TraceChunk.Spans) && a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv) {
+		pt.TraceChunk.Priority = priority
+		return true, decidedByErrorsSampler
+	}
+	if rare {
+		pt.TraceChunk.Priority = priority
+		return true, decidedByRareSampler
+	}
+	return false, decidedByRuleSampler + ":" + rule
 }
 
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
-// along with the sampling rate.
-func (a *Agent) runSamplers(now time.Time, pt traceutil.ProcessedTrace, hasPriority bool) bool {
+// along with the name of the sampler which took it.
+func (a *Agent) runSamplers(now time.Time, pt traceutil.ProcessedTrace, hasPriority bool) (bool, string) {
 	if hasPriority {
 		return a.samplePriorityTrace(now, pt)
 	}
@@ -522,25 +644,28 @@ func (a *Agent) runSamplers(now time.Time, pt traceutil.ProcessedTrace, hasPrior
 // samplePriorityTrace samples traces with priority set on them. PrioritySampler and
 // ErrorSampler are run in parallel. The RareSampler catches traces with rare top-level
 // or measured spans that are not caught by PrioritySampler and ErrorSampler.
-func (a *Agent) samplePriorityTrace(now time.Time, pt traceutil.ProcessedTrace) bool {
+func (a *Agent) samplePriorityTrace(now time.Time, pt traceutil.ProcessedTrace) (bool, string) {
 	// run this early to make sure the signature gets counted by the RareSampler.
 	rare := a.RareSampler.Sample(now, pt.TraceChunk, pt.TracerEnv)
 	if a.PrioritySampler.Sample(now, pt.TraceChunk, pt.Root, pt.TracerEnv, pt.ClientDroppedP0sWeight) {
-		return true
+		return true, decidedByPrioritySampler
 	}
 	if traceContainsError(pt.TraceChunk.Spans) {
-		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), decidedByErrorsSampler
+	}
+	if rare {
+		return true, decidedByRareSampler
 	}
-	return rare
+	return false, decidedByPrioritySampler
 }
 
 // sampleNoPriorityTrace samples traces with no priority set on them. The traces
 // get sampled by either the score sampler or the error sampler if they have an error.
-func (a *Agent) sampleNoPriorityTrace(now time.Time, pt traceutil.ProcessedTrace) bool {
+func (a *Agent) sampleNoPriorityTrace(now time.Time, pt traceutil.ProcessedTrace) (bool, string) {
 	if traceContainsError(pt.TraceChunk.Spans) {
-		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), decidedByErrorsSampler
 	}
-	return a.NoPrioritySampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv)
+	return a.NoPrioritySampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), decidedByNoPrioritySampler
 }
 
 func traceContainsError(trace pb.Trace) bool {
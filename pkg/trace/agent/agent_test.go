@@ -356,6 +356,66 @@ func TestProcess(t *testing.T) {
 		}
 	})
 
+	t.Run("SpanRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.SpanRules = []*config.SpanRule{
+			{Action: config.SpanRuleDropSpan, Match: config.SpanRuleMatch{NameRe: regexp.MustCompile("^internal$")}},
+			{Action: config.SpanRuleHash, KeyRe: regexp.MustCompile(`^usr\.id$`)},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+
+		now := time.Now()
+		root := &pb.Span{
+			TraceID:  1,
+			SpanID:   1,
+			Service:  "a",
+			Name:     "internal",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Metrics:  map[string]float64{"_sampling_priority_v1": 2},
+		}
+		child := &pb.Span{
+			TraceID:  1,
+			SpanID:   2,
+			ParentID: 1,
+			Service:  "a",
+			Name:     "http.request",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (100 * time.Millisecond).Nanoseconds(),
+			Meta:     map[string]string{"usr.id": "123"},
+		}
+		chunk := spansToChunk(root, child)
+		chunk.Priority = int32(sampler.PriorityNone)
+		dropped := &pb.Span{TraceID: 2, SpanID: 1, Service: "a", Name: "internal"}
+		tp := testutil.TracerPayloadWithChunks([]*pb.TraceChunk{chunk, spansToChunk(dropped)})
+		ts := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+
+		go agnt.Process(&api.Payload{
+			TracerPayload: tp,
+			Source:        ts,
+		})
+
+		timeout := time.After(2 * time.Second)
+		select {
+		case ss := <-agnt.TraceWriter.In:
+			assert := assert.New(t)
+			require.Len(t, ss.TracerPayload.Chunks, 1)
+			chunk := ss.TracerPayload.Chunks[0]
+			// the priority is read from the root before it is dropped
+			assert.Equal(int32(2), chunk.Priority)
+			assert.Equal([]*pb.Span{child}, chunk.Spans)
+			assert.Equal(uint64(0), child.ParentID)
+			assert.Equal("a665a45920422f9d", child.Meta["usr.id"])
+			assert.Equal(int64(2), ts.SpansFiltered.Load())
+			assert.Equal(int64(1), ts.TracesFiltered.Load())
+		case <-timeout:
+			t.Fatal("timed out")
+		}
+	})
+
 	t.Run("chunking", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
@@ -939,7 +999,7 @@ func TestSampling(t *testing.T) {
 			a := configureAgent(tt.agentConfig)
 			for _, tc := range tt.testCases {
 				_, hasPriority := sampler.GetSamplingPriority(tc.trace.TraceChunk)
-				sampled := a.runSamplers(time.Now(), tc.trace, hasPriority)
+				sampled, _ := a.runSamplers(time.Now(), tc.trace, hasPriority)
 				assert.EqualValues(t, tc.wantSampled, sampled)
 			}
 		})
@@ -1005,11 +1065,11 @@ func TestSample(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			_, keep, _ := a.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), tt.trace)
+			_, keep, _, _ := a.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), tt.trace)
 			assert.Equal(t, tt.sampledNoFeature, keep)
 			cfg.Features["error_rare_sample_tracer_drop"] = struct{}{}
 			defer delete(cfg.Features, "error_rare_sample_tracer_drop")
-			_, keep, _ = a.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), tt.trace)
+			_, keep, _, _ = a.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), tt.trace)
 			assert.Equal(t, tt.sampledWithFeature, keep)
 		})
 	}
@@ -1630,7 +1690,7 @@ func TestSampleWithPriorityNone(t *testing.T) {
 	defer cancel()
 
 	span := testutil.RandomSpan()
-	numEvents, keep, _ := agnt.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), traceutil.ProcessedTrace{
+	numEvents, keep, _, _ := agnt.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), traceutil.ProcessedTrace{
 		TraceChunk: testutil.TraceChunkWithSpan(span),
 		Root:       span,
 	})
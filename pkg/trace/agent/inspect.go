@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// Names of the processing steps reported by the chunk inspector.
+const (
+	stepNormalizer      = "normalizer"
+	stepSpanRules       = "span_rules"
+	stepIgnoreResources = "ignore_resources"
+	stepFilterTags      = "filter_tags"
+	stepObfuscator      = "obfuscator"
+	stepTruncator       = "truncator"
+	stepReplaceTags     = "replace_tags"
+)
+
+// Names of the components taking the sampling decisions reported by the chunk inspector.
+const (
+	decidedByPrioritySampler   = "priority_sampler"
+	decidedByErrorsSampler     = "errors_sampler"
+	decidedByRareSampler       = "rare_sampler"
+	decidedByNoPrioritySampler = "no_priority_sampler"
+	decidedByRuleSampler       = "rule_sampler"
+	decidedByManualDrop        = "manual_drop"
+	decidedBySpanSampling      = "single_span_sampling"
+	decidedByAnalyzedSpans     = "analyzed_spans"
+	decidedByTailSampling      = "tail_sampling"
+)
+
+// spanState holds the fields of a span which may be modified by the processing steps.
+type spanState struct {
+	service, name, resource, typ string
+	meta                         map[string]string
+	metrics                      map[string]float64
+}
+
+func newSpanState(s *pb.Span) spanState {
+	st := spanState{
+		service:  s.Service,
+		name:     s.Name,
+		resource: s.Resource,
+		typ:      s.Type,
+		meta:     make(map[string]string, len(s.Meta)),
+		metrics:  make(map[string]float64, len(s.Metrics)),
+	}
+	for k, v := range s.Meta {
+		st.meta[k] = v
+	}
+	for k, v := range s.Metrics {
+		st.metrics[k] = v
+	}
+	return st
+}
+
+// obfuscatorStep returns the name of the step obfuscating s.
+func obfuscatorStep(s *pb.Span) string {
+	if s.Type == "" {
+		return stepObfuscator
+	}
+	return stepObfuscator + ":" + s.Type
+}
+
+// modified reports whether s changed since st was taken.
+func (st *spanState) modified(s *pb.Span) bool {
+	if st.service != s.Service || st.name != s.Name || st.resource != s.Resource || st.typ != s.Type {
+		return true
+	}
+	if len(st.meta) != len(s.Meta) || len(st.metrics) != len(s.Metrics) {
+		return true
+	}
+	for k, v := range s.Meta {
+		if old, ok := st.meta[k]; !ok || old != v {
+			return true
+		}
+	}
+	for k, v := range s.Metrics {
+		if old, ok := st.metrics[k]; !ok || old != v {
+			return true
+		}
+	}
+	return false
+}
+
+type spanInspection struct {
+	state     spanState
+	touchedBy []string
+}
+
+// chunkInspector tracks the processing steps modifying or dropping the spans of a chunk, in
+// order to record the chunk along with its sampling decision. A nil *chunkInspector, returned
+// when the recorder is not active, tracks nothing.
+type chunkInspector struct {
+	spans   map[*pb.Span]*spanInspection
+	dropped map[string]int
+}
+
+// newChunkInspector returns an inspector of the spans of chunk, or nil if r is not active.
+func newChunkInspector(r *inspect.Recorder, chunk *pb.TraceChunk) *chunkInspector {
+	if !r.Active() {
+		return nil
+	}
+	ci := &chunkInspector{spans: make(map[*pb.Span]*spanInspection, len(chunk.Spans))}
+	for _, s := range chunk.Spans {
+		if s != nil {
+			ci.spans[s] = &spanInspection{state: newSpanState(s)}
+		}
+	}
+	return ci
+}
+
+// observe records step as having modified the spans which changed since they were last observed.
+func (ci *chunkInspector) observe(step string, spans []*pb.Span) {
+	if ci == nil {
+		return
+	}
+	for _, s := range spans {
+		ci.observeSpan(step, s)
+	}
+}
+
+// observeSpan records step as having modified s if it changed since it was last observed.
+// An empty step only takes the changes into account without reporting them.
+func (ci *chunkInspector) observeSpan(step string, s *pb.Span) {
+	if ci == nil {
+		return
+	}
+	si, ok := ci.spans[s]
+	if !ok || !si.state.modified(s) {
+		return
+	}
+	if step != "" {
+		si.touchedBy = append(si.touchedBy, step)
+	}
+	si.state = newSpanState(s)
+}
+
+// dropSpans records n spans as dropped by step.
+func (ci *chunkInspector) dropSpans(step string, n int) {
+	if ci == nil || n <= 0 {
+		return
+	}
+	if ci.dropped == nil {
+		ci.dropped = make(map[string]int)
+	}
+	ci.dropped[step] += n
+}
+
+// record adds chunk to r, along with the decision taken on it.
+func (ci *chunkInspector) record(r *inspect.Recorder, env string, chunk *pb.TraceChunk, decision, decidedBy string) {
+	if ci == nil {
+		return
+	}
+	rec := &inspect.Record{
+		Time:         time.Now(),
+		Env:          env,
+		Priority:     chunk.Priority,
+		Decision:     decision,
+		DecidedBy:    decidedBy,
+		SpansDropped: ci.dropped,
+		Spans:        make([]inspect.Span, 0, len(chunk.Spans)),
+	}
+	// the chunks rejected by the normalizer may hold nil spans
+	spans := make(pb.Trace, 0, len(chunk.Spans))
+	for _, s := range chunk.Spans {
+		if s != nil {
+			spans = append(spans, s)
+		}
+	}
+	if root := traceutil.GetRoot(spans); root != nil {
+		rec.TraceID = root.TraceID
+		rec.Service = root.Service
+		if rec.Env == "" {
+			rec.Env = traceutil.GetEnv(root, chunk)
+		}
+	}
+	for _, s := range spans {
+		span := inspect.Span{
+			SpanID:   s.SpanID,
+			ParentID: s.ParentID,
+			Service:  s.Service,
+			Name:     s.Name,
+			Resource: s.Resource,
+			Error:    s.Error,
+		}
+		if si, ok := ci.spans[s]; ok {
+			span.TouchedBy = si.touchedBy
+		}
+		rec.Spans = append(rec.Spans, span)
+	}
+	r.Add(rec)
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+)
+
+func TestProcessInspect(t *testing.T) {
+	newSpan := func(id uint64, resource string) *pb.Span {
+		return &pb.Span{
+			TraceID:  id,
+			SpanID:   id,
+			Service:  "db",
+			Name:     "query",
+			Resource: resource,
+			Type:     "sql",
+			Start:    time.Now().Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+		}
+	}
+	process := func(agnt *Agent, priority sampler.SamplingPriority, spans ...*pb.Span) {
+		chunk := testutil.TraceChunkWithSpans(spans)
+		chunk.Priority = int32(priority)
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+		})
+	}
+
+	t.Run("inactive", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+
+		assert.False(t, agnt.Recorder.Active())
+		process(agnt, sampler.PriorityUserKeep, newSpan(1, "SELECT 1"))
+		assert.Empty(t, agnt.Recorder.Recent(inspect.Filter{}))
+	})
+
+	t.Run("decisions", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.InspectBufferSize = 10
+		cfg.Ignore["resource"] = []string{"^INSERT.*"}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+
+		process(agnt, sampler.PriorityUserKeep, newSpan(1, "SELECT name FROM people WHERE age = 42"))
+		process(agnt, sampler.PriorityUserKeep, newSpan(2, "INSERT INTO people VALUES (1)"))
+		process(agnt, sampler.PriorityUserDrop, newSpan(3, "SELECT 1"))
+		foreign := newSpan(5, "SELECT 1")
+		foreign.TraceID = 6
+		process(agnt, sampler.PriorityUserKeep, newSpan(4, "SELECT 1"), foreign)
+
+		recs := agnt.Recorder.Recent(inspect.Filter{})
+		assert.Len(t, recs, 4)
+
+		assert.EqualValues(t, 1, recs[0].TraceID)
+		assert.Equal(t, "db", recs[0].Service)
+		assert.Equal(t, inspect.DecisionKept, recs[0].Decision)
+		assert.Equal(t, decidedByPrioritySampler, recs[0].DecidedBy)
+		assert.Len(t, recs[0].Spans, 1)
+		assert.Equal(t, "SELECT name FROM people WHERE age = ?", recs[0].Spans[0].Resource)
+		assert.Equal(t, []string{"obfuscator:sql"}, recs[0].Spans[0].TouchedBy)
+
+		assert.EqualValues(t, 2, recs[1].TraceID)
+		assert.Equal(t, inspect.DecisionFiltered, recs[1].Decision)
+		assert.Equal(t, stepIgnoreResources, recs[1].DecidedBy)
+
+		assert.EqualValues(t, 3, recs[2].TraceID)
+		assert.Equal(t, inspect.DecisionDropped, recs[2].Decision)
+		assert.Equal(t, decidedByManualDrop, recs[2].DecidedBy)
+
+		assert.Equal(t, inspect.DecisionFiltered, recs[3].Decision)
+		assert.Equal(t, stepNormalizer, recs[3].DecidedBy)
+		assert.Len(t, recs[3].Spans, 2)
+	})
+
+	t.Run("span-rules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.InspectBufferSize = 10
+		cfg.SpanRules = []*config.SpanRule{
+			{Action: config.SpanRuleDropSpan, Match: config.SpanRuleMatch{NameRe: regexp.MustCompile("^query$")}},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+
+		child := newSpan(2, "SELECT 1")
+		child.TraceID = 1
+		child.ParentID = 1
+		process(agnt, sampler.PriorityUserKeep, newSpan(1, "SELECT 1"), child)
+
+		recs := agnt.Recorder.Recent(inspect.Filter{})
+		assert.Len(t, recs, 1)
+		assert.Equal(t, inspect.DecisionFiltered, recs[0].Decision)
+		assert.Equal(t, stepSpanRules, recs[0].DecidedBy)
+		assert.Len(t, recs[0].Spans, 2)
+	})
+}
+
+func TestChunkInspector(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		ci := newChunkInspector(nil, testutil.TraceChunkWithSpan(&pb.Span{}))
+		assert.Nil(t, ci)
+		ci.observe(stepNormalizer, nil)
+		ci.observeSpan(stepTruncator, &pb.Span{})
+		ci.dropSpans(stepSpanRules, 1)
+		ci.record(nil, "", testutil.TraceChunkWithSpan(&pb.Span{}), inspect.DecisionKept, "")
+	})
+
+	t.Run("touched", func(t *testing.T) {
+		r := inspect.NewRecorder(1)
+		s1 := &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Meta: map[string]string{"env": "prod"}}
+		s2 := &pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Metrics: map[string]float64{"a": 1}}
+		chunk := testutil.TraceChunkWithSpans([]*pb.Span{s1, s2})
+		ci := newChunkInspector(r, chunk)
+
+		s2.Name = "normalized"
+		ci.observe(stepNormalizer, chunk.Spans)
+		s1.Meta["k"] = "v"
+		ci.observeSpan("", s1)
+		ci.observeSpan(stepTruncator, s1)
+		s1.Meta["k"] = "x"
+		s2.Metrics["a"] = 2
+		ci.observe(stepReplaceTags, chunk.Spans)
+		ci.dropSpans(stepSpanRules, 2)
+		ci.record(r, "", chunk, inspect.DecisionKept, decidedByRareSampler)
+
+		recs := r.Recent(inspect.Filter{})
+		assert.Len(t, recs, 1)
+		rec := recs[0]
+		assert.Equal(t, "prod", rec.Env)
+		assert.Equal(t, "web", rec.Service)
+		assert.Equal(t, map[string]int{stepSpanRules: 2}, rec.SpansDropped)
+		assert.Equal(t, []string{stepReplaceTags}, rec.Spans[0].TouchedBy)
+		assert.Equal(t, []string{stepNormalizer, stepReplaceTags}, rec.Spans[1].TouchedBy)
+	})
+}
@@ -22,6 +22,8 @@ import (
 const (
 	// MaxTypeLen the maximum length a span type can have
 	MaxTypeLen = 100
+	// MaxSpanLinks the maximum number of links a span can have
+	MaxSpanLinks = 128
 	// tagOrigin specifies the origin of the trace.
 	// DEPRECATED: Origin is now specified as a TraceChunk field.
 	tagOrigin = "_dd.origin"
@@ -138,9 +140,32 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 			delete(s.Meta, "http.status_code")
 		}
 	}
+	if len(s.SpanLinks) > 0 {
+		normalizeSpanLinks(ts, s)
+	}
 	return nil
 }
 
+// normalizeSpanLinks drops the links of the span s which have no trace ID or span ID, and the links
+// exceeding MaxSpanLinks.
+func normalizeSpanLinks(ts *info.TagStats, s *pb.Span) {
+	links := s.SpanLinks[:0]
+	for _, l := range s.SpanLinks {
+		if l == nil || l.TraceID == 0 || l.SpanID == 0 {
+			ts.SpansMalformed.InvalidSpanLink.Inc()
+			log.Debugf("Fixing malformed trace. Span link has no trace ID or span ID (reason:invalid_span_link), dropping it: %s", s)
+			continue
+		}
+		links = append(links, l)
+	}
+	if len(links) > MaxSpanLinks {
+		ts.SpansMalformed.SpanLinksTruncate.Inc()
+		log.Debugf("Fixing malformed trace. Span has too many links (reason:span_links_truncate), keeping the first %d: %s", MaxSpanLinks, s)
+		links = links[:MaxSpanLinks]
+	}
+	s.SpanLinks = links
+}
+
 // normalizeChunk takes a trace chunk and
 // * populates Origin field if it wasn't populated
 // * populates Priority field if it wasn't populated
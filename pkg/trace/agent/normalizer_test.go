@@ -392,6 +392,35 @@ func TestNormalizeTypeTooLong(t *testing.T) {
 	assert.Equal(t, tsMalformed(&info.SpansMalformed{TypeTruncate: *atomic.NewInt64(1)}), ts)
 }
 
+func TestNormalizeSpanLinks(t *testing.T) {
+	a := &Agent{conf: config.New()}
+	t.Run("invalid", func(t *testing.T) {
+		ts := newTagStats()
+		s := newTestSpan()
+		s.SpanLinks = []*pb.SpanLink{
+			{TraceID: 1, SpanID: 2},
+			{TraceID: 0, SpanID: 2},
+			{TraceID: 1, SpanID: 0},
+			nil,
+		}
+		assert.NoError(t, a.normalize(ts, s))
+		assert.Equal(t, []*pb.SpanLink{{TraceID: 1, SpanID: 2}}, s.SpanLinks)
+		assert.Equal(t, tsMalformed(&info.SpansMalformed{InvalidSpanLink: *atomic.NewInt64(3)}), ts)
+	})
+
+	t.Run("too-many", func(t *testing.T) {
+		ts := newTagStats()
+		s := newTestSpan()
+		for i := 0; i < MaxSpanLinks+10; i++ {
+			s.SpanLinks = append(s.SpanLinks, &pb.SpanLink{TraceID: 1, SpanID: uint64(i + 1)})
+		}
+		assert.NoError(t, a.normalize(ts, s))
+		assert.Len(t, s.SpanLinks, MaxSpanLinks)
+		assert.EqualValues(t, MaxSpanLinks, s.SpanLinks[MaxSpanLinks-1].SpanID)
+		assert.Equal(t, tsMalformed(&info.SpansMalformed{SpanLinksTruncate: *atomic.NewInt64(1)}), ts)
+	})
+}
+
 func TestNormalizeServiceTag(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	ts := newTagStats()
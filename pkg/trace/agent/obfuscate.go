@@ -22,20 +22,29 @@ const (
 	tagElasticBody      = "elasticsearch.body"
 	tagSQLQuery         = "sql.query"
 	tagHTTPURL          = "http.url"
+	tagGraphQLQuery     = "graphql.query"
+	tagCassandraQuery   = "cassandra.query"
+	tagDBStatement      = "db.statement"
+	tagDBSystem         = "db.system"
+
+	// tagGraphQLVariablesPrefix is the prefix of the tags holding the values of the GraphQL variables.
+	tagGraphQLVariablesPrefix = "graphql.variables."
 )
 
 const (
-	textNonParsable = "Non-parsable SQL query"
+	textNonParsable    = "Non-parsable SQL query"
+	textNonParsableCQL = "Non-parsable CQL query"
 )
 
 func (a *Agent) obfuscateSpan(span *pb.Span) {
 	o := a.obfuscator
 	switch span.Type {
-	case "sql", "cassandra":
+	case "sql":
 		if span.Resource == "" {
 			return
 		}
-		oq, err := o.ObfuscateSQLString(span.Resource)
+		// the "db.system" tag identifies the database management system, and thus the SQL dialect
+		oq, err := o.ObfuscateSQLStringForDBMS(span.Resource, span.Meta[tagDBSystem])
 		if err != nil {
 			// we have an error, discard the SQL to avoid polluting user resources.
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
@@ -59,6 +68,65 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			return
 		}
 		traceutil.SetMeta(span, tagSQLQuery, oq.Query)
+	case "cassandra":
+		if v, ok := span.Meta[tagCassandraQuery]; ok {
+			if oq, err := o.ObfuscateCQLString(v); err == nil {
+				span.Meta[tagCassandraQuery] = oq.Query
+			} else {
+				span.Meta[tagCassandraQuery] = textNonParsableCQL
+			}
+		}
+		if span.Resource == "" {
+			return
+		}
+		oq, err := o.ObfuscateCQLString(span.Resource)
+		if err != nil {
+			// we have an error, discard the CQL to avoid polluting user resources.
+			log.Debugf("Error parsing CQL query: %v. Resource: %q", err, span.Resource)
+			if span.Meta == nil {
+				span.Meta = make(map[string]string, 1)
+			}
+			if _, ok := span.Meta[tagSQLQuery]; !ok {
+				span.Meta[tagSQLQuery] = textNonParsableCQL
+			}
+			span.Resource = textNonParsableCQL
+			return
+		}
+
+		span.Resource = oq.Query
+
+		if len(oq.Metadata.TablesCSV) > 0 {
+			traceutil.SetMeta(span, "sql.tables", oq.Metadata.TablesCSV)
+		}
+		if span.Meta != nil && span.Meta[tagSQLQuery] != "" {
+			// "sql.query" tag already set by user, do not change it.
+			return
+		}
+		traceutil.SetMeta(span, tagSQLQuery, oq.Query)
+	case "graphql":
+		if span.Resource != "" {
+			span.Resource = o.ObfuscateGraphQLString(span.Resource)
+		}
+		for k, v := range span.Meta {
+			switch {
+			case k == tagGraphQLQuery:
+				span.Meta[k] = o.ObfuscateGraphQLString(v)
+			case strings.HasPrefix(k, tagGraphQLVariablesPrefix):
+				span.Meta[k] = "?"
+			}
+		}
+	case "dynamodb":
+		v, ok := span.Meta[tagDBStatement]
+		if span.Meta == nil || !ok {
+			return
+		}
+		oq, err := o.ObfuscatePartiQLString(v)
+		if err != nil {
+			log.Debugf("Error parsing PartiQL statement: %v. Statement: %q", err, v)
+			span.Meta[tagDBStatement] = textNonParsable
+			return
+		}
+		span.Meta[tagDBStatement] = oq.Query
 	case "redis":
 		span.Resource = o.QuantizeRedisString(span.Resource)
 		if a.conf.Obfuscation.Redis.Enabled {
@@ -103,7 +171,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 	o := a.obfuscator
 	switch b.Type {
-	case "sql", "cassandra":
+	case "sql":
 		oq, err := o.ObfuscateSQLString(b.Resource)
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
@@ -111,6 +179,16 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 		} else {
 			b.Resource = oq.Query
 		}
+	case "cassandra":
+		oq, err := o.ObfuscateCQLString(b.Resource)
+		if err != nil {
+			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
+			b.Resource = textNonParsableCQL
+		} else {
+			b.Resource = oq.Query
+		}
+	case "graphql":
+		b.Resource = o.ObfuscateGraphQLString(b.Resource)
 	case "redis":
 		b.Resource = o.QuantizeRedisString(b.Resource)
 	}
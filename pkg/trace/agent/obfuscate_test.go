@@ -64,6 +64,9 @@ func TestObfuscateStatsGroup(t *testing.T) {
 		{statsGroup("sql", "SELECT 1 FROM db"), "SELECT ? FROM db"},
 		{statsGroup("sql", "SELECT 1\nFROM Blogs AS [b\nORDER BY [b]"), textNonParsable},
 		{statsGroup("redis", "ADD 1, 2"), "ADD"},
+		{statsGroup("cassandra", "SELECT * FROM users WHERE id = 123e4567-e89b-12d3-a456-426614174000"), "SELECT * FROM users WHERE id = ?"},
+		{statsGroup("cassandra", "SELECT * FROM users WHERE name = 'unterminated"), textNonParsableCQL},
+		{statsGroup("graphql", `query { user(id: 42) { name } }`), "query { user(id: ?) { name } }"},
 		{statsGroup("other", "ADD 1, 2"), "ADD 1, 2"},
 	} {
 		agnt, stop := agentWithDefaults()
@@ -252,6 +255,81 @@ func TestSQLResourceQuery(t *testing.T) {
 	assert.Equal("SELECT * FROM users WHERE id = 42", span.Meta["sql.query"])
 }
 
+func TestSQLResourceDBSystem(t *testing.T) {
+	assert := assert.New(t)
+	span := &pb.Span{
+		Resource: "SELECT * FROM `db`.`users` WHERE name = \"Andy\" OR nick IN (\"a\", \"b\")",
+		Type:     "sql",
+		Meta: map[string]string{
+			"db.system": "mysql",
+		},
+	}
+
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal("SELECT * FROM db.users WHERE name = ? OR nick IN ( ? )", span.Resource)
+	assert.Equal("SELECT * FROM db.users WHERE name = ? OR nick IN ( ? )", span.Meta["sql.query"])
+}
+
+func TestCassandraResourceQuery(t *testing.T) {
+	assert := assert.New(t)
+	query := "UPDATE users USING TTL 3600 SET emails = {'a@b.c'} WHERE id = 42"
+	span := &pb.Span{
+		Resource: query,
+		Type:     "cassandra",
+		Meta: map[string]string{
+			"cassandra.query": query,
+		},
+	}
+
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal("UPDATE users USING TTL ? SET emails = ? WHERE id = ?", span.Resource)
+	assert.Equal("UPDATE users USING TTL ? SET emails = ? WHERE id = ?", span.Meta["cassandra.query"])
+	assert.Equal("UPDATE users USING TTL ? SET emails = ? WHERE id = ?", span.Meta["sql.query"])
+}
+
+func TestGraphQLResourceQuery(t *testing.T) {
+	assert := assert.New(t)
+	query := `query GetUser($id: ID!) { user(id: $id, name: "bob") { name } }`
+	span := &pb.Span{
+		Resource: query,
+		Type:     "graphql",
+		Meta: map[string]string{
+			"graphql.query":        query,
+			"graphql.variables.id": "42",
+			"graphql.operation":    "GetUser",
+		},
+	}
+
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal(`query GetUser($id: ID!) { user(id: $id, name: ?) { name } }`, span.Resource)
+	assert.Equal(`query GetUser($id: ID!) { user(id: $id, name: ?) { name } }`, span.Meta["graphql.query"])
+	assert.Equal("?", span.Meta["graphql.variables.id"])
+	assert.Equal("GetUser", span.Meta["graphql.operation"])
+}
+
+func TestDynamoDBStatement(t *testing.T) {
+	assert := assert.New(t)
+	span := &pb.Span{
+		Resource: "DynamoDB.ExecuteStatement",
+		Type:     "dynamodb",
+		Meta: map[string]string{
+			"db.statement": `INSERT INTO "Music" VALUE {'Artist': 'Acme', 'Tags': <<'a', 'b'>>}`,
+		},
+	}
+
+	agnt, stop := agentWithDefaults()
+	defer stop()
+	agnt.obfuscateSpan(span)
+	assert.Equal("DynamoDB.ExecuteStatement", span.Resource)
+	assert.Equal("INSERT INTO Music VALUE ?", span.Meta["db.statement"])
+}
+
 func TestSQLResourceWithoutQuery(t *testing.T) {
 	assert := assert.New(t)
 	span := &pb.Span{
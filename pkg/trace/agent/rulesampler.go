@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// ruleSamplerReportPeriod is the period at which the rule sampler stats are reported.
+const ruleSamplerReportPeriod = 10 * time.Second
+
+// ruleSampler samples the traces matching the sampling rules of the agent. The first rule
+// matching the root span of a chunk keeps a ratio of the traces, up to a number of traces
+// per second. The rules can be updated at runtime, e.g. through remote configuration.
+type ruleSampler struct {
+	mu    sync.RWMutex
+	rules []*samplingRule
+
+	exit chan struct{}
+}
+
+// samplingRule is a compiled sampling rule along with its counters.
+type samplingRule struct {
+	conf *config.SamplingRule
+
+	// service, name and resource are nil when the rule matches any value.
+	service  *regexp.Regexp
+	name     *regexp.Regexp
+	resource *regexp.Regexp
+	tags     []tagMatcher
+
+	limiter *rate.Limiter // nil when the number of traces is not limited
+	kept    *atomic.Int64
+	dropped *atomic.Int64
+	// reportedKept and reportedDropped are the counts already reported as metrics.
+	reportedKept    int64
+	reportedDropped int64
+}
+
+type tagMatcher struct {
+	key   string
+	value *regexp.Regexp
+}
+
+// newRuleSampler returns a sampler applying the given sampling rules.
+func newRuleSampler(rules []*config.SamplingRule) *ruleSampler {
+	s := &ruleSampler{exit: make(chan struct{})}
+	s.UpdateRules(rules)
+	return s
+}
+
+// Start starts reporting the counts of the sampled traces.
+func (s *ruleSampler) Start() {
+	go func() {
+		statsTicker := time.NewTicker(ruleSamplerReportPeriod)
+		defer statsTicker.Stop()
+		for {
+			select {
+			case <-statsTicker.C:
+				s.report()
+			case <-s.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops reporting the counts of the sampled traces.
+func (s *ruleSampler) Stop() {
+	close(s.exit)
+}
+
+// UpdateRules replaces the sampling rules. The counts of the rules keeping their name are preserved.
+func (s *ruleSampler) UpdateRules(rules []*config.SamplingRule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := make(map[string]*samplingRule, len(s.rules))
+	for _, r := range s.rules {
+		previous[r.conf.Name] = r
+	}
+	compiled := make([]*samplingRule, 0, len(rules))
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			log.Errorf("Ignoring sampling rule: %v", err)
+			continue
+		}
+		cr := newSamplingRule(r)
+		if p, ok := previous[r.Name]; ok {
+			cr.kept, cr.dropped = p.kept, p.dropped
+			cr.reportedKept, cr.reportedDropped = p.reportedKept, p.reportedDropped
+		}
+		compiled = append(compiled, cr)
+	}
+	s.rules = compiled
+}
+
+func newSamplingRule(conf *config.SamplingRule) *samplingRule {
+	r := &samplingRule{
+		conf:     conf,
+		service:  globRegexp(conf.Match.Service),
+		name:     globRegexp(conf.Match.Name),
+		resource: globRegexp(conf.Match.Resource),
+		kept:     atomic.NewInt64(0),
+		dropped:  atomic.NewInt64(0),
+	}
+	for _, tag := range conf.Match.Tags {
+		r.tags = append(r.tags, tagMatcher{key: tag.Key, value: globRegexp(tag.Value)})
+	}
+	if conf.MaxPerSecond > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(conf.MaxPerSecond), int(math.Ceil(conf.MaxPerSecond)))
+	}
+	return r
+}
+
+// sample applies the first rule matching root to the chunk. It returns whether the chunk should
+// be kept, and the name of the rule which took the decision, empty if no rule matched.
+func (s *ruleSampler) sample(now time.Time, chunk *pb.TraceChunk, root *pb.Span) (keep bool, rule string) {
+	if s == nil || root == nil {
+		return false, ""
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.rules {
+		if !r.match(root) {
+			continue
+		}
+		keep = sampler.SampleByRate(root.TraceID, r.conf.SampleRate)
+		if keep && r.limiter != nil {
+			keep = r.limiter.AllowN(now, 1)
+		}
+		if keep {
+			r.kept.Inc()
+		} else {
+			r.dropped.Inc()
+		}
+		return keep, r.conf.Name
+	}
+	return false, ""
+}
+
+// match reports whether s meets all the conditions of the rule.
+func (r *samplingRule) match(s *pb.Span) bool {
+	if r.service != nil && !r.service.MatchString(s.Service) {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(s.Name) {
+		return false
+	}
+	if r.resource != nil && !r.resource.MatchString(s.Resource) {
+		return false
+	}
+	for _, tag := range r.tags {
+		v, ok := s.Meta[tag.key]
+		if !ok {
+			m, ok := s.Metrics[tag.key]
+			if !ok {
+				return false
+			}
+			v = strconv.FormatFloat(m, 'f', -1, 64)
+		}
+		if tag.value != nil && !tag.value.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// report reports the counts of the traces sampled by each rule.
+func (s *ruleSampler) report() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]info.SamplingRuleStats, 0, len(s.rules))
+	for _, r := range s.rules {
+		kept, dropped := r.kept.Load(), r.dropped.Load()
+		tags := []string{"rule:" + r.conf.Name}
+		metrics.Count("datadog.trace_agent.sampler.rule.kept", kept-r.reportedKept, tags, 1)
+		metrics.Count("datadog.trace_agent.sampler.rule.dropped", dropped-r.reportedDropped, tags, 1)
+		r.reportedKept, r.reportedDropped = kept, dropped
+		stats = append(stats, info.SamplingRuleStats{
+			Rule:          r.conf.Name,
+			SampleRate:    r.conf.SampleRate,
+			MaxPerSecond:  r.conf.MaxPerSecond,
+			TracesKept:    kept,
+			TracesDropped: dropped,
+		})
+	}
+	info.UpdateRuleSampler(stats)
+}
+
+// globRegexp returns a regular expression matching the strings matched by the glob pattern,
+// where "*" matches any sequence of characters and "?" any single character. It returns nil
+// when the pattern is empty or "*", matching any string.
+func globRegexp(glob string) *regexp.Regexp {
+	if glob == "" || glob == "*" {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, c := range glob {
+		switch c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
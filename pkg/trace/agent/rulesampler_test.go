@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+func TestRuleSamplerMatch(t *testing.T) {
+	s := newRuleSampler([]*config.SamplingRule{
+		{
+			Name: "health",
+			Match: config.SamplingRuleMatch{
+				Service:  "web-*",
+				Resource: "GET /health?",
+				Tags:     []*config.SamplingRuleTag{{Key: "http.status_code", Value: "2*"}, {Key: "region"}},
+			},
+			SampleRate: 1,
+		},
+		{Name: "redis", Match: config.SamplingRuleMatch{Name: "redis.*"}, SampleRate: 0},
+		{Name: "invalid", SampleRate: 2},
+		{Name: "catch-all", SampleRate: 1},
+	})
+	assert.Len(t, s.rules, 3)
+
+	for _, tt := range []struct {
+		span *pb.Span
+		keep bool
+		rule string
+	}{
+		{
+			span: &pb.Span{Service: "web-store", Resource: "GET /healthz", Meta: map[string]string{"http.status_code": "200", "region": "eu"}},
+			keep: true,
+			rule: "health",
+		},
+		{
+			span: &pb.Span{Service: "web-store", Resource: "GET /healthz", Meta: map[string]string{"region": "eu"}, Metrics: map[string]float64{"http.status_code": 204}},
+			keep: true,
+			rule: "health",
+		},
+		{
+			span: &pb.Span{Service: "web-store", Name: "redis.command", Resource: "GET /healthz", Meta: map[string]string{"http.status_code": "200"}},
+			keep: false,
+			rule: "redis",
+		},
+		{
+			span: &pb.Span{Service: "web-store", Name: "redis.command", Resource: "GET /healthz", Meta: map[string]string{"http.status_code": "500", "region": "eu"}},
+			keep: false,
+			rule: "redis",
+		},
+		{
+			span: &pb.Span{Service: "api", Name: "http.request"},
+			keep: true,
+			rule: "catch-all",
+		},
+	} {
+		keep, rule := s.sample(time.Now(), testutil.TraceChunkWithSpan(tt.span), tt.span)
+		assert.Equal(t, tt.keep, keep)
+		assert.Equal(t, tt.rule, rule)
+	}
+	assert.EqualValues(t, 2, s.rules[0].kept.Load())
+	assert.EqualValues(t, 2, s.rules[1].dropped.Load())
+}
+
+func TestRuleSamplerNoRules(t *testing.T) {
+	span := &pb.Span{Service: "web"}
+	keep, rule := newRuleSampler(nil).sample(time.Now(), testutil.TraceChunkWithSpan(span), span)
+	assert.False(t, keep)
+	assert.Equal(t, "", rule)
+
+	var s *ruleSampler
+	_, rule = s.sample(time.Now(), testutil.TraceChunkWithSpan(span), span)
+	assert.Equal(t, "", rule)
+}
+
+func TestRuleSamplerMaxPerSecond(t *testing.T) {
+	s := newRuleSampler([]*config.SamplingRule{{Name: "limited", SampleRate: 1, MaxPerSecond: 2}})
+	now := time.Now()
+	var kept int
+	for i := 0; i < 10; i++ {
+		span := &pb.Span{TraceID: uint64(i + 1)}
+		if keep, _ := s.sample(now, testutil.TraceChunkWithSpan(span), span); keep {
+			kept++
+		}
+	}
+	assert.Equal(t, 2, kept)
+	assert.EqualValues(t, 2, s.rules[0].kept.Load())
+	assert.EqualValues(t, 8, s.rules[0].dropped.Load())
+}
+
+func TestRuleSamplerUpdateRules(t *testing.T) {
+	s := newRuleSampler([]*config.SamplingRule{{Name: "a", SampleRate: 1}})
+	span := &pb.Span{TraceID: 1}
+	s.sample(time.Now(), testutil.TraceChunkWithSpan(span), span)
+
+	s.UpdateRules([]*config.SamplingRule{{Name: "b", SampleRate: 0}, {Name: "a", SampleRate: 0.5}})
+	if assert.Len(t, s.rules, 2) {
+		assert.EqualValues(t, 0, s.rules[0].kept.Load())
+		assert.EqualValues(t, 1, s.rules[1].kept.Load())
+	}
+	_, rule := s.sample(time.Now(), testutil.TraceChunkWithSpan(span), span)
+	assert.Equal(t, "b", rule)
+}
+
+func TestSampleRules(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.SamplingRules = []*config.SamplingRule{{Name: "drop-all", SampleRate: 0}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+
+	for _, tt := range []struct {
+		priority  sampler.SamplingPriority
+		keep      bool
+		decidedBy string
+		after     sampler.SamplingPriority
+	}{
+		{sampler.PriorityAutoKeep, false, "rule_sampler:drop-all", sampler.PriorityUserDrop},
+		{sampler.PriorityAutoDrop, false, "rule_sampler:drop-all", sampler.PriorityUserDrop},
+		{sampler.PriorityNone, false, "rule_sampler:drop-all", sampler.PriorityUserDrop},
+		{sampler.PriorityUserKeep, true, decidedByPrioritySampler, sampler.PriorityUserKeep},
+	} {
+		span := &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Name: "http.request"}
+		chunk := testutil.TraceChunkWithSpan(span)
+		chunk.Priority = int32(tt.priority)
+		_, keep, decidedBy, _ := agnt.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), traceutil.ProcessedTrace{
+			TraceChunk: chunk,
+			Root:       span,
+		})
+		assert.Equal(t, tt.keep, keep, tt.priority)
+		assert.Equal(t, tt.decidedBy, decidedBy, tt.priority)
+		assert.EqualValues(t, tt.after, chunk.Priority, tt.priority)
+	}
+}
+
+func TestSampleRulesCatchUp(t *testing.T) {
+	newAgent := func(t *testing.T, rule *config.SamplingRule) *Agent {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.RareSamplerEnabled = true
+		cfg.SamplingRules = []*config.SamplingRule{rule}
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		return NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+	}
+	sample := func(agnt *Agent, priority sampler.SamplingPriority, span *pb.Span) (*pb.TraceChunk, bool, string) {
+		chunk := testutil.TraceChunkWithSpan(span)
+		chunk.Priority = int32(priority)
+		_, keep, decidedBy, _ := agnt.sample(time.Now(), info.NewReceiverStats().GetTagStats(info.Tags{}), traceutil.ProcessedTrace{
+			TraceChunk: chunk,
+			Root:       span,
+		})
+		return chunk, keep, decidedBy
+	}
+	newSpan := func() *pb.Span {
+		return &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Name: "http.request", Metrics: map[string]float64{"_top_level": 1}}
+	}
+
+	t.Run("keep", func(t *testing.T) {
+		agnt := newAgent(t, &config.SamplingRule{Name: "keep-all", SampleRate: 1})
+		span := newSpan()
+		chunk, keep, decidedBy := sample(agnt, sampler.PriorityAutoKeep, span)
+		assert.True(t, keep)
+		assert.Equal(t, "rule_sampler:keep-all", decidedBy)
+		assert.EqualValues(t, sampler.PriorityUserKeep, chunk.Priority)
+		// the chunk is counted by the priority sampler, which applies its rate to the root
+		assert.Contains(t, span.Metrics, "_sampling_priority_rate_v1")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		agnt := newAgent(t, &config.SamplingRule{Name: "drop-all", SampleRate: 0})
+		span := newSpan()
+		span.Error = 1
+		chunk, keep, decidedBy := sample(agnt, sampler.PriorityAutoDrop, span)
+		assert.True(t, keep)
+		assert.Equal(t, decidedByErrorsSampler, decidedBy)
+		assert.EqualValues(t, sampler.PriorityAutoDrop, chunk.Priority)
+	})
+
+	t.Run("rare", func(t *testing.T) {
+		agnt := newAgent(t, &config.SamplingRule{Name: "drop-all", SampleRate: 0})
+		chunk, keep, decidedBy := sample(agnt, sampler.PriorityAutoKeep, newSpan())
+		assert.True(t, keep)
+		assert.Equal(t, decidedByRareSampler, decidedBy)
+		assert.EqualValues(t, sampler.PriorityAutoKeep, chunk.Priority)
+
+		// the same signature is not rare anymore
+		chunk, keep, decidedBy = sample(agnt, sampler.PriorityAutoKeep, newSpan())
+		assert.False(t, keep)
+		assert.Equal(t, "rule_sampler:drop-all", decidedBy)
+		assert.EqualValues(t, sampler.PriorityUserDrop, chunk.Priority)
+	})
+}
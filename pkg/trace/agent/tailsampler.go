@@ -0,0 +1,380 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+const (
+	// tailSamplerTickPeriod is the maximum delay between the end of the decision wait
+	// of a trace and the decision.
+	tailSamplerTickPeriod = time.Second
+	// tailSamplerReportPeriod is the period at which the tail sampler stats are reported.
+	tailSamplerReportPeriod = 10 * time.Second
+	// tailTraceOverhead is the approximate size of the state kept for each trace waiting for
+	// a decision, charged to the buffer along with its chunks so that the traces whose chunks
+	// are all kept by the samplers are bounded too.
+	tailTraceOverhead = 256
+	// tailDecisionOverhead is the approximate size of a decision kept for the chunks received late.
+	tailDecisionOverhead = 64
+)
+
+// tailSampler buffers the chunks dropped by the samplers by trace ID, and keeps the traces
+// matching one of the tail sampling policies once the decision wait is over. The chunks
+// kept by the samplers are not buffered but they are taken into account by the policies,
+// e.g. a trace is kept because of an error in a chunk received after its root.
+type tailSampler struct {
+	conf *config.TailSamplingConfig
+	out  chan<- *writer.SampledChunks
+
+	mu     sync.Mutex
+	traces map[uint64]*tailTrace
+	// queue holds the buffered traces ordered by arrival, which is also the order of their decisions.
+	queue []*tailTrace
+	// decisions holds the recent decisions, to apply them to the chunks received late.
+	decisions map[uint64]tailDecision
+	// decided holds the IDs of the traces in decisions, ordered by decision.
+	decided []uint64
+	// size is the size of the buffered chunks, along with the overhead of the traces and decisions.
+	size  int64
+	stats info.TailSamplerStats
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// tailTrace is a trace waiting for a tail sampling decision.
+type tailTrace struct {
+	traceID   uint64
+	firstSeen time.Time
+	decided   bool
+
+	start, end  int64
+	rootService string
+	hasRoot     bool
+	hasError    bool
+	// attributes reports which policies matched the attributes of the spans seen so far.
+	attributes []bool
+
+	chunks []tailChunk
+	size   int64
+}
+
+// tailChunk is a buffered chunk along with the metadata of the payload it was received in.
+type tailChunk struct {
+	payload *pb.TracerPayload
+	chunk   *pb.TraceChunk
+}
+
+type tailDecision struct {
+	keep bool
+	at   time.Time
+}
+
+// newTailSampler returns a new tail sampler writing the kept chunks to out, or nil
+// if tail sampling is disabled.
+func newTailSampler(conf *config.TailSamplingConfig, out chan<- *writer.SampledChunks) *tailSampler {
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+	if len(conf.Policies) == 0 {
+		log.Warn("Tail sampling is enabled without any policy, all the buffered traces will be dropped.")
+	}
+	return &tailSampler{
+		conf:      conf,
+		out:       out,
+		traces:    make(map[uint64]*tailTrace),
+		decisions: make(map[uint64]tailDecision),
+		stats:     info.TailSamplerStats{MaxBufferBytes: conf.MaxBufferBytes},
+		exit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start starts taking the decisions for the traces whose decision wait is over.
+func (s *tailSampler) Start() {
+	go func() {
+		defer close(s.done)
+		tick := time.NewTicker(tailSamplerTickPeriod)
+		defer tick.Stop()
+		report := time.NewTicker(tailSamplerReportPeriod)
+		defer report.Stop()
+		for {
+			select {
+			case now := <-tick.C:
+				s.flush(now, false)
+			case <-report.C:
+				s.report()
+			case <-s.exit:
+				s.flush(time.Now(), true)
+				s.report()
+				return
+			}
+		}
+	}()
+}
+
+// Stop takes the decisions for all the buffered traces and stops the tail sampler.
+func (s *tailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+}
+
+// Add buffers a chunk dropped by the samplers until the decision is taken for its trace.
+// header holds the metadata of the payload the chunk was received in, see payloadHeader.
+func (s *tailSampler) Add(now time.Time, header *pb.TracerPayload, chunk *pb.TraceChunk) {
+	s.add(now, header, chunk, true)
+}
+
+// Observe takes into account a chunk kept by the samplers for the decision of its trace.
+func (s *tailSampler) Observe(now time.Time, chunk *pb.TraceChunk) {
+	s.add(now, nil, chunk, false)
+}
+
+func (s *tailSampler) add(now time.Time, header *pb.TracerPayload, chunk *pb.TraceChunk, buffer bool) {
+	if len(chunk.Spans) == 0 {
+		return
+	}
+	traceID := chunk.Spans[0].TraceID
+
+	var kept []*writer.SampledChunks
+	s.mu.Lock()
+	if d, ok := s.decisions[traceID]; ok {
+		// the decision has already been taken for this trace
+		s.mu.Unlock()
+		if d.keep && buffer {
+			s.write([]*writer.SampledChunks{keptChunks([]tailChunk{{payload: header, chunk: chunk}})})
+		}
+		return
+	}
+	t, ok := s.traces[traceID]
+	if !ok {
+		t = &tailTrace{
+			traceID:    traceID,
+			firstSeen:  now,
+			attributes: make([]bool, len(s.conf.Policies)),
+			size:       tailTraceOverhead,
+		}
+		s.traces[traceID] = t
+		s.queue = append(s.queue, t)
+		s.size += tailTraceOverhead
+	}
+	s.observeSpans(t, chunk)
+	if buffer {
+		size := int64(chunk.Msgsize())
+		t.chunks = append(t.chunks, tailChunk{payload: header, chunk: chunk})
+		t.size += size
+		s.size += size
+	}
+	// the buffer is full, decide the oldest traces early
+	for s.size > s.conf.MaxBufferBytes && len(s.queue) > 0 {
+		oldest := s.queue[0]
+		s.queue = s.queue[1:]
+		if oldest.decided {
+			continue
+		}
+		s.stats.EarlyDecisions++
+		if sc := s.decide(now, oldest); sc != nil {
+			kept = append(kept, sc...)
+		}
+	}
+	// the decisions alone fill the buffer, forget the oldest ones
+	for s.size > s.conf.MaxBufferBytes && len(s.decided) > 0 {
+		s.forgetOldestDecision()
+	}
+	s.mu.Unlock()
+	s.write(kept)
+}
+
+// observeSpans updates the state of the trace used by the policies with the spans of chunk.
+func (s *tailSampler) observeSpans(t *tailTrace, chunk *pb.TraceChunk) {
+	for _, span := range chunk.Spans {
+		if t.start == 0 || span.Start < t.start {
+			t.start = span.Start
+		}
+		if end := span.Start + span.Duration; end > t.end {
+			t.end = end
+		}
+		if span.Error != 0 {
+			t.hasError = true
+		}
+		if span.ParentID == 0 {
+			t.rootService = span.Service
+			t.hasRoot = true
+		} else if !t.hasRoot && t.rootService == "" {
+			t.rootService = span.Service
+		}
+		for i, p := range s.conf.Policies {
+			if p.Type != config.TailSamplingAttributePolicy || t.attributes[i] {
+				continue
+			}
+			if v, ok := span.Meta[p.Key]; ok && (p.Re == nil || p.Re.MatchString(v)) {
+				t.attributes[i] = true
+			}
+		}
+	}
+}
+
+// flush takes the decisions for the traces whose decision wait is over, or for all the
+// traces if all is true.
+func (s *tailSampler) flush(now time.Time, all bool) {
+	var kept []*writer.SampledChunks
+	s.mu.Lock()
+	for len(s.queue) > 0 {
+		oldest := s.queue[0]
+		if !all && now.Sub(oldest.firstSeen) < s.conf.DecisionWait {
+			break
+		}
+		s.queue = s.queue[1:]
+		if oldest.decided {
+			continue
+		}
+		if sc := s.decide(now, oldest); sc != nil {
+			kept = append(kept, sc...)
+		}
+	}
+	for len(s.decided) > 0 {
+		if now.Sub(s.decisions[s.decided[0]].at) < s.conf.DecisionWait {
+			break
+		}
+		s.forgetOldestDecision()
+	}
+	s.mu.Unlock()
+	s.write(kept)
+}
+
+// decide applies the policies to the trace and returns the chunks to write if it is kept.
+// s.mu must be held.
+func (s *tailSampler) decide(now time.Time, t *tailTrace) []*writer.SampledChunks {
+	t.decided = true
+	delete(s.traces, t.traceID)
+	s.size -= t.size
+
+	policy := s.match(t)
+	s.decisions[t.traceID] = tailDecision{keep: policy != nil, at: now}
+	s.decided = append(s.decided, t.traceID)
+	s.size += tailDecisionOverhead
+	if len(t.chunks) == 0 {
+		// all the chunks of the trace were kept by the samplers
+		return nil
+	}
+	if policy == nil {
+		s.stats.TracesDropped++
+		return nil
+	}
+	s.stats.TracesKept++
+	log.Debugf("Trace %d kept by tail sampling policy %q", t.traceID, policy.Name)
+
+	// group the chunks by payload to write them together
+	var kept []*writer.SampledChunks
+	byPayload := make(map[*pb.TracerPayload][]tailChunk)
+	for _, c := range t.chunks {
+		byPayload[c.payload] = append(byPayload[c.payload], c)
+	}
+	for _, chunks := range byPayload {
+		kept = append(kept, keptChunks(chunks))
+	}
+	return kept
+}
+
+// forgetOldestDecision forgets the oldest decision. s.mu must be held.
+func (s *tailSampler) forgetOldestDecision() {
+	delete(s.decisions, s.decided[0])
+	s.decided = s.decided[1:]
+	s.size -= tailDecisionOverhead
+}
+
+// match returns the first policy matching the trace, or nil if none does.
+func (s *tailSampler) match(t *tailTrace) *config.TailSamplingPolicy {
+	for i, p := range s.conf.Policies {
+		if p.Service != "" && p.Service != t.rootService {
+			continue
+		}
+		switch p.Type {
+		case config.TailSamplingLatencyPolicy:
+			if t.end-t.start >= p.ThresholdMs*int64(time.Millisecond) {
+				return p
+			}
+		case config.TailSamplingErrorPolicy:
+			if t.hasError {
+				return p
+			}
+		case config.TailSamplingAttributePolicy:
+			if t.attributes[i] {
+				return p
+			}
+		case config.TailSamplingRatePolicy:
+			if sampler.SampleByRate(t.traceID, p.Rate) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// write sends the kept chunks to the trace writer.
+func (s *tailSampler) write(kept []*writer.SampledChunks) {
+	for _, sc := range kept {
+		s.out <- sc
+	}
+}
+
+// report reports the state of the tail sampler.
+func (s *tailSampler) report() {
+	s.mu.Lock()
+	s.stats.BufferedTraces = int64(len(s.traces))
+	s.stats.BufferedBytes = s.size
+	stats := s.stats
+	s.mu.Unlock()
+
+	info.UpdateTailSampler(stats)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.buffered_traces", float64(stats.BufferedTraces), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.buffered_bytes", float64(stats.BufferedBytes), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.traces_kept", float64(stats.TracesKept), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.traces_dropped", float64(stats.TracesDropped), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.early_decisions", float64(stats.EarlyDecisions), nil, 1)
+}
+
+// payloadHeader returns a copy of the payload without its chunks, to hold the metadata of
+// the buffered chunks without retaining the other chunks of the payload.
+func payloadHeader(payload *pb.TracerPayload) *pb.TracerPayload {
+	return &pb.TracerPayload{
+		ContainerID:     payload.ContainerID,
+		LanguageName:    payload.LanguageName,
+		LanguageVersion: payload.LanguageVersion,
+		TracerVersion:   payload.TracerVersion,
+		RuntimeID:       payload.RuntimeID,
+		Tags:            payload.Tags,
+		Env:             payload.Env,
+		Hostname:        payload.Hostname,
+		AppVersion:      payload.AppVersion,
+	}
+}
+
+// keptChunks returns the chunks of a kept trace received in the same payload, ready to be written.
+func keptChunks(chunks []tailChunk) *writer.SampledChunks {
+	tp := *chunks[0].payload
+	tp.Chunks = make([]*pb.TraceChunk, 0, len(chunks))
+	sc := &writer.SampledChunks{TracerPayload: &tp}
+	for _, c := range chunks {
+		c.chunk.Priority = int32(sampler.PriorityAutoKeep)
+		c.chunk.DroppedTrace = false
+		tp.Chunks = append(tp.Chunks, c.chunk)
+		sc.Size += c.chunk.Msgsize()
+		sc.SpanCount += int64(len(c.chunk.Spans))
+	}
+	return sc
+}
@@ -0,0 +1,239 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+func newTestTailSampler(policies ...*config.TailSamplingPolicy) (*tailSampler, chan *writer.SampledChunks) {
+	out := make(chan *writer.SampledChunks, 100)
+	conf := &config.TailSamplingConfig{
+		Enabled:        true,
+		DecisionWait:   10 * time.Second,
+		MaxBufferBytes: 1024 * 1024,
+		Policies:       policies,
+	}
+	return newTailSampler(conf, out), out
+}
+
+func tailChunkWithSpans(spans ...*pb.Span) *pb.TraceChunk {
+	return &pb.TraceChunk{Priority: int32(sampler.PriorityAutoDrop), Spans: spans}
+}
+
+func TestTailSamplerDisabled(t *testing.T) {
+	assert.Nil(t, newTailSampler(nil, nil))
+	assert.Nil(t, newTailSampler(&config.TailSamplingConfig{}, nil))
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	now := time.Now()
+	root := func(traceID uint64, service string, duration time.Duration) *pb.Span {
+		return &pb.Span{TraceID: traceID, SpanID: 1, Service: service, Start: now.UnixNano(), Duration: int64(duration)}
+	}
+	child := func(traceID uint64, err int32, meta map[string]string) *pb.Span {
+		return &pb.Span{TraceID: traceID, SpanID: 2, ParentID: 1, Service: "db", Start: now.UnixNano(), Duration: int64(time.Millisecond), Error: err, Meta: meta}
+	}
+
+	for name, tt := range map[string]struct {
+		policy *config.TailSamplingPolicy
+		keep   []*pb.TraceChunk
+		drop   []*pb.TraceChunk
+	}{
+		"latency": {
+			policy: &config.TailSamplingPolicy{Name: "slow", Type: config.TailSamplingLatencyPolicy, ThresholdMs: 500},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "web", 100*time.Millisecond))},
+		},
+		"latency for a service": {
+			policy: &config.TailSamplingPolicy{Name: "slow", Type: config.TailSamplingLatencyPolicy, ThresholdMs: 500, Service: "web"},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "api", time.Second))},
+		},
+		"error": {
+			policy: &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingErrorPolicy},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second), child(1, 1, nil))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "web", time.Second), child(2, 0, nil))},
+		},
+		"attribute": {
+			policy: &config.TailSamplingPolicy{Name: "customers", Type: config.TailSamplingAttributePolicy, Key: "customer", Re: regexp.MustCompile("^vip-")},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second), child(1, 0, map[string]string{"customer": "vip-1"}))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "web", time.Second), child(2, 0, map[string]string{"customer": "regular"}))},
+		},
+		"attribute presence": {
+			policy: &config.TailSamplingPolicy{Name: "customers", Type: config.TailSamplingAttributePolicy, Key: "customer"},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second), child(1, 0, map[string]string{"customer": "regular"}))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "web", time.Second), child(2, 0, nil))},
+		},
+		"rate": {
+			policy: &config.TailSamplingPolicy{Name: "all", Type: config.TailSamplingRatePolicy, Rate: 1, Service: "web"},
+			keep:   []*pb.TraceChunk{tailChunkWithSpans(root(1, "web", time.Second))},
+			drop:   []*pb.TraceChunk{tailChunkWithSpans(root(2, "api", time.Second))},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, out := newTestTailSampler(tt.policy)
+			header := &pb.TracerPayload{Env: "prod"}
+			for _, c := range append(tt.keep, tt.drop...) {
+				s.Add(now, header, c)
+			}
+
+			// nothing is decided before the end of the decision wait
+			s.flush(now.Add(time.Second), false)
+			assert.Len(t, out, 0)
+
+			s.flush(now.Add(10*time.Second), false)
+			require.Len(t, out, len(tt.keep))
+			for _, c := range tt.keep {
+				sc := <-out
+				assert.Equal(t, []*pb.TraceChunk{c}, sc.TracerPayload.Chunks)
+				assert.Equal(t, "prod", sc.TracerPayload.Env)
+				assert.Equal(t, int32(sampler.PriorityAutoKeep), c.Priority)
+				assert.Equal(t, int64(len(c.Spans)), sc.SpanCount)
+			}
+			assert.Equal(t, int64(len(tt.keep)), s.stats.TracesKept)
+			assert.Equal(t, int64(len(tt.drop)), s.stats.TracesDropped)
+			assert.Empty(t, s.traces)
+			assert.Equal(t, int64(len(s.decisions)*tailDecisionOverhead), s.size)
+
+			// the decisions are forgotten after the decision wait
+			s.flush(now.Add(20*time.Second), false)
+			assert.Empty(t, s.decisions)
+			assert.Equal(t, int64(0), s.size)
+		})
+	}
+}
+
+func TestTailSamplerObservedChunks(t *testing.T) {
+	now := time.Now()
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingErrorPolicy})
+	header := &pb.TracerPayload{}
+
+	// the root is dropped by the samplers, the error is kept by the errors sampler
+	dropped := tailChunkWithSpans(&pb.Span{TraceID: 1, SpanID: 1, Service: "web"})
+	kept := tailChunkWithSpans(&pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Error: 1})
+	s.Add(now, header, dropped)
+	s.Observe(now, kept)
+
+	s.flush(now.Add(10*time.Second), false)
+	require.Len(t, out, 1)
+	assert.Equal(t, []*pb.TraceChunk{dropped}, (<-out).TracerPayload.Chunks)
+
+	// the chunks received after the decision follow it
+	late := tailChunkWithSpans(&pb.Span{TraceID: 1, SpanID: 3, ParentID: 1})
+	s.Add(now.Add(11*time.Second), header, late)
+	require.Len(t, out, 1)
+	assert.Equal(t, []*pb.TraceChunk{late}, (<-out).TracerPayload.Chunks)
+
+	// the decisions are forgotten after the decision wait
+	s.flush(now.Add(20*time.Second), false)
+	assert.Empty(t, s.decisions)
+}
+
+func TestTailSamplerBufferFull(t *testing.T) {
+	now := time.Now()
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Name: "all", Type: config.TailSamplingRatePolicy, Rate: 1})
+	first := tailChunkWithSpans(&pb.Span{TraceID: 1, SpanID: 1})
+	second := tailChunkWithSpans(&pb.Span{TraceID: 2, SpanID: 1})
+	s.conf.MaxBufferBytes = int64(first.Msgsize()+second.Msgsize()) + 2*tailTraceOverhead - 1
+
+	s.Add(now, &pb.TracerPayload{}, first)
+	assert.Len(t, out, 0)
+
+	// the oldest trace is decided early to make room for the new one
+	s.Add(now, &pb.TracerPayload{}, second)
+	require.Len(t, out, 1)
+	assert.Equal(t, []*pb.TraceChunk{first}, (<-out).TracerPayload.Chunks)
+	assert.Equal(t, int64(1), s.stats.EarlyDecisions)
+	assert.Equal(t, int64(second.Msgsize())+tailTraceOverhead+tailDecisionOverhead, s.size)
+
+	// all the traces are decided when stopping
+	s.Start()
+	s.Stop()
+	require.Len(t, out, 1)
+	assert.Equal(t, []*pb.TraceChunk{second}, (<-out).TracerPayload.Chunks)
+}
+
+func TestTailSamplerBufferBoundsObservedChunks(t *testing.T) {
+	now := time.Now()
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingErrorPolicy})
+	s.conf.MaxBufferBytes = 100 * tailTraceOverhead
+
+	// the traces whose chunks are all kept by the samplers are bounded by the buffer too
+	for i := 1; i <= 10000; i++ {
+		s.Observe(now, tailChunkWithSpans(&pb.Span{TraceID: uint64(i), SpanID: 1}))
+		assert.LessOrEqual(t, s.size, s.conf.MaxBufferBytes)
+	}
+	assert.LessOrEqual(t, len(s.traces), 100)
+	assert.LessOrEqual(t, len(s.queue), 100)
+	assert.LessOrEqual(t, len(s.decisions)*tailDecisionOverhead, int(s.conf.MaxBufferBytes))
+	assert.Len(t, s.decided, len(s.decisions))
+	assert.Len(t, out, 0)
+
+	s.flush(now.Add(time.Minute), true)
+	assert.Empty(t, s.traces)
+	s.flush(now.Add(2*time.Minute), false)
+	assert.Empty(t, s.decisions)
+	assert.Equal(t, int64(0), s.size)
+}
+
+func TestProcessTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "slow", Type: config.TailSamplingLatencyPolicy, ThresholdMs: 500}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector())
+	require.NotNil(t, agnt.TailSampler)
+
+	now := time.Now()
+	slow := testutil.TraceChunkWithSpanAndPriority(&pb.Span{
+		Service:  "web",
+		TraceID:  1,
+		SpanID:   1,
+		Start:    now.Add(-2 * time.Second).UnixNano(),
+		Duration: time.Second.Nanoseconds(),
+	}, int32(sampler.PriorityAutoDrop))
+	userDrop := testutil.TraceChunkWithSpanAndPriority(&pb.Span{
+		Service:  "web",
+		TraceID:  2,
+		SpanID:   1,
+		Start:    now.Add(-2 * time.Second).UnixNano(),
+		Duration: time.Second.Nanoseconds(),
+	}, int32(sampler.PriorityUserDrop))
+	tp := testutil.TracerPayloadWithChunks([]*pb.TraceChunk{slow, userDrop})
+	tp.ContainerID = "container"
+	agnt.Process(&api.Payload{
+		TracerPayload: tp,
+		Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+	})
+	assert.Len(t, agnt.TraceWriter.In, 0)
+
+	// the slow trace is kept once the decision wait is over, the trace dropped by the user is not
+	agnt.TailSampler.flush(time.Now().Add(cfg.TailSampling.DecisionWait), false)
+	require.Len(t, agnt.TraceWriter.In, 1)
+	ss := <-agnt.TraceWriter.In
+	assert.Equal(t, "container", ss.TracerPayload.ContainerID)
+	require.Len(t, ss.TracerPayload.Chunks, 1)
+	assert.Equal(t, uint64(1), ss.TracerPayload.Chunks[0].Spans[0].TraceID)
+	assert.Equal(t, int32(sampler.PriorityAutoKeep), ss.TracerPayload.Chunks[0].Priority)
+}
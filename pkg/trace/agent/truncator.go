@@ -51,6 +51,38 @@ func (a *Agent) Truncate(s *pb.Span) {
 			s.Metrics[k] = v
 		}
 	}
+	for _, l := range s.SpanLinks {
+		truncateSpanLink(l)
+	}
+}
+
+// truncateSpanLink checks that the attributes and the tracestate of the span link l are within
+// the max length and modifies them if they are not.
+func truncateSpanLink(l *pb.SpanLink) {
+	for k, v := range l.Attributes {
+		modified := false
+
+		if len(k) > MaxMetaKeyLen {
+			log.Debugf("span.truncate: truncating span link attribute key (max %d chars): %s", MaxMetaKeyLen, k)
+			delete(l.Attributes, k)
+			k = traceutil.TruncateUTF8(k, MaxMetaKeyLen) + "..."
+			modified = true
+		}
+
+		if len(v) > MaxMetaValLen {
+			v = traceutil.TruncateUTF8(v, MaxMetaValLen) + "..."
+			modified = true
+		}
+
+		if modified {
+			l.Attributes[k] = v
+		}
+	}
+	if len(l.Tracestate) > MaxTracestateLen {
+		// a truncated tracestate can not be parsed, drop it instead
+		log.Debugf("span.truncate: dropping span link tracestate (max %d chars): %s", MaxTracestateLen, l.Tracestate)
+		l.Tracestate = ""
+	}
 }
 
 const (
@@ -60,6 +92,8 @@ const (
 	MaxMetaValLen = 25000
 	// MaxMetricsKeyLen the maximum length of a metric name key
 	MaxMetricsKeyLen = MaxMetaKeyLen
+	// MaxTracestateLen the maximum length of a span link tracestate, as defined by W3C trace context
+	MaxTracestateLen = 512
 )
 
 // TruncateResource truncates a span's resource to the maximum allowed length.
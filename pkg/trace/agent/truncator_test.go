@@ -102,6 +102,32 @@ func TestTruncateMetaValueTooLong(t *testing.T) {
 	}
 }
 
+func TestTruncateSpanLinks(t *testing.T) {
+	a := &Agent{conf: config.New()}
+	s := testSpan()
+	tracestate := strings.Repeat("dd=s:1,", 100)
+	s.SpanLinks = []*pb.SpanLink{
+		{
+			TraceID: 1,
+			SpanID:  2,
+			Attributes: map[string]string{
+				strings.Repeat("TOOLONG", 1000): "foo",
+				"foo":                           strings.Repeat("TOOLONG", 25000),
+			},
+			Tracestate: tracestate,
+		},
+		{TraceID: 3, SpanID: 4, Tracestate: "dd=s:1"},
+	}
+	a.Truncate(s)
+	assert.Len(t, s.SpanLinks[0].Attributes, 2)
+	for k, v := range s.SpanLinks[0].Attributes {
+		assert.True(t, len(k) < MaxMetaKeyLen+4)
+		assert.True(t, len(v) < MaxMetaValLen+4)
+	}
+	assert.Empty(t, s.SpanLinks[0].Tracestate)
+	assert.Equal(t, "dd=s:1", s.SpanLinks[1].Tracestate)
+}
+
 func TestTruncateResource(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	t.Run("over", func(t *testing.T) {
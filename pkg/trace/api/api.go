@@ -372,6 +372,30 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 		var tracerPayload pb.TracerPayload
 		_, err = tracerPayload.UnmarshalMsg(buf.Bytes())
 		return &tracerPayload, true, err
+	case zipkinV2, jaegerV1:
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if _, err = io.Copy(buf, req.Body); err != nil {
+			return nil, false, err
+		}
+		var spans []*pb.Span
+		if v == zipkinV2 {
+			spans, err = decodeZipkin(getMediaType(req), buf.Bytes())
+		} else {
+			spans, err = decodeJaeger(getMediaType(req), buf.Bytes())
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		chunks := traceChunksFromForeignSpans(spans)
+		runMetaHook(chunks)
+		return &pb.TracerPayload{
+			LanguageName:    ts.Lang,
+			LanguageVersion: ts.LangVersion,
+			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			Chunks:          chunks,
+			TracerVersion:   ts.TracerVersion,
+		}, true, nil
 	default:
 		var traces pb.Traces
 		if ranHook, err = decodeRequest(req, &traces); err != nil {
@@ -392,7 +416,7 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 // was successful.
 func (r *HTTPReceiver) replyOK(req *http.Request, v Version, w http.ResponseWriter) (n uint64, ok bool) {
 	switch v {
-	case v01, v02, v03:
+	case v01, v02, v03, zipkinV2, jaegerV1:
 		return httpOK(w)
 	default:
 		ratesVersion := req.Header.Get(header.RatesPayloadVersion)
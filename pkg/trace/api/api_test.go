@@ -1083,7 +1083,7 @@ func TestExpvar(t *testing.T) {
 
 	c := config.New()
 	c.DebugServerPort = 5012
-	s := NewDebugServer(c)
+	s := NewDebugServer(c, nil)
 	s.Start()
 	defer s.Stop()
 
@@ -10,6 +10,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"expvar"
 	"fmt"
 	"net"
@@ -20,24 +21,32 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
 )
 
 const (
 	defaultTimeout          = 5 * time.Second
 	defaultShutdownDeadline = 5 * time.Second
+	// tracesFlushInterval is the interval at which the traces streamed by /debug/traces are flushed.
+	tracesFlushInterval = time.Second
 )
 
+// connContextKey is the key of the context value holding the connection of a request.
+type connContextKey struct{}
+
 // DebugServer serves /debug/* endpoints
 type DebugServer struct {
-	conf   *config.AgentConfig
-	server *http.Server
+	conf     *config.AgentConfig
+	server   *http.Server
+	recorder *inspect.Recorder
 }
 
-// NewDebugServer returns a debug server
-func NewDebugServer(conf *config.AgentConfig) *DebugServer {
+// NewDebugServer returns a debug server serving the trace chunks recorded by recorder.
+func NewDebugServer(conf *config.AgentConfig, recorder *inspect.Recorder) *DebugServer {
 	return &DebugServer{
-		conf: conf,
+		conf:     conf,
+		recorder: recorder,
 	}
 }
 
@@ -51,6 +60,9 @@ func (ds *DebugServer) Start() {
 		ReadTimeout:  defaultTimeout,
 		WriteTimeout: defaultTimeout,
 		Handler:      ds.mux(),
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return context.WithValue(ctx, connContextKey{}, c)
+		},
 	}
 	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", ds.conf.DebugServerPort))
 	if err != nil {
@@ -109,5 +121,56 @@ func (ds *DebugServer) mux() *http.ServeMux {
 		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:"+ds.conf.GUIPort)
 		expvar.Handler().ServeHTTP(w, req)
 	}))
+	mux.HandleFunc("/debug/traces", ds.handleTraces)
 	return mux
 }
+
+// handleTraces streams the trace chunks processed by the agent as newline-delimited JSON,
+// starting with the ones kept in the inspection buffer. The chunks can be filtered using
+// the service and env query string parameters.
+func (ds *DebugServer) handleTraces(w http.ResponseWriter, r *http.Request) {
+	if ds.recorder == nil {
+		http.Error(w, "trace inspection is not available", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	// streaming holds the connection open, past the write timeout of the server
+	if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+		_ = conn.SetWriteDeadline(time.Time{})
+	}
+	filter := inspect.Filter{
+		Service: r.URL.Query().Get("service"),
+		Env:     r.URL.Query().Get("env"),
+	}
+	recent, records, unsubscribe := ds.recorder.Subscribe(filter)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	enc := json.NewEncoder(w)
+	for _, rec := range recent {
+		if err := enc.Encode(rec); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+	flushTicker := time.NewTicker(tracesFlushInterval)
+	defer flushTicker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case rec := <-records:
+			if err := enc.Encode(rec); err != nil {
+				log.Debugf("Stopped streaming traces: %v", err)
+				return
+			}
+		case <-flushTicker.C:
+			flusher.Flush()
+		}
+	}
+}
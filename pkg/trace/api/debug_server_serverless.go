@@ -8,11 +8,14 @@
 
 package api
 
-import "github.com/DataDog/datadog-agent/pkg/trace/config"
+import (
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
+)
 
 type DebugServer struct{}
 
-func NewDebugServer(conf *config.AgentConfig) *DebugServer {
+func NewDebugServer(conf *config.AgentConfig, recorder *inspect.Recorder) *DebugServer {
 	return new(DebugServer)
 }
 
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023-present Datadog, Inc.
+
+//go:build !serverless
+// +build !serverless
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/inspect"
+)
+
+func TestDebugTraces(t *testing.T) {
+	t.Run("unavailable", func(t *testing.T) {
+		srv := httptest.NewServer(NewDebugServer(config.New(), nil).mux())
+		defer srv.Close()
+		resp, err := http.Get(srv.URL + "/debug/traces")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		rec := inspect.NewRecorder(10)
+		rec.Add(&inspect.Record{TraceID: 1, Env: "prod", Decision: inspect.DecisionKept})
+		rec.Add(&inspect.Record{TraceID: 2, Env: "staging", Decision: inspect.DecisionKept})
+		srv := httptest.NewServer(NewDebugServer(config.New(), rec).mux())
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/debug/traces?env=prod")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		dec := json.NewDecoder(resp.Body)
+
+		var got inspect.Record
+		assert.NoError(t, dec.Decode(&got))
+		assert.EqualValues(t, 1, got.TraceID)
+
+		rec.Add(&inspect.Record{TraceID: 3, Env: "staging"})
+		rec.Add(&inspect.Record{TraceID: 4, Env: "prod", Decision: inspect.DecisionDropped, DecidedBy: "priority_sampler"})
+		assert.NoError(t, dec.Decode(&got))
+		assert.EqualValues(t, 4, got.TraceID)
+		assert.Equal(t, "priority_sampler", got.DecidedBy)
+	})
+}
@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package thrift implements a reader of the Thrift binary protocol, which is enough to decode
+// the structures sent by the Thrift clients without depending on generated code.
+package thrift
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Type specifies the type of a Thrift value.
+type Type byte
+
+// Thrift types, as encoded by the binary protocol.
+const (
+	Stop   Type = 0
+	Void   Type = 1
+	Bool   Type = 2
+	Byte   Type = 3
+	Double Type = 4
+	I16    Type = 6
+	I32    Type = 8
+	I64    Type = 10
+	String Type = 11
+	Struct Type = 12
+	Map    Type = 13
+	Set    Type = 14
+	List   Type = 15
+)
+
+// maxDepth is the maximum nesting depth of the skipped values.
+const maxDepth = 64
+
+// ErrShortBuffer is returned when the buffer ends in the middle of a value.
+var ErrShortBuffer = errors.New("thrift: unexpected end of buffer")
+
+// Reader reads values encoded with the Thrift binary protocol from a buffer. The first error
+// encountered is kept and returned by Err, after which all reads return zero values.
+type Reader struct {
+	buf []byte
+	off int
+	err error
+}
+
+// NewReader returns a new Reader reading from b.
+func NewReader(b []byte) *Reader {
+	return &Reader{buf: b}
+}
+
+// Err returns the first error encountered by the reader, or nil.
+func (r *Reader) Err() error { return r.err }
+
+func (r *Reader) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// next returns the next n bytes of the buffer.
+func (r *Reader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.buf)-r.off < n {
+		r.setErr(ErrShortBuffer)
+		return nil
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+// ReadFieldBegin reads the header of a struct field. The type is Stop at the end of the struct.
+func (r *Reader) ReadFieldBegin() (typ Type, id int16) {
+	typ = Type(r.ReadByte())
+	if typ == Stop || r.err != nil {
+		return Stop, 0
+	}
+	return typ, r.ReadI16()
+}
+
+// ReadListBegin reads the header of a list or a set, and returns the type and the number of its elements.
+func (r *Reader) ReadListBegin() (elem Type, size int) {
+	elem = Type(r.ReadByte())
+	return elem, r.readSize(1)
+}
+
+// ReadMapBegin reads the header of a map, and returns the types of its keys and values and its size.
+func (r *Reader) ReadMapBegin() (key, value Type, size int) {
+	key = Type(r.ReadByte())
+	value = Type(r.ReadByte())
+	return key, value, r.readSize(2)
+}
+
+// readSize reads the size of a container whose elements take at least min bytes, and makes sure
+// that the buffer may hold them, so that corrupted sizes do not cause large allocations.
+func (r *Reader) readSize(min int) int {
+	size := int(r.ReadI32())
+	if r.err != nil {
+		return 0
+	}
+	if size < 0 || size > (len(r.buf)-r.off)/min {
+		r.setErr(fmt.Errorf("thrift: invalid container size %d", size))
+		return 0
+	}
+	return size
+}
+
+// ReadBool reads a boolean.
+func (r *Reader) ReadBool() bool {
+	return r.ReadByte() != 0
+}
+
+// ReadByte reads a byte.
+func (r *Reader) ReadByte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// ReadI16 reads a 16-bit integer.
+func (r *Reader) ReadI16() int16 {
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+// ReadI32 reads a 32-bit integer.
+func (r *Reader) ReadI32() int32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+// ReadI64 reads a 64-bit integer.
+func (r *Reader) ReadI64() int64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// ReadDouble reads a double precision floating point number.
+func (r *Reader) ReadDouble() float64 {
+	return math.Float64frombits(uint64(r.ReadI64()))
+}
+
+// ReadBinary reads a byte array. The returned slice references the buffer of the reader.
+func (r *Reader) ReadBinary() []byte {
+	return r.next(r.readSize(1))
+}
+
+// ReadString reads a string.
+func (r *Reader) ReadString() string {
+	return string(r.ReadBinary())
+}
+
+// Skip skips a value of the given type.
+func (r *Reader) Skip(typ Type) {
+	r.skip(typ, 0)
+}
+
+func (r *Reader) skip(typ Type, depth int) {
+	if depth > maxDepth {
+		r.setErr(errors.New("thrift: maximum nesting depth exceeded"))
+		return
+	}
+	switch typ {
+	case Bool, Byte:
+		r.next(1)
+	case I16:
+		r.next(2)
+	case I32:
+		r.next(4)
+	case Double, I64:
+		r.next(8)
+	case String:
+		r.ReadBinary()
+	case Struct:
+		for r.err == nil {
+			ftyp, _ := r.ReadFieldBegin()
+			if ftyp == Stop {
+				return
+			}
+			r.skip(ftyp, depth+1)
+		}
+	case Map:
+		key, value, size := r.ReadMapBegin()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(key, depth+1)
+			r.skip(value, depth+1)
+		}
+	case Set, List:
+		elem, size := r.ReadListBegin()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(elem, depth+1)
+		}
+	default:
+		r.setErr(fmt.Errorf("thrift: unknown type %d", typ))
+	}
+}
@@ -0,0 +1,563 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/thrift"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// jaegerBatch is a batch of spans sent by a Jaeger client, along with the process which emitted them.
+type jaegerBatch struct {
+	process jaegerProcess
+	spans   []jaegerSpan
+}
+
+type jaegerProcess struct {
+	serviceName string
+	tags        []jaegerTag
+}
+
+type jaegerSpan struct {
+	traceIDHigh   uint64
+	traceIDLow    uint64
+	spanID        uint64
+	parentID      uint64
+	operationName string
+	references    []jaegerSpanRef
+	flags         uint32
+	start         int64 // nanoseconds
+	duration      int64 // nanoseconds
+	tags          []jaegerTag
+	logs          []jaegerLog
+	// process is set when the span was emitted by another process than the one of its batch.
+	process *jaegerProcess
+}
+
+// jaegerRefChildOf is the type of the references to the parent span.
+const jaegerRefChildOf = 0
+
+type jaegerSpanRef struct {
+	refType     int32
+	traceIDHigh uint64
+	traceIDLow  uint64
+	spanID      uint64
+}
+
+type jaegerTagType int
+
+const (
+	jaegerString jaegerTagType = iota
+	jaegerBool
+	jaegerInt64
+	jaegerFloat64
+	jaegerBinary
+)
+
+type jaegerTag struct {
+	key     string
+	typ     jaegerTagType
+	str     string
+	boolean bool
+	int64   int64
+	float64 float64
+	binary  []byte
+}
+
+// String returns the value of the tag as a string.
+func (t *jaegerTag) String() string {
+	switch t.typ {
+	case jaegerBool:
+		return strconv.FormatBool(t.boolean)
+	case jaegerInt64:
+		return strconv.FormatInt(t.int64, 10)
+	case jaegerFloat64:
+		return strconv.FormatFloat(t.float64, 'f', -1, 64)
+	case jaegerBinary:
+		return base64.StdEncoding.EncodeToString(t.binary)
+	default:
+		return t.str
+	}
+}
+
+type jaegerLog struct {
+	timestamp int64 // nanoseconds
+	fields    []jaegerTag
+}
+
+// decodeJaeger decodes a batch of Jaeger spans and converts them to Datadog spans.
+func decodeJaeger(mediaType string, b []byte) ([]*pb.Span, error) {
+	batch, err := decodeJaegerBatch(mediaType, b)
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]*pb.Span, 0, len(batch.spans))
+	for i := range batch.spans {
+		spans = append(spans, convertJaegerSpan(&batch.process, &batch.spans[i]))
+	}
+	return spans, nil
+}
+
+// decodeJaegerBatch decodes a batch of Jaeger spans encoded, depending on the media type, as a
+// Batch structure with the Thrift binary protocol or as a PostSpansRequest protobuf message.
+func decodeJaegerBatch(mediaType string, b []byte) (*jaegerBatch, error) {
+	switch mediaType {
+	case "application/x-thrift", "application/vnd.apache.thrift.binary":
+		return decodeJaegerThrift(b)
+	case "application/x-protobuf":
+		return decodeJaegerProto(b)
+	default:
+		return nil, fmt.Errorf("unsupported media type: %q", mediaType)
+	}
+}
+
+// decodeJaegerThrift decodes a Batch structure encoded with the Thrift binary protocol.
+// See https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift
+func decodeJaegerThrift(b []byte) (*jaegerBatch, error) {
+	r := thrift.NewReader(b)
+	var batch jaegerBatch
+	readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+		switch {
+		case id == 1 && typ == thrift.Struct:
+			readJaegerThriftProcess(r, &batch.process)
+		case id == 2 && typ == thrift.List:
+			_, n := r.ReadListBegin()
+			batch.spans = make([]jaegerSpan, n)
+			for i := range batch.spans {
+				readJaegerThriftSpan(r, &batch.spans[i])
+			}
+		default:
+			return false
+		}
+		return true
+	})
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// readThriftStruct reads the fields of a structure, calling fn with each of them. The fields for
+// which fn returns false are skipped.
+func readThriftStruct(r *thrift.Reader, fn func(id int16, typ thrift.Type) bool) {
+	for r.Err() == nil {
+		typ, id := r.ReadFieldBegin()
+		if typ == thrift.Stop {
+			return
+		}
+		if !fn(id, typ) {
+			r.Skip(typ)
+		}
+	}
+}
+
+func readJaegerThriftProcess(r *thrift.Reader, p *jaegerProcess) {
+	readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+		switch {
+		case id == 1 && typ == thrift.String:
+			p.serviceName = r.ReadString()
+		case id == 2 && typ == thrift.List:
+			p.tags = readJaegerThriftTags(r)
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+func readJaegerThriftSpan(r *thrift.Reader, s *jaegerSpan) {
+	readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+		switch {
+		case id == 1 && typ == thrift.I64:
+			s.traceIDLow = uint64(r.ReadI64())
+		case id == 2 && typ == thrift.I64:
+			s.traceIDHigh = uint64(r.ReadI64())
+		case id == 3 && typ == thrift.I64:
+			s.spanID = uint64(r.ReadI64())
+		case id == 4 && typ == thrift.I64:
+			s.parentID = uint64(r.ReadI64())
+		case id == 5 && typ == thrift.String:
+			s.operationName = r.ReadString()
+		case id == 6 && typ == thrift.List:
+			_, n := r.ReadListBegin()
+			s.references = make([]jaegerSpanRef, n)
+			for i := range s.references {
+				ref := &s.references[i]
+				readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+					switch {
+					case id == 1 && typ == thrift.I32:
+						ref.refType = r.ReadI32()
+					case id == 2 && typ == thrift.I64:
+						ref.traceIDLow = uint64(r.ReadI64())
+					case id == 3 && typ == thrift.I64:
+						ref.traceIDHigh = uint64(r.ReadI64())
+					case id == 4 && typ == thrift.I64:
+						ref.spanID = uint64(r.ReadI64())
+					default:
+						return false
+					}
+					return true
+				})
+			}
+		case id == 7 && typ == thrift.I32:
+			s.flags = uint32(r.ReadI32())
+		case id == 8 && typ == thrift.I64:
+			s.start = r.ReadI64() * 1000
+		case id == 9 && typ == thrift.I64:
+			s.duration = r.ReadI64() * 1000
+		case id == 10 && typ == thrift.List:
+			s.tags = readJaegerThriftTags(r)
+		case id == 11 && typ == thrift.List:
+			_, n := r.ReadListBegin()
+			s.logs = make([]jaegerLog, n)
+			for i := range s.logs {
+				l := &s.logs[i]
+				readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+					switch {
+					case id == 1 && typ == thrift.I64:
+						l.timestamp = r.ReadI64() * 1000
+					case id == 2 && typ == thrift.List:
+						l.fields = readJaegerThriftTags(r)
+					default:
+						return false
+					}
+					return true
+				})
+			}
+		default:
+			return false
+		}
+		return true
+	})
+}
+
+// jaegerThriftTagTypes maps the Thrift TagType enum to the tag types.
+var jaegerThriftTagTypes = map[int32]jaegerTagType{
+	0: jaegerString,
+	1: jaegerFloat64,
+	2: jaegerBool,
+	3: jaegerInt64,
+	4: jaegerBinary,
+}
+
+func readJaegerThriftTags(r *thrift.Reader) []jaegerTag {
+	_, n := r.ReadListBegin()
+	tags := make([]jaegerTag, n)
+	for i := range tags {
+		t := &tags[i]
+		readThriftStruct(r, func(id int16, typ thrift.Type) bool {
+			switch {
+			case id == 1 && typ == thrift.String:
+				t.key = r.ReadString()
+			case id == 2 && typ == thrift.I32:
+				t.typ = jaegerThriftTagTypes[r.ReadI32()]
+			case id == 3 && typ == thrift.String:
+				t.str = r.ReadString()
+			case id == 4 && typ == thrift.Double:
+				t.float64 = r.ReadDouble()
+			case id == 5 && typ == thrift.Bool:
+				t.boolean = r.ReadBool()
+			case id == 6 && typ == thrift.I64:
+				t.int64 = r.ReadI64()
+			case id == 7 && typ == thrift.String:
+				t.binary = append([]byte(nil), r.ReadBinary()...)
+			default:
+				return false
+			}
+			return true
+		})
+	}
+	return tags
+}
+
+// decodeJaegerProto decodes a PostSpansRequest protobuf message.
+// See https://github.com/jaegertracing/jaeger-idl/blob/main/proto/api_v2/collector.proto
+func decodeJaegerProto(b []byte) (*jaegerBatch, error) {
+	var batch jaegerBatch
+	err := rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		return rangeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+			switch num {
+			case 1:
+				var s jaegerSpan
+				if err := decodeJaegerProtoSpan(v, &s); err != nil {
+					return err
+				}
+				batch.spans = append(batch.spans, s)
+			case 2:
+				return decodeJaegerProtoProcess(v, &batch.process)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func decodeJaegerProtoSpan(b []byte, s *jaegerSpan) error {
+	return rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		var err error
+		switch num {
+		case 1:
+			s.traceIDHigh, s.traceIDLow, err = jaegerProtoTraceID(v)
+		case 2:
+			s.spanID, err = jaegerProtoSpanID(v)
+		case 3:
+			s.operationName = string(v)
+		case 4:
+			var ref jaegerSpanRef
+			err = rangeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+				var err error
+				switch num {
+				case 1:
+					ref.traceIDHigh, ref.traceIDLow, err = jaegerProtoTraceID(v)
+				case 2:
+					ref.spanID, err = jaegerProtoSpanID(v)
+				case 3:
+					ref.refType = int32(n)
+				}
+				return err
+			})
+			s.references = append(s.references, ref)
+		case 5:
+			s.flags = uint32(n)
+		case 6:
+			s.start, err = decodeProtoTimestamp(v)
+		case 7:
+			s.duration, err = decodeProtoTimestamp(v)
+		case 8:
+			var t jaegerTag
+			err = decodeJaegerProtoTag(v, &t)
+			s.tags = append(s.tags, t)
+		case 9:
+			var l jaegerLog
+			err = rangeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+				var err error
+				switch num {
+				case 1:
+					l.timestamp, err = decodeProtoTimestamp(v)
+				case 2:
+					var t jaegerTag
+					err = decodeJaegerProtoTag(v, &t)
+					l.fields = append(l.fields, t)
+				}
+				return err
+			})
+			s.logs = append(s.logs, l)
+		case 10:
+			s.process = new(jaegerProcess)
+			err = decodeJaegerProtoProcess(v, s.process)
+		}
+		return err
+	})
+}
+
+func decodeJaegerProtoProcess(b []byte, p *jaegerProcess) error {
+	return rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			p.serviceName = string(v)
+		case 2:
+			var t jaegerTag
+			if err := decodeJaegerProtoTag(v, &t); err != nil {
+				return err
+			}
+			p.tags = append(p.tags, t)
+		}
+		return nil
+	})
+}
+
+// jaegerProtoTagTypes maps the protobuf ValueType enum to the tag types.
+var jaegerProtoTagTypes = map[uint64]jaegerTagType{
+	0: jaegerString,
+	1: jaegerBool,
+	2: jaegerInt64,
+	3: jaegerFloat64,
+	4: jaegerBinary,
+}
+
+func decodeJaegerProtoTag(b []byte, t *jaegerTag) error {
+	return rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			t.key = string(v)
+		case 2:
+			t.typ = jaegerProtoTagTypes[n]
+		case 3:
+			t.str = string(v)
+		case 4:
+			t.boolean = n != 0
+		case 5:
+			t.int64 = int64(n)
+		case 6:
+			t.float64 = math.Float64frombits(n)
+		case 7:
+			t.binary = append([]byte(nil), v...)
+		}
+		return nil
+	})
+}
+
+// jaegerProtoTraceID returns the upper and lower 64 bits of the 128-bit trace ID b.
+func jaegerProtoTraceID(b []byte) (high, low uint64, err error) {
+	switch len(b) {
+	case 16:
+		return binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:]), nil
+	case 8:
+		return 0, binary.BigEndian.Uint64(b), nil
+	}
+	return 0, 0, fmt.Errorf("invalid trace ID length %d", len(b))
+}
+
+func jaegerProtoSpanID(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("invalid span ID length %d", len(b))
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// decodeProtoTimestamp decodes a google.protobuf.Timestamp or a google.protobuf.Duration message,
+// and returns it in nanoseconds.
+func decodeProtoTimestamp(b []byte) (int64, error) {
+	var seconds, nanos int64
+	err := rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, _ []byte, n uint64) error {
+		switch num {
+		case 1:
+			seconds = int64(n)
+		case 2:
+			nanos = int64(int32(n))
+		}
+		return nil
+	})
+	return seconds*1e9 + nanos, err
+}
+
+// jaegerKinds maps the values of the "span.kind" tag to the OpenTelemetry span kinds.
+var jaegerKinds = map[string]ptrace.SpanKind{
+	"client":   ptrace.SpanKindClient,
+	"server":   ptrace.SpanKindServer,
+	"producer": ptrace.SpanKindProducer,
+	"consumer": ptrace.SpanKindConsumer,
+	"internal": ptrace.SpanKindInternal,
+}
+
+const (
+	// jaegerFlagDebug is set on the spans of the traces which the user forced to sample.
+	jaegerFlagDebug = 2
+)
+
+// convertJaegerSpan converts the Jaeger span in to a Datadog span, using the tags of the process
+// which emitted it as meta. Numeric tags are set as metrics, logs are set as events, the references
+// to other spans than the parent as span links and the upper 64 bits of the trace ID as the "_dd.p.tid" tag.
+func convertJaegerSpan(process *jaegerProcess, in *jaegerSpan) *pb.Span {
+	if in.process != nil {
+		process = in.process
+	}
+	span := &pb.Span{
+		TraceID:  in.traceIDLow,
+		SpanID:   in.spanID,
+		ParentID: in.parentID,
+		Service:  process.serviceName,
+		Name:     in.operationName,
+		Start:    in.start,
+		Duration: in.duration,
+		Meta:     make(map[string]string, len(process.tags)+len(in.tags)),
+		Metrics:  map[string]float64{},
+	}
+	for _, ref := range in.references {
+		if span.ParentID == 0 && ref.refType == jaegerRefChildOf && ref.traceIDLow == in.traceIDLow {
+			span.ParentID = ref.spanID
+			continue
+		}
+		if ref.spanID == span.ParentID && ref.traceIDLow == in.traceIDLow {
+			continue
+		}
+		// the other references, e.g. to the spans which produced the message consumed by a
+		// FOLLOWS_FROM span, are kept as links
+		span.SpanLinks = append(span.SpanLinks, &pb.SpanLink{
+			TraceID:     ref.traceIDLow,
+			TraceIDHigh: ref.traceIDHigh,
+			SpanID:      ref.spanID,
+		})
+	}
+	traceutil.SetTraceIDHigh(span, in.traceIDHigh)
+	for i := range process.tags {
+		setMetaOTLP(span, process.tags[i].key, process.tags[i].String())
+	}
+	for i := range in.tags {
+		t := &in.tags[i]
+		switch {
+		case t.key == "error":
+			if t.String() == "true" {
+				span.Error = 1
+			}
+		case t.typ == jaegerInt64:
+			setMetricOTLP(span, t.key, float64(t.int64))
+		case t.typ == jaegerFloat64:
+			setMetricOTLP(span, t.key, t.float64)
+		default:
+			setMetaOTLP(span, t.key, t.String())
+		}
+	}
+	if len(in.logs) > 0 {
+		events := make([]spanEvent, 0, len(in.logs))
+		for _, l := range in.logs {
+			e := spanEvent{TimeUnixNano: uint64(l.timestamp), Attributes: make(map[string]string, len(l.fields))}
+			for i := range l.fields {
+				e.Attributes[l.fields[i].key] = l.fields[i].String()
+			}
+			e.Name = e.Attributes["event"]
+			delete(e.Attributes, "event")
+			if span.Error == 1 && e.Name == "error" {
+				jaegerErrorFromLog(span, e.Attributes)
+			}
+			events = append(events, e)
+		}
+		setMetaOTLP(span, "events", marshalSpanEvents(events))
+	}
+	if in.flags&jaegerFlagDebug != 0 {
+		if _, ok := span.Metrics["_sampling_priority_v1"]; !ok {
+			span.Metrics["_sampling_priority_v1"] = float64(sampler.PriorityUserKeep)
+		}
+	}
+	completeSpan(span, jaegerKinds[span.Meta["span.kind"]])
+	return span
+}
+
+// jaegerErrorFromLog sets the error tags of the span from the fields of an error log, following
+// the OpenTracing semantic conventions.
+func jaegerErrorFromLog(span *pb.Span, fields map[string]string) {
+	for field, tag := range map[string]string{
+		"message":    "error.msg",
+		"error.kind": "error.type",
+		"stack":      "error.stack",
+	} {
+		if v, ok := fields[field]; ok {
+			span.Meta[tag] = v
+		}
+	}
+	if _, ok := span.Meta["error.msg"]; !ok {
+		if v, ok := fields["error.object"]; ok {
+			span.Meta["error.msg"] = v
+		}
+	}
+}
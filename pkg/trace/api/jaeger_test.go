@@ -0,0 +1,300 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/thrift"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// thriftWriter writes values with the Thrift binary protocol.
+type thriftWriter struct{ bytes.Buffer }
+
+func (w *thriftWriter) field(typ thrift.Type, id int16) {
+	w.WriteByte(byte(typ))
+	binary.Write(w, binary.BigEndian, id)
+}
+
+func (w *thriftWriter) stop() { w.WriteByte(byte(thrift.Stop)) }
+
+func (w *thriftWriter) list(elem thrift.Type, size int32) {
+	w.WriteByte(byte(elem))
+	binary.Write(w, binary.BigEndian, size)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(thrift.I32, id)
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(thrift.I64, id)
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *thriftWriter) str(id int16, v string) {
+	w.field(thrift.String, id)
+	binary.Write(w, binary.BigEndian, int32(len(v)))
+	w.WriteString(v)
+}
+
+// tag writes a Jaeger Tag structure, with v being a string, a bool, an int64 or a float64.
+func (w *thriftWriter) tag(key string, v interface{}) {
+	w.str(1, key)
+	switch v := v.(type) {
+	case string:
+		w.i32(2, 0)
+		w.str(3, v)
+	case float64:
+		w.i32(2, 1)
+		w.field(thrift.Double, 4)
+		binary.Write(w, binary.BigEndian, math.Float64bits(v))
+	case bool:
+		w.i32(2, 2)
+		w.field(thrift.Bool, 5)
+		if v {
+			w.WriteByte(1)
+		} else {
+			w.WriteByte(0)
+		}
+	case int64:
+		w.i32(2, 3)
+		w.i64(6, v)
+	}
+	w.stop()
+}
+
+// jaegerTestThriftBatch returns a Batch holding a server span and its child.
+func jaegerTestThriftBatch() []byte {
+	var w thriftWriter
+	// Process
+	w.field(thrift.Struct, 1)
+	w.str(1, "backend")
+	w.field(thrift.List, 2)
+	w.list(thrift.Struct, 1)
+	w.tag("hostname", "host-1")
+	w.stop()
+	// Spans
+	w.field(thrift.List, 2)
+	w.list(thrift.Struct, 2)
+
+	w.i64(1, 0x463ad2c6a4bc5d7f)
+	w.i64(2, 0x5af7183fb1d4cf5f)
+	w.i64(3, 0x352bff9a74ca9ad2)
+	w.i64(4, 0)
+	w.str(5, "get /api")
+	w.i32(7, 1)
+	w.i64(8, 1556604172355737)
+	w.i64(9, 1431)
+	w.field(thrift.List, 10)
+	w.list(thrift.Struct, 5)
+	w.tag("span.kind", "server")
+	w.tag("http.method", "GET")
+	w.tag("http.route", "/api")
+	w.tag("http.status_code", int64(500))
+	w.tag("error", true)
+	w.field(thrift.List, 11)
+	w.list(thrift.Struct, 1)
+	w.i64(1, 1556604172355800)
+	w.field(thrift.List, 2)
+	w.list(thrift.Struct, 3)
+	w.tag("event", "error")
+	w.tag("message", "connection refused")
+	w.tag("error.kind", "IOError")
+	w.stop()
+	// unknown fields are skipped
+	w.field(thrift.Map, 42)
+	w.WriteByte(byte(thrift.String))
+	w.WriteByte(byte(thrift.I32))
+	binary.Write(&w, binary.BigEndian, int32(1))
+	binary.Write(&w, binary.BigEndian, int32(1))
+	w.WriteString("k")
+	binary.Write(&w, binary.BigEndian, int32(1))
+	w.stop()
+
+	w.i64(1, 0x463ad2c6a4bc5d7f)
+	w.i64(2, 0x5af7183fb1d4cf5f)
+	w.i64(3, 0x6b221d5bc9e6496c)
+	w.field(thrift.List, 6)
+	w.list(thrift.Struct, 2)
+	w.i32(1, 0) // CHILD_OF
+	w.i64(2, 0x463ad2c6a4bc5d7f)
+	w.i64(3, 0x5af7183fb1d4cf5f)
+	w.i64(4, 0x352bff9a74ca9ad2)
+	w.stop()
+	w.i32(1, 1) // FOLLOWS_FROM
+	w.i64(2, 0x1c8e43a21bf2a3d4)
+	w.i64(3, 0)
+	w.i64(4, 0x2f5c7d8e9a1b3c4d)
+	w.stop()
+	w.str(5, "select")
+	w.i32(7, 3)
+	w.i64(8, 1556604172355800)
+	w.i64(9, 800)
+	w.field(thrift.List, 10)
+	w.list(thrift.Struct, 3)
+	w.tag("span.kind", "client")
+	w.tag("db.system", "mysql")
+	w.tag("rows", 1.5)
+	w.stop()
+
+	w.stop()
+	return w.Bytes()
+}
+
+func TestDecodeJaegerThrift(t *testing.T) {
+	spans, err := decodeJaeger("application/x-thrift", jaegerTestThriftBatch())
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	server := spans[0]
+	assert.Equal(t, uint64(0x463ad2c6a4bc5d7f), server.TraceID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), server.SpanID)
+	assert.Equal(t, uint64(0), server.ParentID)
+	assert.Equal(t, "backend", server.Service)
+	assert.Equal(t, "get /api", server.Name)
+	assert.Equal(t, "GET /api", server.Resource)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, int64(1556604172355737000), server.Start)
+	assert.Equal(t, int64(1431000), server.Duration)
+	assert.Equal(t, int32(1), server.Error)
+	assert.Equal(t, "connection refused", server.Meta["error.msg"])
+	assert.Equal(t, "IOError", server.Meta["error.type"])
+	assert.Equal(t, "5af7183fb1d4cf5f", server.Meta["_dd.p.tid"])
+	assert.Equal(t, "host-1", server.Meta["hostname"])
+	assert.Equal(t, float64(500), server.Metrics["http.status_code"])
+	assert.Equal(t, `[{"time_unix_nano":1556604172355800000,"name":"error","attributes":{"error.kind":"IOError","message":"connection refused"}}]`, server.Meta["events"])
+	assert.NotContains(t, server.Metrics, "_sampling_priority_v1")
+
+	client := spans[1]
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), client.ParentID)
+	assert.Equal(t, []*pb.SpanLink{{TraceID: 0x1c8e43a21bf2a3d4, SpanID: 0x2f5c7d8e9a1b3c4d}}, client.SpanLinks)
+	assert.Nil(t, server.SpanLinks)
+	assert.Equal(t, "db", client.Type)
+	assert.Equal(t, 1.5, client.Metrics["rows"])
+	assert.Equal(t, float64(sampler.PriorityUserKeep), client.Metrics["_sampling_priority_v1"])
+
+	_, err = decodeJaeger("application/vnd.apache.thrift.binary", jaegerTestThriftBatch()[:100])
+	assert.Error(t, err)
+	_, err = decodeJaeger("application/json", jaegerTestThriftBatch())
+	assert.Error(t, err)
+}
+
+func TestDecodeJaegerProto(t *testing.T) {
+	appendMessage := func(b []byte, num protowire.Number, m []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, m)
+	}
+	appendVarint := func(b []byte, num protowire.Number, v uint64) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+	keyValue := func(key string, typ uint64, num protowire.Number, v []byte) []byte {
+		var kv []byte
+		kv = appendMessage(kv, 1, []byte(key))
+		kv = appendVarint(kv, 2, typ)
+		switch typ {
+		case 3: // FLOAT64
+			kv = protowire.AppendTag(kv, num, protowire.Fixed64Type)
+			kv = protowire.AppendFixed64(kv, binary.LittleEndian.Uint64(v))
+		case 0, 4: // STRING, BINARY
+			kv = appendMessage(kv, num, v)
+		default:
+			kv = appendVarint(kv, num, binary.LittleEndian.Uint64(v))
+		}
+		return kv
+	}
+	le := func(v uint64) []byte { return binary.LittleEndian.AppendUint64(nil, v) }
+
+	var process []byte
+	process = appendMessage(process, 1, []byte("backend"))
+	process = appendMessage(process, 2, keyValue("hostname", 0, 3, []byte("host-1")))
+
+	var start, duration []byte
+	start = appendVarint(start, 1, 1556604172)
+	start = appendVarint(start, 2, 355737000)
+	duration = appendVarint(duration, 2, 1431000)
+
+	var span []byte
+	span = appendMessage(span, 1, []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0x46, 0x3a, 0xd2, 0xc6, 0xa4, 0xbc, 0x5d, 0x7f})
+	span = appendMessage(span, 2, []byte{0x35, 0x2b, 0xff, 0x9a, 0x74, 0xca, 0x9a, 0xd2})
+	span = appendMessage(span, 3, []byte("select"))
+	var ref []byte
+	ref = appendMessage(ref, 1, []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0x46, 0x3a, 0xd2, 0xc6, 0xa4, 0xbc, 0x5d, 0x7f})
+	ref = appendMessage(ref, 2, []byte{0x6b, 0x22, 0x1d, 0x5b, 0xc9, 0xe6, 0x49, 0x6c})
+	span = appendMessage(span, 4, ref)
+	span = appendVarint(span, 5, 3)
+	span = appendMessage(span, 6, start)
+	span = appendMessage(span, 7, duration)
+	span = appendMessage(span, 8, keyValue("span.kind", 0, 3, []byte("client")))
+	span = appendMessage(span, 8, keyValue("db.system", 0, 3, []byte("mysql")))
+	span = appendMessage(span, 8, keyValue("cached", 1, 4, le(1)))
+	span = appendMessage(span, 8, keyValue("rows", 2, 5, le(42)))
+	span = appendMessage(span, 8, keyValue("ratio", 3, 6, le(math.Float64bits(0.5))))
+	span = appendMessage(span, 8, keyValue("raw", 4, 7, []byte{0xde, 0xad}))
+
+	var batch []byte
+	batch = appendMessage(batch, 1, span)
+	batch = appendMessage(batch, 2, process)
+	var req []byte
+	req = appendMessage(req, 1, batch)
+
+	spans, err := decodeJaeger("application/x-protobuf", req)
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal(t, uint64(0x463ad2c6a4bc5d7f), s.TraceID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), s.SpanID)
+	assert.Equal(t, uint64(0x6b221d5bc9e6496c), s.ParentID)
+	assert.Equal(t, "backend", s.Service)
+	assert.Equal(t, "select", s.Name)
+	assert.Equal(t, "db", s.Type)
+	assert.Equal(t, int64(1556604172355737000), s.Start)
+	assert.Equal(t, int64(1431000), s.Duration)
+	assert.Equal(t, "5af7183fb1d4cf5f", s.Meta["_dd.p.tid"])
+	assert.Equal(t, "host-1", s.Meta["hostname"])
+	assert.Equal(t, "true", s.Meta["cached"])
+	assert.Equal(t, "3q0=", s.Meta["raw"])
+	assert.Equal(t, float64(42), s.Metrics["rows"])
+	assert.Equal(t, 0.5, s.Metrics["ratio"])
+	assert.Equal(t, float64(sampler.PriorityUserKeep), s.Metrics["_sampling_priority_v1"])
+
+	_, err = decodeJaeger("application/x-protobuf", req[:len(req)-5])
+	assert.Error(t, err)
+}
+
+func TestReceiverJaeger(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(jaegerV1, r.handleTraces))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/x-thrift", bytes.NewReader(jaegerTestThriftBatch()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case p := <-r.out:
+		require.Len(t, p.Chunks(), 1)
+		assert.Len(t, p.Chunk(0).Spans, 2)
+		assert.Equal(t, int32(sampler.PriorityUserKeep), p.Chunk(0).Priority)
+	case <-time.After(time.Second):
+		t.Fatal("no data received")
+	}
+}
@@ -446,6 +446,30 @@ func marshalLinks(links ptrace.SpanLinkSlice) string {
 	return str.String()
 }
 
+// convertLinks converts the OTLP span links into Datadog span links.
+func convertLinks(links ptrace.SpanLinkSlice) []*pb.SpanLink {
+	out := make([]*pb.SpanLink, 0, links.Len())
+	for i := 0; i < links.Len(); i++ {
+		l := links.At(i)
+		traceID := [16]byte(l.TraceID())
+		link := &pb.SpanLink{
+			TraceID:     traceIDToUint64(traceID),
+			TraceIDHigh: traceIDHighToUint64(traceID),
+			SpanID:      spanIDToUint64(l.SpanID()),
+			Tracestate:  l.TraceState().AsRaw(),
+		}
+		if l.Attributes().Len() > 0 {
+			link.Attributes = make(map[string]string, l.Attributes().Len())
+			l.Attributes().Range(func(k string, v pcommon.Value) bool {
+				link.Attributes[k] = v.AsString()
+				return true
+			})
+		}
+		out = append(out, link)
+	}
+	return out
+}
+
 // setMetaOTLP sets the k/v OTLP attribute pair as a tag on span s.
 func setMetaOTLP(s *pb.Span, k, v string) {
 	switch k {
@@ -497,6 +521,7 @@ func (o *OTLPReceiver) convertSpan(rattr map[string]string, lib pcommon.Instrume
 		setMetaOTLP(span, k, v)
 	}
 	setMetaOTLP(span, "otel.trace_id", hex.EncodeToString(traceID[:]))
+	traceutil.SetTraceIDHigh(span, traceIDHighToUint64(traceID))
 	if _, ok := span.Meta["version"]; !ok {
 		if ver := rattr[string(semconv.AttributeServiceVersion)]; ver != "" {
 			setMetaOTLP(span, "version", ver)
@@ -507,6 +532,7 @@ func (o *OTLPReceiver) convertSpan(rattr map[string]string, lib pcommon.Instrume
 	}
 	if in.Links().Len() > 0 {
 		setMetaOTLP(span, "_dd.span_links", marshalLinks(in.Links()))
+		span.SpanLinks = convertLinks(in.Links())
 	}
 	if svc, ok := in.Attributes().Get(semconv.AttributePeerService); ok {
 		// the span attribute "peer.service" takes precedence over any resource attributes,
@@ -675,6 +701,10 @@ func traceIDToUint64(b [16]byte) uint64 {
 	return binary.BigEndian.Uint64(b[len(b)-8:])
 }
 
+func traceIDHighToUint64(b [16]byte) uint64 {
+	return binary.BigEndian.Uint64(b[:8])
+}
+
 func spanIDToUint64(b [8]byte) uint64 {
 	return binary.BigEndian.Uint64(b[:])
 }
@@ -105,6 +105,25 @@ var otlpTestSpanConfig = &testutil.OTLPSpan{
 
 var otlpTestSpan = testutil.NewOTLPSpan(otlpTestSpanConfig)
 
+// otlpTestSpanLinks holds the span links converted from the links of otlpTestSpanConfig.
+var otlpTestSpanLinks = []*pb.SpanLink{
+	{
+		TraceID:     0x0123456789abcdef,
+		TraceIDHigh: 0xfedcba9876543210,
+		SpanID:      0xabcdef0123456789,
+		Tracestate:  "dd=asdf256,ee=jkl;128",
+		Attributes:  map[string]string{"a1": "v1", "a2": "v2"},
+	},
+	{
+		TraceID:     0xabcdef0123456789,
+		TraceIDHigh: 0xabcdef0123456789,
+		SpanID:      0xfedcba9876543210,
+		Attributes:  map[string]string{"a3": "v2", "a4": "v4"},
+	},
+	{TraceID: 0xabcdef0123456789, TraceIDHigh: 0xabcdef0123456789, SpanID: 0xfedcba9876543210},
+	{TraceID: 0xabcdef0123456789, TraceIDHigh: 0xabcdef0123456789, SpanID: 0xfedcba9876543210},
+}
+
 var otlpTestTracesRequest = testutil.NewOTLPTracesRequest([]testutil.OTLPResourceSpan{
 	{
 		LibName:    "libname",
@@ -854,6 +873,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 				Meta: map[string]string{
 					"name":                    "john",
 					"otel.trace_id":           "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":               "72df520af2bde7a5",
 					"env":                     "staging",
 					"otel.status_code":        "Error",
 					"otel.status_description": "Error",
@@ -872,7 +892,8 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"approx": 1.2,
 					"count":  2,
 				},
-				Type: "web",
+				SpanLinks: otlpTestSpanLinks,
+				Type:      "web",
 			},
 		}, {
 			rattr: map[string]string{
@@ -974,6 +995,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"env":                     "prod",
 					"deployment.environment":  "prod",
 					"otel.trace_id":           "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":               "72df520af2bde7a5",
 					"otel.status_code":        "Error",
 					"otel.status_description": "Error",
 					"otel.library.name":       "ddtracer",
@@ -994,7 +1016,8 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"approx": 1.2,
 					"count":  2,
 				},
-				Type: "web",
+				SpanLinks: otlpTestSpanLinks,
+				Type:      "web",
 			},
 		}, {
 			rattr: map[string]string{
@@ -1102,6 +1125,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"w3c.tracestate":          "state",
 					"version":                 "v1.2.3",
 					"otel.trace_id":           "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":               "72df520af2bde7a5",
 					"events":                  "[{\"time_unix_nano\":123,\"name\":\"boom\",\"attributes\":{\"message\":\"Out of memory\",\"accuracy\":\"2.4\"},\"dropped_attributes_count\":2},{\"time_unix_nano\":456,\"name\":\"exception\",\"attributes\":{\"exception.message\":\"Out of memory\",\"exception.type\":\"mem\",\"exception.stacktrace\":\"1/2/3\"},\"dropped_attributes_count\":2}]",
 					"_dd.span_links":          `[{"trace_id":"fedcba98765432100123456789abcdef","span_id":"abcdef0123456789","trace_state":"dd=asdf256,ee=jkl;128","attributes":{"a1":"v1","a2":"v2"},"dropped_attributes_count":24},{"trace_id":"abcdef0123456789abcdef0123456789","span_id":"fedcba9876543210","attributes":{"a3":"v2","a4":"v4"}},{"trace_id":"abcdef0123456789abcdef0123456789","span_id":"fedcba9876543210","dropped_attributes_count":2},{"trace_id":"abcdef0123456789abcdef0123456789","span_id":"fedcba9876543210"}]`,
 					"error.msg":               "Out of memory",
@@ -1115,7 +1139,8 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"count":                                2,
 					sampler.KeySamplingRateEventExtraction: 0,
 				},
-				Type: "web",
+				SpanLinks: otlpTestSpanLinks,
+				Type:      "web",
 			},
 		}, {
 			rattr: map[string]string{
@@ -1164,6 +1189,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"otel.library.version":            "v2",
 					"name":                            "john",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 				},
 				Metrics: map[string]float64{
 					"approx":                               1.2,
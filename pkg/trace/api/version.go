@@ -68,7 +68,7 @@ const (
 	//
 	// 	1. An array of all unique strings present in the payload (a dictionary referred to by index).
 	// 	2. An array of traces, where each trace is an array of spans. A span is encoded as an array having
-	// 	   exactly 12 elements, representing all span properties, in this exact order:
+	// 	   12 or 13 elements, representing all span properties, in this exact order:
 	//
 	// 		 0: Service   (uint32)
 	// 		 1: Name      (uint32)
@@ -82,12 +82,22 @@ const (
 	// 		 9: Meta      (map[uint32]uint32)
 	// 		10: Metrics   (map[uint32]float64)
 	// 		11: Type      (uint32)
+	// 		12: SpanLinks (array, optional)
+	//
+	// 	   A span link is encoded as an array having exactly 6 elements, in this exact order:
+	//
+	// 		 0: TraceID     (uint64)
+	// 		 1: TraceIDHigh (uint64)
+	// 		 2: SpanID      (uint64)
+	// 		 3: Attributes  (map[uint32]uint32)
+	// 		 4: Tracestate  (uint32)
+	// 		 5: Flags       (uint32)
 	//
 	// 	Considerations:
 	//
-	// 	- The "uint32" typed values in "Service", "Name", "Resource", "Type", "Meta" and "Metrics" represent
-	// 	  the index at which the corresponding string is found in the dictionary. If any of the values are the
-	// 	  empty string, then the empty string must be added into the dictionary.
+	// 	- The "uint32" typed values in "Service", "Name", "Resource", "Type", "Meta", "Metrics", "Attributes" and
+	// 	  "Tracestate" represent the index at which the corresponding string is found in the dictionary. If any
+	// 	  of the values are the empty string, then the empty string must be added into the dictionary.
 	//
 	// 	- None of the elements can be nil. If any of them are unset, they should be given their "zero-value". Here
 	// 	  is an example of a span with all unset values:
@@ -118,4 +128,25 @@ const (
 	// Response: Service sampling rates (see description in v04).
 	//
 	V07 Version = "v0.7"
+
+	// zipkinV2
+	//
+	// Request: Zipkin spans.
+	// 	Content-Type: application/json or application/x-protobuf
+	// 	Payload: A list of Zipkin v2 spans (https://zipkin.io/zipkin-api/#/default/post_spans)
+	//
+	// Response: 200/OK.
+	//
+	zipkinV2 Version = "zipkin_v2"
+
+	// jaegerV1
+	//
+	// Request: A batch of Jaeger spans.
+	// 	Content-Type: application/x-thrift, application/vnd.apache.thrift.binary or application/x-protobuf
+	// 	Payload: A Batch encoded with the Thrift binary protocol or a PostSpansRequest protobuf message
+	// 	(https://github.com/jaegertracing/jaeger-idl)
+	//
+	// Response: 200/OK.
+	//
+	jaegerV1 Version = "jaeger_v1"
 )
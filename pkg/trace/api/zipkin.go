@@ -0,0 +1,384 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// zipkinSpan is a span of the Zipkin v2 API.
+// See https://zipkin.io/zipkin-api/#/default/post_spans
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ID             string             `json:"id"`
+	ParentID       string             `json:"parentId"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind"`
+	Timestamp      uint64             `json:"timestamp"` // microseconds
+	Duration       uint64             `json:"duration"`  // microseconds
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+	Debug          bool               `json:"debug"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int32  `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"` // microseconds
+	Value     string `json:"value"`
+}
+
+// decodeZipkin decodes a list of Zipkin v2 spans and converts them to Datadog spans.
+func decodeZipkin(mediaType string, b []byte) ([]*pb.Span, error) {
+	in, err := decodeZipkinSpans(mediaType, b)
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]*pb.Span, 0, len(in))
+	for i := range in {
+		span, err := convertZipkinSpan(&in[i])
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// decodeZipkinSpans decodes a list of Zipkin v2 spans encoded in JSON or, when the media type is
+// "application/x-protobuf", in protobuf.
+func decodeZipkinSpans(mediaType string, b []byte) ([]zipkinSpan, error) {
+	if mediaType == "application/x-protobuf" {
+		return decodeZipkinProto(b)
+	}
+	var spans []zipkinSpan
+	if err := json.Unmarshal(b, &spans); err != nil {
+		return nil, err
+	}
+	return spans, nil
+}
+
+// decodeZipkinProto decodes a ListOfSpans message.
+// See https://github.com/openzipkin/zipkin-api/blob/master/zipkin.proto
+func decodeZipkinProto(b []byte) ([]zipkinSpan, error) {
+	var spans []zipkinSpan
+	err := rangeProtoFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		var s zipkinSpan
+		if err := decodeZipkinProtoSpan(v, &s); err != nil {
+			return err
+		}
+		spans = append(spans, s)
+		return nil
+	})
+	return spans, err
+}
+
+var zipkinProtoKinds = map[uint64]string{1: "CLIENT", 2: "SERVER", 3: "PRODUCER", 4: "CONSUMER"}
+
+func decodeZipkinProtoSpan(b []byte, s *zipkinSpan) error {
+	return rangeProtoFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			s.TraceID = hex.EncodeToString(v)
+		case 2:
+			s.ParentID = hex.EncodeToString(v)
+		case 3:
+			s.ID = hex.EncodeToString(v)
+		case 4:
+			s.Kind = zipkinProtoKinds[n]
+		case 5:
+			s.Name = string(v)
+		case 6:
+			s.Timestamp = n
+		case 7:
+			s.Duration = n
+		case 8, 9:
+			var e zipkinEndpoint
+			if err := decodeZipkinProtoEndpoint(v, &e); err != nil {
+				return err
+			}
+			if num == 8 {
+				s.LocalEndpoint = &e
+			} else {
+				s.RemoteEndpoint = &e
+			}
+		case 10:
+			var a zipkinAnnotation
+			err := rangeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					a.Timestamp = n
+				case 2:
+					a.Value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			s.Annotations = append(s.Annotations, a)
+		case 11:
+			var key, value string
+			err := rangeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(v)
+				case 2:
+					value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			s.Tags[key] = value
+		case 12:
+			s.Debug = n != 0
+		}
+		return nil
+	})
+}
+
+func decodeZipkinProtoEndpoint(b []byte, e *zipkinEndpoint) error {
+	return rangeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			e.ServiceName = string(v)
+		case 2:
+			if len(v) == net.IPv4len {
+				e.IPv4 = net.IP(v).String()
+			}
+		case 3:
+			if len(v) == net.IPv6len {
+				e.IPv6 = net.IP(v).String()
+			}
+		case 4:
+			e.Port = int32(n)
+		}
+		return nil
+	})
+}
+
+// rangeProtoFields calls fn with each field of the protobuf message b, along with its value:
+// v holds the bytes of length-delimited fields and n the value of the other fields.
+func rangeProtoFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+		var (
+			v []byte
+			n uint64
+		)
+		switch typ {
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zipkinKinds maps the Zipkin span kinds to the OpenTelemetry ones.
+var zipkinKinds = map[string]ptrace.SpanKind{
+	"CLIENT":   ptrace.SpanKindClient,
+	"SERVER":   ptrace.SpanKindServer,
+	"PRODUCER": ptrace.SpanKindProducer,
+	"CONSUMER": ptrace.SpanKindConsumer,
+}
+
+// convertZipkinSpan converts the Zipkin span in to a Datadog span. Its tags are set as meta, its
+// annotations are set as events and the upper 64 bits of its trace ID as the "_dd.p.tid" tag.
+func convertZipkinSpan(in *zipkinSpan) (*pb.Span, error) {
+	traceIDHigh, traceID, err := parseHexID(in.TraceID, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID %q: %v", in.TraceID, err)
+	}
+	_, spanID, err := parseHexID(in.ID, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID %q: %v", in.ID, err)
+	}
+	var parentID uint64
+	if in.ParentID != "" {
+		if _, parentID, err = parseHexID(in.ParentID, 16); err != nil {
+			return nil, fmt.Errorf("invalid parent ID %q: %v", in.ParentID, err)
+		}
+	}
+	span := &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     in.Name,
+		Start:    int64(in.Timestamp) * 1000,
+		Duration: int64(in.Duration) * 1000,
+		Meta:     make(map[string]string, len(in.Tags)+1),
+		Metrics:  map[string]float64{},
+	}
+	if in.LocalEndpoint != nil {
+		span.Service = in.LocalEndpoint.ServiceName
+	}
+	if e := in.RemoteEndpoint; e != nil {
+		setMetaIfNotEmpty(span, "peer.service", e.ServiceName)
+		setMetaIfNotEmpty(span, "peer.ipv4", e.IPv4)
+		setMetaIfNotEmpty(span, "peer.ipv6", e.IPv6)
+		if e.Port != 0 {
+			span.Meta["peer.port"] = strconv.Itoa(int(e.Port))
+		}
+	}
+	traceutil.SetTraceIDHigh(span, traceIDHigh)
+	if in.Kind != "" {
+		span.Meta["span.kind"] = strings.ToLower(in.Kind)
+	}
+	for k, v := range in.Tags {
+		switch k {
+		case "error":
+			// the value of the error tag is the error message, if any
+			span.Error = 1
+			if v != "" && v != "true" {
+				span.Meta["error.msg"] = v
+			}
+		case "sampling.priority":
+			if p, err := strconv.ParseFloat(v, 64); err == nil {
+				setMetricOTLP(span, k, p)
+			}
+		default:
+			setMetaOTLP(span, k, v)
+		}
+	}
+	if len(in.Annotations) > 0 {
+		events := make([]spanEvent, 0, len(in.Annotations))
+		for _, a := range in.Annotations {
+			events = append(events, spanEvent{TimeUnixNano: a.Timestamp * 1000, Name: a.Value})
+		}
+		setMetaOTLP(span, "events", marshalSpanEvents(events))
+	}
+	if in.Debug {
+		if _, ok := span.Metrics["_sampling_priority_v1"]; !ok {
+			span.Metrics["_sampling_priority_v1"] = float64(sampler.PriorityUserKeep)
+		}
+	}
+	completeSpan(span, zipkinKinds[in.Kind])
+	return span, nil
+}
+
+// parseHexID parses the hexadecimal ID s of at most maxLen digits, and returns its upper and
+// lower 64 bits.
+func parseHexID(s string, maxLen int) (high, low uint64, err error) {
+	if s == "" || len(s) > maxLen {
+		return 0, 0, errors.New("invalid length")
+	}
+	if len(s) > 16 {
+		if high, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		s = s[len(s)-16:]
+	}
+	low, err = strconv.ParseUint(s, 16, 64)
+	return high, low, err
+}
+
+func setMetaIfNotEmpty(span *pb.Span, k, v string) {
+	if v != "" {
+		span.Meta[k] = v
+	}
+}
+
+// spanEvent is an event that occurred during a span, such as a Zipkin annotation or a Jaeger log.
+// It is marshalled in the format of the OTLP span events.
+type spanEvent struct {
+	TimeUnixNano uint64            `json:"time_unix_nano,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// marshalSpanEvents marshals events into JSON.
+func marshalSpanEvents(events []spanEvent) string {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// completeSpan sets the resource and the type of the span when they were not set by its tags,
+// based on its tags and on its kind.
+func completeSpan(span *pb.Span, kind ptrace.SpanKind) {
+	if span.Resource == "" {
+		if r := resourceFromTags(span.Meta); r != "" {
+			span.Resource = r
+		} else {
+			span.Resource = span.Name
+		}
+	}
+	if span.Type == "" {
+		span.Type = spanKind2Type(kind, span)
+	}
+}
+
+// traceChunksFromForeignSpans groups the spans by trace ID into chunks, whose sampling priority is
+// set by the "_sampling_priority_v1" metric of their spans, if any. The other chunks are left to the
+// samplers of the agent.
+func traceChunksFromForeignSpans(spans []*pb.Span) []*pb.TraceChunk {
+	var chunks []*pb.TraceChunk
+	byID := make(map[uint64]*pb.TraceChunk)
+	for _, s := range spans {
+		chunk, ok := byID[s.TraceID]
+		if !ok {
+			chunk = &pb.TraceChunk{Priority: int32(sampler.PriorityNone)}
+			byID[s.TraceID] = chunk
+			chunks = append(chunks, chunk)
+		}
+		if p, ok := s.Metrics["_sampling_priority_v1"]; ok {
+			chunk.Priority = int32(p)
+		}
+		chunk.Spans = append(chunk.Spans, s)
+	}
+	return chunks
+}
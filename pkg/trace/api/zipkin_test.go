@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+const zipkinTestPayload = `[
+  {
+    "traceId": "5af7183fb1d4cf5f463ad2c6a4bc5d7f",
+    "id": "352bff9a74ca9ad2",
+    "name": "get /api",
+    "kind": "SERVER",
+    "timestamp": 1556604172355737,
+    "duration": 1431,
+    "localEndpoint": {"serviceName": "backend", "ipv4": "192.168.99.1", "port": 3306},
+    "remoteEndpoint": {"serviceName": "frontend", "ipv4": "172.19.0.2", "port": 58648},
+    "tags": {"http.method": "GET", "http.route": "/api", "error": "connection refused"},
+    "annotations": [{"timestamp": 1556604172355800, "value": "ws"}]
+  },
+  {
+    "traceId": "463ad2c6a4bc5d7f",
+    "parentId": "352bff9a74ca9ad2",
+    "id": "6b221d5bc9e6496c",
+    "name": "select",
+    "kind": "CLIENT",
+    "timestamp": 1556604172355800,
+    "duration": 800,
+    "localEndpoint": {"serviceName": "backend"},
+    "tags": {"db.system": "mysql", "sampling.priority": "2"},
+    "debug": true
+  }
+]`
+
+func TestConvertZipkinSpans(t *testing.T) {
+	spans, err := decodeZipkin("application/json", []byte(zipkinTestPayload))
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	server := spans[0]
+	assert.Equal(t, uint64(0x463ad2c6a4bc5d7f), server.TraceID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), server.SpanID)
+	assert.Equal(t, uint64(0), server.ParentID)
+	assert.Equal(t, "backend", server.Service)
+	assert.Equal(t, "get /api", server.Name)
+	assert.Equal(t, "GET /api", server.Resource)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, int64(1556604172355737000), server.Start)
+	assert.Equal(t, int64(1431000), server.Duration)
+	assert.Equal(t, int32(1), server.Error)
+	assert.Equal(t, "connection refused", server.Meta["error.msg"])
+	assert.Equal(t, "5af7183fb1d4cf5f", server.Meta["_dd.p.tid"])
+	assert.Equal(t, "server", server.Meta["span.kind"])
+	assert.Equal(t, "frontend", server.Meta["peer.service"])
+	assert.Equal(t, "172.19.0.2", server.Meta["peer.ipv4"])
+	assert.Equal(t, "58648", server.Meta["peer.port"])
+	assert.Equal(t, `[{"time_unix_nano":1556604172355800000,"name":"ws"}]`, server.Meta["events"])
+
+	client := spans[1]
+	assert.Equal(t, uint64(0x463ad2c6a4bc5d7f), client.TraceID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), client.ParentID)
+	assert.Equal(t, "db", client.Type)
+	assert.Equal(t, "select", client.Resource)
+	assert.NotContains(t, client.Meta, "_dd.p.tid")
+	assert.Equal(t, float64(sampler.PriorityUserKeep), client.Metrics["_sampling_priority_v1"])
+
+	chunks := traceChunksFromForeignSpans(spans)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, int32(sampler.PriorityUserKeep), chunks[0].Priority)
+}
+
+func TestConvertZipkinSpanInvalidIDs(t *testing.T) {
+	for _, payload := range []string{
+		`[{"traceId": "", "id": "1"}]`,
+		`[{"traceId": "xyz", "id": "1"}]`,
+		`[{"traceId": "1", "id": "00000000000000001"}]`,
+		`[{"traceId": "5af7183fb1d4cf5f463ad2c6a4bc5d7f1", "id": "1"}]`,
+	} {
+		_, err := decodeZipkin("application/json", []byte(payload))
+		assert.Error(t, err, payload)
+	}
+}
+
+func TestDecodeZipkinProto(t *testing.T) {
+	var endpoint []byte
+	endpoint = protowire.AppendTag(endpoint, 1, protowire.BytesType)
+	endpoint = protowire.AppendString(endpoint, "backend")
+
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0x46, 0x3a, 0xd2, 0xc6, 0xa4, 0xbc, 0x5d, 0x7f})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0x35, 0x2b, 0xff, 0x9a, 0x74, 0xca, 0x9a, 0xd2})
+	span = protowire.AppendTag(span, 4, protowire.VarintType)
+	span = protowire.AppendVarint(span, 2) // SERVER
+	span = protowire.AppendTag(span, 5, protowire.BytesType)
+	span = protowire.AppendString(span, "get /api")
+	span = protowire.AppendTag(span, 6, protowire.Fixed64Type)
+	span = protowire.AppendFixed64(span, 1556604172355737)
+	span = protowire.AppendTag(span, 7, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1431)
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint)
+
+	var tag []byte
+	tag = protowire.AppendTag(tag, 1, protowire.BytesType)
+	tag = protowire.AppendString(tag, "http.method")
+	tag = protowire.AppendTag(tag, 2, protowire.BytesType)
+	tag = protowire.AppendString(tag, "GET")
+	span = protowire.AppendTag(span, 11, protowire.BytesType)
+	span = protowire.AppendBytes(span, tag)
+
+	var list []byte
+	list = protowire.AppendTag(list, 1, protowire.BytesType)
+	list = protowire.AppendBytes(list, span)
+
+	spans, err := decodeZipkin("application/x-protobuf", list)
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	assert.Equal(t, uint64(0x463ad2c6a4bc5d7f), spans[0].TraceID)
+	assert.Equal(t, uint64(0x352bff9a74ca9ad2), spans[0].SpanID)
+	assert.Equal(t, "backend", spans[0].Service)
+	assert.Equal(t, "get /api", spans[0].Name)
+	assert.Equal(t, "web", spans[0].Type)
+	assert.Equal(t, int64(1556604172355737000), spans[0].Start)
+	assert.Equal(t, int64(1431000), spans[0].Duration)
+	assert.Equal(t, "GET", spans[0].Meta["http.method"])
+	assert.Equal(t, "5af7183fb1d4cf5f", spans[0].Meta["_dd.p.tid"])
+
+	_, err = decodeZipkin("application/x-protobuf", list[:len(list)-3])
+	assert.Error(t, err)
+}
+
+func TestReceiverZipkin(t *testing.T) {
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(zipkinV2, r.handleTraces))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(zipkinTestPayload))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case p := <-r.out:
+		require.Len(t, p.Chunks(), 1)
+		assert.Len(t, p.Chunk(0).Spans, 2)
+		assert.Equal(t, int32(sampler.PriorityUserKeep), p.Chunk(0).Priority)
+		assert.Equal(t, "backend", p.Chunk(0).Spans[0].Service)
+	case <-time.After(time.Second):
+		t.Fatal("no data received")
+	}
+
+	resp, err = http.Post(server.URL, "application/json", strings.NewReader(`{"not": "a list"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -8,6 +8,7 @@ package config
 import (
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
@@ -226,6 +227,134 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// Span rule actions.
+const (
+	// SpanRuleDelete removes the matching tags.
+	SpanRuleDelete = "delete"
+	// SpanRuleHash replaces the values of the matching tags with their hash.
+	SpanRuleHash = "hash"
+	// SpanRuleReplace replaces the parts of the values of the matching tags matching a pattern.
+	SpanRuleReplace = "replace"
+	// SpanRuleMoveToMetrics moves the matching tags with a numeric value from Meta to Metrics.
+	SpanRuleMoveToMetrics = "move_to_metrics"
+	// SpanRuleMoveToMeta moves the matching metrics from Metrics to Meta.
+	SpanRuleMoveToMeta = "move_to_meta"
+	// SpanRuleDropSpan drops the matching spans.
+	SpanRuleDropSpan = "drop_span"
+)
+
+// SpanRule specifies a rule rewriting the tags of the spans matching its conditions,
+// or dropping them.
+type SpanRule struct {
+	// Name identifies the rule.
+	Name string `mapstructure:"name"`
+
+	// Action is the action applied to the matching spans, one of "delete", "hash", "replace",
+	// "move_to_metrics", "move_to_meta" or "drop_span".
+	Action string `mapstructure:"action"`
+
+	// Key is a glob pattern matching the keys of the tags the action applies to. It is
+	// not used by "drop_span" rules.
+	Key string `mapstructure:"key"`
+
+	// KeyRe holds the compiled Key and is only used internally.
+	KeyRe *regexp.Regexp `mapstructure:"-" json:"-"`
+
+	// Pattern specifies the regexp pattern to be used by "replace" rules. It must compile.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-" json:"-"`
+
+	// Repl specifies the replacement string used by "replace" rules when Pattern matches.
+	Repl string `mapstructure:"repl"`
+
+	// Match holds the conditions a span has to meet for the rule to apply. A rule
+	// without conditions applies to all the spans.
+	Match SpanRuleMatch `mapstructure:"match"`
+}
+
+// SpanRuleMatch holds the conditions of a span rule. The conditions are regexp
+// patterns, all of them have to match.
+type SpanRuleMatch struct {
+	Service  string `mapstructure:"service"`
+	Name     string `mapstructure:"name"`
+	Resource string `mapstructure:"resource"`
+
+	// Tags are matched against the tags of the span, a span without the tag does not match.
+	Tags []*SpanRuleTag `mapstructure:"tags"`
+
+	// ServiceRe, NameRe and ResourceRe hold the compiled patterns and are only used internally.
+	ServiceRe  *regexp.Regexp `mapstructure:"-" json:"-"`
+	NameRe     *regexp.Regexp `mapstructure:"-" json:"-"`
+	ResourceRe *regexp.Regexp `mapstructure:"-" json:"-"`
+}
+
+// SpanRuleTag is a condition on the value of a span tag.
+type SpanRuleTag struct {
+	Key     string `mapstructure:"key"`
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-" json:"-"`
+}
+
+// SamplingRule is a rule of the agent rule sampler. It keeps a ratio of the traces whose root
+// span matches it, up to a number of traces per second, for the traces without a user-set
+// sampling priority.
+type SamplingRule struct {
+	// Name identifies the rule.
+	Name string `mapstructure:"name" json:"name"`
+
+	// Match holds the conditions the root span of a trace has to meet for the rule to apply.
+	// A rule without conditions applies to all the traces.
+	Match SamplingRuleMatch `mapstructure:"match" json:"match"`
+
+	// SampleRate is the ratio of the matching traces kept by the rule, between 0 and 1.
+	SampleRate float64 `mapstructure:"sample_rate" json:"sample_rate"`
+
+	// MaxPerSecond is the maximum number of traces kept by the rule per second. When 0, the
+	// number of traces is not limited.
+	MaxPerSecond float64 `mapstructure:"max_per_second" json:"max_per_second"`
+}
+
+// SamplingRuleMatch holds the conditions of a sampling rule. The conditions are glob patterns,
+// where "*" matches any sequence of characters and "?" any single character. All of them have
+// to match.
+type SamplingRuleMatch struct {
+	Service  string `mapstructure:"service" json:"service"`
+	Name     string `mapstructure:"name" json:"name"`
+	Resource string `mapstructure:"resource" json:"resource"`
+
+	// Tags are matched against the tags of the span, a span without the tag does not match.
+	Tags []*SamplingRuleTag `mapstructure:"tags" json:"tags"`
+}
+
+// SamplingRuleTag is a condition on the value of a span tag.
+type SamplingRuleTag struct {
+	Key   string `mapstructure:"key" json:"key"`
+	Value string `mapstructure:"value" json:"value"`
+}
+
+// Validate returns an error if the rule is not valid.
+func (r *SamplingRule) Validate() error {
+	if r.Name == "" {
+		return errors.New(`all rules must have a "name" property`)
+	}
+	if r.SampleRate < 0 || r.SampleRate > 1 {
+		return fmt.Errorf("rule %q: %q must be between 0 and 1", r.Name, "sample_rate")
+	}
+	if r.MaxPerSecond < 0 {
+		return fmt.Errorf("rule %q: %q must not be negative", r.Name, "max_per_second")
+	}
+	for _, tag := range r.Match.Tags {
+		if tag == nil || tag.Key == "" {
+			return fmt.Errorf("rule %q: all tag conditions must have a %q", r.Name, "key")
+		}
+	}
+	return nil
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -241,6 +370,118 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+const (
+	// OutputTypeDatadog ships payloads to the configured Datadog intake endpoints.
+	OutputTypeDatadog = "datadog"
+	// OutputTypeFile writes payloads as newline-delimited JSON into rotated local files.
+	OutputTypeFile = "file"
+	// OutputTypeOTLP exports traces to an OTLP/gRPC collector.
+	OutputTypeOTLP = "otlp"
+)
+
+// WriterOutput specifies a destination to which the trace and stats writers ship
+// their payloads. Each output is served by its own sender, with an independent
+// retry queue.
+type WriterOutput struct {
+	// Type specifies the kind of output. It must be one of the OutputType* constants.
+	Type string `mapstructure:"type" json:"type"`
+
+	// Path specifies the directory into which "file" outputs write.
+	Path string `mapstructure:"path" json:"path"`
+
+	// MaxSizeMB specifies the size in megabytes at which files written by "file"
+	// outputs are rotated. Zero means the default of 100MB.
+	MaxSizeMB int `mapstructure:"max_size_mb" json:"max_size_mb"`
+
+	// MaxBackups specifies the number of rotated files kept by "file" outputs.
+	// Zero means the default of 5.
+	MaxBackups int `mapstructure:"max_backups" json:"max_backups"`
+
+	// Endpoint specifies the host:port of the collector for "otlp" outputs.
+	Endpoint string `mapstructure:"endpoint" json:"endpoint"`
+
+	// Insecure disables TLS on connections made by "otlp" outputs.
+	Insecure bool `mapstructure:"insecure" json:"insecure"`
+}
+
+// Validate reports whether the output o is correctly configured.
+func (o *WriterOutput) Validate() error {
+	switch o.Type {
+	case OutputTypeDatadog:
+	case OutputTypeFile:
+		if o.Path == "" {
+			return fmt.Errorf("%q output: %q is required", o.Type, "path")
+		}
+	case OutputTypeOTLP:
+		if o.Endpoint == "" {
+			return fmt.Errorf("%q output: %q is required", o.Type, "endpoint")
+		}
+	default:
+		return fmt.Errorf("unknown output type %q", o.Type)
+	}
+	if o.MaxSizeMB < 0 || o.MaxBackups < 0 {
+		return fmt.Errorf("%q output: %q and %q must not be negative", o.Type, "max_size_mb", "max_backups")
+	}
+	return nil
+}
+
+// Tail sampling policy types.
+const (
+	// TailSamplingLatencyPolicy keeps the traces lasting longer than a threshold.
+	TailSamplingLatencyPolicy = "latency"
+	// TailSamplingErrorPolicy keeps the traces holding a span with an error.
+	TailSamplingErrorPolicy = "error"
+	// TailSamplingAttributePolicy keeps the traces holding a span with a matching tag.
+	TailSamplingAttributePolicy = "attribute"
+	// TailSamplingRatePolicy keeps a ratio of the traces.
+	TailSamplingRatePolicy = "rate"
+)
+
+// TailSamplingConfig holds the configuration of the tail sampling stage, which buffers the
+// chunks dropped by the samplers to take a decision once the whole trace has been seen.
+type TailSamplingConfig struct {
+	// Enabled reports whether the tail sampling stage is enabled.
+	Enabled bool
+
+	// DecisionWait is how long the chunks of a trace are buffered after its first chunk is received.
+	DecisionWait time.Duration
+
+	// MaxBufferBytes is the maximum size of the buffered chunks, in bytes. Once it is reached, the
+	// decision is taken early for the oldest traces.
+	MaxBufferBytes int64
+
+	// Policies are the policies applied to the buffered traces, a trace is kept if any of them matches.
+	Policies []*TailSamplingPolicy
+}
+
+// TailSamplingPolicy is a policy keeping the buffered traces matching it.
+type TailSamplingPolicy struct {
+	// Name identifies the policy.
+	Name string `mapstructure:"name"`
+
+	// Type is the type of the policy, one of "latency", "error", "attribute" or "rate".
+	Type string `mapstructure:"type"`
+
+	// Service restricts the policy to the traces whose root span has this service, if set.
+	Service string `mapstructure:"service"`
+
+	// ThresholdMs is the minimum duration of the traces kept by a "latency" policy, in milliseconds.
+	ThresholdMs int64 `mapstructure:"threshold_ms"`
+
+	// Key is the tag looked for in the spans by an "attribute" policy.
+	Key string `mapstructure:"key"`
+
+	// Pattern specifies the regexp the tag value must match for an "attribute" policy. When
+	// empty, the tag only needs to be present.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-" json:"-"`
+
+	// Rate is the ratio of the traces kept by a "rate" policy, between 0 and 1.
+	Rate float64 `mapstructure:"rate"`
+}
+
 // FargateOrchestratorName is a Fargate orchestrator name.
 type FargateOrchestratorName string
 
@@ -316,6 +557,17 @@ type AgentConfig struct {
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
 
+	// StatsAdditionalTags are the span tags used as additional aggregation dimensions
+	// of the computed stats, e.g. peer.service or db.instance.
+	StatsAdditionalTags []string
+	// StatsAdditionalTagsMaxCardinality is the maximum number of distinct values of each
+	// additional tag in a stats bucket. The tag is left out of the stats of the spans
+	// exceeding it.
+	StatsAdditionalTagsMaxCardinality int
+	// ComputeStatsBySpanKind enables the computation of stats on the spans with a server,
+	// consumer, client or producer span.kind, on top of the top-level and measured spans.
+	ComputeStatsBySpanKind bool
+
 	// Sampler configuration
 	ExtraSampleRate float64
 	TargetTPS       float64
@@ -329,6 +581,13 @@ type AgentConfig struct {
 	RareSamplerCooldownPeriod time.Duration
 	RareSamplerCardinality    int
 
+	// TailSampling holds the configuration of the tail sampling stage.
+	TailSampling *TailSamplingConfig
+
+	// SamplingRules are the rules of the rule sampler, applied in order to the traces without
+	// a user-set sampling priority. They can be updated through remote configuration.
+	SamplingRules []*SamplingRule
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -349,6 +608,10 @@ type AgentConfig struct {
 	TraceWriter             *WriterConfig
 	ConnectionResetInterval time.Duration // frequency at which outgoing connections are reset. 0 means no reset is performed
 
+	// WriterOutputs specifies the destinations of the trace and stats writers. When
+	// empty, payloads are only sent to the Datadog intake endpoints.
+	WriterOutputs []*WriterOutput
+
 	// internal telemetry
 	StatsdEnabled  bool
 	StatsdHost     string
@@ -376,6 +639,10 @@ type AgentConfig struct {
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
 
+	// SpanRules are used to remove or rewrite tags and to drop spans before the traces
+	// are sampled and the stats are computed.
+	SpanRules []*SpanRule
+
 	// GlobalTags list metadata that will be added to all spans
 	GlobalTags map[string]string
 
@@ -435,6 +702,9 @@ type AgentConfig struct {
 
 	// DebugServerPort defines the port used by the debug server
 	DebugServerPort int
+	// InspectBufferSize is the number of recently processed trace chunks kept for inspection
+	// through the debug server. When 0, chunks are only recorded while a client inspects them.
+	InspectBufferSize int
 }
 
 // RemoteClient client is used to APM Sampling Updates from a remote source.
@@ -462,6 +732,8 @@ func New() *AgentConfig {
 
 		BucketInterval: time.Duration(10) * time.Second,
 
+		StatsAdditionalTagsMaxCardinality: 100,
+
 		ExtraSampleRate: 1.0,
 		TargetTPS:       10,
 		ErrorTPS:        10,
@@ -473,6 +745,11 @@ func New() *AgentConfig {
 		RareSamplerCooldownPeriod: 5 * time.Minute,
 		RareSamplerCardinality:    200,
 
+		TailSampling: &TailSamplingConfig{
+			DecisionWait:   10 * time.Second,
+			MaxBufferBytes: 50 * 1024 * 1024, // 50MB
+		},
+
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
 		MaxRequestBytes:        25 * 1024 * 1024, // 25MB
@@ -20,3 +20,22 @@ func TestInAzureAppServices(t *testing.T) {
 	assert.False(t, isEmpty)
 
 }
+
+func TestSamplingRuleValidate(t *testing.T) {
+	assert.NoError(t, (&SamplingRule{Name: "a", SampleRate: 0.5, MaxPerSecond: 10}).Validate())
+	assert.Error(t, (&SamplingRule{SampleRate: 0.5}).Validate())
+	assert.Error(t, (&SamplingRule{Name: "a", SampleRate: 1.5}).Validate())
+	assert.Error(t, (&SamplingRule{Name: "a", SampleRate: -1}).Validate())
+	assert.Error(t, (&SamplingRule{Name: "a", MaxPerSecond: -1}).Validate())
+	assert.Error(t, (&SamplingRule{Name: "a", Match: SamplingRuleMatch{Tags: []*SamplingRuleTag{{Value: "x"}}}}).Validate())
+}
+
+func TestWriterOutputValidate(t *testing.T) {
+	assert.NoError(t, (&WriterOutput{Type: OutputTypeDatadog}).Validate())
+	assert.NoError(t, (&WriterOutput{Type: OutputTypeFile, Path: "/tmp"}).Validate())
+	assert.NoError(t, (&WriterOutput{Type: OutputTypeOTLP, Endpoint: "localhost:4317"}).Validate())
+	assert.Error(t, (&WriterOutput{Type: "kafka"}).Validate())
+	assert.Error(t, (&WriterOutput{Type: OutputTypeFile}).Validate())
+	assert.Error(t, (&WriterOutput{Type: OutputTypeOTLP}).Validate())
+	assert.Error(t, (&WriterOutput{Type: OutputTypeFile, Path: "/tmp", MaxSizeMB: -1}).Validate())
+}
@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// SpanRules is a filter which removes or rewrites the tags of the spans matching
+// its rules, and drops the spans matching its "drop_span" rules.
+type SpanRules struct {
+	rules []*config.SpanRule
+}
+
+// NewSpanRules returns a new SpanRules filter which will use the given set of rules.
+func NewSpanRules(rules []*config.SpanRule) *SpanRules {
+	return &SpanRules{rules: rules}
+}
+
+// Apply applies the rules to the spans of the trace in order, and returns the spans which
+// are not dropped. The children of a dropped span are attached to its parent, so that the
+// trace remains a tree and the top-level spans are computed consistently. When all the spans
+// are dropped, they are left in place in the backing array of trace. A nil SpanRules keeps
+// the trace unchanged.
+func (f *SpanRules) Apply(trace pb.Trace) pb.Trace {
+	if f == nil || len(f.rules) == 0 {
+		return trace
+	}
+	// dropped maps the IDs of the dropped spans to the IDs of their parents
+	var dropped map[uint64]uint64
+	for _, s := range trace {
+		for _, rule := range f.rules {
+			if !matchSpan(&rule.Match, s) {
+				continue
+			}
+			if rule.Action == config.SpanRuleDropSpan {
+				if dropped == nil {
+					dropped = make(map[uint64]uint64)
+				}
+				dropped[s.SpanID] = s.ParentID
+				break
+			}
+			applySpanRule(rule, s)
+		}
+	}
+	if len(dropped) == 0 {
+		return trace
+	}
+	n := 0
+	for _, s := range trace {
+		if _, ok := dropped[s.SpanID]; ok {
+			continue
+		}
+		// the number of iterations is bounded in case the dropped spans form a cycle
+		for i := 0; i < len(dropped); i++ {
+			parentID, ok := dropped[s.ParentID]
+			if !ok {
+				break
+			}
+			s.ParentID = parentID
+		}
+		trace[n] = s
+		n++
+	}
+	if n == 0 {
+		return trace[:0]
+	}
+	// set everything at the back of the array to nil to avoid memory leaking
+	for i := n; i < len(trace); i++ {
+		trace[i] = nil
+	}
+	return trace[:n]
+}
+
+// matchSpan reports whether the span meets all the conditions of m.
+func matchSpan(m *config.SpanRuleMatch, s *pb.Span) bool {
+	if m.ServiceRe != nil && !m.ServiceRe.MatchString(s.Service) {
+		return false
+	}
+	if m.NameRe != nil && !m.NameRe.MatchString(s.Name) {
+		return false
+	}
+	if m.ResourceRe != nil && !m.ResourceRe.MatchString(s.Resource) {
+		return false
+	}
+	for _, tag := range m.Tags {
+		v, ok := s.Meta[tag.Key]
+		if !ok {
+			metric, ok := s.Metrics[tag.Key]
+			if !ok {
+				return false
+			}
+			v = strconv.FormatFloat(metric, 'f', -1, 64)
+		}
+		if tag.Re != nil && !tag.Re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// applySpanRule applies the action of the rule to the tags of the span matching its key.
+func applySpanRule(rule *config.SpanRule, s *pb.Span) {
+	switch rule.Action {
+	case config.SpanRuleDelete:
+		for k := range s.Meta {
+			if rule.KeyRe.MatchString(k) {
+				delete(s.Meta, k)
+			}
+		}
+		for k := range s.Metrics {
+			if rule.KeyRe.MatchString(k) {
+				delete(s.Metrics, k)
+			}
+		}
+	case config.SpanRuleHash:
+		for k, v := range s.Meta {
+			if rule.KeyRe.MatchString(k) {
+				s.Meta[k] = hashTagValue(v)
+			}
+		}
+	case config.SpanRuleReplace:
+		for k, v := range s.Meta {
+			if rule.KeyRe.MatchString(k) {
+				s.Meta[k] = rule.Re.ReplaceAllString(v, rule.Repl)
+			}
+		}
+	case config.SpanRuleMoveToMetrics:
+		for k, v := range s.Meta {
+			if !rule.KeyRe.MatchString(k) {
+				continue
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				// non-numeric values are kept in Meta
+				continue
+			}
+			if s.Metrics == nil {
+				s.Metrics = make(map[string]float64)
+			}
+			s.Metrics[k] = f
+			delete(s.Meta, k)
+		}
+	case config.SpanRuleMoveToMeta:
+		for k, v := range s.Metrics {
+			if !rule.KeyRe.MatchString(k) {
+				continue
+			}
+			if s.Meta == nil {
+				s.Meta = make(map[string]string)
+			}
+			s.Meta[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			delete(s.Metrics, k)
+		}
+	}
+}
+
+// hashTagValue returns the first 16 hexadecimal digits of the SHA-256 hash of v, so that
+// equal values can still be correlated without exposing them.
+func hashTagValue(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:8])
+}
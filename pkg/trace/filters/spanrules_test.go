@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+func TestSpanRulesTags(t *testing.T) {
+	for name, tt := range map[string]struct {
+		rule        *config.SpanRule
+		meta        map[string]string
+		metrics     map[string]float64
+		wantMeta    map[string]string
+		wantMetrics map[string]float64
+	}{
+		"delete": {
+			rule:        &config.SpanRule{Action: config.SpanRuleDelete, KeyRe: regexp.MustCompile(`^usr\..*$`)},
+			meta:        map[string]string{"usr.id": "123", "usr.email": "a@b.c", "http.url": "/"},
+			metrics:     map[string]float64{"usr.age": 42, "count": 1},
+			wantMeta:    map[string]string{"http.url": "/"},
+			wantMetrics: map[string]float64{"count": 1},
+		},
+		"hash": {
+			rule:     &config.SpanRule{Action: config.SpanRuleHash, KeyRe: regexp.MustCompile(`^usr\.id$`)},
+			meta:     map[string]string{"usr.id": "123", "http.url": "/"},
+			wantMeta: map[string]string{"usr.id": "a665a45920422f9d", "http.url": "/"},
+		},
+		"replace": {
+			rule:     &config.SpanRule{Action: config.SpanRuleReplace, KeyRe: regexp.MustCompile(`^http\.url$`), Re: regexp.MustCompile(`\?.*$`)},
+			meta:     map[string]string{"http.url": "/users?id=123", "other.url": "/users?id=123"},
+			wantMeta: map[string]string{"http.url": "/users", "other.url": "/users?id=123"},
+		},
+		"move to metrics": {
+			rule:        &config.SpanRule{Action: config.SpanRuleMoveToMetrics, KeyRe: regexp.MustCompile(`^db\..*$`)},
+			meta:        map[string]string{"db.rows": "12", "db.name": "users"},
+			wantMeta:    map[string]string{"db.name": "users"},
+			wantMetrics: map[string]float64{"db.rows": 12},
+		},
+		"move to meta": {
+			rule:        &config.SpanRule{Action: config.SpanRuleMoveToMeta, KeyRe: regexp.MustCompile(`^usr\.id$`)},
+			metrics:     map[string]float64{"usr.id": 1234567, "count": 1},
+			wantMeta:    map[string]string{"usr.id": "1234567"},
+			wantMetrics: map[string]float64{"count": 1},
+		},
+		"conditions": {
+			rule: &config.SpanRule{
+				Action: config.SpanRuleDelete,
+				KeyRe:  regexp.MustCompile(`^usr\.id$`),
+				Match: config.SpanRuleMatch{
+					ServiceRe: regexp.MustCompile("^web$"),
+					Tags:      []*config.SpanRuleTag{{Key: "env", Re: regexp.MustCompile("^prod$")}},
+				},
+			},
+			meta:     map[string]string{"usr.id": "123", "env": "staging"},
+			wantMeta: map[string]string{"usr.id": "123", "env": "staging"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			span := &pb.Span{Service: "web", SpanID: 1, Meta: tt.meta, Metrics: tt.metrics}
+			trace := NewSpanRules([]*config.SpanRule{tt.rule}).Apply(pb.Trace{span})
+			assert.Equal(t, pb.Trace{span}, trace)
+			if len(tt.wantMeta) == 0 {
+				assert.Empty(t, span.Meta)
+			} else {
+				assert.Equal(t, tt.wantMeta, span.Meta)
+			}
+			if len(tt.wantMetrics) == 0 {
+				assert.Empty(t, span.Metrics)
+			} else {
+				assert.Equal(t, tt.wantMetrics, span.Metrics)
+			}
+		})
+	}
+}
+
+func TestSpanRulesDropSpan(t *testing.T) {
+	assert := assert.New(t)
+	rules := NewSpanRules([]*config.SpanRule{
+		{
+			Action: config.SpanRuleDropSpan,
+			Match: config.SpanRuleMatch{
+				NameRe: regexp.MustCompile("^cache"),
+				Tags:   []*config.SpanRuleTag{{Key: "cache.hit", Re: regexp.MustCompile("^1$")}},
+			},
+		},
+		{Action: config.SpanRuleDelete, KeyRe: regexp.MustCompile(`^usr\.id$`)},
+	})
+
+	root := &pb.Span{SpanID: 1, Name: "http.request", Meta: map[string]string{"usr.id": "123"}}
+	hit := &pb.Span{SpanID: 2, ParentID: 1, Name: "cache.get", Metrics: map[string]float64{"cache.hit": 1}}
+	hitChild := &pb.Span{SpanID: 3, ParentID: 2, Name: "cache.get"}
+	grandChild := &pb.Span{SpanID: 4, ParentID: 3, Name: "serialize"}
+	miss := &pb.Span{SpanID: 5, ParentID: 1, Name: "cache.get", Metrics: map[string]float64{"cache.hit": 0}}
+
+	trace := rules.Apply(pb.Trace{root, hit, hitChild, grandChild, miss})
+	// hitChild is not dropped as it does not have the cache.hit tag
+	assert.Equal(pb.Trace{root, hitChild, grandChild, miss}, trace)
+	assert.Equal(uint64(1), hitChild.ParentID)
+	assert.Equal(uint64(3), grandChild.ParentID)
+	assert.Empty(root.Meta)
+
+	// the dropped spans forming a cycle do not block the filter
+	a := &pb.Span{SpanID: 1, ParentID: 2, Name: "cache.get", Metrics: map[string]float64{"cache.hit": 1}}
+	b := &pb.Span{SpanID: 2, ParentID: 1, Name: "cache.get", Metrics: map[string]float64{"cache.hit": 1}}
+	c := &pb.Span{SpanID: 3, ParentID: 1}
+	assert.Equal(pb.Trace{c}, rules.Apply(pb.Trace{a, b, c}))
+
+	// the spans are left in place when all of them are dropped
+	all := pb.Trace{a, b}
+	assert.Empty(rules.Apply(all))
+	assert.Equal(pb.Trace{a, b}, all)
+}
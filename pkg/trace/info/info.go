@@ -39,6 +39,8 @@ var (
 	// The rates by service with empty env values removed (As they are confusing to view for customers)
 	rateByServiceFiltered map[string]float64
 	rateLimiterStats      RateLimiterStats
+	tailSamplerStats      TailSamplerStats
+	ruleSamplerStats      []SamplingRuleStats
 	start                 = time.Now()
 	once                  sync.Once
 	infoTmpl              *template.Template
@@ -79,6 +81,13 @@ const (
   {{if lt .Status.RateLimiter.TargetRate 1.0}}
   WARNING: Rate-limiter keep percentage: {{percent .Status.RateLimiter.TargetRate}} %
   {{end}}
+  {{if gt .Status.TailSampler.MaxBufferBytes 0}}
+  Tail sampling: {{.Status.TailSampler.BufferedTraces}} traces buffered ({{.Status.TailSampler.BufferedBytes}} / {{.Status.TailSampler.MaxBufferBytes}} bytes), {{.Status.TailSampler.TracesKept}} kept, {{.Status.TailSampler.TracesDropped}} dropped
+  {{if gt .Status.TailSampler.EarlyDecisions 0}}WARNING: Tail sampling buffer full, {{.Status.TailSampler.EarlyDecisions}} traces decided early{{end}}
+  {{end}}
+  {{ range $i, $r := .Status.RuleSampler }}
+  Sampling rule '{{ $r.Rule }}' ({{percent $r.SampleRate}} %): {{ $r.TracesKept }} kept, {{ $r.TracesDropped }} dropped
+  {{ end }}
 
   --- Writer stats (1 min) ---
 
@@ -203,6 +212,63 @@ func publishRateLimiterStats() interface{} {
 	return rateLimiterStats
 }
 
+// TailSamplerStats contains the state of the tail sampling stage.
+type TailSamplerStats struct {
+	// BufferedTraces is the number of traces waiting for a sampling decision.
+	BufferedTraces int64
+	// BufferedBytes is the size of the chunks waiting for a sampling decision.
+	BufferedBytes int64
+	// MaxBufferBytes is the size above which the decision is taken early for the oldest traces.
+	MaxBufferBytes int64
+	// TracesKept is the number of traces kept by a tail sampling policy.
+	TracesKept int64
+	// TracesDropped is the number of traces not matching any tail sampling policy.
+	TracesDropped int64
+	// EarlyDecisions is the number of decisions taken before the end of the decision wait
+	// because the buffer was full.
+	EarlyDecisions int64
+}
+
+// UpdateTailSampler updates internal stats about the tail sampling stage.
+func UpdateTailSampler(ss TailSamplerStats) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	tailSamplerStats = ss
+}
+
+func publishTailSamplerStats() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return tailSamplerStats
+}
+
+// SamplingRuleStats contains the counts of the traces sampled by a rule of the rule sampler.
+type SamplingRuleStats struct {
+	// Rule is the name of the rule.
+	Rule string
+	// SampleRate is the ratio of the matching traces kept by the rule.
+	SampleRate float64
+	// MaxPerSecond is the maximum number of traces kept by the rule per second, 0 meaning no limit.
+	MaxPerSecond float64
+	// TracesKept is the number of traces kept by the rule.
+	TracesKept int64
+	// TracesDropped is the number of traces dropped by the rule, including those exceeding MaxPerSecond.
+	TracesDropped int64
+}
+
+// UpdateRuleSampler updates internal stats about the rules of the rule sampler.
+func UpdateRuleSampler(ss []SamplingRuleStats) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	ruleSamplerStats = ss
+}
+
+func publishRuleSamplerStats() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return ruleSamplerStats
+}
+
 func publishUptime() interface{} {
 	return int(time.Since(start) / time.Second)
 }
@@ -237,13 +303,15 @@ type StatusInfo struct {
 		Version   string
 		GitCommit string
 	} `json:"version"`
-	Receiver      []TagStats         `json:"receiver"`
-	RateByService map[string]float64 `json:"ratebyservice_filtered"`
-	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
-	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
-	Watchdog      watchdog.Info      `json:"watchdog"`
-	RateLimiter   RateLimiterStats   `json:"ratelimiter"`
-	Config        config.AgentConfig `json:"config"`
+	Receiver      []TagStats          `json:"receiver"`
+	RateByService map[string]float64  `json:"ratebyservice_filtered"`
+	TraceWriter   TraceWriterInfo     `json:"trace_writer"`
+	StatsWriter   StatsWriterInfo     `json:"stats_writer"`
+	Watchdog      watchdog.Info       `json:"watchdog"`
+	RateLimiter   RateLimiterStats    `json:"ratelimiter"`
+	TailSampler   TailSamplerStats    `json:"tailsampler"`
+	RuleSampler   []SamplingRuleStats `json:"rulesampler"`
+	Config        config.AgentConfig  `json:"config"`
 }
 
 func getProgramBanner(version string) (string, string) {
@@ -361,6 +429,8 @@ func initInfo(conf *config.AgentConfig) error {
 	expvar.Publish("ratebyservice_filtered", expvar.Func(publishRateByServiceFiltered))
 	expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
 	expvar.Publish("ratelimiter", expvar.Func(publishRateLimiterStats))
+	expvar.Publish("tailsampler", expvar.Func(publishTailSamplerStats))
+	expvar.Publish("rulesampler", expvar.Func(publishRuleSamplerStats))
 
 	// copy the config to ensure we don't expose sensitive data such as API keys
 	c := *conf
@@ -442,6 +442,8 @@ func TestPublishReceiverStats(t *testing.T) {
 				atom(10),
 				atom(11),
 				atom(12),
+				atom(13),
+				atom(14),
 			},
 			TracesFiltered:     atom(4),
 			TracesPriorityNone: atom(5),
@@ -495,6 +497,8 @@ func TestPublishReceiverStats(t *testing.T) {
 				"InvalidStartDate":      10.0,
 				"InvalidDuration":       11.0,
 				"InvalidHTTPStatusCode": 12.0,
+				"InvalidSpanLink":       13.0,
+				"SpanLinksTruncate":     14.0,
 			},
 			"SpansReceived": 10.0,
 			"TracerVersion": "",
@@ -541,6 +545,36 @@ func TestPublishRateLimiterStats(t *testing.T) {
 		})
 }
 
+func TestPublishTailSamplerStats(t *testing.T) {
+	tailSamplerStats = TailSamplerStats{1, 2, 3, 4, 5, 6}
+
+	testExpvarPublish(t, publishTailSamplerStats,
+		map[string]interface{}{
+			"BufferedTraces": 1.0,
+			"BufferedBytes":  2.0,
+			"MaxBufferBytes": 3.0,
+			"TracesKept":     4.0,
+			"TracesDropped":  5.0,
+			"EarlyDecisions": 6.0,
+		})
+}
+
+func TestPublishRuleSamplerStats(t *testing.T) {
+	ruleSamplerStats = []SamplingRuleStats{{"rule", 0.5, 10, 1, 2}}
+	defer func() { ruleSamplerStats = nil }()
+
+	testExpvarPublish(t, publishRuleSamplerStats,
+		[]interface{}{
+			map[string]interface{}{
+				"Rule":          "rule",
+				"SampleRate":    0.5,
+				"MaxPerSecond":  10.0,
+				"TracesKept":    1.0,
+				"TracesDropped": 2.0,
+			},
+		})
+}
+
 func TestScrubCreds(t *testing.T) {
 	assert := assert.New(t)
 	conf := testInit(t)
@@ -62,6 +62,8 @@ func TestSpansMalformed(t *testing.T) {
 			"service_truncate":         0,
 			"invalid_start_date":       0,
 			"invalid_http_status_code": 0,
+			"invalid_span_link":        0,
+			"span_links_truncate":      0,
 			"invalid_duration":         0,
 			"duplicate_span_id":        0,
 			"service_empty":            1,
@@ -260,7 +262,7 @@ func TestReceiverStats(t *testing.T) {
 	t.Run("PublishAndReset", func(t *testing.T) {
 		rs := testStats()
 		rs.PublishAndReset()
-		assert.EqualValues(t, 39, statsclient.counts.Load())
+		assert.EqualValues(t, 41, statsclient.counts.Load())
 		assertStatsAreReset(t, rs)
 	})
 
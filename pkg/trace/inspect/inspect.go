@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package inspect records the trace chunks processed by the agent along with the decisions
+// taken on them, so that they can be inspected through the debug server.
+package inspect
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// Decisions taken on a recorded chunk.
+const (
+	// DecisionKept is set on the chunks sent to the intake.
+	DecisionKept = "kept"
+	// DecisionDropped is set on the chunks dropped by the samplers.
+	DecisionDropped = "dropped"
+	// DecisionFiltered is set on the chunks rejected by a filter.
+	DecisionFiltered = "filtered"
+	// DecisionBuffered is set on the chunks held by the tail sampler until the end of the trace.
+	DecisionBuffered = "buffered"
+)
+
+// subscriberBufferLen is the number of records buffered for a subscriber; the records
+// received while the buffer is full are not sent to the subscriber.
+const subscriberBufferLen = 100
+
+// Span is a span of a recorded chunk.
+type Span struct {
+	SpanID   uint64 `json:"span_id"`
+	ParentID uint64 `json:"parent_id"`
+	Service  string `json:"service"`
+	Name     string `json:"name"`
+	Resource string `json:"resource"`
+	Error    int32  `json:"error,omitempty"`
+	// TouchedBy lists the filters and obfuscators which modified the span, in order.
+	TouchedBy []string `json:"touched_by,omitempty"`
+}
+
+// Record is a trace chunk processed by the agent, along with the decision taken on it.
+type Record struct {
+	Time     time.Time `json:"time"`
+	TraceID  uint64    `json:"trace_id"`
+	Env      string    `json:"env"`
+	Service  string    `json:"service"`
+	Priority int32     `json:"priority"`
+	// Decision is the decision taken on the chunk, one of the Decision* constants.
+	Decision string `json:"decision"`
+	// DecidedBy is the sampler or the filter which took the decision.
+	DecidedBy string `json:"decided_by,omitempty"`
+	// SpansDropped counts the spans removed from the chunk, by filter.
+	SpansDropped map[string]int `json:"spans_dropped,omitempty"`
+	Spans        []Span         `json:"spans"`
+}
+
+// Filter selects the records matching all its non-empty fields.
+type Filter struct {
+	Service string `json:"service"`
+	Env     string `json:"env"`
+}
+
+// Match reports whether r matches f. The service matches the service of any span of the chunk.
+func (f Filter) Match(r *Record) bool {
+	if f.Env != "" && f.Env != r.Env {
+		return false
+	}
+	if f.Service == "" || f.Service == r.Service {
+		return true
+	}
+	for _, s := range r.Spans {
+		if s.Service == f.Service {
+			return true
+		}
+	}
+	return false
+}
+
+type subscriber struct {
+	filter Filter
+	out    chan *Record
+}
+
+// Recorder keeps the latest records in a bounded ring buffer and broadcasts the new records to
+// its subscribers. Records only need to be built while the recorder is active, which is when a
+// ring buffer is configured or when it has subscribers. A nil *Recorder is never active.
+type Recorder struct {
+	active *atomic.Bool
+
+	mu   sync.Mutex
+	ring []*Record // latest records, the oldest one being at ring[next] once the ring is full
+	next int
+	full bool
+	subs map[*subscriber]struct{}
+}
+
+// NewRecorder returns a new recorder keeping the latest size records. When size is 0, the
+// records are only sent to the subscribers.
+func NewRecorder(size int) *Recorder {
+	return &Recorder{
+		active: atomic.NewBool(size > 0),
+		ring:   make([]*Record, size),
+		subs:   make(map[*subscriber]struct{}),
+	}
+}
+
+// Active reports whether records should be added to the recorder.
+func (r *Recorder) Active() bool {
+	return r != nil && r.active.Load()
+}
+
+// Add adds rec to the ring buffer and sends it to the matching subscribers.
+func (r *Recorder) Add(rec *Record) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.ring) > 0 {
+		r.ring[r.next] = rec
+		r.next = (r.next + 1) % len(r.ring)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	for s := range r.subs {
+		if !s.filter.Match(rec) {
+			continue
+		}
+		select {
+		case s.out <- rec:
+		default:
+			// the subscriber is too slow, skip the record rather than blocking the pipeline
+		}
+	}
+}
+
+// Recent returns the records of the ring buffer matching f, from the oldest to the newest.
+func (r *Recorder) Recent(f Filter) []*Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recentLocked(f)
+}
+
+func (r *Recorder) recentLocked(f Filter) []*Record {
+	var out []*Record
+	n := r.next
+	if r.full {
+		n = len(r.ring)
+	}
+	for i := 0; i < n; i++ {
+		rec := r.ring[i]
+		if r.full {
+			rec = r.ring[(r.next+i)%len(r.ring)]
+		}
+		if f.Match(rec) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// Subscribe returns the records of the ring buffer matching f along with a channel receiving
+// the new ones. The returned function must be called to unsubscribe.
+func (r *Recorder) Subscribe(f Filter) (recent []*Record, records <-chan *Record, unsubscribe func()) {
+	s := &subscriber{filter: f, out: make(chan *Record, subscriberBufferLen)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subs[s] = struct{}{}
+	r.active.Store(true)
+	return r.recentLocked(f), s.out, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.subs, s)
+		r.active.Store(len(r.ring) > 0 || len(r.subs) > 0)
+	}
+}
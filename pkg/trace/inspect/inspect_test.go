@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package inspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMatch(t *testing.T) {
+	r := &Record{Env: "prod", Service: "web", Spans: []Span{{Service: "web"}, {Service: "db"}}}
+	for _, tt := range []struct {
+		f     Filter
+		match bool
+	}{
+		{Filter{}, true},
+		{Filter{Env: "prod"}, true},
+		{Filter{Env: "staging"}, false},
+		{Filter{Service: "web"}, true},
+		{Filter{Service: "db", Env: "prod"}, true},
+		{Filter{Service: "cache"}, false},
+	} {
+		assert.Equal(t, tt.match, tt.f.Match(r), tt.f)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		var r *Recorder
+		assert.False(t, r.Active())
+		r.Add(&Record{})
+	})
+
+	t.Run("ring", func(t *testing.T) {
+		r := NewRecorder(3)
+		assert.True(t, r.Active())
+		assert.Empty(t, r.Recent(Filter{}))
+		for i := uint64(1); i <= 5; i++ {
+			r.Add(&Record{TraceID: i, Env: []string{"even", "odd"}[i%2]})
+		}
+		var ids []uint64
+		for _, rec := range r.Recent(Filter{}) {
+			ids = append(ids, rec.TraceID)
+		}
+		assert.Equal(t, []uint64{3, 4, 5}, ids)
+		recs := r.Recent(Filter{Env: "even"})
+		assert.Len(t, recs, 1)
+		assert.EqualValues(t, 4, recs[0].TraceID)
+	})
+
+	t.Run("subscribe", func(t *testing.T) {
+		r := NewRecorder(0)
+		assert.False(t, r.Active())
+		recent, records, unsubscribe := r.Subscribe(Filter{Service: "web"})
+		assert.Empty(t, recent)
+		assert.True(t, r.Active())
+
+		r.Add(&Record{TraceID: 1, Service: "db"})
+		r.Add(&Record{TraceID: 2, Service: "web"})
+		assert.EqualValues(t, 2, (<-records).TraceID)
+
+		// a slow subscriber does not block the recorder
+		for i := 0; i < subscriberBufferLen+1; i++ {
+			r.Add(&Record{Service: "web"})
+		}
+		assert.Len(t, records, subscriberBufferLen)
+
+		unsubscribe()
+		assert.False(t, r.Active())
+	})
+
+	t.Run("subscribe-recent", func(t *testing.T) {
+		r := NewRecorder(2)
+		r.Add(&Record{TraceID: 1})
+		recent, _, unsubscribe := r.Subscribe(Filter{})
+		assert.Len(t, recent, 1)
+		unsubscribe()
+		assert.True(t, r.Active())
+	})
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package inspect
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stream prints the records matching f streamed by the /debug/traces endpoint of the debug server
+// listening at addr (e.g. "http://127.0.0.1:5012") to w, until ctx is done or the stream ends.
+func Stream(ctx context.Context, w io.Writer, addr string, f Filter) error {
+	q := url.Values{}
+	if f.Service != "" {
+		q.Set("service", f.Service)
+	}
+	if f.Env != "" {
+		q.Set("env", f.Env)
+	}
+	u := strings.TrimSuffix(addr, "/") + "/debug/traces"
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach the trace-agent debug server at %s: %v", addr, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF || errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		writeRecord(w, &rec)
+	}
+}
+
+// writeRecord writes a human readable representation of rec to w.
+func writeRecord(w io.Writer, rec *Record) {
+	decision := rec.Decision
+	if rec.DecidedBy != "" {
+		decision += " by " + rec.DecidedBy
+	}
+	fmt.Fprintf(w, "%s trace_id:%d env:%s service:%s priority:%d %s (%d spans)\n",
+		rec.Time.Format(time.RFC3339Nano), rec.TraceID, rec.Env, rec.Service, rec.Priority, decision, len(rec.Spans))
+	if len(rec.SpansDropped) > 0 {
+		steps := make([]string, 0, len(rec.SpansDropped))
+		for step, n := range rec.SpansDropped {
+			steps = append(steps, fmt.Sprintf("%s:%d", step, n))
+		}
+		sort.Strings(steps)
+		fmt.Fprintf(w, "  spans dropped: %s\n", strings.Join(steps, ","))
+	}
+	for _, s := range rec.Spans {
+		fmt.Fprintf(w, "  span_id:%d parent_id:%d service:%s name:%s resource:%q", s.SpanID, s.ParentID, s.Service, s.Name, s.Resource)
+		if s.Error != 0 {
+			fmt.Fprint(w, " error")
+		}
+		if len(s.TouchedBy) > 0 {
+			fmt.Fprintf(w, " touched_by:%s", strings.Join(s.TouchedBy, ","))
+		}
+		fmt.Fprintln(w)
+	}
+}
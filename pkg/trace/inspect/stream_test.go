@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	t.Run("records", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/debug/traces", r.URL.Path)
+			assert.Equal(t, "web", r.URL.Query().Get("service"))
+			assert.Equal(t, "prod", r.URL.Query().Get("env"))
+			enc := json.NewEncoder(w)
+			enc.Encode(&Record{
+				Time:         time.Unix(0, 0).UTC(),
+				TraceID:      1,
+				Env:          "prod",
+				Service:      "web",
+				Priority:     1,
+				Decision:     DecisionKept,
+				DecidedBy:    "priority_sampler",
+				SpansDropped: map[string]int{"span_rules": 1},
+				Spans: []Span{
+					{SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", TouchedBy: []string{"obfuscator:http", "truncator"}},
+					{SpanID: 2, ParentID: 1, Service: "db", Name: "query", Resource: "SELECT ?", Error: 1},
+				},
+			})
+		}))
+		defer srv.Close()
+
+		var out bytes.Buffer
+		assert.NoError(t, Stream(context.Background(), &out, srv.URL, Filter{Service: "web", Env: "prod"}))
+		assert.Equal(t, `1970-01-01T00:00:00Z trace_id:1 env:prod service:web priority:1 kept by priority_sampler (2 spans)
+  spans dropped: span_rules:1
+  span_id:1 parent_id:0 service:web name:http.request resource:"GET /" touched_by:obfuscator:http,truncator
+  span_id:2 parent_id:1 service:db name:query resource:"SELECT ?" error
+`, out.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "trace inspection is not available", http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		err := Stream(context.Background(), &bytes.Buffer{}, srv.URL, Filter{})
+		assert.EqualError(t, err, "404 Not Found: trace inspection is not available")
+	})
+}
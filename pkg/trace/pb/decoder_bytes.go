@@ -196,6 +196,39 @@ func parseUint64Bytes(bts []byte) (uint64, []byte, error) {
 	}
 }
 
+// parseUint32Bytes parses an uint32 even if the sent value is an int32, for the same
+// reasons as parseUint64Bytes.
+func parseUint32Bytes(bts []byte) (uint32, []byte, error) {
+	if msgp.IsNil(bts) {
+		bts, err := msgp.ReadNilBytes(bts)
+		return 0, bts, err
+	}
+	// read the generic representation type without decoding
+	t := msgp.NextType(bts)
+
+	var (
+		i   int32
+		u   uint32
+		err error
+	)
+	switch t {
+	case msgp.UintType:
+		u, bts, err = msgp.ReadUint32Bytes(bts)
+		if err != nil {
+			return 0, bts, err
+		}
+		return u, bts, err
+	case msgp.IntType:
+		i, bts, err = msgp.ReadInt32Bytes(bts)
+		if err != nil {
+			return 0, bts, err
+		}
+		return uint32(i), bts, nil
+	default:
+		return 0, bts, msgp.TypeError{Encoded: t, Method: msgp.IntType}
+	}
+}
+
 // cast to int32 values that are int32 but that are sent in uint32
 // over the wire. Set to 0 if they overflow the MaxInt32 size. This
 // cast should be used ONLY while decoding int32 values that are
@@ -87,9 +87,12 @@ func (t *Traces) UnmarshalMsgDictionary(bts []byte) error {
 }
 
 // spanPropertyCount specifies the number of top-level properties that a span
-// has.
+// has, without its optional span links.
 const spanPropertyCount = 12
 
+// spanLinkPropertyCount specifies the number of properties that a span link has.
+const spanLinkPropertyCount = 6
+
 // UnmarshalMsgDictionary decodes a span from the given decoder dc, looking up strings
 // in the given dictionary dict. For details, see the documentation for endpoint v0.5
 // in pkg/trace/api/version.go
@@ -102,9 +105,10 @@ func (z *Span) UnmarshalMsgDictionary(bts []byte, dict []string) ([]byte, error)
 	if err != nil {
 		return bts, err
 	}
-	if sz != spanPropertyCount {
-		return bts, errors.New("encoded span needs exactly 12 elements in array")
+	if sz != spanPropertyCount && sz != spanPropertyCount+1 {
+		return bts, errors.New("encoded span needs exactly 12 or 13 elements in array")
 	}
+	hasLinks := sz == spanPropertyCount+1
 	// Service (0)
 	z.Service, bts, err = dictionaryString(bts, dict)
 	if err != nil {
@@ -216,5 +220,87 @@ func (z *Span) UnmarshalMsgDictionary(bts []byte, dict []string) ([]byte, error)
 	if err != nil {
 		return bts, err
 	}
+	z.SpanLinks = z.SpanLinks[:0]
+	if !hasLinks {
+		return bts, nil
+	}
+	// SpanLinks (12)
+	sz, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	if sz > uint32(len(bts)) {
+		return bts, errors.New("too many span links")
+	}
+	for ; sz > 0; sz-- {
+		link := new(SpanLink)
+		if bts, err = link.UnmarshalMsgDictionary(bts, dict); err != nil {
+			return bts, err
+		}
+		z.SpanLinks = append(z.SpanLinks, link)
+	}
 	return bts, nil
 }
+
+// UnmarshalMsgDictionary decodes a span link from the given bytes, looking up strings
+// in the given dictionary dict. For details, see the documentation for endpoint v0.5
+// in pkg/trace/api/version.go
+func (z *SpanLink) UnmarshalMsgDictionary(bts []byte, dict []string) ([]byte, error) {
+	var (
+		sz  uint32
+		err error
+	)
+	sz, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	if sz != spanLinkPropertyCount {
+		return bts, errors.New("encoded span link needs exactly 6 elements in array")
+	}
+	// TraceID (0)
+	z.TraceID, bts, err = parseUint64Bytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	// TraceIDHigh (1)
+	z.TraceIDHigh, bts, err = parseUint64Bytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	// SpanID (2)
+	z.SpanID, bts, err = parseUint64Bytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	// Attributes (3)
+	sz, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return bts, err
+	}
+	if sz > uint32(len(bts)) {
+		return bts, errors.New("too many span link attributes")
+	}
+	if sz > 0 {
+		z.Attributes = make(map[string]string, sz)
+	}
+	for ; sz > 0; sz-- {
+		var key, val string
+		key, bts, err = dictionaryString(bts, dict)
+		if err != nil {
+			return bts, err
+		}
+		val, bts, err = dictionaryString(bts, dict)
+		if err != nil {
+			return bts, err
+		}
+		z.Attributes[key] = val
+	}
+	// Tracestate (4)
+	z.Tracestate, bts, err = dictionaryString(bts, dict)
+	if err != nil {
+		return bts, err
+	}
+	// Flags (5)
+	z.Flags, bts, err = parseUint32Bytes(bts)
+	return bts, err
+}
@@ -80,6 +80,60 @@ func TestUnmarshalMsgDictionary(t *testing.T) {
 	})
 }
 
+func TestUnmarshalMsgDictionarySpanLinks(t *testing.T) {
+	data := [2]interface{}{
+		0: []string{
+			0: "",
+			1: "my-service",
+			2: "my-name",
+			3: "my-resource",
+			4: "link.name",
+			5: "retry",
+			6: "dd=s:1",
+		},
+		1: [][][13]interface{}{
+			{
+				{
+					1,
+					2,
+					3,
+					uint64(1),
+					uint64(2),
+					uint64(0),
+					int64(123),
+					int64(456),
+					0,
+					map[interface{}]interface{}{},
+					map[interface{}]float64{},
+					0,
+					[][6]interface{}{
+						{uint64(3), uint64(0), uint64(4), map[interface{}]interface{}{}, 0, 0},
+						{uint64(5), uint64(6), uint64(7), map[interface{}]interface{}{4: 5}, 6, 1},
+					},
+				},
+			},
+		},
+	}
+	b, err := vmsgp.Marshal(&data)
+	assert.NoError(t, err)
+
+	var traces Traces
+	if err := traces.UnmarshalMsgDictionary(b); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*SpanLink{
+		{TraceID: 3, SpanID: 4},
+		{
+			TraceID:     5,
+			TraceIDHigh: 6,
+			SpanID:      7,
+			Attributes:  map[string]string{"link.name": "retry"},
+			Tracestate:  "dd=s:1",
+			Flags:       1,
+		},
+	}, traces[0][0].SpanLinks)
+}
+
 func TestUnmarshalMsgDictionaryLimitsSize(t *testing.T) {
 	ps := [][]byte{
 		[]byte("\x9e\xdd\xff\xff\xff\xff"),
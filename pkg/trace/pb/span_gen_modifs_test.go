@@ -410,3 +410,53 @@ func TestDeserialization(t *testing.T) {
 		})
 	})
 }
+
+func TestSpanLinksDeserialization(t *testing.T) {
+	t.Run("Nil", func(t *testing.T) {
+		b := newEmptyMessage()
+		b = msgp.AppendString(b, "span_links")
+		b = msgp.AppendNil(b)
+		s, err := decodeBytes(b)
+		assert.Nil(t, err)
+		assert.Nil(t, s.SpanLinks)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		in := Span{
+			TraceID: 1,
+			SpanID:  2,
+			SpanLinks: []*SpanLink{
+				{TraceID: 3, SpanID: 4},
+				{
+					TraceID:     5,
+					TraceIDHigh: 6,
+					SpanID:      7,
+					Attributes:  map[string]string{"link.name": "retry"},
+					Tracestate:  "dd=s:1",
+					Flags:       1,
+				},
+			},
+		}
+		b, err := in.MarshalMsg(nil)
+		assert.Nil(t, err)
+		s, err := decodeBytes(b)
+		assert.Nil(t, err)
+		assert.Equal(t, in.SpanLinks, s.SpanLinks)
+	})
+
+	t.Run("SignedIntegers", func(t *testing.T) {
+		b := newEmptyMessage()
+		b = msgp.AppendString(b, "span_links")
+		b = msgp.AppendArrayHeader(b, 1)
+		b = msgp.AppendMapHeader(b, 3)
+		b = msgp.AppendString(b, "trace_id")
+		b = msgp.AppendInt64(b, 3)
+		b = msgp.AppendString(b, "span_id")
+		b = msgp.AppendInt64(b, 4)
+		b = msgp.AppendString(b, "flags")
+		b = msgp.AppendInt64(b, 1)
+		s, err := decodeBytes(b)
+		assert.Nil(t, err)
+		assert.Equal(t, []*SpanLink{{TraceID: 3, SpanID: 4, Flags: 1}}, s.SpanLinks)
+	})
+}
@@ -6,6 +6,7 @@ package remoteconfighandler
 import (
 	reflect "reflect"
 
+	config "github.com/DataDog/datadog-agent/pkg/trace/config"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -113,3 +114,38 @@ func (mr *MockrareSamplerMockRecorder) SetEnabled(enabled interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEnabled", reflect.TypeOf((*MockrareSampler)(nil).SetEnabled), enabled)
 }
+
+// MockruleSampler is a mock of ruleSampler interface.
+type MockruleSampler struct {
+	ctrl     *gomock.Controller
+	recorder *MockruleSamplerMockRecorder
+}
+
+// MockruleSamplerMockRecorder is the mock recorder for MockruleSampler.
+type MockruleSamplerMockRecorder struct {
+	mock *MockruleSampler
+}
+
+// NewMockruleSampler creates a new mock instance.
+func NewMockruleSampler(ctrl *gomock.Controller) *MockruleSampler {
+	mock := &MockruleSampler{ctrl: ctrl}
+	mock.recorder = &MockruleSamplerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockruleSampler) EXPECT() *MockruleSamplerMockRecorder {
+	return m.recorder
+}
+
+// UpdateRules mocks base method.
+func (m *MockruleSampler) UpdateRules(rules []*config.SamplingRule) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateRules", rules)
+}
+
+// UpdateRules indicates an expected call of UpdateRules.
+func (mr *MockruleSamplerMockRecorder) UpdateRules(rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRules", reflect.TypeOf((*MockruleSampler)(nil).UpdateRules), rules)
+}
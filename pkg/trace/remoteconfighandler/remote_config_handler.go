@@ -26,16 +26,21 @@ type rareSampler interface {
 	SetEnabled(enabled bool)
 }
 
+type ruleSampler interface {
+	UpdateRules(rules []*config.SamplingRule)
+}
+
 // RemoteConfigHandler holds pointers to samplers that need to be updated when APM remote config changes
 type RemoteConfigHandler struct {
 	remoteClient    config.RemoteClient
 	prioritySampler prioritySampler
 	errorsSampler   errorsSampler
 	rareSampler     rareSampler
+	ruleSampler     ruleSampler
 	agentConfig     *config.AgentConfig
 }
 
-func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler) *RemoteConfigHandler {
+func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler, ruleSampler ruleSampler) *RemoteConfigHandler {
 	if conf.RemoteSamplingClient == nil {
 		return nil
 	}
@@ -45,6 +50,7 @@ func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler
 		prioritySampler: prioritySampler,
 		rareSampler:     rareSampler,
 		errorsSampler:   errorsSampler,
+		ruleSampler:     ruleSampler,
 		agentConfig:     conf,
 	}
 }
@@ -119,4 +125,34 @@ func (h *RemoteConfigHandler) updateSamplers(config apmsampling.SamplerConfig) {
 		rareSamplerEnabled = h.agentConfig.RareSamplerEnabled
 	}
 	h.rareSampler.SetEnabled(rareSamplerEnabled)
+
+	samplingRules := h.agentConfig.SamplingRules
+	if confForEnv != nil && confForEnv.SamplingRules != nil {
+		samplingRules = convertSamplingRules(*confForEnv.SamplingRules)
+	} else if config.AllEnvs.SamplingRules != nil {
+		samplingRules = convertSamplingRules(*config.AllEnvs.SamplingRules)
+	}
+	h.ruleSampler.UpdateRules(samplingRules)
+}
+
+// convertSamplingRules returns the agent configuration of the remote sampling rules.
+func convertSamplingRules(rules []apmsampling.SamplingRule) []*config.SamplingRule {
+	out := make([]*config.SamplingRule, 0, len(rules))
+	for _, r := range rules {
+		rule := &config.SamplingRule{
+			Name: r.Name,
+			Match: config.SamplingRuleMatch{
+				Service:  r.Match.Service,
+				Name:     r.Match.Name,
+				Resource: r.Match.Resource,
+			},
+			SampleRate:   r.SampleRate,
+			MaxPerSecond: r.MaxPerSecond,
+		}
+		for _, tag := range r.Match.Tags {
+			rule.Match.Tags = append(rule.Match.Tags, &config.SamplingRuleTag{Key: tag.Key, Value: tag.Value})
+		}
+		out = append(out, rule)
+	}
+	return out
 }
@@ -24,8 +24,9 @@ func TestStart(t *testing.T) {
 	prioritySampler := NewMockprioritySampler(ctrl)
 	errorsSampler := NewMockerrorsSampler(ctrl)
 	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
 
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
 
 	remoteClient.EXPECT().RegisterAPMUpdate(gomock.Any()).Times(1)
 	remoteClient.EXPECT().Start().Times(1)
@@ -46,9 +47,10 @@ func TestPrioritySampler(t *testing.T) {
 	prioritySampler := NewMockprioritySampler(ctrl)
 	errorsSampler := NewMockerrorsSampler(ctrl)
 	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
 
 	agentConfig := config.AgentConfig{RemoteSamplingClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -64,6 +66,7 @@ func TestPrioritySampler(t *testing.T) {
 	prioritySampler.EXPECT().UpdateTargetTPS(float64(42)).Times(1)
 	errorsSampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
 	rareSampler.EXPECT().SetEnabled(true).Times(1)
+	ruleSampler.EXPECT().UpdateRules(gomock.Nil()).Times(1)
 
 	h.onUpdate(map[string]state.APMSamplingConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": config})
 
@@ -76,9 +79,10 @@ func TestErrorsSampler(t *testing.T) {
 	prioritySampler := NewMockprioritySampler(ctrl)
 	errorsSampler := NewMockerrorsSampler(ctrl)
 	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
 
 	agentConfig := config.AgentConfig{RemoteSamplingClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -94,6 +98,7 @@ func TestErrorsSampler(t *testing.T) {
 	prioritySampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
 	errorsSampler.EXPECT().UpdateTargetTPS(float64(42)).Times(1)
 	rareSampler.EXPECT().SetEnabled(true).Times(1)
+	ruleSampler.EXPECT().UpdateRules(gomock.Nil()).Times(1)
 
 	h.onUpdate(map[string]state.APMSamplingConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": config})
 
@@ -106,9 +111,10 @@ func TestRareSampler(t *testing.T) {
 	prioritySampler := NewMockprioritySampler(ctrl)
 	errorsSampler := NewMockerrorsSampler(ctrl)
 	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
 
 	agentConfig := config.AgentConfig{RemoteSamplingClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -124,6 +130,7 @@ func TestRareSampler(t *testing.T) {
 	prioritySampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
 	errorsSampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
 	rareSampler.EXPECT().SetEnabled(false).Times(1)
+	ruleSampler.EXPECT().UpdateRules(gomock.Nil()).Times(1)
 
 	h.onUpdate(map[string]state.APMSamplingConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": config})
 
@@ -136,15 +143,17 @@ func TestEnvPrecedence(t *testing.T) {
 	prioritySampler := NewMockprioritySampler(ctrl)
 	errorsSampler := NewMockerrorsSampler(ctrl)
 	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
 
 	agentConfig := config.AgentConfig{RemoteSamplingClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DefaultEnv: "agent-env"}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
 			PrioritySamplerTargetTPS: pointer.Ptr(42.0),
 			ErrorsSamplerTargetTPS:   pointer.Ptr(42.0),
 			RareSamplerEnabled:       pointer.Ptr(true),
+			SamplingRules:            &[]apmsampling.SamplingRule{{Name: "all-envs", SampleRate: 1}},
 		},
 		ByEnv: []apmsampling.EnvAndConfig{{
 			Env: "agent-env",
@@ -152,10 +161,13 @@ func TestEnvPrecedence(t *testing.T) {
 				PrioritySamplerTargetTPS: pointer.Ptr(43.0),
 				ErrorsSamplerTargetTPS:   pointer.Ptr(43.0),
 				RareSamplerEnabled:       pointer.Ptr(false),
+				SamplingRules:            &[]apmsampling.SamplingRule{{Name: "agent-env", SampleRate: 0.5}},
 			},
 		}},
 	}
 
+	wantRules := []*config.SamplingRule{{Name: "agent-env", SampleRate: 0.5}}
+
 	raw, _ := json.Marshal(payload)
 	config := state.APMSamplingConfig{
 		Config: raw,
@@ -164,6 +176,58 @@ func TestEnvPrecedence(t *testing.T) {
 	prioritySampler.EXPECT().UpdateTargetTPS(float64(43)).Times(1)
 	errorsSampler.EXPECT().UpdateTargetTPS(float64(43)).Times(1)
 	rareSampler.EXPECT().SetEnabled(false).Times(1)
+	ruleSampler.EXPECT().UpdateRules(wantRules).Times(1)
+
+	h.onUpdate(map[string]state.APMSamplingConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": config})
+
+	ctrl.Finish()
+}
+
+func TestRuleSampler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	remoteClient := NewMockRemoteClient(ctrl)
+	prioritySampler := NewMockprioritySampler(ctrl)
+	errorsSampler := NewMockerrorsSampler(ctrl)
+	rareSampler := NewMockrareSampler(ctrl)
+	ruleSampler := NewMockruleSampler(ctrl)
+
+	agentConfig := config.AgentConfig{RemoteSamplingClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, ruleSampler)
+
+	payload := apmsampling.SamplerConfig{
+		AllEnvs: apmsampling.SamplerEnvConfig{
+			SamplingRules: &[]apmsampling.SamplingRule{{
+				Name: "health-checks",
+				Match: apmsampling.SamplingRuleMatch{
+					Service:  "web-*",
+					Resource: "GET /health*",
+					Tags:     []apmsampling.SamplingRuleTag{{Key: "http.status_code", Value: "2??"}},
+				},
+				SampleRate:   0.1,
+				MaxPerSecond: 5,
+			}},
+		},
+	}
+	want := []*config.SamplingRule{{
+		Name: "health-checks",
+		Match: config.SamplingRuleMatch{
+			Service:  "web-*",
+			Resource: "GET /health*",
+			Tags:     []*config.SamplingRuleTag{{Key: "http.status_code", Value: "2??"}},
+		},
+		SampleRate:   0.1,
+		MaxPerSecond: 5,
+	}}
+
+	raw, _ := json.Marshal(payload)
+	config := state.APMSamplingConfig{
+		Config: raw,
+	}
+
+	prioritySampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
+	errorsSampler.EXPECT().UpdateTargetTPS(float64(41)).Times(1)
+	rareSampler.EXPECT().SetEnabled(true).Times(1)
+	ruleSampler.EXPECT().UpdateRules(want).Times(1)
 
 	h.onUpdate(map[string]state.APMSamplingConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": config})
 
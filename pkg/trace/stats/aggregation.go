@@ -17,6 +17,7 @@ import (
 const (
 	tagStatusCode = "http.status_code"
 	tagSynthetics = "synthetics"
+	tagSpanKind   = "span.kind"
 )
 
 // Aggregation contains all the dimension on which we aggregate statistics.
@@ -33,6 +34,9 @@ type BucketsAggregationKey struct {
 	Type       string
 	StatusCode uint32
 	Synthetics bool
+	// AdditionalTags holds the "key:value" pairs of the configured additional tags, in the
+	// order of the configuration, separated by additionalTagsSeparator.
+	AdditionalTags string
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -86,6 +90,117 @@ func NewAggregationFromGroup(g pb.ClientGroupedStats) Aggregation {
 			Name:       g.Name,
 			StatusCode: g.HTTPStatusCode,
 			Synthetics: g.Synthetics,
+			// the tags of grouped stats are already in a canonical order
+			AdditionalTags: joinAdditionalTags(g.AdditionalTags),
 		},
 	}
 }
+
+// additionalTagsSeparator separates the "key:value" pairs of the AdditionalTags aggregation
+// key. Tag values may contain commas (e.g. http.url), but not a NUL byte.
+const additionalTagsSeparator = "\x00"
+
+// joinAdditionalTags returns the "key:value" pairs as an AdditionalTags aggregation key.
+func joinAdditionalTags(tags []string) string {
+	return strings.Join(tags, additionalTagsSeparator)
+}
+
+// splitAdditionalTags returns the "key:value" pairs of the AdditionalTags aggregation key.
+func splitAdditionalTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, additionalTagsSeparator)
+}
+
+// computeStatsForSpanKind reports whether the span.kind of s is one of the kinds stats are
+// computed on when stats by span kind are enabled.
+func computeStatsForSpanKind(s *pb.Span) bool {
+	switch strings.ToLower(s.Meta[tagSpanKind]) {
+	case "server", "consumer", "client", "producer":
+		return true
+	}
+	return false
+}
+
+// additionalTags extracts the additional aggregation dimensions from spans and grouped stats,
+// bounding the number of distinct values of each tag. It is not safe for concurrent use and
+// is meant to be scoped to a single stats bucket.
+type additionalTags struct {
+	keys           []string
+	maxCardinality int
+	values         map[string]map[string]struct{} // distinct values seen for each key
+	blocked        int64                          // number of values left out because of the cardinality limit
+}
+
+// newAdditionalTags returns a new additionalTags extracting the given tag keys. It returns nil
+// when keys is empty, in which case no additional tag is extracted.
+func newAdditionalTags(keys []string, maxCardinality int) *additionalTags {
+	if len(keys) == 0 {
+		return nil
+	}
+	return &additionalTags{
+		keys:           keys,
+		maxCardinality: maxCardinality,
+		values:         make(map[string]map[string]struct{}, len(keys)),
+	}
+}
+
+// allow reports whether value v of tag k can be used as an aggregation dimension.
+func (t *additionalTags) allow(k, v string) bool {
+	seen, ok := t.values[k]
+	if !ok {
+		seen = make(map[string]struct{})
+		t.values[k] = seen
+	}
+	if _, ok := seen[v]; ok {
+		return true
+	}
+	if t.maxCardinality > 0 && len(seen) >= t.maxCardinality {
+		t.blocked++
+		log.Debugf("Leaving tag %q out of the stats: more than %d distinct values in the bucket.", k, t.maxCardinality)
+		return false
+	}
+	seen[v] = struct{}{}
+	return true
+}
+
+// fromSpan returns the additional tags of s as an aggregation key.
+func (t *additionalTags) fromSpan(s *pb.Span) string {
+	if t == nil {
+		return ""
+	}
+	return t.join(func(k string) string { return s.Meta[k] })
+}
+
+// fromTags returns the configured tags out of the given "key:value" pairs as an aggregation key.
+func (t *additionalTags) fromTags(tags []string) string {
+	if t == nil || len(tags) == 0 {
+		return ""
+	}
+	return t.join(func(k string) string {
+		for _, tag := range tags {
+			if len(tag) > len(k) && tag[len(k)] == ':' && strings.HasPrefix(tag, k) {
+				return tag[len(k)+1:]
+			}
+		}
+		return ""
+	})
+}
+
+func (t *additionalTags) join(get func(k string) string) string {
+	var b strings.Builder
+	for _, k := range t.keys {
+		v := get(k)
+		if v == "" || !t.allow(k, v) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(additionalTagsSeparator)
+		}
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(v)
+	}
+	return b.String()
+}
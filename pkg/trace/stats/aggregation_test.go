@@ -8,6 +8,8 @@ package stats
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 )
 
@@ -51,3 +53,63 @@ func TestGetStatusCode(t *testing.T) {
 		}
 	}
 }
+
+func TestAdditionalTags(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		tags := newAdditionalTags(nil, 10)
+		assert.Nil(t, tags)
+		assert.Equal(t, "", tags.fromSpan(&pb.Span{Meta: map[string]string{"peer.service": "db"}}))
+		assert.Equal(t, "", tags.fromTags([]string{"peer.service:db"}))
+	})
+
+	t.Run("fromSpan", func(t *testing.T) {
+		tags := newAdditionalTags([]string{"peer.service", "span.kind", "db.instance"}, 10)
+		s := &pb.Span{Meta: map[string]string{"db.instance": "users", "peer.service": "db", "http.method": "GET"}}
+		assert.Equal(t, []string{"peer.service:db", "db.instance:users"}, splitAdditionalTags(tags.fromSpan(s)))
+		assert.Equal(t, "", tags.fromSpan(&pb.Span{}))
+	})
+
+	t.Run("fromTags", func(t *testing.T) {
+		tags := newAdditionalTags([]string{"peer.service", "db.instance"}, 10)
+		in := []string{"db.instance:users", "peer.service.name:x", "host:h", "peer.service:db:1"}
+		assert.Equal(t, []string{"peer.service:db:1", "db.instance:users"}, splitAdditionalTags(tags.fromTags(in)))
+	})
+
+	t.Run("comma", func(t *testing.T) {
+		tags := newAdditionalTags([]string{"http.url", "db.instance"}, 10)
+		s := &pb.Span{Meta: map[string]string{"http.url": "/search?q=a,b", "db.instance": "users,orders"}}
+		assert.Equal(t, []string{"http.url:/search?q=a,b", "db.instance:users,orders"}, splitAdditionalTags(tags.fromSpan(s)))
+	})
+
+	t.Run("cardinality", func(t *testing.T) {
+		tags := newAdditionalTags([]string{"peer.service"}, 2)
+		for _, tt := range []struct{ in, out string }{
+			{"a", "peer.service:a"},
+			{"b", "peer.service:b"},
+			{"c", ""},
+			{"a", "peer.service:a"},
+		} {
+			assert.Equal(t, tt.out, tags.fromSpan(&pb.Span{Meta: map[string]string{"peer.service": tt.in}}))
+		}
+		assert.EqualValues(t, 1, tags.blocked)
+	})
+}
+
+func TestComputeStatsForSpanKind(t *testing.T) {
+	for kind, want := range map[string]bool{
+		"server":   true,
+		"CLIENT":   true,
+		"producer": true,
+		"consumer": true,
+		"internal": false,
+		"":         false,
+	} {
+		assert.Equal(t, want, computeStatsForSpanKind(&pb.Span{Meta: map[string]string{"span.kind": kind}}), kind)
+	}
+}
+
+func TestNewAggregationFromGroupAdditionalTags(t *testing.T) {
+	a := NewAggregationFromGroup(pb.ClientGroupedStats{Service: "s", AdditionalTags: []string{"peer.service:db", "db.instance:users,orders"}})
+	assert.Equal(t, []string{"peer.service:db", "db.instance:users,orders"}, splitAdditionalTags(a.AdditionalTags))
+	assert.Nil(t, splitAdditionalTags(""))
+}
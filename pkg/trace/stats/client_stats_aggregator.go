@@ -45,6 +45,11 @@ type ClientStatsAggregator struct {
 	agentHostname string
 	agentVersion  string
 
+	// additionalTags are the tags of the grouped stats used as additional aggregation
+	// dimensions, bounded to maxTagCardinality distinct values per tag and bucket.
+	additionalTags    []string
+	maxTagCardinality int
+
 	exit chan struct{}
 	done chan struct{}
 }
@@ -62,6 +67,9 @@ func NewClientStatsAggregator(conf *config.AgentConfig, out chan pb.StatsPayload
 		oldestTs:      alignAggTs(time.Now().Add(bucketDuration - oldestBucketStart)),
 		exit:          make(chan struct{}),
 		done:          make(chan struct{}),
+
+		additionalTags:    conf.StatsAdditionalTags,
+		maxTagCardinality: conf.StatsAdditionalTagsMaxCardinality,
 	}
 }
 
@@ -131,9 +139,10 @@ func (a *ClientStatsAggregator) add(now time.Time, p pb.ClientStatsPayload) {
 		}
 		b, ok := a.buckets[ts.Unix()]
 		if !ok {
-			b = &bucket{ts: ts}
+			b = &bucket{ts: ts, additionalTags: newAdditionalTags(a.additionalTags, a.maxTagCardinality)}
 			a.buckets[ts.Unix()] = b
 		}
+		b.filterAdditionalTags(clientBucket)
 		p.Stats = []pb.ClientStatsBucket{clientBucket}
 		a.flush(b.add(p))
 	}
@@ -171,6 +180,20 @@ type bucket struct {
 	n int
 	// agg contains the aggregated Hits/Errors/Duration counts
 	agg map[PayloadAggregationKey]map[BucketsAggregationKey]*aggregatedCounts
+	// additionalTags keeps the configured additional tags of the grouped stats, if any
+	additionalTags *additionalTags
+}
+
+// filterAdditionalTags keeps only the configured additional tags on the grouped stats of cb,
+// so that the payloads holding the distributions and the aggregated counts share the same
+// aggregation dimensions.
+func (b *bucket) filterAdditionalTags(cb pb.ClientStatsBucket) {
+	for i, g := range cb.Stats {
+		if len(g.AdditionalTags) == 0 {
+			continue
+		}
+		cb.Stats[i].AdditionalTags = splitAdditionalTags(b.additionalTags.fromTags(g.AdditionalTags))
+	}
 }
 
 func (b *bucket) add(p pb.ClientStatsPayload) []pb.ClientStatsPayload {
@@ -237,6 +260,7 @@ func (b *bucket) aggregationToPayloads() []pb.ClientStatsPayload {
 				HTTPStatusCode: aggrKey.StatusCode,
 				Type:           aggrKey.Type,
 				Synthetics:     aggrKey.Synthetics,
+				AdditionalTags: splitAdditionalTags(aggrKey.AdditionalTags),
 				Hits:           counts.hits,
 				Errors:         counts.errors,
 				Duration:       counts.duration,
@@ -271,6 +295,8 @@ func newBucketAggregationKey(b pb.ClientGroupedStats) BucketsAggregationKey {
 		Type:       b.Type,
 		Synthetics: b.Synthetics,
 		StatusCode: b.HTTPStatusCode,
+		// additional tags were filtered in the order of the configuration on reception
+		AdditionalTags: joinAdditionalTags(b.AdditionalTags),
 	}
 }
 
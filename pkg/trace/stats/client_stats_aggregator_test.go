@@ -71,6 +71,9 @@ func getTestStatsWithStart(start time.Time) pb.ClientStatsPayload {
 	b := pb.ClientStatsBucket{}
 	fuzzer.Fuzz(&b)
 	b.Start = uint64(start.UnixNano())
+	for i := range b.Stats {
+		b.Stats[i].AdditionalTags = nil
+	}
 	p := pb.ClientStatsPayload{}
 	fuzzer.Fuzz(&p)
 	p.Tags = nil
@@ -252,6 +255,37 @@ func TestFuzzCountFields(t *testing.T) {
 	}
 }
 
+func TestAdditionalTagsAggregation(t *testing.T) {
+	assert := assert.New(t)
+	a := newTestAggregator()
+	a.additionalTags = []string{"peer.service", "db.instance"}
+	a.maxTagCardinality = 10
+	testTime := time.Unix(time.Now().Unix(), 0)
+
+	payload := func(hits uint64, tags ...string) pb.ClientStatsPayload {
+		p := payloadWithCounts(testTime, BucketsAggregationKey{Service: "s"}, hits, 0, 0)
+		p.Stats[0].Stats[0].AdditionalTags = tags
+		return p
+	}
+	a.add(testTime, payload(1, "db.instance:users", "peer.service:db", "host:h1"))
+	a.add(testTime, payload(2, "peer.service:db", "db.instance:users", "host:h2"))
+	a.add(testTime, payload(4, "host:h3"))
+	assert.Len(a.out, 2)
+	// unconfigured tags are removed from the distributions
+	for _, p := range (<-a.out).Stats {
+		assert.Equal([]string{"peer.service:db", "db.instance:users"}, p.Stats[0].Stats[0].AdditionalTags)
+	}
+	assert.Nil((<-a.out).Stats[0].Stats[0].Stats[0].AdditionalTags)
+
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	aggCounts := <-a.out
+	assertAggCountsPayload(t, aggCounts)
+	assert.ElementsMatch(aggCounts.Stats[0].Stats[0].Stats, []pb.ClientGroupedStats{
+		{Service: "s", Hits: 3, AdditionalTags: []string{"peer.service:db", "db.instance:users"}},
+		{Service: "s", Hits: 4},
+	})
+}
+
 func TestCountAggregation(t *testing.T) {
 	assert := assert.New(t)
 	type tt struct {
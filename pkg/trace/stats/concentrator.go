@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
@@ -45,6 +46,13 @@ type Concentrator struct {
 	agentEnv      string
 	agentHostname string
 	agentVersion  string
+
+	// additionalTags are the span tags used as additional aggregation dimensions, bounded to
+	// maxTagCardinality distinct values per tag and bucket.
+	additionalTags    []string
+	maxTagCardinality int
+	// computeStatsBySpanKind enables stats on spans with an eligible span.kind.
+	computeStatsBySpanKind bool
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -64,6 +72,10 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
 		agentVersion:  conf.AgentVersion,
+
+		additionalTags:         conf.StatsAdditionalTags,
+		maxTagCardinality:      conf.StatsAdditionalTagsMaxCardinality,
+		computeStatsBySpanKind: conf.ComputeStatsBySpanKind,
 	}
 	return &c
 }
@@ -165,7 +177,8 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 	}
 	for _, s := range pt.TraceChunk.Spans {
 		isTop := traceutil.HasTopLevel(s)
-		if !(isTop || traceutil.IsMeasured(s)) || traceutil.IsPartialSnapshot(s) {
+		eligibleSpanKind := c.computeStatsBySpanKind && computeStatsForSpanKind(s)
+		if !(isTop || traceutil.IsMeasured(s) || eligibleSpanKind) || traceutil.IsPartialSnapshot(s) {
 			continue
 		}
 		end := s.Start + s.Duration
@@ -179,6 +192,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 		b, ok := c.buckets[btime]
 		if !ok {
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
+			b.additionalTags = newAdditionalTags(c.additionalTags, c.maxTagCardinality)
 			c.buckets[btime] = b
 		}
 		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey)
@@ -192,6 +206,7 @@ func (c *Concentrator) Flush() pb.StatsPayload {
 
 func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 	m := make(map[PayloadAggregationKey][]pb.ClientStatsBucket)
+	var blockedTags int64
 
 	c.mu.Lock()
 	for ts, srb := range c.buckets {
@@ -205,6 +220,9 @@ func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 		for k, b := range srb.Export() {
 			m[k] = append(m[k], b)
 		}
+		if srb.additionalTags != nil {
+			blockedTags += srb.additionalTags.blocked
+		}
 		delete(c.buckets, ts)
 	}
 	// After flushing, update the oldest timestamp allowed to prevent having stats for
@@ -215,6 +233,9 @@ func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 		c.oldestTs = newOldestTs
 	}
 	c.mu.Unlock()
+	if blockedTags > 0 {
+		metrics.Count("datadog.trace_agent.stats.additional_tags_blocked", blockedTags, nil, 1)
+	}
 	sb := make([]pb.ClientStatsPayload, 0, len(m))
 	for k, s := range m {
 		p := pb.ClientStatsPayload{
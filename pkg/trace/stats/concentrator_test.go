@@ -8,6 +8,7 @@ package stats
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -526,3 +527,45 @@ func TestIgnoresPartialSpans(t *testing.T) {
 	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
 	assert.Empty(stats.GetStats())
 }
+
+func TestConcentratorAdditionalTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+
+	root := testSpan(1, 0, 100, 5, "A1", "resource1", 0)
+	spans := []*pb.Span{root}
+	for i, peer := range []string{"db-1", "db-2", "db-3", "db-1"} {
+		s := testSpan(uint64(i+2), 1, 50, 5, "A1", "resource2", 0)
+		s.Meta = map[string]string{"span.kind": "client", "peer.service": peer, "db.instance": "users"}
+		spans = append(spans, s)
+	}
+	traceutil.ComputeTopLevel(spans)
+	testTrace := toProcessedTrace(spans, "none", "")
+
+	t.Run("disabled", func(t *testing.T) {
+		c := NewTestConcentrator(now)
+		c.addNow(testTrace, "")
+		stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+		assert.Len(stats.Stats[0].Stats[0].Stats, 1)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := NewTestConcentrator(now)
+		c.additionalTags = []string{"peer.service", "db.instance"}
+		c.maxTagCardinality = 2
+		c.computeStatsBySpanKind = true
+		c.addNow(testTrace, "")
+		stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+		hits := make(map[string]uint64)
+		for _, g := range stats.Stats[0].Stats[0].Stats {
+			hits[g.Resource+" "+strings.Join(g.AdditionalTags, ",")] = g.Hits
+		}
+		assert.Equal(map[string]uint64{
+			"resource1 ": 1,
+			"resource2 peer.service:db-1,db.instance:users": 2,
+			"resource2 peer.service:db-2,db.instance:users": 1,
+			// db-3 exceeds the cardinality limit of peer.service
+			"resource2 db.instance:users": 1,
+		}, hits)
+	})
+}
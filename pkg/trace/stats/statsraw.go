@@ -72,6 +72,7 @@ func (s *groupedStats) export(a Aggregation) (pb.ClientGroupedStats, error) {
 		OkSummary:      okSummary,
 		ErrorSummary:   errSummary,
 		Synthetics:     a.Synthetics,
+		AdditionalTags: splitAdditionalTags(a.AdditionalTags),
 	}, nil
 }
 
@@ -101,6 +102,9 @@ type RawBucket struct {
 
 	// this should really remain private as it's subject to refactoring
 	data map[Aggregation]*groupedStats
+
+	// additionalTags extracts the additional aggregation dimensions of the spans, if any
+	additionalTags *additionalTags
 }
 
 // NewRawBucket opens a new calculation bucket for time ts and initializes it properly
@@ -149,6 +153,7 @@ func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin s
 		panic("env should never be empty")
 	}
 	aggr := NewAggregationFromSpan(s, origin, aggKey)
+	aggr.AdditionalTags = sb.additionalTags.fromSpan(s)
 	sb.add(s, weight, isTop, aggr)
 }
 
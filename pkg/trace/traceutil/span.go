@@ -7,6 +7,8 @@ package traceutil
 
 import (
 	"bytes"
+	"fmt"
+	"strconv"
 
 	"github.com/tinylib/msgp/msgp"
 
@@ -23,6 +25,8 @@ const (
 	tracerTopLevelKey = "_dd.top_level"
 	// partialVersionKey is a metric carrying the snapshot seq number in the case the span is a partial snapshot
 	partialVersionKey = "_dd.partial_version"
+	// traceIDHighKey is a meta carrying the upper 64 bits of 128-bit trace IDs, as 16 hexadecimal digits
+	traceIDHighKey = "_dd.p.tid"
 )
 
 // HasTopLevel returns true if span is top-level.
@@ -141,3 +145,26 @@ func GetMetric(s *pb.Span, key string) (float64, bool) {
 	val, ok := s.Metrics[key]
 	return val, ok
 }
+
+// SetTraceIDHigh sets the upper 64 bits of the 128-bit trace ID of the span s. Nothing is set for
+// 64-bit trace IDs, whose upper bits are zero.
+func SetTraceIDHigh(s *pb.Span, high uint64) {
+	if high == 0 {
+		return
+	}
+	SetMeta(s, traceIDHighKey, fmt.Sprintf("%016x", high))
+}
+
+// GetTraceIDHigh returns the upper 64 bits of the 128-bit trace ID of the span s. It returns false
+// if the trace ID of the span has 64 bits or if its upper bits are malformed.
+func GetTraceIDHigh(s *pb.Span) (uint64, bool) {
+	v, ok := GetMeta(s, traceIDHighKey)
+	if !ok || len(v) != 16 {
+		return 0, false
+	}
+	high, err := strconv.ParseUint(v, 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return high, true
+}
@@ -45,6 +45,29 @@ func TestSetMeta(t *testing.T) {
 	}
 }
 
+func TestGetSetTraceIDHigh(t *testing.T) {
+	s := &pb.Span{}
+	SetTraceIDHigh(s, 0)
+	assert.Nil(t, s.Meta)
+	_, ok := GetTraceIDHigh(s)
+	assert.False(t, ok)
+
+	SetTraceIDHigh(s, 0x5af7183fb1d4cf5f)
+	assert.Equal(t, "5af7183fb1d4cf5f", s.Meta["_dd.p.tid"])
+	high, ok := GetTraceIDHigh(s)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0x5af7183fb1d4cf5f), high)
+
+	SetTraceIDHigh(s, 1)
+	assert.Equal(t, "0000000000000001", s.Meta["_dd.p.tid"])
+
+	for _, v := range []string{"", "1", "5af7183fb1d4cf5z", "5af7183fb1d4cf5f0"} {
+		s.Meta["_dd.p.tid"] = v
+		_, ok = GetTraceIDHigh(s)
+		assert.False(t, ok, v)
+	}
+}
+
 func TestGetSetMetaStruct(t *testing.T) {
 	for _, s := range []*pb.Span{
 		{},
@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// output implementations deliver payloads to a destination other than the Datadog
+// intake. They are used by senders in place of the HTTP client, so that they benefit
+// from the same queueing, retry and telemetry mechanisms.
+type output interface {
+	// send delivers the payload p. It returns a *retriableError if the payload
+	// may be sent again at a later time.
+	send(p *payload) error
+	// close releases any resources held by the output. It is called once all
+	// payloads have been sent.
+	close() error
+	// String returns a description of the destination, used when reporting events.
+	String() string
+}
+
+const (
+	// defaultOutputMaxSizeMB is the default size at which file outputs are rotated.
+	defaultOutputMaxSizeMB = 100
+	// defaultOutputMaxBackups is the default number of rotated files kept by file outputs.
+	defaultOutputMaxBackups = 5
+)
+
+// newOutput returns a new output for the given configuration, serving payloads
+// that would otherwise be sent to the given intake API path. It returns nil if
+// the output does not support this kind of payload.
+func newOutput(cfg *config.WriterOutput, apiPath string) (output, error) {
+	switch cfg.Type {
+	case config.OutputTypeFile:
+		maxSize := cfg.MaxSizeMB
+		if maxSize == 0 {
+			maxSize = defaultOutputMaxSizeMB
+		}
+		maxBackups := cfg.MaxBackups
+		if maxBackups == 0 {
+			maxBackups = defaultOutputMaxBackups
+		}
+		// each writer writes into its own file, named after the last element of its
+		// API path (e.g. "traces.json" or "stats.json")
+		name := filepath.Join(cfg.Path, path.Base(apiPath)+".json")
+		return newFileOutput(name, int64(maxSize)*1024*1024, maxBackups)
+	case config.OutputTypeOTLP:
+		if apiPath != pathTraces {
+			// OTLP has no equivalent for APM stats
+			return nil, nil
+		}
+		return newOTLPOutput(cfg.Endpoint, cfg.Insecure)
+	default:
+		return nil, fmt.Errorf("unknown output type %q", cfg.Type)
+	}
+}
+
+// decodePayload decodes the body of the payload p, as encoded by the trace or stats
+// writers, based on its headers. It returns a *pb.AgentPayload or a *pb.StatsPayload.
+func decodePayload(p *payload) (interface{}, error) {
+	var r io.Reader = bytes.NewReader(p.body.Bytes())
+	if p.headers["Content-Encoding"] == "gzip" {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	switch ct := p.headers["Content-Type"]; ct {
+	case "application/x-protobuf":
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		var ap pb.AgentPayload
+		if err := proto.Unmarshal(b, &ap); err != nil {
+			return nil, err
+		}
+		return &ap, nil
+	case "application/msgpack":
+		var sp pb.StatsPayload
+		if err := msgp.Decode(r, &sp); err != nil {
+			return nil, err
+		}
+		return &sp, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload content type %q", ct)
+	}
+}
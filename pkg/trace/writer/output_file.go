@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var _ output = (*fileOutput)(nil)
+
+// fileOutput writes payloads as newline-delimited JSON into a local file, rotating
+// it once it reaches a maximum size.
+type fileOutput struct {
+	name       string // file name
+	maxSize    int64  // size in bytes at which the file is rotated
+	maxBackups int    // number of rotated files kept
+
+	mu   sync.Mutex // guards below fields
+	f    *os.File   // current file; nil until the first write
+	size int64      // current file size
+}
+
+// newFileOutput returns a new fileOutput writing to the file name.
+func newFileOutput(name string, maxSize int64, maxBackups int) (*fileOutput, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return nil, err
+	}
+	return &fileOutput{
+		name:       name,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}, nil
+}
+
+// send implements output.
+func (o *fileOutput) send(p *payload) error {
+	v, err := decodePayload(p)
+	if err != nil {
+		// retrying won't help
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.f != nil && o.size > 0 && o.size+int64(len(b)) > o.maxSize {
+		if err := o.rotate(); err != nil {
+			return &retriableError{err}
+		}
+	}
+	if o.f == nil {
+		if err := o.open(); err != nil {
+			return &retriableError{err}
+		}
+	}
+	n, err := o.f.Write(b)
+	o.size += int64(n)
+	if err != nil {
+		return &retriableError{err}
+	}
+	return nil
+}
+
+// open opens the output file for appending.
+func (o *fileOutput) open() error {
+	f, err := os.OpenFile(o.name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	o.f = f
+	o.size = fi.Size()
+	return nil
+}
+
+// rotate closes the current file and shifts it, along with previous backups, by
+// one position: name becomes name.1, name.1 becomes name.2 and so on. The oldest
+// backup is overwritten.
+func (o *fileOutput) rotate() error {
+	if err := o.f.Close(); err != nil {
+		return err
+	}
+	o.f = nil
+	o.size = 0
+	for i := o.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", o.name, i), fmt.Sprintf("%s.%d", o.name, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if o.maxBackups == 0 {
+		return os.Remove(o.name)
+	}
+	return os.Rename(o.name, o.name+".1")
+}
+
+// close implements output.
+func (o *fileOutput) close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.f == nil {
+		return nil
+	}
+	err := o.f.Close()
+	o.f = nil
+	return err
+}
+
+// String implements output.
+func (o *fileOutput) String() string { return "file://" + o.name }
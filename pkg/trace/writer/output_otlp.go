@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// otlpExportTimeout specifies the maximum duration of an export request.
+const otlpExportTimeout = 10 * time.Second
+
+var _ output = (*otlpOutput)(nil)
+
+// otlpOutput exports trace payloads to an OTLP/gRPC collector.
+type otlpOutput struct {
+	endpoint string
+	conn     *grpc.ClientConn
+	client   ptraceotlp.GRPCClient
+}
+
+// newOTLPOutput returns a new otlpOutput exporting to the collector at endpoint.
+// The connection is established lazily.
+func newOTLPOutput(endpoint string, noTLS bool) (*otlpOutput, error) {
+	creds := credentials.NewTLS(&tls.Config{})
+	if noTLS {
+		creds = insecure.NewCredentials()
+	}
+	conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &otlpOutput{
+		endpoint: endpoint,
+		conn:     conn,
+		client:   ptraceotlp.NewGRPCClient(conn),
+	}, nil
+}
+
+// send implements output.
+func (o *otlpOutput) send(p *payload) error {
+	v, err := decodePayload(p)
+	if err != nil {
+		return err
+	}
+	ap, ok := v.(*pb.AgentPayload)
+	if !ok {
+		return fmt.Errorf("unsupported payload type %T", v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	_, err = o.client.Export(ctx, ptraceotlp.NewExportRequestFromTraces(agentPayloadToOTLP(ap)))
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return &retriableError{err}
+	default:
+		return err
+	}
+}
+
+// close implements output.
+func (o *otlpOutput) close() error { return o.conn.Close() }
+
+// String implements output.
+func (o *otlpOutput) String() string { return "otlp://" + o.endpoint }
+
+// agentPayloadToOTLP converts the agent payload ap to OTLP traces. Each tracer payload
+// becomes a resource, and each span is mapped such that the OTLP receiver would convert
+// it back to an equivalent Datadog span.
+func agentPayloadToOTLP(ap *pb.AgentPayload) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	for _, tp := range ap.TracerPayloads {
+		rspans := traces.ResourceSpans().AppendEmpty()
+		rattr := rspans.Resource().Attributes()
+		putNonEmpty(rattr, "deployment.environment", tp.Env)
+		putNonEmpty(rattr, "service.version", tp.AppVersion)
+		putNonEmpty(rattr, "telemetry.sdk.language", tp.LanguageName)
+		putNonEmpty(rattr, "container.id", tp.ContainerID)
+		if tp.Hostname != "" {
+			rattr.PutStr("host.name", tp.Hostname)
+		} else {
+			putNonEmpty(rattr, "host.name", ap.HostName)
+		}
+		spans := rspans.ScopeSpans().AppendEmpty().Spans()
+		for _, chunk := range tp.Chunks {
+			for _, s := range chunk.Spans {
+				spanToOTLP(s, chunk.Priority, spans.AppendEmpty())
+			}
+		}
+	}
+	return traces
+}
+
+// spanToOTLP fills out with the contents of the Datadog span s, which is part of a
+// chunk having the given sampling priority.
+func spanToOTLP(s *pb.Span, priority int32, out ptrace.Span) {
+	var traceID [16]byte
+	if high, ok := traceutil.GetTraceIDHigh(s); ok {
+		binary.BigEndian.PutUint64(traceID[:8], high)
+	}
+	binary.BigEndian.PutUint64(traceID[8:], s.TraceID)
+	out.SetTraceID(pcommon.TraceID(traceID))
+	out.SetSpanID(uint64ToSpanID(s.SpanID))
+	if s.ParentID != 0 {
+		out.SetParentSpanID(uint64ToSpanID(s.ParentID))
+	}
+	out.SetName(s.Resource)
+	out.SetKind(spanKindFromMeta(s.Meta["span.kind"]))
+	out.SetStartTimestamp(pcommon.Timestamp(s.Start))
+	out.SetEndTimestamp(pcommon.Timestamp(s.Start + s.Duration))
+
+	attr := out.Attributes()
+	attr.EnsureCapacity(len(s.Meta) + len(s.Metrics) + 5)
+	for k, v := range s.Meta {
+		attr.PutStr(k, v)
+	}
+	for k, v := range s.Metrics {
+		attr.PutDouble(k, v)
+	}
+	attr.PutStr("service.name", s.Service)
+	attr.PutStr("operation.name", s.Name)
+	attr.PutStr("resource.name", s.Resource)
+	putNonEmpty(attr, "span.type", s.Type)
+	attr.PutInt("sampling.priority", int64(priority))
+	if s.Error != 0 {
+		out.Status().SetCode(ptrace.StatusCodeError)
+		out.Status().SetMessage(s.Meta["error.msg"])
+	}
+}
+
+// putNonEmpty sets the attribute k to v in m, unless v is empty.
+func putNonEmpty(m pcommon.Map, k, v string) {
+	if v != "" {
+		m.PutStr(k, v)
+	}
+}
+
+func uint64ToSpanID(id uint64) pcommon.SpanID {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return pcommon.SpanID(b)
+}
+
+// spanKindFromMeta returns the OTLP span kind corresponding to the value of a
+// span's "span.kind" tag.
+func spanKindFromMeta(kind string) ptrace.SpanKind {
+	switch kind {
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	case "internal":
+		return ptrace.SpanKindInternal
+	default:
+		return ptrace.SpanKindUnspecified
+	}
+}
@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// readLines returns the lines found in the file name.
+func readLines(t *testing.T, name string) []string {
+	f, err := os.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 10*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	return lines
+}
+
+func TestTraceWriterOutputs(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	dir := t.TempDir()
+	cfg := &config.AgentConfig{
+		Hostname:   testHostname,
+		DefaultEnv: testEnv,
+		Endpoints: []*config.Endpoint{{
+			APIKey: "123",
+			Host:   srv.URL,
+		}},
+		TraceWriter: &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+	}
+
+	testSpans := []*SampledChunks{
+		randomSampledSpans(20, 8),
+		randomSampledSpans(10, 0),
+	}
+	write := func(outputs ...*config.WriterOutput) *TraceWriter {
+		cfg.WriterOutputs = outputs
+		tw := NewTraceWriter(cfg, mockSampler, mockSampler, mockSampler, telemetry.NewNoopCollector())
+		tw.In = make(chan *SampledChunks)
+		go tw.Run()
+		for _, ss := range testSpans {
+			tw.In <- ss
+		}
+		tw.Stop()
+		return tw
+	}
+
+	t.Run("file", func(t *testing.T) {
+		tw := write(&config.WriterOutput{Type: config.OutputTypeFile, Path: dir})
+		assert.Len(t, tw.senders, 1)
+		assert.Equal(t, 0, srv.Total())
+
+		lines := readLines(t, filepath.Join(dir, "traces.json"))
+		require.Len(t, lines, 1)
+		var ap pb.AgentPayload
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &ap))
+		assert.Equal(t, testHostname, ap.HostName)
+		assert.Equal(t, testEnv, ap.Env)
+		assert.Len(t, ap.TracerPayloads, len(testSpans))
+		assert.EqualValues(t, 1, tw.stats.Payloads.Load())
+	})
+
+	t.Run("fan-out", func(t *testing.T) {
+		tw := write(
+			&config.WriterOutput{Type: config.OutputTypeDatadog},
+			&config.WriterOutput{Type: config.OutputTypeFile, Path: dir},
+		)
+		assert.Len(t, tw.senders, 2)
+		assert.Equal(t, 1, srv.Accepted())
+		payloadsContain(t, srv.Payloads(), testSpans)
+		assert.Len(t, readLines(t, filepath.Join(dir, "traces.json")), 2)
+		assert.EqualValues(t, 2, tw.stats.Payloads.Load())
+	})
+}
+
+func TestStatsWriterOutputs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.AgentConfig{
+		Endpoints: []*config.Endpoint{{
+			APIKey: "123",
+			Host:   "http://localhost:1",
+		}},
+		StatsWriter: &config.WriterConfig{},
+		WriterOutputs: []*config.WriterOutput{
+			{Type: config.OutputTypeFile, Path: dir},
+			{Type: config.OutputTypeOTLP, Endpoint: "localhost:1", Insecure: true},
+		},
+	}
+	in := make(chan pb.StatsPayload)
+	sw := NewStatsWriter(cfg, in, telemetry.NewNoopCollector())
+	// OTLP outputs do not support stats
+	assert.Len(t, sw.senders, 1)
+	go sw.Run()
+	in <- pb.StatsPayload{Stats: []pb.ClientStatsPayload{testutil.StatsPayloadSample()}}
+	sw.Stop()
+
+	lines := readLines(t, filepath.Join(dir, "stats.json"))
+	require.Len(t, lines, 1)
+	var sp pb.StatsPayload
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &sp))
+	assert.NotEmpty(t, sp.Stats)
+}
+
+func TestFileOutputRotate(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out", "traces.json")
+	o, err := newFileOutput(name, 10, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		p := newPayload(map[string]string{"Content-Type": "application/x-protobuf"})
+		b, err := (&pb.AgentPayload{HostName: fmt.Sprintf("host-%d", i)}).Marshal()
+		require.NoError(t, err)
+		p.body.Write(b)
+		require.NoError(t, o.send(p))
+	}
+	require.NoError(t, o.close())
+
+	for suffix, host := range map[string]string{"": "host-3", ".1": "host-2", ".2": "host-1"} {
+		lines := readLines(t, name+suffix)
+		require.Len(t, lines, 1)
+		assert.Contains(t, lines[0], host)
+	}
+	_, err = os.Stat(name + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFileOutputInvalidPayload(t *testing.T) {
+	o, err := newFileOutput(filepath.Join(t.TempDir(), "traces.json"), 1024, 1)
+	require.NoError(t, err)
+	p := newPayload(map[string]string{"Content-Type": "text/plain"})
+	err = o.send(p)
+	assert.Error(t, err)
+	_, retriable := err.(*retriableError)
+	assert.False(t, retriable)
+}
+
+// testOTLPServer is an OTLP/gRPC server recording the traces it receives.
+type testOTLPServer struct {
+	ptraceotlp.UnimplementedGRPCServer
+
+	mu     sync.Mutex
+	traces []ptrace.Traces
+	err    error
+}
+
+func (s *testOTLPServer) Export(_ context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return ptraceotlp.NewExportResponse(), s.err
+	}
+	s.traces = append(s.traces, req.Traces())
+	return ptraceotlp.NewExportResponse(), nil
+}
+
+func TestOTLPOutput(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &testOTLPServer{}
+	gsrv := grpc.NewServer()
+	ptraceotlp.RegisterGRPCServer(gsrv, srv)
+	go gsrv.Serve(ln)
+	defer gsrv.Stop()
+
+	o, err := newOTLPOutput(ln.Addr().String(), true)
+	require.NoError(t, err)
+	defer o.close()
+
+	span := &pb.Span{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /users",
+		TraceID:  42,
+		SpanID:   2,
+		ParentID: 1,
+		Start:    100,
+		Duration: 50,
+		Error:    1,
+		Meta:     map[string]string{"span.kind": "server", "error.msg": "boom"},
+		Metrics:  map[string]float64{"http.status_code": 500},
+	}
+	traceutil.SetTraceIDHigh(span, 7)
+	ap := &pb.AgentPayload{
+		HostName: "agent-host",
+		TracerPayloads: []*pb.TracerPayload{{
+			Env:          "prod",
+			LanguageName: "go",
+			Chunks:       []*pb.TraceChunk{{Priority: 2, Spans: []*pb.Span{span}}},
+		}},
+	}
+	b, err := ap.Marshal()
+	require.NoError(t, err)
+	p := newPayload(map[string]string{"Content-Type": "application/x-protobuf"})
+	p.body.Write(b)
+	require.NoError(t, o.send(p))
+
+	require.Len(t, srv.traces, 1)
+	rspans := srv.traces[0].ResourceSpans().At(0)
+	host, _ := rspans.Resource().Attributes().Get("host.name")
+	assert.Equal(t, "agent-host", host.Str())
+	env, _ := rspans.Resource().Attributes().Get("deployment.environment")
+	assert.Equal(t, "prod", env.Str())
+
+	out := rspans.ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, "0000000000000007000000000000002a", out.TraceID().String())
+	assert.Equal(t, "0000000000000002", out.SpanID().String())
+	assert.Equal(t, "0000000000000001", out.ParentSpanID().String())
+	assert.Equal(t, "GET /users", out.Name())
+	assert.Equal(t, ptrace.SpanKindServer, out.Kind())
+	assert.EqualValues(t, 150, out.EndTimestamp())
+	assert.Equal(t, ptrace.StatusCodeError, out.Status().Code())
+	assert.Equal(t, "boom", out.Status().Message())
+	for k, want := range map[string]interface{}{
+		"service.name":      "web",
+		"operation.name":    "http.request",
+		"http.status_code":  float64(500),
+		"sampling.priority": int64(2),
+	} {
+		v, ok := out.Attributes().Get(k)
+		if assert.True(t, ok, k) {
+			assert.Equal(t, want, v.AsRaw(), k)
+		}
+	}
+
+	t.Run("retriable", func(t *testing.T) {
+		srv.mu.Lock()
+		srv.err = status.Error(codes.Unavailable, "busy")
+		srv.mu.Unlock()
+		p := newPayload(map[string]string{"Content-Type": "application/x-protobuf"})
+		p.body.Write(b)
+		err := o.send(p)
+		assert.IsType(t, &retriableError{}, err)
+	})
+}
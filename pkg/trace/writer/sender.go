@@ -27,8 +27,40 @@ import (
 )
 
 // newSenders returns a list of senders based on the given agent configuration, using climit
-// as the maximum number of concurrent outgoing connections, writing to path.
+// as the maximum number of concurrent outgoing connections, writing to path. One sender is
+// created for each configured output, so that each of them has an independent retry queue.
 func newSenders(cfg *config.AgentConfig, r eventRecorder, path string, climit, qsize int, telemetryCollector telemetry.TelemetryCollector) []*sender {
+	if len(cfg.WriterOutputs) == 0 {
+		return newIntakeSenders(cfg, r, path, climit, qsize, telemetryCollector)
+	}
+	var senders []*sender
+	for _, o := range cfg.WriterOutputs {
+		if o.Type == config.OutputTypeDatadog {
+			senders = append(senders, newIntakeSenders(cfg, r, path, climit, qsize, telemetryCollector)...)
+			continue
+		}
+		out, err := newOutput(o, path)
+		if err != nil {
+			log.Errorf("Error creating %q output, it will be ignored: %v", o.Type, err)
+			continue
+		}
+		if out == nil {
+			// the output does not support this kind of payload
+			continue
+		}
+		senders = append(senders, newSender(&senderConfig{
+			output:    out,
+			maxConns:  1,
+			maxQueued: qsize,
+			recorder:  r,
+		}))
+	}
+	return senders
+}
+
+// newIntakeSenders returns a sender for each of the Datadog intake endpoints found in the
+// given agent configuration.
+func newIntakeSenders(cfg *config.AgentConfig, r eventRecorder, path string, climit, qsize int, telemetryCollector telemetry.TelemetryCollector) []*sender {
 	if e := cfg.Endpoints; len(e) == 0 || e[0].Host == "" || e[0].APIKey == "" {
 		panic(errors.New("config was not properly validated"))
 	}
@@ -129,6 +161,9 @@ type senderConfig struct {
 	recorder eventRecorder
 	// userAgent is the computed user agent we'll use when communicating with Datadog
 	userAgent string
+	// output specifies an alternative destination for payloads. When set, the
+	// HTTP related fields above are unused.
+	output output
 }
 
 // sender is responsible for sending payloads to a given URL. It uses a size-limited
@@ -188,6 +223,11 @@ func (s *sender) Stop() {
 	s.closed = true
 	s.mu.Unlock()
 	close(s.queue)
+	if s.cfg.output != nil {
+		if err := s.cfg.output.close(); err != nil {
+			log.Errorf("Error closing output %s: %v", s.cfg.output, err)
+		}
+	}
 }
 
 // WaitForInflight blocks until all in progress payloads are sent,
@@ -235,13 +275,18 @@ func (s *sender) Push(p *payload) {
 
 // sendPayload sends the payload p to the destination URL.
 func (s *sender) sendPayload(p *payload) {
-	req, err := p.httpRequest(s.cfg.url)
-	if err != nil {
-		log.Errorf("http.Request: %s", err)
-		return
-	}
 	start := time.Now()
-	err = s.do(req)
+	var err error
+	if s.cfg.output != nil {
+		err = s.cfg.output.send(p)
+	} else {
+		req, rerr := p.httpRequest(s.cfg.url)
+		if rerr != nil {
+			log.Errorf("http.Request: %s", rerr)
+			return
+		}
+		err = s.do(req)
+	}
 	stats := &eventData{
 		bytes:    p.body.Len(),
 		count:    1,
@@ -317,7 +362,11 @@ func (s *sender) recordEvent(t eventType, data *eventData) {
 	if s.cfg.recorder == nil {
 		return
 	}
-	data.host = s.cfg.url.Hostname()
+	if s.cfg.output != nil {
+		data.host = s.cfg.output.String()
+	} else {
+		data.host = s.cfg.url.Hostname()
+	}
 	data.connectionFill = float64(len(s.climit)) / float64(cap(s.climit))
 	data.queueFill = float64(len(s.queue)) / float64(cap(s.queue))
 	s.cfg.recorder.recordEvent(t, data)